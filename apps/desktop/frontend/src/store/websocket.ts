@@ -29,7 +29,9 @@ interface WebSocketState {
 }
 
 const WS_URL = 'ws://localhost:8080/ws';
+const WS_TICKET_URL = 'http://localhost:8080/ws/ticket';
 const PING_INTERVAL = 30000; // 30秒
+const NO_RECONNECT_CLOSE_CODES = [4400, 4403, 4406]; // 对应 @xpaste/protocol 中的 WS_CLOSE_CODES
 const RECONNECT_DELAY = 5000; // 5秒
 
 export const useWebSocketStore = create<WebSocketState>((set, get) => {
@@ -140,7 +142,7 @@ export const useWebSocketStore = create<WebSocketState>((set, get) => {
     maxReconnectAttempts: 5,
     onlineDevices: [],
 
-    connect: () => {
+    connect: async () => {
       const { isAuthenticated, currentDevice, token } = useAuthStore.getState();
       if (!isAuthenticated || !currentDevice || !token) {
         set({ error: 'Not authenticated or missing device/token' });
@@ -154,8 +156,22 @@ export const useWebSocketStore = create<WebSocketState>((set, get) => {
       set({ isConnecting: true, error: null });
       
       try {
-       const wsUrl = `${WS_URL}?device_id=${currentDevice.device_id}&token=${token}`;
-       const newSocket = new WebSocket(wsUrl);
+        // 先换取一次性连接票据，避免把 JWT 放进 URL
+        const ticketResponse = await fetch(WS_TICKET_URL, {
+          method: 'POST',
+          headers: {
+            'Content-Type': 'application/json',
+            Authorization: `Bearer ${token}`,
+          },
+          body: JSON.stringify({ device_id: currentDevice.device_id }),
+        });
+        if (!ticketResponse.ok) {
+          throw new Error(`Failed to obtain WebSocket ticket: ${ticketResponse.status}`);
+        }
+        const { data } = await ticketResponse.json();
+
+        const wsUrl = `${WS_URL}?ticket=${encodeURIComponent(data.ticket)}`;
+        const newSocket = new WebSocket(wsUrl);
        set({ socket: newSocket });
 
         newSocket.onopen = () => {
@@ -190,7 +206,13 @@ export const useWebSocketStore = create<WebSocketState>((set, get) => {
           });
           stopPing();
           
-          // 如果不是主动断开连接，尝试重连
+          // 握手被拒绝（设备不可用、Origin 不允许等）时重连也无济于事
+          if (NO_RECONNECT_CLOSE_CODES.includes(event.code)) {
+            set({ error: `连接被拒绝：${event.reason}` });
+            return;
+          }
+
+          // 如果不是主动断开连接，尝试重连（票据为一次性，重连时会重新获取）
           if (event.code !== 1000) {
             attemptReconnect();
           }
@@ -23,6 +23,229 @@ export interface WsMessage<T = any> {
   timestamp: number;
 }
 
+/**
+ * 同步服务 WebSocket 协议
+ *
+ * 以下定义是 sync-api 中 Go 结构体的唯一来源：
+ * services/sync-api/internal/websocket/protocol_gen.go 由
+ * `go generate ./internal/websocket` 根据本节生成，请勿手动修改生成文件。
+ */
+
+/**
+ * 消息编码 schema 版本，变更字段或编号时递增
+ */
+export const WS_SCHEMA_VERSION = 1;
+
+/**
+ * WebSocket 子协议（通过 Sec-WebSocket-Protocol 协商）
+ * 未协商时服务端回退为 JSON 文本帧
+ */
+export const WS_SUBPROTOCOLS = {
+  JSON: 'xpaste.v1.json',
+  CBOR: 'xpaste.v1.cbor',
+} as const;
+
+export type WsSubprotocol = typeof WS_SUBPROTOCOLS[keyof typeof WS_SUBPROTOCOLS];
+
+/**
+ * WebSocket 消息类型
+ */
+export const WS_MESSAGE_TYPES = {
+  CLIP_SYNC: 'clip_sync',
+  CLIP_NEW: 'clip_new',
+  CLIP_UPDATE: 'clip_update',
+  CLIP_DELETE: 'clip_delete',
+  DEVICE_ONLINE: 'device_online',
+  DEVICE_OFFLINE: 'device_offline',
+  DEVICE_UPDATE: 'device_update',
+  DEVICE_APPROVAL_REQUEST: 'device_approval_request',
+  SECURITY_ALERT: 'security_alert',
+  RULE_NOTIFICATION: 'rule_notification',
+  HEARTBEAT: 'heartbeat',
+  PING: 'ping',
+  PONG: 'pong',
+  ERROR: 'error',
+  PRESENCE_UPDATE: 'presence_update',
+  PRESENCE_SUBSCRIBE: 'presence_subscribe',
+  PRESENCE_UNSUBSCRIBE: 'presence_unsubscribe',
+  PRESENCE_SNAPSHOT: 'presence_snapshot',
+  PAIRING_REQUEST: 'pairing_request',
+  PAIRING_APPROVE: 'pairing_approve',
+  PAIRING_DENY: 'pairing_deny',
+  PAIRING_RESULT: 'pairing_result',
+} as const;
+
+export type WsMessageType = typeof WS_MESSAGE_TYPES[keyof typeof WS_MESSAGE_TYPES];
+
+/**
+ * CBOR 编码时使用的整数字段键（JSON 编码使用字段名）
+ */
+export const WS_MESSAGE_FIELDS = {
+  type: 1,
+  data: 2,
+  timestamp: 3,
+  message_id: 4,
+  silent: 5,
+} as const;
+
+/**
+ * 握手被拒绝或连接被服务端关闭时使用的关闭码
+ * 4xxx 为应用自定义关闭码，客户端收到 UNAUTHORIZED 时应重新获取连接票据
+ */
+export const WS_CLOSE_CODES = {
+  SERVICE_RESTART: 1012,
+  BAD_REQUEST: 4400,
+  UNAUTHORIZED: 4401,
+  FORBIDDEN: 4403,
+  ORIGIN_NOT_ALLOWED: 4406,
+} as const;
+
+export type WsCloseCode = typeof WS_CLOSE_CODES[keyof typeof WS_CLOSE_CODES];
+
+/**
+ * 同步服务 WebSocket 消息
+ */
+export interface WsEnvelope<T = any> {
+  type: WsMessageType;
+  data?: T;
+  timestamp: number;
+  message_id?: string;
+  /** 目标设备处于勿扰状态：照常同步数据，但不要弹出通知或自动写入系统剪贴板 */
+  silent?: boolean;
+}
+
+/**
+ * 设备在线状态（presence）
+ * offline 只由服务端设置，客户端通过 presence_update 上报其余状态
+ */
+export const PRESENCE_STATES = {
+  OFFLINE: 'offline',
+  ACTIVE: 'active',
+  IDLE: 'idle',
+  LOCKED: 'locked',
+  DND: 'dnd',
+} as const;
+
+export type PresenceState = typeof PRESENCE_STATES[keyof typeof PRESENCE_STATES];
+
+/**
+ * presence_update 消息数据
+ * 客户端上报时只需 state 和 app_category，服务端转发给订阅者时补充其余字段
+ */
+export interface DevicePresence {
+  device_id?: string;
+  state: PresenceState;
+  app_category?: string;
+  is_online?: boolean;
+  updated_at?: string;
+}
+
+/**
+ * 设备配对
+ * 已信任设备发起配对（POST /pairing），新设备通过数字配对码或扫描二维码认领（POST /pairing/claim），
+ * 发起设备收到 pairing_request 后回复 pairing_approve 或 pairing_deny，
+ * 新设备轮询 POST /pairing/redeem，批准后获得绑定该设备的令牌
+ */
+export const PAIRING_STATUSES = {
+  PENDING: 'pending',
+  CLAIMED: 'claimed',
+  APPROVED: 'approved',
+  DENIED: 'denied',
+  COMPLETED: 'completed',
+  EXPIRED: 'expired',
+} as const;
+
+export type PairingStatus = typeof PAIRING_STATUSES[keyof typeof PAIRING_STATUSES];
+
+export interface PairingSessionResponse {
+  pairing_id: string;
+  code: string;
+  /** 二维码内容：xpaste://pair?server=...&id=...&secret=... */
+  qr_payload: string;
+  status: PairingStatus;
+  expires_at: string;
+  expires_in: number;
+}
+
+export interface PairingClaimRequest {
+  /** 数字配对码，与 pairing_id + secret 二选一 */
+  code?: string;
+  pairing_id?: string;
+  secret?: string;
+  device_id?: string;
+  name: string;
+  platform: string;
+  version?: string;
+  model?: string;
+  os_version?: string;
+}
+
+export interface PairingClaimResponse {
+  pairing_id: string;
+  /** 新设备轮询 /pairing/redeem 时使用的凭证 */
+  claim_token: string;
+  status: PairingStatus;
+  expires_at: string;
+}
+
+/**
+ * pairing_request 消息数据（发送给发起配对的设备）
+ */
+export interface PairingRequestEvent {
+  pairing_id: string;
+  name: string;
+  platform: string;
+  model?: string;
+  os_version?: string;
+  ip?: string;
+  expires_at: string;
+}
+
+/**
+ * pairing_approve / pairing_deny 消息数据
+ */
+export interface PairingDecision {
+  pairing_id: string;
+  /** 批准时新设备的信任级别，默认 full */
+  trust_level?: Exclude<DeviceTrustLevel, 'none'>;
+}
+
+/**
+ * 设备批准与信任级别
+ * 用户的第一台设备自动批准，之后新注册的设备处于 pending 状态，
+ * 已批准设备会收到 device_approval_request 消息，通过 POST /devices/:id/approve 或 /deny 处理
+ */
+export const DEVICE_TRUST_LEVELS = {
+  /** 未批准，不能读写剪贴板 */
+  NONE: 'none',
+  /** 只能读取批准之后创建的剪贴板项 */
+  LIMITED: 'limited',
+  /** 完全信任，可以批准其他设备 */
+  FULL: 'full',
+} as const;
+
+export type DeviceTrustLevel = typeof DEVICE_TRUST_LEVELS[keyof typeof DEVICE_TRUST_LEVELS];
+
+export interface ApproveDeviceRequest {
+  /** 默认 full */
+  trust_level?: Exclude<DeviceTrustLevel, 'none'>;
+}
+
+/**
+ * device_approval_request 消息数据（发送给用户的其他在线设备）
+ */
+export interface DeviceApprovalRequestEvent {
+  device_id: string;
+  name: string;
+  platform: string;
+  model?: string;
+  os_version?: string;
+  status: string;
+  trust_level: DeviceTrustLevel;
+  last_ip?: string;
+  registered_at: string;
+}
+
 /**
  * HTTP API 响应格式
  */
@@ -45,6 +268,7 @@ export interface LoginRequest {
   deviceId: string;
   deviceName: string;
   platform: string;
+  trust_token?: string; // 两步验证时记住设备得到的信任令牌
 }
 
 export interface LoginResponse {
@@ -53,6 +277,390 @@ export interface LoginResponse {
   expiresAt: number;
 }
 
+/**
+ * 登录会话（刷新令牌为不透明字符串，每次刷新后轮换）
+ */
+export interface SessionInfo {
+  session_id: string;
+  device_id?: string;
+  device_name?: string;
+  platform?: string;
+  ip: string;
+  user_agent?: string;
+  created_at: string;
+  last_used_at?: string;
+  expires_at: string;
+  current: boolean;
+}
+
+/**
+ * 两步验证（TOTP）
+ * 开启后登录返回 202 和挑战令牌，凭挑战令牌和验证码（或恢复码）调用 verify 完成登录
+ */
+export interface TwoFactorSetupResponse {
+  secret: string;
+  otpauth_uri: string; // 用于生成二维码
+}
+
+export interface TwoFactorStatus {
+  enabled: boolean;
+  enabled_at?: string;
+  recovery_codes_remaining: number;
+  trusted_devices: number;
+}
+
+export interface TwoFactorChallengeResponse {
+  two_factor_required: true;
+  challenge_token: string;
+  expires_in: number;
+}
+
+export interface VerifyTwoFactorRequest {
+  challenge_token: string;
+  code: string;
+  remember_device?: boolean;
+}
+
+/**
+ * 密码重置（POST /auth/password/reset），令牌来自重置邮件
+ */
+export interface ResetPasswordRequest {
+  token: string;
+  new_password: string;
+}
+
+/**
+ * 单点登录（OIDC）：发起后在浏览器中打开 authorization_url，再用 login_token 轮询 /auth/oidc/token
+ */
+export interface OidcConfigResponse {
+  enabled: boolean;
+  provider_name?: string;
+}
+
+export interface OidcAuthorizeRequest {
+  device_id?: string;
+  redirect_uri?: string;
+}
+
+export interface OidcAuthorizeResponse {
+  authorization_url: string;
+  login_token: string;
+  expires_in: number;
+}
+
+/**
+ * 团队与团队剪贴板
+ * 创建剪贴板项时传入 team_id 发布到团队剪贴板，团队成员的在线设备收到 clip_new / clip_update / clip_delete，
+ * 列表接口通过 scope（user、team、all）和 team_id 筛选
+ */
+export const TEAM_ROLES = {
+  /** 管理成员和管理员，修改或删除团队 */
+  OWNER: 'owner',
+  /** 管理普通成员，删除任何团队剪贴板项 */
+  ADMIN: 'admin',
+  /** 读取、发布，删除自己发布的剪贴板项 */
+  MEMBER: 'member',
+  /** 只读 */
+  VIEWER: 'viewer',
+} as const;
+
+export type TeamRole = typeof TEAM_ROLES[keyof typeof TEAM_ROLES];
+
+export type ClipScope = 'user' | 'team' | 'all';
+
+export interface TeamInfo {
+  id: number;
+  name: string;
+  description: string;
+  owner_id: number;
+  /** 当前用户在团队中的角色 */
+  role: TeamRole;
+  member_count: number;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface TeamMemberInfo {
+  user_id: number;
+  username: string;
+  display_name: string;
+  role: TeamRole;
+  joined_at: string;
+}
+
+export interface AddTeamMemberRequest {
+  /** 用户名或邮箱 */
+  username: string;
+  /** 默认 member */
+  role?: Exclude<TeamRole, 'owner'>;
+}
+
+/**
+ * 分享链接
+ * 公开访问 /s/:slug，有密码时通过 X-Share-Password 请求头提交；
+ * 401 需要密码，403 密码错误，404 不存在或已撤销，410 已过期或达到查看次数上限
+ */
+export const SHARE_PASSWORD_HEADER = 'X-Share-Password';
+
+export interface CreateShareLinkRequest {
+  clip_id: number;
+  password?: string;
+  expires_at?: string;
+  /** 0 表示不限制 */
+  max_views?: number;
+  /** 第一次查看后自动撤销 */
+  burn_after_reading?: boolean;
+}
+
+export interface ShareLinkInfo {
+  id: number;
+  slug: string;
+  url: string;
+  clip_item_id: number;
+  has_password: boolean;
+  expires_at: string | null;
+  max_views: number;
+  view_count: number;
+  burn_after_reading: boolean;
+  /** 未撤销、未过期且未达到查看次数上限 */
+  active: boolean;
+  last_viewed_at: string | null;
+  revoked_at: string | null;
+  created_at: string;
+}
+
+export interface ShareViewInfo {
+  id: number;
+  share_link_id: number;
+  result: 'viewed' | 'wrong_password';
+  ip: string;
+  user_agent: string;
+  referer: string;
+  created_at: string;
+}
+
+/**
+ * 出站 webhook
+ * 请求头 X-XPaste-Signature-256 = "sha256=" + hex(HMAC-SHA256(secret, `${timestamp}.${body}`))，
+ * timestamp 取自 X-XPaste-Timestamp；重新投递使用相同的 X-XPaste-Event-ID
+ */
+export const WEBHOOK_HEADERS = {
+  EVENT: 'X-XPaste-Event',
+  EVENT_ID: 'X-XPaste-Event-ID',
+  DELIVERY: 'X-XPaste-Delivery',
+  TIMESTAMP: 'X-XPaste-Timestamp',
+  SIGNATURE: 'X-XPaste-Signature-256',
+} as const;
+
+export type WebhookEvent =
+  | 'clip.created'
+  | 'clip.updated'
+  | 'clip.deleted'
+  | 'device.online'
+  | 'device.offline'
+  | 'ping';
+
+export interface CreateWebhookRequest {
+  name?: string;
+  url: string;
+  events: WebhookEvent[];
+  /** 以下筛选为空表示不限制，多个值之间为“或” */
+  tags?: string[];
+  clip_types?: Array<'text' | 'image' | 'file' | 'url'>;
+  device_ids?: string[];
+  active?: boolean;
+}
+
+export interface WebhookInfo {
+  id: number;
+  name: string;
+  url: string;
+  /** 只在创建和轮换密钥时返回 */
+  secret?: string;
+  active: boolean;
+  events: WebhookEvent[];
+  tags: string[] | null;
+  clip_types: string[] | null;
+  device_ids: string[] | null;
+  last_delivery_at: string | null;
+  /** 连续失败次数 */
+  failure_count: number;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface WebhookDeliveryInfo {
+  id: number;
+  webhook_id: number;
+  event_id: string;
+  event: WebhookEvent;
+  payload: string;
+  status: 'pending' | 'succeeded' | 'failed';
+  attempts: number;
+  next_attempt_at: string | null;
+  redelivery_of: number | null;
+  response_status: number;
+  response_body: string;
+  error: string;
+  duration_ms: number;
+  delivered_at: string | null;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface WebhookPayload {
+  id: string;
+  event: WebhookEvent;
+  created_at: string;
+  data: {
+    clip?: Record<string, unknown>;
+    device?: Record<string, unknown>;
+    webhook?: WebhookInfo;
+  };
+}
+
+/**
+ * 入站 webhook 和邮件
+ * 外部系统向 POST /in/:token（令牌以 xpin_ 开头）提交内容，或发送邮件到 <令牌>@<收件域名>
+ */
+export type InboundChannel = 'webhook' | 'email';
+
+export interface InboundEndpointInfo {
+  channel: InboundChannel;
+  prefix: string;
+  /** 只在创建和轮换时返回 */
+  url?: string;
+  /** 只在创建和轮换时返回 */
+  address?: string;
+  tags: string[] | null;
+  last_received_at: string | null;
+  received_count: number;
+  created_at: string;
+}
+
+export interface InboundClipRequest {
+  /** 为空时按内容识别为 text 或 url */
+  type?: 'text' | 'image' | 'file' | 'url';
+  /** 图片和文件为 base64 或 data URL */
+  content: string;
+  title?: string;
+  description?: string;
+  tags?: string[];
+  metadata?: Record<string, unknown>;
+  file_name?: string;
+  mime_type?: string;
+}
+
+/**
+ * 剪贴板项规则
+ * 新建剪贴板项时按 priority 从小到大匹配，所有设置了的条件都满足时执行动作
+ */
+export interface ClipRuleConditions {
+  types?: Array<'text' | 'image' | 'file' | 'url'>;
+  /** RE2 语法，只对文本和链接生效 */
+  content_regex?: string;
+  device_ids?: string[];
+  platforms?: Array<'windows' | 'macos' | 'linux' | 'android' | 'ios' | 'web'>;
+  /** 值为 "*" 时只要求字段存在 */
+  metadata?: Record<string, string>;
+}
+
+export interface ClipRuleActions {
+  add_tags?: string[];
+  /** Go 时长格式，例如 "2m"、"24h" */
+  expires_in?: string;
+  /** 可以使用 $1、${name} 引用正则捕获组 */
+  set_title?: string;
+  drop?: boolean;
+  /** 推送 rule_notification 消息，可以引用捕获组 */
+  notify?: string;
+}
+
+export interface ClipRule {
+  id: number;
+  name: string;
+  enabled: boolean;
+  priority: number;
+  /** 匹配后不再执行后续规则 */
+  stop: boolean;
+  conditions: ClipRuleConditions;
+  actions: ClipRuleActions;
+  match_count: number;
+  last_matched_at: string | null;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ClipRuleMatch {
+  /** 试运行未保存的规则时为 0 */
+  rule_id: number;
+  name: string;
+}
+
+/** rule_notification 消息的数据 */
+export interface RuleNotification {
+  rule_id: number;
+  name: string;
+  message: string;
+  /** 被丢弃的剪贴板项 id 为 0 */
+  clip?: ClipItem;
+}
+
+/** POST /rules/dry-run 的结果 */
+export interface ClipRuleResult {
+  matched: ClipRuleMatch[];
+  dropped: boolean;
+  dropped_by?: ClipRuleMatch;
+  notifications: RuleNotification[];
+  /** 执行动作后的剪贴板项 */
+  clip: ClipItem;
+}
+
+/**
+ * 个人访问令牌（供脚本、CLI 使用，以 xpat_ 开头）
+ */
+export const ACCESS_TOKEN_SCOPES = {
+  CLIPS_READ: 'clips:read',
+  CLIPS_WRITE: 'clips:write',
+  DEVICES_READ: 'devices:read',
+} as const;
+
+export type AccessTokenScope = typeof ACCESS_TOKEN_SCOPES[keyof typeof ACCESS_TOKEN_SCOPES];
+
+export interface CreateAccessTokenRequest {
+  name: string;
+  scopes: AccessTokenScope[];
+  expires_in_days?: number;
+}
+
+export interface AccessTokenInfo {
+  id: number;
+  name: string;
+  prefix: string;
+  scopes: AccessTokenScope[];
+  expires_at?: string;
+  last_used_at?: string;
+  last_used_ip?: string;
+  expired: boolean;
+  created_at: string;
+}
+
+export interface CreateAccessTokenResponse extends AccessTokenInfo {
+  token: string; // 只在创建时返回
+}
+
+/**
+ * 安全告警（security_alert 消息数据）
+ */
+export interface SecurityAlert {
+  type: 'refresh_token_reuse' | 'account_locked';
+  session_id?: string;
+  device_id?: string;
+  ip?: string;
+  until?: string; // account_locked 时为锁定截止时间
+  time: string;
+}
+
 /**
  * 设备注册
  */
@@ -139,12 +747,31 @@ export const API_PATHS = {
     LOGIN: `/api/${API_VERSION}/auth/login`,
     REFRESH: `/api/${API_VERSION}/auth/refresh`,
     LOGOUT: `/api/${API_VERSION}/auth/logout`,
+    SESSIONS: `/api/${API_VERSION}/auth/sessions`,
+    SESSION: (id: string) => `/api/${API_VERSION}/auth/sessions/${id}`,
+    TOKENS: `/api/${API_VERSION}/auth/tokens`,
+    TWO_FACTOR: `/api/${API_VERSION}/auth/2fa`,
+    TWO_FACTOR_SETUP: `/api/${API_VERSION}/auth/2fa/setup`,
+    TWO_FACTOR_ENABLE: `/api/${API_VERSION}/auth/2fa/enable`,
+    TWO_FACTOR_DISABLE: `/api/${API_VERSION}/auth/2fa/disable`,
+    TWO_FACTOR_RECOVERY_CODES: `/api/${API_VERSION}/auth/2fa/recovery-codes`,
+    TWO_FACTOR_VERIFY: `/api/${API_VERSION}/auth/2fa/verify`,
+    VERIFY_EMAIL: `/api/${API_VERSION}/auth/verify-email`,
+    RESEND_VERIFICATION: `/api/${API_VERSION}/auth/verify-email/resend`,
+    FORGOT_PASSWORD: `/api/${API_VERSION}/auth/password/forgot`,
+    RESET_PASSWORD: `/api/${API_VERSION}/auth/password/reset`,
+    OIDC_CONFIG: `/api/${API_VERSION}/auth/oidc/config`,
+    OIDC_AUTHORIZE: `/api/${API_VERSION}/auth/oidc/authorize`,
+    OIDC_TOKEN: `/api/${API_VERSION}/auth/oidc/token`,
+    TOKEN: (id: number) => `/api/${API_VERSION}/auth/tokens/${id}`,
   },
   DEVICES: {
     REGISTER: `/api/${API_VERSION}/devices/register`,
     LIST: `/api/${API_VERSION}/devices`,
     UPDATE: (id: string) => `/api/${API_VERSION}/devices/${id}`,
     DELETE: (id: string) => `/api/${API_VERSION}/devices/${id}`,
+    APPROVE: (id: string) => `/api/${API_VERSION}/devices/${id}/approve`,
+    DENY: (id: string) => `/api/${API_VERSION}/devices/${id}/deny`,
   },
   CLIPS: {
     PULL: `/api/${API_VERSION}/clips/pull`,
@@ -152,6 +779,72 @@ export const API_PATHS = {
     LIST: `/api/${API_VERSION}/clips`,
     GET: (id: string) => `/api/${API_VERSION}/clips/${id}`,
     DELETE: (id: string) => `/api/${API_VERSION}/clips/${id}`,
+    REVEAL: (id: string) => `/api/${API_VERSION}/clips/${id}/reveal`,
+  },
+  PAIRING: {
+    CREATE: `/api/${API_VERSION}/pairing`,
+    CLAIM: `/api/${API_VERSION}/pairing/claim`,
+    REDEEM: `/api/${API_VERSION}/pairing/redeem`,
+  },
+  TEAMS: {
+    LIST: `/api/${API_VERSION}/teams`,
+    GET: (id: number) => `/api/${API_VERSION}/teams/${id}`,
+    MEMBERS: (id: number) => `/api/${API_VERSION}/teams/${id}/members`,
+    MEMBER: (id: number, userId: number) => `/api/${API_VERSION}/teams/${id}/members/${userId}`,
+  },
+  SHARES: {
+    LIST: `/api/${API_VERSION}/shares`,
+    GET: (id: number) => `/api/${API_VERSION}/shares/${id}`,
+    VIEWS: (id: number) => `/api/${API_VERSION}/shares/${id}/views`,
+    PUBLIC: (slug: string) => `/s/${slug}`,
+    PUBLIC_RAW: (slug: string) => `/s/${slug}/raw`,
+    PUBLIC_DOWNLOAD: (slug: string) => `/s/${slug}/download`,
+  },
+  INBOUND: {
+    LIST: `/api/${API_VERSION}/inbound`,
+    CHANNEL: (channel: 'webhook' | 'email') => `/api/${API_VERSION}/inbound/${channel}`,
+    RECEIVE: (token: string) => `/in/${token}`,
+  },
+  RULES: {
+    LIST: `/api/${API_VERSION}/rules`,
+    GET: (id: number) => `/api/${API_VERSION}/rules/${id}`,
+    DRY_RUN: `/api/${API_VERSION}/rules/dry-run`,
   },
-  WS: `/api/${API_VERSION}/ws`,
-} as const;
\ No newline at end of file
+  WEBHOOKS: {
+    LIST: `/api/${API_VERSION}/webhooks`,
+    GET: (id: number) => `/api/${API_VERSION}/webhooks/${id}`,
+    ROTATE_SECRET: (id: number) => `/api/${API_VERSION}/webhooks/${id}/rotate-secret`,
+    PING: (id: number) => `/api/${API_VERSION}/webhooks/${id}/ping`,
+    DELIVERIES: (id: number) => `/api/${API_VERSION}/webhooks/${id}/deliveries`,
+    DELIVERY: (id: number, deliveryId: number) => `/api/${API_VERSION}/webhooks/${id}/deliveries/${deliveryId}`,
+    REDELIVER: (id: number, deliveryId: number) =>
+      `/api/${API_VERSION}/webhooks/${id}/deliveries/${deliveryId}/redeliver`,
+  },
+  ADMIN: {
+    USERS: `/api/${API_VERSION}/admin/users`,
+    USER: (id: number) => `/api/${API_VERSION}/admin/users/${id}`,
+    USER_DEVICES: (id: number) => `/api/${API_VERSION}/admin/users/${id}/devices`,
+    USER_ROLE: (id: number) => `/api/${API_VERSION}/admin/users/${id}/role`,
+    USER_SUSPEND: (id: number) => `/api/${API_VERSION}/admin/users/${id}/suspend`,
+    USER_BAN: (id: number) => `/api/${API_VERSION}/admin/users/${id}/ban`,
+    USER_ACTIVATE: (id: number) => `/api/${API_VERSION}/admin/users/${id}/activate`,
+    USER_LOGOUT: (id: number) => `/api/${API_VERSION}/admin/users/${id}/logout`,
+    USER_RESET_PASSWORD: (id: number) => `/api/${API_VERSION}/admin/users/${id}/reset-password`,
+    USER_UNLOCK: (id: number) => `/api/${API_VERSION}/admin/users/${id}/unlock`,
+    MAINTENANCE_CLEANUP: `/api/${API_VERSION}/admin/maintenance/cleanup`,
+    AUDIT_LOGS: `/api/${API_VERSION}/admin/audit-logs`,
+  },
+  WS: '/ws',
+  WS_TICKET: '/ws/ticket',
+} as const;
+
+/**
+ * WebSocket 连接票据（POST /ws/ticket）
+ * 票据只能使用一次，握手时以 `?ticket=` 携带
+ */
+export interface WsTicketResponse {
+  ticket: string;
+  device_id: string;
+  expires_at: string;
+  expires_in: number;
+}
\ No newline at end of file
@@ -19,9 +19,25 @@ import type {
   PushClipsResponse,
   WsMessage,
   WsEventType,
+  WsTicketResponse,
+  PairingSessionResponse,
+  PairingClaimRequest,
+  PairingClaimResponse,
+  DeviceTrustLevel,
+  SessionInfo,
+  AccessTokenInfo,
+  CreateAccessTokenRequest,
+  CreateAccessTokenResponse,
+  TwoFactorSetupResponse,
+  TwoFactorStatus,
+  VerifyTwoFactorRequest,
+  ResetPasswordRequest,
+  OidcConfigResponse,
+  OidcAuthorizeRequest,
+  OidcAuthorizeResponse,
 } from '@xpaste/protocol';
 
-import { API_PATHS, WS_EVENTS } from '@xpaste/protocol';
+import { API_PATHS, WS_EVENTS, WS_MESSAGE_TYPES } from '@xpaste/protocol';
 
 /**
  * SDK 配置
@@ -143,6 +159,187 @@ export class XPasteSyncClient {
     return results;
   }
 
+  /**
+   * 在已登录的设备上发起配对，返回配对码和二维码内容
+   */
+  async startPairing(): Promise<PairingSessionResponse> {
+    const response = await this.request<PairingSessionResponse>('POST', API_PATHS.PAIRING.CREATE, {
+      device_id: this.authInfo?.deviceId,
+    });
+    return response.data!;
+  }
+
+  /**
+   * 新设备认领配对（配对码或二维码中的 pairing_id + secret）
+   */
+  async claimPairing(request: PairingClaimRequest): Promise<PairingClaimResponse> {
+    const response = await this.request<PairingClaimResponse>('POST', API_PATHS.PAIRING.CLAIM, request);
+    return response.data!;
+  }
+
+  /**
+   * 新设备轮询配对结果，等待批准时 data.status 为 claimed，批准后返回设备和令牌
+   */
+  async redeemPairing(claimToken: string): Promise<ApiResponse> {
+    return this.request('POST', API_PATHS.PAIRING.REDEEM, { claim_token: claimToken });
+  }
+
+  /**
+   * 发起设备批准或拒绝配对请求（通过 WebSocket 发送）
+   */
+  decidePairing(pairingId: string, approve: boolean, trustLevel?: Exclude<DeviceTrustLevel, 'none'>): void {
+    if (!this.ws || this.ws.readyState !== WebSocket.OPEN) {
+      throw new Error('WebSocket is not connected');
+    }
+
+    this.ws.send(JSON.stringify({
+      type: approve ? WS_MESSAGE_TYPES.PAIRING_APPROVE : WS_MESSAGE_TYPES.PAIRING_DENY,
+      data: { pairing_id: pairingId, trust_level: approve ? trustLevel : undefined },
+      timestamp: Math.floor(Date.now() / 1000),
+    }));
+  }
+
+  /**
+   * 批准等待中的新设备，或调整已批准设备的信任级别（需在完全信任的设备上调用）
+   */
+  async approveDevice(deviceId: string, trustLevel?: Exclude<DeviceTrustLevel, 'none'>): Promise<ApiResponse> {
+    return this.request('POST', API_PATHS.DEVICES.APPROVE(deviceId), { trust_level: trustLevel });
+  }
+
+  /**
+   * 拒绝等待批准的新设备
+   */
+  async denyDevice(deviceId: string): Promise<ApiResponse> {
+    return this.request('POST', API_PATHS.DEVICES.DENY(deviceId));
+  }
+
+  /**
+   * 列出当前用户的有效登录会话
+   */
+  async listSessions(): Promise<SessionInfo[]> {
+    const response = await this.request<SessionInfo[]>('GET', API_PATHS.AUTH.SESSIONS);
+    return response.data ?? [];
+  }
+
+  /**
+   * 撤销指定登录会话
+   */
+  async revokeSession(sessionId: string): Promise<ApiResponse> {
+    return this.request('DELETE', API_PATHS.AUTH.SESSION(sessionId));
+  }
+
+  /**
+   * 获取两步验证状态
+   */
+  async getTwoFactorStatus(): Promise<TwoFactorStatus> {
+    const response = await this.request<TwoFactorStatus>('GET', API_PATHS.AUTH.TWO_FACTOR);
+    return response.data!;
+  }
+
+  /**
+   * 生成 TOTP 密钥，返回的 otpauth_uri 用于生成二维码
+   */
+  async setupTwoFactor(): Promise<TwoFactorSetupResponse> {
+    const response = await this.request<TwoFactorSetupResponse>('POST', API_PATHS.AUTH.TWO_FACTOR_SETUP);
+    return response.data!;
+  }
+
+  /**
+   * 用验证码确认并开启两步验证，返回一次性恢复码
+   */
+  async enableTwoFactor(code: string): Promise<string[]> {
+    const response = await this.request<{ recovery_codes: string[] }>('POST', API_PATHS.AUTH.TWO_FACTOR_ENABLE, { code });
+    return response.data!.recovery_codes;
+  }
+
+  /**
+   * 关闭两步验证
+   */
+  async disableTwoFactor(password: string, code: string): Promise<ApiResponse> {
+    return this.request('POST', API_PATHS.AUTH.TWO_FACTOR_DISABLE, { password, code });
+  }
+
+  /**
+   * 登录返回挑战令牌后，提交验证码完成登录
+   */
+  async verifyTwoFactor(request: VerifyTwoFactorRequest): Promise<ApiResponse> {
+    return this.request('POST', API_PATHS.AUTH.TWO_FACTOR_VERIFY, request);
+  }
+
+  /**
+   * 提交验证邮件中的令牌验证邮箱
+   */
+  async verifyEmail(token: string): Promise<ApiResponse> {
+    return this.request('POST', API_PATHS.AUTH.VERIFY_EMAIL, { token });
+  }
+
+  /**
+   * 重新发送验证邮件
+   */
+  async resendVerification(email: string): Promise<ApiResponse> {
+    return this.request('POST', API_PATHS.AUTH.RESEND_VERIFICATION, { email });
+  }
+
+  /**
+   * 发送密码重置邮件
+   */
+  async forgotPassword(email: string): Promise<ApiResponse> {
+    return this.request('POST', API_PATHS.AUTH.FORGOT_PASSWORD, { email });
+  }
+
+  /**
+   * 凭重置邮件中的令牌设置新密码，成功后所有会话失效
+   */
+  async resetPassword(request: ResetPasswordRequest): Promise<ApiResponse> {
+    return this.request('POST', API_PATHS.AUTH.RESET_PASSWORD, request);
+  }
+
+  /**
+   * 获取单点登录配置（是否启用、身份提供方名称）
+   */
+  async getOidcConfig(): Promise<OidcConfigResponse> {
+    const response = await this.request<OidcConfigResponse>('GET', API_PATHS.AUTH.OIDC_CONFIG);
+    return response.data!;
+  }
+
+  /**
+   * 发起单点登录，返回需在浏览器中打开的授权地址和登录令牌
+   */
+  async startOidcLogin(request: OidcAuthorizeRequest = {}): Promise<OidcAuthorizeResponse> {
+    const response = await this.request<OidcAuthorizeResponse>('POST', API_PATHS.AUTH.OIDC_AUTHORIZE, request);
+    return response.data!;
+  }
+
+  /**
+   * 用登录令牌兑换会话；浏览器中尚未完成登录时服务端返回 202，需稍后重试
+   */
+  async redeemOidcLogin(loginToken: string): Promise<ApiResponse> {
+    return this.request('POST', API_PATHS.AUTH.OIDC_TOKEN, { login_token: loginToken });
+  }
+
+  /**
+   * 创建个人访问令牌（令牌明文只返回一次）
+   */
+  async createAccessToken(request: CreateAccessTokenRequest): Promise<CreateAccessTokenResponse> {
+    const response = await this.request<CreateAccessTokenResponse>('POST', API_PATHS.AUTH.TOKENS, request);
+    return response.data!;
+  }
+
+  /**
+   * 列出个人访问令牌
+   */
+  async listAccessTokens(): Promise<AccessTokenInfo[]> {
+    const response = await this.request<AccessTokenInfo[]>('GET', API_PATHS.AUTH.TOKENS);
+    return response.data ?? [];
+  }
+
+  /**
+   * 撤销个人访问令牌
+   */
+  async revokeAccessToken(id: number): Promise<ApiResponse> {
+    return this.request('DELETE', API_PATHS.AUTH.TOKEN(id));
+  }
+
   /**
    * 连接 WebSocket
    */
@@ -151,7 +348,15 @@ export class XPasteSyncClient {
       throw new Error('Not authenticated');
     }
 
-    const wsUrl = `${this.config.baseUrl.replace('http', 'ws')}${API_PATHS.WS}?token=${this.authInfo.token}`;
+    // 先换取一次性连接票据，避免把 JWT 放进 URL
+    const response = await this.request<WsTicketResponse>('POST', API_PATHS.WS_TICKET, {
+      device_id: this.authInfo.deviceId,
+    });
+    if (!response.data) {
+      throw new Error('Failed to obtain WebSocket ticket');
+    }
+
+    const wsUrl = `${this.config.baseUrl.replace('http', 'ws')}${API_PATHS.WS}?ticket=${encodeURIComponent(response.data.ticket)}`;
     
     this.ws = new WebSocket(wsUrl);
     
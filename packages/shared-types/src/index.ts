@@ -15,9 +15,56 @@ export interface ClipItem {
   contentRef: string;
   createdAt: number;
   deviceId: string;
+  /** 归属范围，默认 user；team 表示团队共享剪贴板项 */
+  scope?: 'user' | 'team';
+  teamId?: string;
   deleted?: boolean;
   note?: string;
   favorite?: boolean;
+  /** 识别到敏感数据时存在 */
+  sensitive?: ClipSensitiveInfo;
+  /** 服务端识别的内容子类型 */
+  subtype?: ClipSubtype;
+  classification?: ClipClassification;
+}
+
+/**
+ * 内容子类型
+ */
+export type ClipSubtype = 'code' | 'json' | 'email' | 'phone' | 'color' | 'path' | 'date' | 'otp' | 'url';
+
+/**
+ * 内容识别结果，只包含与子类型相关的字段
+ */
+export interface ClipClassification {
+  subtype: ClipSubtype;
+  /** code：语言 */
+  language?: string;
+  /** url：规范化后的链接 */
+  url?: string;
+  host?: string;
+  email?: string;
+  /** phone：只保留数字和开头的 + */
+  phone?: string;
+  /** color：#rrggbb 或 #rrggbbaa */
+  color?: string;
+  path?: string;
+  path_style?: 'unix' | 'windows';
+  /** date：RFC3339，只有日期时为 YYYY-MM-DD */
+  date?: string;
+  otp?: string;
+}
+
+/**
+ * 剪贴板项的敏感数据标记
+ */
+export interface ClipSensitiveInfo {
+  /** 敏感数据类别，如 credit_card、aws_access_key、jwt、private_key、high_entropy */
+  kinds: string[];
+  /** 响应中的内容已遮盖，原始内容需调用 reveal 接口获取 */
+  masked: boolean;
+  /** 不同步到这些平台 */
+  excluded_platforms?: string[];
 }
 
 /**
@@ -45,6 +92,7 @@ export interface Setting {
  */
 export interface HistoryFilter {
   type?: ClipItemType;
+  subtype?: ClipSubtype[];
   keyword?: string;
   favorite?: boolean;
   limit?: number;
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 根据 packages/protocol 中的 WebSocket 协议定义生成 Go 代码
+
+// messageField Message 结构体字段在 Go 侧的类型映射
+type messageField struct {
+	GoName string
+	GoType string
+}
+
+// 已知字段的 Go 类型，新增字段时需要在这里补充
+var knownFields = map[string]messageField{
+	"type":       {GoName: "Type", GoType: "MessageType"},
+	"data":       {GoName: "Data", GoType: "interface{}"},
+	"timestamp":  {GoName: "Timestamp", GoType: "int64"},
+	"message_id": {GoName: "MessageID", GoType: "string"},
+	"silent":     {GoName: "Silent", GoType: "bool"},
+}
+
+// 可省略的字段（对应 TypeScript 中的可选字段）
+var optionalFields = map[string]bool{
+	"data":       true,
+	"message_id": true,
+	"silent":     true,
+}
+
+var (
+	versionPattern = regexp.MustCompile(`export const WS_SCHEMA_VERSION = (\d+);`)
+	entryPattern   = regexp.MustCompile(`^\s*([A-Za-z_]+):\s*(?:'([^']*)'|(\d+)),?\s*$`)
+)
+
+type entry struct {
+	Key   string
+	Value string
+}
+
+func main() {
+	var (
+		in  = flag.String("in", "../../../../packages/protocol/src/index.ts", "协议定义文件")
+		out = flag.String("out", "protocol_gen.go", "生成的 Go 文件")
+		pkg = flag.String("pkg", "websocket", "生成代码的包名")
+	)
+	flag.Parse()
+
+	src, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatalf("Failed to read protocol definitions: %v", err)
+	}
+
+	code, err := generate(string(src), *pkg)
+	if err != nil {
+		log.Fatalf("Failed to generate protocol code: %v", err)
+	}
+
+	if err := os.WriteFile(*out, code, 0644); err != nil {
+		log.Fatalf("Failed to write %s: %v", *out, err)
+	}
+}
+
+// generate 生成协议代码
+func generate(src, pkg string) ([]byte, error) {
+	match := versionPattern.FindStringSubmatch(src)
+	if match == nil {
+		return nil, fmt.Errorf("WS_SCHEMA_VERSION not found")
+	}
+	version := match[1]
+
+	subprotocols, err := parseObject(src, "WS_SUBPROTOCOLS")
+	if err != nil {
+		return nil, err
+	}
+	messageTypes, err := parseObject(src, "WS_MESSAGE_TYPES")
+	if err != nil {
+		return nil, err
+	}
+	closeCodes, err := parseObject(src, "WS_CLOSE_CODES")
+	if err != nil {
+		return nil, err
+	}
+	fields, err := parseObject(src, "WS_MESSAGE_FIELDS")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gen-protocol from packages/protocol/src/index.ts. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+
+	fmt.Fprintf(&buf, "// SchemaVersion 消息编码 schema 版本\n")
+	fmt.Fprintf(&buf, "const SchemaVersion = %s\n\n", version)
+
+	fmt.Fprintf(&buf, "// WebSocket 子协议\n")
+	fmt.Fprintf(&buf, "const (\n")
+	for _, e := range subprotocols {
+		fmt.Fprintf(&buf, "\tSubprotocol%s = %q\n", camelCase(e.Key), e.Value)
+	}
+	fmt.Fprintf(&buf, ")\n\n")
+
+	fmt.Fprintf(&buf, "// 消息类型\n")
+	fmt.Fprintf(&buf, "const (\n")
+	for _, e := range messageTypes {
+		fmt.Fprintf(&buf, "\tMessageType%s MessageType = %q\n", camelCase(e.Key), e.Value)
+	}
+	fmt.Fprintf(&buf, ")\n\n")
+
+	fmt.Fprintf(&buf, "// 关闭码\n")
+	fmt.Fprintf(&buf, "const (\n")
+	for _, e := range closeCodes {
+		fmt.Fprintf(&buf, "\tClose%s = %s\n", camelCase(e.Key), e.Value)
+	}
+	fmt.Fprintf(&buf, ")\n\n")
+
+	fmt.Fprintf(&buf, "// Message WebSocket 消息结构\n")
+	fmt.Fprintf(&buf, "type Message struct {\n")
+	for _, e := range fields {
+		field, ok := knownFields[e.Key]
+		if !ok {
+			return nil, fmt.Errorf("unknown message field %q, add it to knownFields", e.Key)
+		}
+		jsonTag, cborTag := e.Key, e.Value+",keyasint"
+		if optionalFields[e.Key] {
+			jsonTag += ",omitempty"
+			cborTag += ",omitempty"
+		}
+		fmt.Fprintf(&buf, "\t%s %s `json:\"%s\" cbor:\"%s\"`\n", field.GoName, field.GoType, jsonTag, cborTag)
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	return format.Source(buf.Bytes())
+}
+
+// parseObject 解析 `export const NAME = { KEY: 'value', ... } as const;` 形式的定义
+func parseObject(src, name string) ([]entry, error) {
+	start := strings.Index(src, "export const "+name+" = {")
+	if start < 0 {
+		return nil, fmt.Errorf("%s not found", name)
+	}
+	end := strings.Index(src[start:], "} as const;")
+	if end < 0 {
+		return nil, fmt.Errorf("%s is not terminated", name)
+	}
+
+	body := src[start : start+end]
+	lines := strings.Split(body, "\n")[1:]
+
+	var entries []entry
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		m := entryPattern.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("cannot parse %s entry: %q", name, strings.TrimSpace(line))
+		}
+		value := m[2]
+		if m[3] != "" {
+			if _, err := strconv.Atoi(m[3]); err != nil {
+				return nil, fmt.Errorf("invalid number in %s: %w", name, err)
+			}
+			value = m[3]
+		}
+		entries = append(entries, entry{Key: m[1], Value: value})
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s is empty", name)
+	}
+	return entries, nil
+}
+
+// 按 Go 命名习惯保持全大写的缩写
+var initialisms = map[string]string{
+	"id":   "ID",
+	"url":  "URL",
+	"json": "JSON",
+	"cbor": "CBOR",
+}
+
+// camelCase 将 CLIP_NEW / message_id 转换为 ClipNew / MessageID
+func camelCase(s string) string {
+	parts := strings.Split(strings.ToLower(s), "_")
+	for i, p := range parts {
+		if p == "" {
+			continue
+		}
+		if initialism, ok := initialisms[p]; ok {
+			parts[i] = initialism
+			continue
+		}
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "")
+}
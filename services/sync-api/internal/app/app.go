@@ -14,8 +14,11 @@ import (
 	"xpaste-sync/internal/config"
 	"xpaste-sync/internal/database"
 	"xpaste-sync/internal/handlers"
+	"xpaste-sync/internal/inbound"
 	"xpaste-sync/internal/logger"
+	"xpaste-sync/internal/mailer"
 	"xpaste-sync/internal/middleware"
+	"xpaste-sync/internal/oidc"
 	"xpaste-sync/internal/services"
 	"xpaste-sync/internal/websocket"
 )
@@ -27,6 +30,7 @@ type App struct {
 	services  *services.Services
 	handlers  *handlers.Handlers
 	websocket *websocket.WebSocketService
+	smtp      *inbound.SMTPServer // 未启用邮件入站时为 nil
 }
 
 // New 创建新的应用程序实例
@@ -37,6 +41,11 @@ func New() (*App, error) {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
+	// 加载 JWT 签名密钥
+	if err := middleware.ConfigureJWT(&cfg.JWT); err != nil {
+		return nil, fmt.Errorf("failed to configure JWT: %w", err)
+	}
+
 	// 初始化日志系统
 	if err := logger.Initialize(cfg); err != nil {
 		return nil, fmt.Errorf("failed to initialize logger: %w", err)
@@ -62,9 +71,28 @@ func New() (*App, error) {
 	if err := services.InitializeServices(); err != nil {
 		return nil, fmt.Errorf("failed to initialize services: %w", err)
 	}
+	services.Token.SetSessionTTL(cfg.JWT.RefreshTokenTTL)
+	services.TwoFactor.Configure(cfg.Auth.TwoFactorChallengeTTL, cfg.Auth.TwoFactorTrustWindow)
+	services.LoginGuard.Configure(cfg.Auth.LockoutThreshold, cfg.Auth.IPLockoutThreshold, cfg.Auth.LockoutDuration, cfg.Auth.FailureWindow)
+	middleware.ConfigureAdmins(cfg.Auth.AdminUsers)
+
+	// 初始化邮件发送器
+	mail, err := mailer.New(&cfg.Mail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mailer: %w", err)
+	}
+	services.Email.Configure(mail, cfg.Mail.LinkBaseURL, cfg.Auth.EmailVerificationTTL, cfg.Auth.PasswordResetTTL)
+	services.Share.Configure(cfg.Share.BaseURL, cfg.Share.MaxTTL)
+	services.Webhook.Configure(webhookOptions(&cfg.Webhook))
+	services.Inbound.Configure(inboundOptions(&cfg.Inbound))
+
+	// 配置单点登录，身份提供方元数据在首次登录时获取
+	if cfg.OIDC.Enabled() {
+		services.OIDC.Configure(oidcOptions(&cfg.OIDC))
+	}
 
 	// 初始化 WebSocket 服务
-	websocketService := websocket.NewWebSocketService(services)
+	websocketService := websocket.NewWebSocketService(services, cfg)
 
 	// 初始化处理器
 	handlers := handlers.NewHandlers(services)
@@ -95,19 +123,82 @@ func New() (*App, error) {
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
-	return &App{
+	app := &App{
 		config:    cfg,
 		server:    server,
 		services:  services,
 		handlers:  handlers,
 		websocket: websocketService,
-	}, nil
+	}
+	if cfg.Inbound.SMTPEnabled() {
+		app.smtp = inbound.NewSMTPServer(inbound.SMTPOptions{
+			Addr:     cfg.Inbound.SMTPAddr,
+			Hostname: cfg.Inbound.SMTPHostname,
+			MaxSize:  cfg.Inbound.MaxSize,
+		}, services.Inbound)
+	}
+	return app, nil
+}
+
+// oidcOptions 根据配置创建单点登录选项
+func oidcOptions(cfg *config.OIDCConfig) services.OIDCOptions {
+	return services.OIDCOptions{
+		Provider: oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.Issuer,
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+			GroupsClaim:  cfg.GroupsClaim,
+		}),
+		ProviderName:         cfg.ProviderName,
+		RoleMapping:          cfg.RoleMapping,
+		DefaultRole:          cfg.DefaultRole,
+		AutoProvision:        cfg.AutoProvision,
+		AllowedDomains:       cfg.AllowedDomains,
+		RequireVerifiedEmail: cfg.RequireVerifiedEmail,
+		AllowedRedirectURIs:  cfg.AllowedRedirectURIs,
+	}
+}
+
+// webhookOptions 根据配置创建 webhook 投递选项
+func webhookOptions(cfg *config.WebhookConfig) services.WebhookOptions {
+	return services.WebhookOptions{
+		Timeout:              cfg.Timeout,
+		MaxAttempts:          cfg.MaxAttempts,
+		RetryBase:            cfg.RetryBase,
+		RetryMax:             cfg.RetryMax,
+		AllowPrivateNetworks: cfg.AllowPrivateNetworks,
+		MaxPerUser:           cfg.MaxPerUser,
+	}
+}
+
+// inboundOptions 根据配置创建入站选项，未启用邮件入站时不设置收件域名
+func inboundOptions(cfg *config.InboundConfig) services.InboundOptions {
+	opts := services.InboundOptions{
+		BaseURL: cfg.BaseURL,
+		MaxSize: cfg.MaxSize,
+	}
+	if cfg.SMTPEnabled() {
+		opts.EmailDomain = cfg.EmailDomain
+	}
+	return opts
 }
 
 // Run 启动应用程序
 func (a *App) Run() error {
 	// 启动 WebSocket 服务
-	a.websocket.Start()
+	a.websocket.Start(context.Background())
+
+	// 启动 webhook 投递
+	a.services.Webhook.Start(context.Background())
+
+	// 启动邮件入站
+	if a.smtp != nil {
+		if err := a.smtp.Start(); err != nil {
+			return err
+		}
+	}
 
 	// 启动 HTTP 服务器
 	go func() {
@@ -134,8 +225,22 @@ func (a *App) Shutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// 停止 WebSocket 服务
-	a.websocket.Stop()
+	// 排空 WebSocket 连接：拒绝新连接，通知客户端稍后重连并等待发送队列清空
+	drainCtx, drainCancel := context.WithTimeout(ctx, a.config.Sync.ShutdownDrainTimeout)
+	if err := a.websocket.Stop(drainCtx); err != nil {
+		logger.Warnf("WebSocket connections not fully drained: %v", err)
+	}
+	drainCancel()
+
+	// 停止接收邮件，等待进行中的会话结束
+	if a.smtp != nil {
+		if err := a.smtp.Shutdown(ctx); err != nil {
+			logger.Warnf("Inbound SMTP sessions not fully closed: %v", err)
+		}
+	}
+
+	// 停止 webhook 投递，未完成的投递在下次启动后继续
+	a.services.Webhook.Stop()
 
 	// 关闭 HTTP 服务器
 	if err := a.server.Shutdown(ctx); err != nil {
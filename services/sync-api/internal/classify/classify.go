@@ -0,0 +1,192 @@
+// Package classify 识别文本剪贴板内容的子类型（代码、JSON、邮箱、电话、颜色、文件路径、日期、验证码和链接）
+//
+// 识别结果包含规范化后的值，客户端据此提供快捷操作，例如打开链接、拨打电话、复制验证码
+package classify
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/sharepage"
+)
+
+// maxLineLength 单行子类型（链接、邮箱等）的最大长度，更长的内容不做单行识别
+const maxLineLength = 4096
+
+var (
+	otpPattern        = regexp.MustCompile(`^(?:\d{4,8}|\d{3}[- ]\d{3})$`)
+	otpMessagePattern = regexp.MustCompile(`(?i)(?:code|otp|passcode|pin|验证码|校验码|动态码)\D{0,20}?\b(\d{4,8})\b`)
+	otpSuffixPattern  = regexp.MustCompile(`(?i)\b(\d{4,8})\b\D{0,30}?(?:code|otp|passcode|验证码|校验码|动态码)`)
+	emailPattern      = regexp.MustCompile(`^[A-Za-z0-9._%+-]+@[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?)*\.[A-Za-z]{2,}$`)
+	phonePattern      = regexp.MustCompile(`^\+?[0-9(][0-9 ().-]{5,}[0-9]$`)
+	windowsPathRegexp = regexp.MustCompile(`^(?:[A-Za-z]:[\\/]|\\\\[^\\\s]+\\)`)
+)
+
+// dateLayouts 日期格式，只有日期的格式在后
+var dateLayouts = []struct {
+	layout   string
+	dateOnly bool
+}{
+	{time.RFC3339Nano, false},
+	{"2006-01-02T15:04:05", false},
+	{"2006-01-02 15:04:05", false},
+	{"2006-01-02 15:04", false},
+	{"2006/01/02 15:04:05", false},
+	{"2006/01/02 15:04", false},
+	{time.RFC1123Z, false},
+	{time.RFC1123, false},
+	{time.RFC850, false},
+	{time.RFC822Z, false},
+	{time.RFC822, false},
+	{time.UnixDate, false},
+	{time.ANSIC, false},
+	{"2006-01-02", true},
+	{"2006/01/02", true},
+	{"2006.01.02", true},
+	{"2006年1月2日", true},
+	{"January 2, 2006", true},
+	{"Jan 2, 2006", true},
+	{"2 January 2006", true},
+	{"2 Jan 2006", true},
+}
+
+// lineDetectors 单行内容的识别顺序：纯数字先按验证码识别，日期先于电话识别
+var lineDetectors = []func(string) *models.ClipClassification{
+	detectOTP,
+	detectURL,
+	detectEmail,
+	detectDate,
+	detectPhone,
+	detectColor,
+	detectPath,
+}
+
+// Classify 识别内容的子类型，无法识别时返回 nil
+// hint、fileName 为客户端在 metadata 中提供的语言和文件名，用于识别代码的语言
+func Classify(content string, hint string, fileName string) *models.ClipClassification {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return nil
+	}
+
+	if len(trimmed) <= maxLineLength && !strings.ContainsAny(trimmed, "\r\n") {
+		for _, detect := range lineDetectors {
+			if result := detect(trimmed); result != nil {
+				return result
+			}
+		}
+	}
+
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+		return &models.ClipClassification{Subtype: models.ClipSubtypeJSON}
+	}
+	if result := detectOTPMessage(trimmed); result != nil {
+		return result
+	}
+	switch language := sharepage.DetectLanguage(content, hint, fileName); language {
+	case sharepage.LanguagePlainText, sharepage.LanguageMarkdown:
+	default:
+		return &models.ClipClassification{Subtype: models.ClipSubtypeCode, Language: language}
+	}
+	return nil
+}
+
+// detectOTP 纯数字验证码，如 123456、123-456
+func detectOTP(s string) *models.ClipClassification {
+	if !otpPattern.MatchString(s) {
+		return nil
+	}
+	return &models.ClipClassification{Subtype: models.ClipSubtypeOTP, OTP: digitsOnly(s)}
+}
+
+// detectOTPMessage 短信、邮件中的验证码，如“您的验证码是 123456”“123456 is your code”
+func detectOTPMessage(s string) *models.ClipClassification {
+	if len(s) > 300 || strings.Count(s, "\n") > 4 {
+		return nil
+	}
+	for _, pattern := range []*regexp.Regexp{otpMessagePattern, otpSuffixPattern} {
+		if m := pattern.FindStringSubmatch(s); m != nil {
+			return &models.ClipClassification{Subtype: models.ClipSubtypeOTP, OTP: m[1]}
+		}
+	}
+	return nil
+}
+
+// detectEmail 邮箱地址，域名转为小写
+func detectEmail(s string) *models.ClipClassification {
+	if !emailPattern.MatchString(s) {
+		return nil
+	}
+	at := strings.LastIndexByte(s, '@')
+	return &models.ClipClassification{Subtype: models.ClipSubtypeEmail, Email: s[:at] + strings.ToLower(s[at:])}
+}
+
+// detectPhone 电话号码，7 到 15 位数字；没有 + 和分隔符的纯数字只接受 10、11 位，避免把编号当作电话
+func detectPhone(s string) *models.ClipClassification {
+	if !phonePattern.MatchString(s) {
+		return nil
+	}
+	digits := digitsOnly(s)
+	if len(digits) < 7 || len(digits) > 15 {
+		return nil
+	}
+	if len(digits) == len(s) && len(digits) != 10 && len(digits) != 11 {
+		return nil
+	}
+	if strings.HasPrefix(s, "+") {
+		digits = "+" + digits
+	}
+	return &models.ClipClassification{Subtype: models.ClipSubtypePhone, Phone: digits}
+}
+
+// detectDate 日期和时间，没有时区时按 UTC 处理
+func detectDate(s string) *models.ClipClassification {
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout.layout, s)
+		if err != nil {
+			continue
+		}
+		if layout.dateOnly {
+			return &models.ClipClassification{Subtype: models.ClipSubtypeDate, Date: t.Format("2006-01-02")}
+		}
+		return &models.ClipClassification{Subtype: models.ClipSubtypeDate, Date: t.Format(time.RFC3339)}
+	}
+	return nil
+}
+
+// detectPath Unix 路径（/、~/、./、../ 开头）和 Windows 路径（盘符或 UNC）
+func detectPath(s string) *models.ClipClassification {
+	if windowsPathRegexp.MatchString(s) {
+		return &models.ClipClassification{Subtype: models.ClipSubtypePath, Path: s, PathStyle: "windows"}
+	}
+
+	rest := s
+	for _, prefix := range []string{"~/", "./", "../", "/"} {
+		if strings.HasPrefix(s, prefix) {
+			rest = s[len(prefix):]
+			break
+		}
+	}
+	if rest == s || rest == "" || strings.HasPrefix(rest, "/") || strings.ContainsAny(rest, "<>|\"") {
+		return nil
+	}
+	// 包含空格时至少有两级目录，避免把 /giphy cats 这样的斜杠命令当作路径
+	if strings.Contains(rest, " ") && !strings.Contains(rest, "/") {
+		return nil
+	}
+	return &models.ClipClassification{Subtype: models.ClipSubtypePath, Path: s, PathStyle: "unix"}
+}
+
+// digitsOnly 只保留数字
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package classify
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"xpaste-sync/internal/models"
+)
+
+var (
+	hexColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+	rgbColorPattern = regexp.MustCompile(`(?i)^rgba?\(\s*(\d{1,3})\s*[, ]\s*(\d{1,3})\s*[, ]\s*(\d{1,3})\s*(?:[,/]\s*([\d.]+%?)\s*)?\)$`)
+	hslColorPattern = regexp.MustCompile(`(?i)^hsla?\(\s*([\d.]+)(?:deg)?\s*[, ]\s*([\d.]+)%\s*[, ]\s*([\d.]+)%\s*(?:[,/]\s*([\d.]+%?)\s*)?\)$`)
+)
+
+// detectColor CSS 颜色值（#hex、rgb()、hsl()），统一转换为小写的 #rrggbb 或 #rrggbbaa
+// 三、四位的 #hex 需要包含字母，避免把 #123 这样的 issue 编号当作颜色
+func detectColor(s string) *models.ClipClassification {
+	var color string
+	switch {
+	case hexColorPattern.MatchString(s):
+		hex := strings.ToLower(s[1:])
+		if len(hex) <= 4 {
+			if !strings.ContainsAny(hex, "abcdef") {
+				return nil
+			}
+			var expanded strings.Builder
+			for i := 0; i < len(hex); i++ {
+				expanded.WriteString(strings.Repeat(string(hex[i]), 2))
+			}
+			hex = expanded.String()
+		}
+		color = "#" + hex
+	case rgbColorPattern.MatchString(s):
+		m := rgbColorPattern.FindStringSubmatch(s)
+		r, _ := strconv.Atoi(m[1])
+		g, _ := strconv.Atoi(m[2])
+		b, _ := strconv.Atoi(m[3])
+		if r > 255 || g > 255 || b > 255 {
+			return nil
+		}
+		alpha, ok := parseAlpha(m[4])
+		if !ok {
+			return nil
+		}
+		color = hexColor(r, g, b, alpha)
+	case hslColorPattern.MatchString(s):
+		m := hslColorPattern.FindStringSubmatch(s)
+		h, _ := strconv.ParseFloat(m[1], 64)
+		sat, _ := strconv.ParseFloat(m[2], 64)
+		l, _ := strconv.ParseFloat(m[3], 64)
+		if sat > 100 || l > 100 {
+			return nil
+		}
+		alpha, ok := parseAlpha(m[4])
+		if !ok {
+			return nil
+		}
+		r, g, b := hslToRGB(math.Mod(h, 360), sat/100, l/100)
+		color = hexColor(r, g, b, alpha)
+	default:
+		return nil
+	}
+	return &models.ClipClassification{Subtype: models.ClipSubtypeColor, Color: color}
+}
+
+// parseAlpha 解析透明度（0-1 或百分比），未提供时为 1
+func parseAlpha(value string) (float64, bool) {
+	if value == "" {
+		return 1, true
+	}
+	percent := strings.HasSuffix(value, "%")
+	alpha, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 0, false
+	}
+	if percent {
+		alpha /= 100
+	}
+	return alpha, alpha >= 0 && alpha <= 1
+}
+
+// hexColor 转换为 #rrggbb，不透明时省略透明度
+func hexColor(r, g, b int, alpha float64) string {
+	if alpha >= 1 {
+		return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", r, g, b, int(math.Round(alpha*255)))
+}
+
+// hslToRGB HSL 转 RGB，h 为角度，s、l 为 0-1
+func hslToRGB(h, s, l float64) (int, int, int) {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return int(math.Round((r + m) * 255)), int(math.Round((g + m) * 255)), int(math.Round((b + m) * 255))
+}
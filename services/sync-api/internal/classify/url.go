@@ -0,0 +1,99 @@
+package classify
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"xpaste-sync/internal/models"
+)
+
+// trackingParams 规范化链接时移除的跟踪参数，utm_ 开头的参数也会移除
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "yclid": true,
+	"mc_cid": true, "mc_eid": true, "igshid": true, "_hsenc": true, "_hsmi": true,
+}
+
+// detectURL 链接，www. 开头时补全 https；mailto: 识别为邮箱，file: 识别为文件路径
+func detectURL(s string) *models.ClipClassification {
+	if strings.ContainsAny(s, " \t") {
+		return nil
+	}
+	raw := s
+	if strings.HasPrefix(strings.ToLower(raw), "www.") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return nil
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "mailto":
+		address, _, _ := strings.Cut(u.Opaque, "?")
+		if address, err = url.PathUnescape(address); err != nil {
+			return nil
+		}
+		return detectEmail(address)
+	case "file":
+		if u.Path == "" {
+			return nil
+		}
+		if path := strings.TrimPrefix(u.Path, "/"); windowsPathRegexp.MatchString(path) {
+			return &models.ClipClassification{Subtype: models.ClipSubtypePath, Path: path, PathStyle: "windows"}
+		}
+		return &models.ClipClassification{Subtype: models.ClipSubtypePath, Path: u.Path, PathStyle: "unix"}
+	case "http", "https", "ftp", "ftps", "ws", "wss":
+		if !validHost(u.Hostname()) {
+			return nil
+		}
+		normalized := NormalizeURL(u)
+		return &models.ClipClassification{Subtype: models.ClipSubtypeURL, URL: normalized.String(), Host: normalized.Hostname()}
+	}
+	return nil
+}
+
+// NormalizeURL 规范化链接：协议和域名转为小写，去掉默认端口、空路径、空的片段和跟踪参数，其余参数按名称排序
+func NormalizeURL(u *url.URL) *url.URL {
+	normalized := *u
+	normalized.Scheme = strings.ToLower(u.Scheme)
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (normalized.Scheme == "http" || normalized.Scheme == "ws") && port == "80" ||
+		(normalized.Scheme == "https" || normalized.Scheme == "wss") && port == "443" ||
+		normalized.Scheme == "ftp" && port == "21" {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	normalized.Host = host
+
+	if normalized.Path == "" && normalized.Opaque == "" {
+		normalized.Path = "/"
+	}
+	if normalized.RawQuery != "" {
+		query := normalized.Query()
+		for key := range query {
+			if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+				query.Del(key)
+			}
+		}
+		normalized.RawQuery = query.Encode()
+	}
+	normalized.ForceQuery = false
+	return &normalized
+}
+
+// validHost 域名至少包含一个点，或为 localhost、IP 地址
+func validHost(host string) bool {
+	if host == "" {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") || net.ParseIP(host) != nil {
+		return true
+	}
+	return strings.Contains(strings.Trim(host, "."), ".")
+}
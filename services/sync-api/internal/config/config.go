@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
@@ -13,6 +14,12 @@ type Config struct {
 	Server   ServerConfig   `json:"server"`
 	Database DatabaseConfig `json:"database"`
 	JWT      JWTConfig      `json:"jwt"`
+	Auth     AuthConfig     `json:"auth"`
+	Mail     MailConfig     `json:"mail"`
+	OIDC     OIDCConfig     `json:"oidc"`
+	Share    ShareConfig    `json:"share"`
+	Webhook  WebhookConfig  `json:"webhook"`
+	Inbound  InboundConfig  `json:"inbound"`
 	CORS     CORSConfig     `json:"cors"`
 	Log      LogConfig      `json:"log"`
 	Upload   UploadConfig   `json:"upload"`
@@ -40,13 +47,115 @@ type DatabaseConfig struct {
 	LogLevel        string        `json:"log_level"` // silent, error, warn, info
 }
 
+// DefaultJWTSecret 未设置 JWT_SECRET 时使用的开发密钥，公开在源码中，release 模式下拒绝使用
+const DefaultJWTSecret = "xpaste-secret-key-change-in-production"
+
 // JWTConfig JWT 配置
 type JWTConfig struct {
 	Secret           string        `json:"secret"`
 	AccessTokenTTL   time.Duration `json:"access_token_ttl"`
-	RefreshTokenTTL  time.Duration `json:"refresh_token_ttl"`
+	RefreshTokenTTL  time.Duration `json:"refresh_token_ttl"` // 会话（刷新令牌家族）有效期
 	Issuer           string        `json:"issuer"`
 	Audience         string        `json:"audience"`
+
+	// 签名密钥，按 kid 区分；为空时使用 Secret 作为 kid 为 default 的 HS256 密钥
+	Keys         []JWTKeyConfig `json:"keys"`
+	KeysFile     string         `json:"keys_file"`      // 密钥列表 JSON 文件，内容为 JWTKeyConfig 数组
+	SigningKeyID string         `json:"signing_key_id"` // 签发新令牌使用的 kid，为空时使用第一个密钥
+}
+
+// JWTKeyConfig 单个 JWT 签名密钥
+// HS256 使用 Secret；EdDSA（Ed25519）和 RS256 使用 PEM 私钥，只提供公钥时仅用于验证轮换前签发的令牌
+type JWTKeyConfig struct {
+	ID             string `json:"kid"`
+	Algorithm      string `json:"alg"` // HS256、EdDSA、RS256
+	Secret         string `json:"secret,omitempty"`
+	PrivateKeyFile string `json:"private_key_file,omitempty"`
+	PublicKeyFile  string `json:"public_key_file,omitempty"`
+}
+
+// AuthConfig 登录安全配置
+type AuthConfig struct {
+	TwoFactorChallengeTTL time.Duration `json:"two_factor_challenge_ttl"` // 两步验证挑战令牌有效期
+	TwoFactorTrustWindow  time.Duration `json:"two_factor_trust_window"`  // 记住设备后跳过两步验证的时长，0 表示不允许记住设备
+
+	LockoutThreshold   int           `json:"lockout_threshold"`    // 账户连续登录失败多少次后临时锁定，达到一半后开始指数退避
+	IPLockoutThreshold int           `json:"ip_lockout_threshold"` // 同一 IP 连续登录失败多少次后临时锁定
+	LockoutDuration    time.Duration `json:"lockout_duration"`     // 首次锁定时长，之后每次失败翻倍，最长 24 小时
+	FailureWindow      time.Duration `json:"failure_window"`       // 失败计数保留时长，超过后重新计数
+
+	AdminUsers []string `json:"admin_users"` // 管理员用户名
+
+	EmailVerificationTTL time.Duration `json:"email_verification_ttl"` // 邮箱验证链接有效期
+	PasswordResetTTL     time.Duration `json:"password_reset_ttl"`     // 密码重置链接有效期
+}
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	Driver      string `json:"driver"`        // smtp、file、log
+	From        string `json:"from"`          // 发件人地址
+	LinkBaseURL string `json:"link_base_url"` // 邮件中链接的地址前缀，例如客户端或网页的地址
+	FileDir     string `json:"file_dir"`      // file 驱动保存 .eml 文件的目录
+
+	SMTPHost     string `json:"smtp_host"`
+	SMTPPort     int    `json:"smtp_port"`
+	SMTPUsername string `json:"smtp_username"`
+	SMTPPassword string `json:"-"`
+	SMTPTLS      string `json:"smtp_tls"` // starttls、tls（隐式 TLS，通常为 465 端口）、none
+}
+
+// OIDCConfig OpenID Connect 单点登录配置，Issuer 为空时不启用
+type OIDCConfig struct {
+	Issuer       string   `json:"issuer"` // 身份提供方地址，从 <issuer>/.well-known/openid-configuration 发现端点
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"-"`            // 公共客户端可为空
+	RedirectURL  string   `json:"redirect_url"` // 在身份提供方登记的回调地址，指向 /api/v1/auth/oidc/callback
+	Scopes       []string `json:"scopes"`
+	ProviderName string   `json:"provider_name"` // 登录按钮上显示的名称
+
+	GroupsClaim string            `json:"groups_claim"` // ID 令牌中组信息所在的声明
+	RoleMapping map[string]string `json:"role_mapping"` // 组到角色的映射，为空时不改变用户角色
+	DefaultRole string            `json:"default_role"` // 配置了映射但没有匹配的组时使用的角色
+
+	AutoProvision        bool     `json:"auto_provision"`         // 没有对应账户时自动创建
+	AllowedDomains       []string `json:"allowed_domains"`        // 允许登录的邮箱域名，为空表示不限制
+	RequireVerifiedEmail bool     `json:"require_verified_email"` // 自动创建账户时要求 email_verified，按邮箱关联已有账户总是要求
+	AllowedRedirectURIs  []string `json:"allowed_redirect_uris"`  // 登录完成后允许跳转的客户端地址前缀
+}
+
+// Enabled 是否启用单点登录
+func (c *OIDCConfig) Enabled() bool {
+	return c.Issuer != "" && c.ClientID != ""
+}
+
+// ShareConfig 分享链接配置
+type ShareConfig struct {
+	BaseURL string        `json:"base_url"` // 分享地址前缀，为空时使用请求的地址
+	MaxTTL  time.Duration `json:"max_ttl"`  // 分享链接最长有效期，0 表示不限制
+}
+
+// WebhookConfig 出站 webhook 配置
+type WebhookConfig struct {
+	Timeout              time.Duration `json:"timeout"`                // 单次请求超时
+	MaxAttempts          int           `json:"max_attempts"`           // 最多尝试次数（包括第一次）
+	RetryBase            time.Duration `json:"retry_base"`             // 第一次重试的间隔，之后每次翻倍
+	RetryMax             time.Duration `json:"retry_max"`              // 重试间隔上限
+	AllowPrivateNetworks bool          `json:"allow_private_networks"` // 是否允许投递到回环和内网地址
+	MaxPerUser           int           `json:"max_per_user"`           // 每个用户最多的订阅数
+}
+
+// InboundConfig 入站 webhook 和邮件配置
+type InboundConfig struct {
+	BaseURL      string `json:"base_url"`      // 入站 webhook 地址前缀，为空时使用请求的地址
+	SMTPAddr     string `json:"smtp_addr"`     // SMTP 收件服务监听地址，为空表示不启用
+	SMTPHostname string `json:"smtp_hostname"` // SMTP 问候语中的主机名
+	EmailDomain  string `json:"email_domain"`  // 收件地址的域名，需要将该域名的 MX 记录指向本服务
+	MaxSize      int64  `json:"max_size"`      // 单次提交或单封邮件的最大字节数
+}
+
+// SMTPEnabled 是否启用邮件入站
+func (c *InboundConfig) SMTPEnabled() bool {
+	return c.SMTPAddr != "" && c.EmailDomain != ""
 }
 
 // CORSConfig CORS 配置
@@ -91,6 +200,24 @@ type SyncConfig struct {
 	SyncBatchSize     int           `json:"sync_batch_size"`     // 同步批次大小
 	WebSocketTimeout  time.Duration `json:"websocket_timeout"`   // WebSocket 连接超时
 	HeartbeatInterval time.Duration `json:"heartbeat_interval"`  // 心跳间隔
+
+	WebSocketBufferSize           int  `json:"websocket_buffer_size"`            // WebSocket 读写缓冲区大小（字节）
+	WebSocketCompression          bool `json:"websocket_compression"`            // 是否协商 permessage-deflate 压缩
+	WebSocketCompressionLevel     int  `json:"websocket_compression_level"`      // 压缩级别（1-9）
+	WebSocketCompressionThreshold int  `json:"websocket_compression_threshold"`  // 超过该大小（字节）的消息才压缩
+
+	WebSocketTicketTTL time.Duration `json:"websocket_ticket_ttl"` // 一次性连接票据有效期
+
+	WebSocketAllowAnyOrigin bool `json:"websocket_allow_any_origin"` // 允许任意 Origin 握手，CORS 允许列表中的 * 只有开启后才对 WebSocket 生效
+
+	WebSocketSendQueueSize    int               `json:"websocket_send_queue_size"`   // 每个连接的发送队列长度
+	WebSocketOverflowPolicy   string            `json:"websocket_overflow_policy"`   // 队列已满时的默认策略：drop_oldest、coalesce、disconnect
+	WebSocketOverflowPolicies map[string]string `json:"websocket_overflow_policies"` // 按消息类型覆盖溢出策略
+	WebSocketResyncWatermark  int               `json:"websocket_resync_watermark"`  // 队列回落到该长度以下时发送合并的重新同步通知
+
+	ShutdownDrainTimeout    time.Duration `json:"shutdown_drain_timeout"`    // 关闭时等待连接排空的最长时间
+	ShutdownReconnectDelay  time.Duration `json:"shutdown_reconnect_delay"`  // 关闭时建议客户端的重连延迟
+	ShutdownReconnectJitter time.Duration `json:"shutdown_reconnect_jitter"` // 重连延迟的随机抖动上限
 }
 
 // Load 加载配置
@@ -114,11 +241,71 @@ func Load() (*Config, error) {
 			LogLevel:        getEnv("DB_LOG_LEVEL", "warn"),
 		},
 		JWT: JWTConfig{
-			Secret:          getEnv("JWT_SECRET", "xpaste-secret-key-change-in-production"),
+			Secret:          getEnv("JWT_SECRET", DefaultJWTSecret),
 			AccessTokenTTL:  getEnvAsDuration("JWT_ACCESS_TOKEN_TTL", "24h"),
 			RefreshTokenTTL: getEnvAsDuration("JWT_REFRESH_TOKEN_TTL", "168h"), // 7 days
 			Issuer:          getEnv("JWT_ISSUER", "xpaste"),
 			Audience:        getEnv("JWT_AUDIENCE", "xpaste-users"),
+			KeysFile:        getEnv("JWT_KEYS_FILE", ""),
+			SigningKeyID:    getEnv("JWT_SIGNING_KEY_ID", ""),
+		},
+		Auth: AuthConfig{
+			TwoFactorChallengeTTL: getEnvAsDuration("AUTH_2FA_CHALLENGE_TTL", "5m"),
+			TwoFactorTrustWindow:  getEnvAsDuration("AUTH_2FA_TRUST_WINDOW", "720h"), // 30 days
+			LockoutThreshold:      getEnvAsInt("AUTH_LOCKOUT_THRESHOLD", 10),
+			IPLockoutThreshold:    getEnvAsInt("AUTH_IP_LOCKOUT_THRESHOLD", 50),
+			LockoutDuration:       getEnvAsDuration("AUTH_LOCKOUT_DURATION", "15m"),
+			FailureWindow:         getEnvAsDuration("AUTH_FAILURE_WINDOW", "1h"),
+			AdminUsers:            getEnvAsSlice("AUTH_ADMIN_USERS", []string{}),
+			EmailVerificationTTL:  getEnvAsDuration("AUTH_EMAIL_VERIFICATION_TTL", "24h"),
+			PasswordResetTTL:      getEnvAsDuration("AUTH_PASSWORD_RESET_TTL", "1h"),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "log"),
+			From:         getEnv("MAIL_FROM", "xPaste <no-reply@localhost>"),
+			LinkBaseURL:  getEnv("MAIL_LINK_BASE_URL", "http://localhost:8080"),
+			FileDir:      getEnv("MAIL_FILE_DIR", "./data/mail"),
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			SMTPTLS:      getEnv("SMTP_TLS", "starttls"),
+		},
+		OIDC: OIDCConfig{
+			Issuer:       getEnv("OIDC_ISSUER", ""),
+			ClientID:     getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:  getEnv("OIDC_REDIRECT_URL", "http://localhost:8080/api/v1/auth/oidc/callback"),
+			Scopes:       getEnvAsSlice("OIDC_SCOPES", []string{"openid", "email", "profile"}),
+			ProviderName: getEnv("OIDC_PROVIDER_NAME", "SSO"),
+
+			GroupsClaim: getEnv("OIDC_GROUPS_CLAIM", "groups"),
+			RoleMapping: getEnvAsMap("OIDC_ROLE_MAPPING", map[string]string{}),
+			DefaultRole: getEnv("OIDC_DEFAULT_ROLE", "user"),
+
+			AutoProvision:        getEnvAsBool("OIDC_AUTO_PROVISION", true),
+			AllowedDomains:       getEnvAsSlice("OIDC_ALLOWED_DOMAINS", []string{}),
+			RequireVerifiedEmail: getEnvAsBool("OIDC_REQUIRE_VERIFIED_EMAIL", true),
+			AllowedRedirectURIs:  getEnvAsSlice("OIDC_ALLOWED_REDIRECT_URIS", []string{}),
+		},
+		Share: ShareConfig{
+			BaseURL: strings.TrimRight(getEnv("SHARE_BASE_URL", ""), "/"),
+			MaxTTL:  getEnvAsDuration("SHARE_MAX_TTL", "0"),
+		},
+		Webhook: WebhookConfig{
+			Timeout:              getEnvAsDuration("WEBHOOK_TIMEOUT", "10s"),
+			MaxAttempts:          getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			RetryBase:            getEnvAsDuration("WEBHOOK_RETRY_BASE", "30s"),
+			RetryMax:             getEnvAsDuration("WEBHOOK_RETRY_MAX", "1h"),
+			AllowPrivateNetworks: getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+			MaxPerUser:           getEnvAsInt("WEBHOOK_MAX_PER_USER", 20),
+		},
+		Inbound: InboundConfig{
+			BaseURL:      strings.TrimRight(getEnv("INBOUND_BASE_URL", ""), "/"),
+			SMTPAddr:     getEnv("INBOUND_SMTP_ADDR", ""),
+			SMTPHostname: getEnv("INBOUND_SMTP_HOSTNAME", "localhost"),
+			EmailDomain:  getEnv("INBOUND_EMAIL_DOMAIN", ""),
+			MaxSize:      getEnvAsInt64("INBOUND_MAX_SIZE", 10*1024*1024), // 10MB
 		},
 		CORS: CORSConfig{
 			AllowOrigins:     getEnvAsSlice("CORS_ALLOW_ORIGINS", []string{"*"}),
@@ -155,12 +342,80 @@ func Load() (*Config, error) {
 			SyncBatchSize:     getEnvAsInt("SYNC_BATCH_SIZE", 100),
 			WebSocketTimeout:  getEnvAsDuration("SYNC_WEBSOCKET_TIMEOUT", "60s"),
 			HeartbeatInterval: getEnvAsDuration("SYNC_HEARTBEAT_INTERVAL", "30s"),
+
+			WebSocketBufferSize:           getEnvAsInt("SYNC_WEBSOCKET_BUFFER_SIZE", 4096),
+			WebSocketCompression:          getEnvAsBool("SYNC_WEBSOCKET_COMPRESSION", true),
+			WebSocketCompressionLevel:     getEnvAsInt("SYNC_WEBSOCKET_COMPRESSION_LEVEL", 1),
+			WebSocketCompressionThreshold: getEnvAsInt("SYNC_WEBSOCKET_COMPRESSION_THRESHOLD", 256),
+
+			WebSocketTicketTTL: getEnvAsDuration("SYNC_WEBSOCKET_TICKET_TTL", "30s"),
+
+			WebSocketAllowAnyOrigin: getEnvAsBool("SYNC_WEBSOCKET_ALLOW_ANY_ORIGIN", false),
+
+			WebSocketSendQueueSize:  getEnvAsInt("SYNC_WEBSOCKET_SEND_QUEUE_SIZE", 256),
+			WebSocketOverflowPolicy: getEnv("SYNC_WEBSOCKET_OVERFLOW_POLICY", "drop_oldest"),
+			WebSocketOverflowPolicies: getEnvAsMap("SYNC_WEBSOCKET_OVERFLOW_POLICIES", map[string]string{
+				"clip_sync":   "coalesce",
+				"clip_new":    "coalesce",
+				"clip_update": "coalesce",
+				"clip_delete": "coalesce",
+			}),
+			WebSocketResyncWatermark: getEnvAsInt("SYNC_WEBSOCKET_RESYNC_WATERMARK", 32),
+
+			ShutdownDrainTimeout:    getEnvAsDuration("SYNC_SHUTDOWN_DRAIN_TIMEOUT", "10s"),
+			ShutdownReconnectDelay:  getEnvAsDuration("SYNC_SHUTDOWN_RECONNECT_DELAY", "2s"),
+			ShutdownReconnectJitter: getEnvAsDuration("SYNC_SHUTDOWN_RECONNECT_JITTER", "5s"),
 		},
 	}
 
+	if config.JWT.KeysFile != "" {
+		keys, err := loadJWTKeys(config.JWT.KeysFile)
+		if err != nil {
+			return nil, err
+		}
+		config.JWT.Keys = keys
+	}
+
+	if config.IsProduction() {
+		if err := config.JWT.validateSecrets(); err != nil {
+			return nil, err
+		}
+	}
+
 	return config, nil
 }
 
+// validateSecrets 检查签名密钥不为空且不是源码中的默认值，release 模式启动时调用
+func (c *JWTConfig) validateSecrets() error {
+	if len(c.Keys) == 0 {
+		if c.Secret == "" || c.Secret == DefaultJWTSecret {
+			return fmt.Errorf("JWT_SECRET must be set to a non-default value in release mode")
+		}
+		return nil
+	}
+
+	for _, key := range c.Keys {
+		if key.Algorithm == "HS256" && key.Secret == DefaultJWTSecret {
+			return fmt.Errorf("JWT key %q must have a non-default secret in release mode", key.ID)
+		}
+	}
+	return nil
+}
+
+// loadJWTKeys 从 JSON 文件读取签名密钥列表
+func loadJWTKeys(path string) ([]JWTKeyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT keys file: %w", err)
+	}
+
+	var keys []JWTKeyConfig
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse JWT keys file: %w", err)
+	}
+	return keys, nil
+}
+
 // GetAddr 获取服务器地址
 func (c *Config) GetAddr() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
@@ -230,4 +485,22 @@ func getEnvAsSlice(key string, defaultValue []string) []string {
 		return strings.Split(value, ",")
 	}
 	return defaultValue
+}
+
+// getEnvAsMap 解析 key1=value1,key2=value2 形式的环境变量
+func getEnvAsMap(key string, defaultValue map[string]string) map[string]string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	result := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return result
 }
\ No newline at end of file
@@ -0,0 +1,48 @@
+package config
+
+import "testing"
+
+func TestLoadRejectsDefaultJWTSecretInRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		secret  string
+		wantErr bool
+	}{
+		{"release without secret", "release", "", true},
+		{"release with default secret", "release", DefaultJWTSecret, true},
+		{"release with secret", "release", "a-strong-random-secret", false},
+		{"debug without secret", "debug", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GIN_MODE", tt.mode)
+			t.Setenv("JWT_SECRET", tt.secret)
+			t.Setenv("JWT_KEYS_FILE", "")
+
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.secret == "" && cfg.JWT.Secret != DefaultJWTSecret {
+				t.Errorf("JWT.Secret = %q, want default in debug mode", cfg.JWT.Secret)
+			}
+		})
+	}
+}
+
+func TestValidateSecretsWithKeyFile(t *testing.T) {
+	cfg := &JWTConfig{Keys: []JWTKeyConfig{
+		{ID: "2024", Algorithm: "EdDSA", PrivateKeyFile: "ed25519.pem"},
+		{ID: "default", Algorithm: "HS256", Secret: DefaultJWTSecret},
+	}}
+	if err := cfg.validateSecrets(); err == nil {
+		t.Error("HS256 key with default secret accepted")
+	}
+
+	cfg.Keys[1].Secret = "rotated-secret"
+	if err := cfg.validateSecrets(); err != nil {
+		t.Errorf("validateSecrets() = %v", err)
+	}
+}
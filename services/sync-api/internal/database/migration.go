@@ -27,6 +27,12 @@ func MigrateDatabase() error {
 
 	log.Println("Starting database migration...")
 
+	// 迁移前的版本，用于决定需要执行的数据回填；新数据库或无法获取时为 0
+	previousVersion, err := getCurrentMigrationVersion()
+	if err != nil {
+		previousVersion = 0
+	}
+
 	// 1. 首先检查是否需要清理旧的不兼容表结构
 	if err := cleanupIncompatibleTables(); err != nil {
 		return fmt.Errorf("failed to cleanup incompatible tables: %w", err)
@@ -37,6 +43,11 @@ func MigrateDatabase() error {
 		return fmt.Errorf("failed to auto migrate models: %w", err)
 	}
 
+	// 2.1 回填新增列的数据
+	if err := backfillData(previousVersion); err != nil {
+		return fmt.Errorf("failed to backfill data: %w", err)
+	}
+
 	// 3. 创建必要的索引
 	if err := createCustomIndexes(); err != nil {
 		return fmt.Errorf("failed to create custom indexes: %w", err)
@@ -116,7 +127,40 @@ func getCurrentMigrationVersion() (int, error) {
 func getCurrentCodeVersion() int {
 	// 这里定义当前代码的数据库版本
 	// 每次修改数据库结构时，需要增加这个版本号
-	return 1
+	// 2: 设备在线状态（presence、app_category）
+	// 3: 设备配对会话（pairing_sessions）
+	// 4: 设备批准与信任级别（trust_level、approved_at、approved_by）
+	// 5: 登录会话（auth_sessions）
+	// 6: 刷新令牌（refresh_tokens）
+	// 7: 个人访问令牌（personal_access_tokens）
+	// 8: 两步验证（user_totps、recovery_codes、login_challenges、two_factor_trusts）
+	// 9: 登录保护和审计日志（login_throttles、audit_logs）
+	// 10: 邮箱验证和密码重置（users.email_verified_at、email_tokens）
+	// 11: 用户角色和单点登录（users.role、user_identities、oidc_login_states）
+	// 12: 用户状态原因（users.status_reason、users.status_changed_at）
+	// 13: 团队和团队剪贴板（teams、team_members、clip_items.scope、clip_items.team_id）
+	// 14: 分享链接（share_links、share_views）
+	// 15: 出站 webhook（webhooks、webhook_deliveries）
+	// 16: 入站 webhook 和邮件（inbound_endpoints）
+	// 17: 剪贴板项规则（clip_rules）
+	// 18: 剪贴板项敏感数据标记（clip_items.sensitive、sensitive_matches、masked、excluded_platforms）
+	// 19: 剪贴板项内容子类型（clip_items.subtype、classification）
+	// 20: 等待验证邮箱的注册账户（users.pending_verification）
+	// 21: 团队邀请（team_invitations）
+	return 21
+}
+
+// backfillData 按迁移前的版本回填新增列，只在从旧版本升级时执行
+func backfillData(previousVersion int) error {
+	// 10: 引入邮箱验证前的用户视为已验证，避免已有账户被当作未验证账户处理
+	if previousVersion < 10 {
+		result := DB.Exec("UPDATE users SET email_verified_at = COALESCE(created_at, CURRENT_TIMESTAMP) WHERE email_verified_at IS NULL")
+		if result.Error != nil {
+			return fmt.Errorf("failed to backfill users.email_verified_at: %w", result.Error)
+		}
+		log.Printf("Backfilled email_verified_at for %d existing users", result.RowsAffected)
+	}
+	return nil
 }
 
 // recordMigrationStatus 记录迁移状态
@@ -195,6 +239,28 @@ func autoMigrateModels() error {
 		&models.ClipItem{},
 		&models.OcrResult{},
 		&models.Setting{},
+		&models.PairingSession{},
+		&models.AuthSession{},
+		&models.RefreshToken{},
+		&models.PersonalAccessToken{},
+		&models.UserTOTP{},
+		&models.RecoveryCode{},
+		&models.LoginChallenge{},
+		&models.TwoFactorTrust{},
+		&models.LoginThrottle{},
+		&models.AuditLog{},
+		&models.EmailToken{},
+		&models.UserIdentity{},
+		&models.OIDCLoginState{},
+		&models.Team{},
+		&models.TeamMember{},
+		&models.TeamInvitation{},
+		&models.ShareLink{},
+		&models.ShareView{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.InboundEndpoint{},
+		&models.ClipRule{},
 	}
 
 	for _, model := range models {
@@ -393,7 +459,7 @@ func ResetDatabase() error {
 	log.Println("Resetting database...")
 
 	// 删除所有表
-	tables := []string{"ocr_results", "clip_items", "settings", "devices", "users"}
+	tables := []string{"clip_rules", "inbound_endpoints", "webhook_deliveries", "webhooks", "share_views", "share_links", "team_invitations", "team_members", "teams", "oidc_login_states", "user_identities", "email_tokens", "audit_logs", "login_throttles", "two_factor_trusts", "login_challenges", "recovery_codes", "user_totps", "personal_access_tokens", "refresh_tokens", "auth_sessions", "pairing_sessions", "ocr_results", "clip_items", "settings", "devices", "users"}
 	for _, table := range tables {
 		if err := DB.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", table)).Error; err != nil {
 			log.Printf("Warning: failed to drop table %s: %v", table, err)
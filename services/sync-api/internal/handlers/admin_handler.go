@@ -0,0 +1,571 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/middleware"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
+)
+
+// AdminHandler 管理员处理器
+type AdminHandler struct {
+	adminService      *services.AdminService
+	userService       *services.UserService
+	loginGuardService *services.LoginGuardService
+	clipService       *services.ClipService
+	deviceService     *services.DeviceService
+	db                *gorm.DB
+}
+
+// NewAdminHandler 创建管理员处理器
+func NewAdminHandler(adminService *services.AdminService, userService *services.UserService, loginGuardService *services.LoginGuardService, clipService *services.ClipService, deviceService *services.DeviceService, db *gorm.DB) *AdminHandler {
+	return &AdminHandler{
+		adminService:      adminService,
+		userService:       userService,
+		loginGuardService: loginGuardService,
+		clipService:       clipService,
+		deviceService:     deviceService,
+		db:                db,
+	}
+}
+
+// ListUsers 获取用户列表
+// @Summary 获取用户列表
+// @Description 分页获取用户，按注册时间倒序；可按关键词（用户名、邮箱或显示名称）、状态和角色筛选
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "搜索关键词"
+// @Param status query string false "状态" Enums(inactive, active, suspended, banned)
+// @Param role query string false "角色" Enums(user, admin)
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} models.Response{data=models.ListResponse} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "权限不足"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /admin/users [get]
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	var params models.PaginationParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+	var filter models.AdminUserFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	users, pagination, err := h.adminService.ListUsers(&filter, &params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get users: "+err.Error()))
+		return
+	}
+
+	userResponses := make([]*models.AdminUserResponse, len(users))
+	for i, user := range users {
+		userResponses[i] = user.ToAdminResponse()
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Users retrieved successfully", &models.ListResponse{
+		Items:      userResponses,
+		Pagination: pagination,
+	}))
+}
+
+// GetUser 获取用户详情
+// @Summary 获取用户详情
+// @Description 获取用户信息、设备数、剪贴板数量、存储用量、有效会话数和登录锁定状态
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} models.Response{data=services.AdminUserDetail} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "权限不足"
+// @Failure 404 {object} models.Response "用户不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /admin/users/{id} [get]
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.adminService.GetUserDetail(userID)
+	if err != nil {
+		respondAdminUserError(c, err, "Failed to get user")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("User retrieved successfully", detail))
+}
+
+// GetUserDevices 获取用户的设备
+// @Summary 获取用户的设备
+// @Description 获取用户的所有设备，包括在线状态、信任级别和最后活动时间
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} models.Response{data=[]models.DeviceResponse} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "权限不足"
+// @Failure 404 {object} models.Response "用户不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /admin/users/{id}/devices [get]
+func (h *AdminHandler) GetUserDevices(c *gin.Context) {
+	userID, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	devices, err := h.adminService.GetUserDevices(userID)
+	if err != nil {
+		respondAdminUserError(c, err, "Failed to get devices")
+		return
+	}
+
+	deviceResponses := make([]*models.DeviceResponse, len(devices))
+	for i, device := range devices {
+		deviceResponses[i] = device.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Devices retrieved successfully", deviceResponses))
+}
+
+// SuspendUser 暂停用户
+// @Summary 暂停用户
+// @Description 暂停用户并记录原因，立即撤销用户的所有会话；暂停期间不能登录
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body models.UserStatusRequest true "原因"
+// @Success 200 {object} models.Response{data=models.AdminUserResponse} "暂停成功"
+// @Failure 400 {object} models.Response "请求参数错误或操作自己的账户"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "权限不足"
+// @Failure 404 {object} models.Response "用户不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /admin/users/{id}/suspend [post]
+func (h *AdminHandler) SuspendUser(c *gin.Context) {
+	h.setUserStatus(c, models.UserStatusSuspended, "User suspended successfully")
+}
+
+// BanUser 封禁用户
+// @Summary 封禁用户
+// @Description 封禁用户并记录原因，立即撤销用户的所有会话
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body models.UserStatusRequest true "原因"
+// @Success 200 {object} models.Response{data=models.AdminUserResponse} "封禁成功"
+// @Failure 400 {object} models.Response "请求参数错误或操作自己的账户"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "权限不足"
+// @Failure 404 {object} models.Response "用户不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /admin/users/{id}/ban [post]
+func (h *AdminHandler) BanUser(c *gin.Context) {
+	h.setUserStatus(c, models.UserStatusBanned, "User banned successfully")
+}
+
+// ActivateUser 恢复用户
+// @Summary 恢复用户
+// @Description 解除暂停或封禁，或激活未激活的账户
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body models.UserStatusRequest false "备注"
+// @Success 200 {object} models.Response{data=models.AdminUserResponse} "恢复成功"
+// @Failure 400 {object} models.Response "请求参数错误或操作自己的账户"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "权限不足"
+// @Failure 404 {object} models.Response "用户不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /admin/users/{id}/activate [post]
+func (h *AdminHandler) ActivateUser(c *gin.Context) {
+	h.setUserStatus(c, models.UserStatusActive, "User activated successfully")
+}
+
+// setUserStatus 修改用户状态的公共处理
+func (h *AdminHandler) setUserStatus(c *gin.Context, status models.UserStatus, message string) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	userID, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.UserStatusRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+			return
+		}
+	}
+
+	user, err := h.adminService.SetStatus(userID, status, req.Reason, adminID.(uint), c.ClientIP())
+	if err != nil {
+		respondAdminUserError(c, err, "Failed to update user status")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage(message, user.ToAdminResponse()))
+}
+
+// ForceLogout 强制下线
+// @Summary 强制下线
+// @Description 撤销用户的所有会话并断开其设备的实时连接，个人访问令牌不受影响
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} models.Response "操作成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "权限不足"
+// @Failure 404 {object} models.Response "用户不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /admin/users/{id}/logout [post]
+func (h *AdminHandler) ForceLogout(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	userID, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.adminService.ForceLogout(userID, adminID.(uint), c.ClientIP())
+	if err != nil {
+		respondAdminUserError(c, err, "Failed to log out user")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("User logged out successfully", gin.H{
+		"user_id":          userID,
+		"revoked_sessions": revoked,
+	}))
+}
+
+// ResetUserPassword 重置用户密码
+// @Summary 重置用户密码
+// @Description 传入新密码时直接设置，撤销用户的所有会话并解除登录锁定；不传时向用户邮箱发送密码重置邮件
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body models.AdminResetPasswordRequest false "新密码"
+// @Success 200 {object} models.Response "操作成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "权限不足"
+// @Failure 404 {object} models.Response "用户不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /admin/users/{id}/reset-password [post]
+func (h *AdminHandler) ResetUserPassword(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	userID, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminResetPasswordRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+			return
+		}
+	}
+
+	emailSent, err := h.adminService.ResetPassword(userID, req.NewPassword, adminID.(uint), c.ClientIP())
+	if err != nil {
+		respondAdminUserError(c, err, "Failed to reset password")
+		return
+	}
+
+	message := "Password reset successfully"
+	if emailSent {
+		message = "Password reset email sent"
+	}
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage(message, gin.H{
+		"user_id":    userID,
+		"email_sent": emailSent,
+	}))
+}
+
+// ListAuditLogs 获取审计日志
+// @Summary 获取审计日志
+// @Description 分页获取审计日志，按时间倒序；可按用户、操作人和事件类型筛选
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int false "用户ID"
+// @Param actor_id query int false "操作人ID"
+// @Param event query string false "事件类型"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} models.Response{data=models.ListResponse} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "权限不足"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /admin/audit-logs [get]
+func (h *AdminHandler) ListAuditLogs(c *gin.Context) {
+	var params models.PaginationParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+	var filter models.AuditLogFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	logs, pagination, err := h.adminService.ListAuditLogs(&filter, &params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get audit logs: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Audit logs retrieved successfully", &models.ListResponse{
+		Items:      logs,
+		Pagination: pagination,
+	}))
+}
+
+// UpdateUserRole 修改用户角色
+// @Summary 修改用户角色
+// @Description 设置用户角色（user 或 admin），不能修改自己的角色；操作记录到审计日志
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body models.UpdateUserRoleRequest true "角色"
+// @Success 200 {object} models.Response{data=models.UserResponse} "修改成功"
+// @Failure 400 {object} models.Response "请求参数错误或修改自己的角色"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "权限不足"
+// @Failure 404 {object} models.Response "用户不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /admin/users/{id}/role [put]
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid user ID"))
+		return
+	}
+
+	var req models.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	user, err := h.userService.SetRole(uint(userID), req.Role, adminID.(uint), c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse("User not found"))
+		case errors.Is(err, models.ErrInvalidRole):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid role"))
+		case errors.Is(err, models.ErrCannotEditSelf):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Cannot change your own role"))
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to update role: "+err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("User role updated successfully", user.ToResponse()))
+}
+
+// UnlockUser 解除账户锁定
+// @Summary 解除账户锁定
+// @Description 清除账户的登录失败计数和锁定状态，请求中指定 IP 时同时解除该 IP 的锁定；操作记录到审计日志
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body models.UnlockUserRequest false "解锁请求"
+// @Success 200 {object} models.Response "解锁成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "权限不足"
+// @Failure 404 {object} models.Response "用户不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /admin/users/{id}/unlock [post]
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid user ID"))
+		return
+	}
+
+	var req models.UnlockUserRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+			return
+		}
+	}
+
+	if _, err := h.userService.GetUserByID(uint(userID)); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("User not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get user: "+err.Error()))
+		return
+	}
+
+	if err := h.loginGuardService.UnlockAccount(uint(userID), req.IP, adminID.(uint), c.ClientIP()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to unlock user: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("User unlocked successfully", gin.H{
+		"user_id": userID,
+		"ip":      req.IP,
+	}))
+}
+
+// RunCleanup 执行清理任务
+// @Summary 执行清理任务
+// @Description 删除已过期的剪贴板项，并将超过指定时长未活动的设备标记为离线
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param offline_after query string false "设备未活动多久后标记为离线" default(10m)
+// @Success 200 {object} models.Response "清理完成"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "权限不足"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /admin/maintenance/cleanup [post]
+func (h *AdminHandler) RunCleanup(c *gin.Context) {
+	offlineAfter, err := time.ParseDuration(c.DefaultQuery("offline_after", "10m"))
+	if err != nil || offlineAfter <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid offline_after duration"))
+		return
+	}
+
+	if err := h.clipService.CleanupExpiredClipItems(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Cleanup failed: "+err.Error()))
+		return
+	}
+	if err := h.deviceService.CleanupOfflineDevices(offlineAfter); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Cleanup failed: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Cleanup completed", gin.H{
+		"tasks":         []string{"expired_clip_items", "offline_devices"},
+		"offline_after": offlineAfter.String(),
+	}))
+}
+
+// parseUserIDParam 解析路径中的用户ID，无效时写入 400 响应
+func parseUserIDParam(c *gin.Context) (uint, bool) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid user ID"))
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+// respondAdminUserError 用户管理操作的错误响应
+func respondAdminUserError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse("User not found"))
+	case errors.Is(err, models.ErrCannotManageSelf):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Cannot perform this action on your own account"))
+	case errors.Is(err, models.ErrReasonRequired):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Reason is required"))
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse(message+": "+err.Error()))
+	}
+}
+
+// RegisterRoutes 注册管理员路由，每个路由按所需权限授权
+func (h *AdminHandler) RegisterRoutes(router *gin.RouterGroup) {
+	admin := router.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(h.db))
+	{
+		users := admin.Group("/users")
+		{
+			read := middleware.RequirePermission(models.PermissionUsersRead)
+			users.GET("", read, h.ListUsers)
+			users.GET("/:id", read, h.GetUser)
+			users.GET("/:id/devices", read, h.GetUserDevices)
+
+			manage := middleware.RequirePermission(models.PermissionUsersManage)
+			users.PUT("/:id/role", manage, h.UpdateUserRole)
+			users.POST("/:id/suspend", manage, h.SuspendUser)
+			users.POST("/:id/ban", manage, h.BanUser)
+			users.POST("/:id/activate", manage, h.ActivateUser)
+			users.POST("/:id/logout", manage, h.ForceLogout)
+			users.POST("/:id/reset-password", manage, h.ResetUserPassword)
+			users.POST("/:id/unlock", manage, h.UnlockUser)
+		}
+
+		admin.GET("/audit-logs", middleware.RequirePermission(models.PermissionAuditRead), h.ListAuditLogs)
+
+		maintenance := admin.Group("/maintenance")
+		maintenance.Use(middleware.RequirePermission(models.PermissionSystemMaintenance))
+		{
+			maintenance.POST("/cleanup", h.RunCleanup)
+		}
+	}
+}
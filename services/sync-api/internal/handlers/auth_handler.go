@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"xpaste-sync/internal/logger"
 	"xpaste-sync/internal/middleware"
 	"xpaste-sync/internal/models"
 	"xpaste-sync/internal/services"
@@ -13,27 +18,39 @@ import (
 
 // AuthHandler 认证处理器
 type AuthHandler struct {
-	userService *services.UserService
-	db          *gorm.DB
+	userService        *services.UserService
+	tokenService       *services.TokenService
+	accessTokenService *services.AccessTokenService
+	twoFactorService   *services.TwoFactorService
+	settingService     *services.SettingService
+	emailService       *services.EmailService
+	db                 *gorm.DB
 }
 
 // NewAuthHandler 创建认证处理器
-func NewAuthHandler(userService *services.UserService, db *gorm.DB) *AuthHandler {
+func NewAuthHandler(userService *services.UserService, tokenService *services.TokenService, accessTokenService *services.AccessTokenService, twoFactorService *services.TwoFactorService, settingService *services.SettingService, emailService *services.EmailService, db *gorm.DB) *AuthHandler {
 	return &AuthHandler{
-		userService: userService,
-		db:          db,
+		userService:        userService,
+		tokenService:       tokenService,
+		accessTokenService: accessTokenService,
+		twoFactorService:   twoFactorService,
+		settingService:     settingService,
+		emailService:       emailService,
+		db:                 db,
 	}
 }
 
 // Register 用户注册
 // @Summary 用户注册
-// @Description 创建新用户账户
+// @Description 创建新用户账户并发送邮箱验证邮件；系统设置关闭注册时返回 403；要求验证邮箱时返回 201，验证后才能登录
 // @Tags 认证
 // @Accept json
 // @Produce json
 // @Param request body models.RegisterRequest true "注册请求"
-// @Success 201 {object} models.Response{data=models.AuthResult} "注册成功"
+// @Success 200 {object} models.Response{data=models.AuthResult} "注册成功"
+// @Success 201 {object} models.Response{data=models.VerificationPendingResponse} "注册成功，等待验证邮箱"
 // @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 403 {object} models.Response "已关闭注册"
 // @Failure 409 {object} models.Response "用户已存在"
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /auth/register [post]
@@ -50,10 +67,15 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-
+	// 检查是否允许注册
+	if !h.settingService.GetSystemBool(models.SettingKeyAllowRegistration, true) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse("Registration is disabled"))
+		return
+	}
+	requireVerification := h.settingService.GetSystemBool(models.SettingKeyRequireEmailVerification, false)
 
 	// 注册用户
-	user, err := h.userService.Register(&req)
+	user, err := h.userService.Register(&req, requireVerification)
 	if err != nil {
 		if err == models.ErrUserExists {
 			c.JSON(http.StatusConflict, models.ErrorResponse("User already exists"))
@@ -63,26 +85,30 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// 生成令牌
-	accessToken, err := middleware.GenerateToken(user.ID, user.Username, "")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate access token: "+err.Error()))
+	// 发送验证邮件，发送失败不影响注册，用户可重新请求
+	if err := h.emailService.SendVerification(user); err != nil {
+		logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to send verification email")
+	}
+
+	if requireVerification {
+		c.JSON(http.StatusCreated, models.SuccessResponseWithMessage("Registration successful, please verify your email address", &models.VerificationPendingResponse{
+			User:                 user.ToResponse(),
+			VerificationRequired: true,
+		}))
 		return
 	}
 
-	refreshToken, err := middleware.GenerateRefreshToken(user.ID, user.Username)
+	// 创建会话并生成令牌
+	session, refreshToken, err := h.tokenService.CreateSession(user.ID, "", c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate refresh token: "+err.Error()))
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create session: "+err.Error()))
 		return
 	}
 
-	// 构建响应
-	authResult := &models.AuthResult{
-		User:         user.ToResponse(),
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    3600, // 1小时
+	authResult, err := issueTokens(user, session, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate tokens: "+err.Error()))
+		return
 	}
 
 	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("User registered successfully", authResult))
@@ -90,14 +116,17 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Login 用户登录
 // @Summary 用户登录
-// @Description 用户登录获取访问令牌
+// @Description 用户登录获取访问令牌；开启两步验证时返回 202 和挑战令牌，需调用 /auth/2fa/verify 完成登录；连续失败后按账户和 IP 退避，达到阈值临时锁定
 // @Tags 认证
 // @Accept json
 // @Produce json
 // @Param request body models.LoginRequest true "登录请求"
 // @Success 200 {object} models.Response{data=models.AuthResult} "登录成功"
+// @Success 202 {object} models.Response{data=models.TwoFactorChallengeResponse} "需要两步验证"
 // @Failure 400 {object} models.Response "请求参数错误"
 // @Failure 401 {object} models.Response "认证失败"
+// @Failure 403 {object} models.Response "设备已被撤销或邮箱未验证"
+// @Failure 429 {object} models.Response "连续登录失败，退避或临时锁定中"
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -117,14 +146,19 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	clientIP := c.ClientIP()
 
 	// 用户登录
-	user, err := h.userService.Login(&req, clientIP)
+	user, err := h.userService.Login(&req, clientIP, c.Request.UserAgent())
 	if err != nil {
+		var throttled *models.LoginThrottledError
+		if errors.As(err, &throttled) {
+			respondLoginThrottled(c, throttled)
+			return
+		}
 		if err == models.ErrInvalidCredentials {
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Invalid credentials"))
 			return
 		}
-		if err == models.ErrUserNotFound {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse("User not found"))
+		if err == models.ErrEmailNotVerified {
+			c.JSON(http.StatusForbidden, models.ErrorResponse("Email address has not been verified"))
 			return
 		}
 		if err == models.ErrUserInactive {
@@ -135,34 +169,72 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// 生成令牌
-	accessToken, err := middleware.GenerateToken(user.ID, user.Username, req.DeviceID)
+	// 开启两步验证时先返回挑战令牌，受信任设备在有效期内跳过
+	twoFactorEnabled, err := h.twoFactorService.IsEnabled(user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate access token: "+err.Error()))
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Login failed: "+err.Error()))
+		return
+	}
+	if twoFactorEnabled {
+		trusted, err := h.twoFactorService.IsTrusted(user.ID, req.DeviceID, req.TrustToken)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Login failed: "+err.Error()))
+			return
+		}
+		if !trusted {
+			challengeToken, ttl, err := h.twoFactorService.CreateChallenge(user.ID, req.DeviceID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create challenge: "+err.Error()))
+				return
+			}
+			c.JSON(http.StatusAccepted, models.SuccessResponseWithMessage("Two-factor authentication required", &models.TwoFactorChallengeResponse{
+				TwoFactorRequired: true,
+				ChallengeToken:    challengeToken,
+				ExpiresIn:         int(ttl.Seconds()),
+			}))
+			return
+		}
+	}
+
+	// 无需两步验证（或受信任设备跳过）时登录到此完成，清零失败计数
+	if err := h.userService.CompleteLogin(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Login failed: "+err.Error()))
 		return
 	}
 
-	refreshToken, err := middleware.GenerateRefreshToken(user.ID, user.Username)
+	// 创建绑定设备的会话并生成令牌
+	session, refreshToken, err := h.tokenService.CreateSession(user.ID, req.DeviceID, clientIP, c.Request.UserAgent())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate refresh token: "+err.Error()))
+		if errors.Is(err, models.ErrDeviceDisabled) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse("Device access has been revoked"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create session: "+err.Error()))
 		return
 	}
 
-	// 构建响应
-	authResult := &models.AuthResult{
-		User:         user.ToResponse(),
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    3600, // 1小时
+	authResult, err := issueTokens(user, session, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate tokens: "+err.Error()))
+		return
 	}
 
 	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Login successful", authResult))
 }
 
+// respondLoginThrottled 登录退避或锁定响应，带 Retry-After 头
+func respondLoginThrottled(c *gin.Context, throttled *models.LoginThrottledError) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	if throttled.Locked {
+		c.JSON(http.StatusTooManyRequests, models.ErrorResponse("Account is temporarily locked due to too many failed login attempts"))
+		return
+	}
+	c.JSON(http.StatusTooManyRequests, models.ErrorResponse("Too many failed login attempts, please try again later"))
+}
+
 // RefreshToken 刷新访问令牌
 // @Summary 刷新访问令牌
-// @Description 使用刷新令牌获取新的访问令牌
+// @Description 使用刷新令牌获取新的访问令牌，同时返回新的刷新令牌，旧刷新令牌随即失效；已使用过的刷新令牌再次使用会撤销整个会话
 // @Tags 认证
 // @Accept json
 // @Produce json
@@ -179,15 +251,28 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// 验证刷新令牌
-	claims, err := middleware.ValidateRefreshToken(req.Token)
+	// 轮换刷新令牌：旧令牌作废，已轮换的令牌被再次使用时撤销整个会话
+	session, refreshToken, err := h.tokenService.RotateRefreshToken(req.Token, c.ClientIP())
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Invalid refresh token: "+err.Error()))
+		switch {
+		case errors.Is(err, models.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Refresh token has already been used, session revoked"))
+		case errors.Is(err, models.ErrSessionRevoked):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Session has been revoked"))
+		case errors.Is(err, models.ErrSessionExpired):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Session expired"))
+		case errors.Is(err, models.ErrDeviceDisabled):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Device access has been revoked"))
+		case errors.Is(err, models.ErrInvalidToken):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Invalid refresh token"))
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to refresh token: "+err.Error()))
+		}
 		return
 	}
 
 	// 获取用户信息
-	user, err := h.userService.GetUserByID(claims.UserID)
+	user, err := h.userService.GetUserByID(session.UserID)
 	if err != nil {
 		if err == models.ErrUserNotFound {
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse("User not found"))
@@ -203,35 +288,19 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// 生成新的访问令牌
-	accessToken, err := middleware.GenerateToken(user.ID, user.Username, claims.DeviceID)
+	// 生成新的访问令牌，仍绑定原会话和设备
+	authResult, err := issueTokens(user, session, refreshToken)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate access token: "+err.Error()))
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate tokens: "+err.Error()))
 		return
 	}
 
-	// 生成新的刷新令牌
-	newRefreshToken, err := middleware.GenerateRefreshToken(user.ID, user.Username)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate refresh token: "+err.Error()))
-		return
-	}
-
-	// 构建响应
-	authResult := &models.AuthResult{
-		User:         user.ToResponse(),
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    3600, // 1小时
-	}
-
 	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Token refreshed successfully", authResult))
 }
 
 // Logout 用户登出
 // @Summary 用户登出
-// @Description 用户登出（客户端应删除令牌）
+// @Description 用户登出，撤销当前会话，该会话签发的访问令牌和刷新令牌立即失效
 // @Tags 认证
 // @Accept json
 // @Produce json
@@ -247,12 +316,16 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	// 这里可以添加令牌黑名单逻辑，或者记录登出日志
-	// 目前只是简单返回成功，客户端需要删除本地存储的令牌
+	sessionID, _ := middleware.GetSessionIDFromContext(c)
+	if err := h.tokenService.RevokeSession(userID.(uint), sessionID, models.RevokeReasonLogout); err != nil &&
+		!errors.Is(err, models.ErrSessionNotFound) {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to revoke session: "+err.Error()))
+		return
+	}
 
 	c.JSON(http.StatusOK, models.SuccessResponse("Logout successful", gin.H{
-		"user_id": userID,
-		"message": "Please delete the token from client storage",
+		"user_id":    userID,
+		"session_id": sessionID,
 	}))
 }
 
@@ -367,7 +440,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 			c.JSON(http.StatusNotFound, models.ErrorResponse("User not found"))
 			return
 		}
-		if err == models.ErrInvalidCredentials {
+		if err == models.ErrInvalidPassword {
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Invalid old password"))
 			return
 		}
@@ -405,6 +478,215 @@ func (h *AuthHandler) GetUserStats(c *gin.Context) {
 	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("User stats retrieved successfully", stats))
 }
 
+// ListSessions 获取登录会话列表
+// @Summary 获取登录会话列表
+// @Description 获取当前用户所有有效的登录会话，包含设备、IP和最后使用时间
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Response{data=[]models.SessionResponse} "获取成功"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/sessions [get]
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	sessionID, _ := middleware.GetSessionIDFromContext(c)
+	sessions, err := h.tokenService.ListSessions(userID.(uint), sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get sessions: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Sessions retrieved successfully", sessions))
+}
+
+// RevokeSession 撤销登录会话
+// @Summary 撤销登录会话
+// @Description 撤销指定会话，会话的访问令牌和刷新令牌立即失效，绑定设备的实时连接被断开；只有完全信任的设备可以撤销会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param session_id path string true "会话ID"
+// @Success 200 {object} models.Response "撤销成功"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "当前设备不是完全信任的设备"
+// @Failure 404 {object} models.Response "会话不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/sessions/{session_id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	sessionID := c.Param("session_id")
+	if err := h.tokenService.RevokeSession(userID.(uint), sessionID, models.RevokeReasonUserRevoked); err != nil {
+		if errors.Is(err, models.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Session not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to revoke session: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Session revoked successfully", gin.H{
+		"session_id": sessionID,
+	}))
+}
+
+// CreateAccessToken 创建个人访问令牌
+// @Summary 创建个人访问令牌
+// @Description 创建供脚本、CLI 使用的个人访问令牌，令牌明文只在本次响应中返回；令牌不绑定设备，只有完全信任的设备可以创建
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateAccessTokenRequest true "创建请求"
+// @Success 201 {object} models.Response{data=models.CreateAccessTokenResponse} "创建成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "当前设备不是完全信任的设备"
+// @Failure 409 {object} models.Response "令牌数量已达上限"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/tokens [post]
+func (h *AuthHandler) CreateAccessToken(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var req models.CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	token, plain, err := h.accessTokenService.CreateToken(userID.(uint), &req)
+	if err != nil {
+		if errors.Is(err, models.ErrTooManyAccessTokens) {
+			c.JSON(http.StatusConflict, models.ErrorResponse("Too many access tokens"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create access token: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponseWithMessage("Access token created successfully", &models.CreateAccessTokenResponse{
+		AccessTokenResponse: token.ToResponse(),
+		Token:               plain,
+	}))
+}
+
+// ListAccessTokens 获取个人访问令牌列表
+// @Summary 获取个人访问令牌列表
+// @Description 获取当前用户未撤销的个人访问令牌，包含权限、过期时间和最后使用时间
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Response{data=[]models.AccessTokenResponse} "获取成功"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/tokens [get]
+func (h *AuthHandler) ListAccessTokens(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	tokens, err := h.accessTokenService.ListTokens(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get access tokens: "+err.Error()))
+		return
+	}
+
+	responses := make([]*models.AccessTokenResponse, len(tokens))
+	for i := range tokens {
+		responses[i] = tokens[i].ToResponse()
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Access tokens retrieved successfully", responses))
+}
+
+// RevokeAccessToken 撤销个人访问令牌
+// @Summary 撤销个人访问令牌
+// @Description 撤销指定的个人访问令牌，令牌立即失效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "令牌ID"
+// @Success 200 {object} models.Response "撤销成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "令牌不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/tokens/{id} [delete]
+func (h *AuthHandler) RevokeAccessToken(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid token ID"))
+		return
+	}
+
+	if err := h.accessTokenService.RevokeToken(userID.(uint), uint(tokenID)); err != nil {
+		if errors.Is(err, models.ErrAccessTokenNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Access token not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to revoke access token: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Access token revoked successfully", gin.H{
+		"id": tokenID,
+	}))
+}
+
+// JWKS 公开签名公钥
+// @Summary 获取 JWT 签名公钥
+// @Description 以 JWKS 格式返回 EdDSA、RS256 签名密钥的公钥（包括轮换前仍在验证的密钥），HS256 密钥不公开
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} middleware.JWKSet "公钥列表"
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, middleware.PublicJWKS())
+}
+
+// issueTokens 为会话签发访问令牌，refreshToken 为会话当前的刷新令牌
+func issueTokens(user *models.User, session *models.AuthSession, refreshToken string) (*models.AuthResult, error) {
+	accessToken, err := middleware.GenerateToken(user.ID, user.Username, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	return &models.AuthResult{
+		User:         user.ToResponse(),
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(middleware.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
 // RegisterRoutes 注册认证相关路由
 func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup) {
 	auth := router.Group("/auth")
@@ -423,6 +705,11 @@ func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup) {
 			authenticated.PUT("/profile", h.UpdateProfile)
 			authenticated.POST("/change-password", h.ChangePassword)
 			authenticated.GET("/stats", h.GetUserStats)
+			authenticated.GET("/sessions", h.ListSessions)
+			authenticated.DELETE("/sessions/:session_id", middleware.FullTrustDeviceMiddleware(h.db), h.RevokeSession)
+			authenticated.GET("/tokens", h.ListAccessTokens)
+			authenticated.POST("/tokens", middleware.FullTrustDeviceMiddleware(h.db), h.CreateAccessToken)
+			authenticated.DELETE("/tokens/:id", h.RevokeAccessToken)
 		}
 	}
 }
\ No newline at end of file
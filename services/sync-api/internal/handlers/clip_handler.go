@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -28,9 +29,35 @@ func NewClipHandler(clipService *services.ClipService, db *gorm.DB) *ClipHandler
 	}
 }
 
+// clips 按令牌绑定设备的信任级别和平台返回剪贴板服务，受限设备只能访问批准之后创建的剪贴板项，
+// 排除了该平台的敏感剪贴板项不可访问
+func (h *ClipHandler) clips(c *gin.Context) *services.ClipService {
+	device, exists := middleware.GetDeviceFromContext(c)
+	if !exists {
+		return h.clipService
+	}
+	since, _ := device.ReadableSince()
+	return h.clipService.WithReadableSince(since).WithPlatform(device.Platform)
+}
+
+// respondTeamError 团队剪贴板权限错误的响应，不是团队错误时返回 false
+func respondTeamError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, models.ErrTeamNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Team not found"))
+	case errors.Is(err, models.ErrTeamPermissionDenied):
+		c.JSON(http.StatusForbidden, models.ErrorResponse("Insufficient team permission"))
+	case errors.Is(err, services.ErrInvalidClipScope):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid scope, expected user, team or all"))
+	default:
+		return false
+	}
+	return true
+}
+
 // CreateClip 创建剪贴板项
 // @Summary 创建剪贴板项
-// @Description 创建新的剪贴板项
+// @Description 创建新的剪贴板项；传入 team_id 时发布到团队剪贴板（需要成员及以上角色），并推送到所有团队成员的在线设备
 // @Tags 剪贴板
 // @Accept json
 // @Produce json
@@ -40,6 +67,9 @@ func NewClipHandler(clipService *services.ClipService, db *gorm.DB) *ClipHandler
 // @Success 200 {object} models.Response{data=models.ClipItemResponse} "内容已存在，更新使用时间"
 // @Failure 400 {object} models.Response "请求参数错误"
 // @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备未批准或团队权限不足"
+// @Failure 404 {object} models.Response "团队不存在"
+// @Failure 422 {object} models.Response "被剪贴板项规则丢弃"
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /clips [post]
 func (h *ClipHandler) CreateClip(c *gin.Context) {
@@ -73,11 +103,18 @@ func (h *ClipHandler) CreateClip(c *gin.Context) {
 	// 创建剪贴板项
 	clip, err := h.clipService.CreateClipItem(userID.(uint), &req)
 	if err != nil {
+		if respondTeamError(c, err) {
+			return
+		}
+		if errors.Is(err, models.ErrClipDroppedByRule) {
+			c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse("Clip item dropped by rule"))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create clip item: " + err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.SuccessResponseWithMessage("Clip item created successfully", clip.ToResponse()))
+	c.JSON(http.StatusCreated, models.SuccessResponseWithMessage("Clip item created successfully", services.MaskClipResponse(clip)))
 }
 
 // GetClip 获取剪贴板项
@@ -91,6 +128,7 @@ func (h *ClipHandler) CreateClip(c *gin.Context) {
 // @Success 200 {object} models.Response{data=models.ClipItemResponse} "获取成功"
 // @Failure 400 {object} models.Response "请求参数错误"
 // @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备未批准"
 // @Failure 404 {object} models.Response "剪贴板项不存在"
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /clips/{id} [get]
@@ -108,7 +146,7 @@ func (h *ClipHandler) GetClip(c *gin.Context) {
 		return
 	}
 
-	clip, err := h.clipService.GetClipItem(userID.(uint), uint(id))
+	clip, err := h.clips(c).GetClipItem(userID.(uint), uint(id))
 	if err != nil {
 		if err == models.ErrClipNotFound {
 			c.JSON(http.StatusNotFound, models.ErrorResponse("Clip item not found"))
@@ -118,19 +156,68 @@ func (h *ClipHandler) GetClip(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Clip item retrieved successfully", clip.ToResponse()))
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Clip item retrieved successfully", services.MaskClipResponse(clip)))
+}
+
+// RevealClip 查看剪贴板项原文
+// @Summary 查看剪贴板项原文
+// @Description 返回包含敏感数据的剪贴板项的原文，列表和详情响应中只返回遮盖后的内容
+// @Tags 剪贴板
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "剪贴板项ID"
+// @Success 200 {object} models.Response{data=models.ClipItemResponse} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备未批准"
+// @Failure 404 {object} models.Response "剪贴板项不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /clips/{id}/reveal [post]
+func (h *ClipHandler) RevealClip(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid clip ID: "+err.Error()))
+		return
+	}
+
+	clip, err := h.clips(c).GetClipItem(userID.(uint), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrClipNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Clip item not found"))
+		case errors.Is(err, models.ErrClipItemExpired):
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Clip item expired"))
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get clip item: "+err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Clip item revealed successfully", clip.ToResponse()))
 }
 
 // GetClips 获取剪贴板项列表
 // @Summary 获取剪贴板项列表
-// @Description 获取当前用户的剪贴板项列表
+// @Description 获取当前用户的剪贴板项列表，默认只包含个人剪贴板项；scope=team 返回所在团队的剪贴板项，scope=all 同时返回两者
+// @Description 包含敏感数据的剪贴板项按用户设置返回遮盖后的内容，原文通过 POST /clips/{id}/reveal 获取
 // @Tags 剪贴板
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param limit query int false "每页数量" default(20)
+// @Param scope query string false "归属范围" Enums(user,team,all) default(user)
+// @Param team_id query int false "只返回指定团队的剪贴板项"
 // @Param type query string false "类型筛选" Enums(text,image,file,url)
+// @Param subtype query string false "内容子类型筛选（逗号分隔，满足其一即可）：code、json、email、phone、color、path、date、otp、url"
+// @Param language query string false "代码的语言筛选，如 go、python"
 // @Param device_id query string false "设备ID筛选"
 // @Param status query string false "状态筛选" Enums(active,expired)
 // @Param search query string false "搜索关键词"
@@ -143,6 +230,8 @@ func (h *ClipHandler) GetClip(c *gin.Context) {
 // @Success 200 {object} models.Response{data=models.ListResponse} "获取成功"
 // @Failure 400 {object} models.Response "请求参数错误"
 // @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备未批准"
+// @Failure 404 {object} models.Response "团队不存在"
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /clips [get]
 func (h *ClipHandler) GetClips(c *gin.Context) {
@@ -168,7 +257,9 @@ func (h *ClipHandler) GetClips(c *gin.Context) {
 			Page:     page,
 			PageSize: limit,
 		},
+		Scope:          c.Query("scope"),
 		Type:           c.Query("type"),
+		Language:       c.Query("language"),
 		DeviceID:       c.Query("device_id"),
 		Status:         c.Query("status"),
 		Search:         c.Query("search"),
@@ -176,6 +267,17 @@ func (h *ClipHandler) GetClips(c *gin.Context) {
 		OrderBy:        c.DefaultQuery("sort", "updated_at") + " " + c.DefaultQuery("order", "desc"),
 	}
 
+	// 解析团队
+	if teamIDStr := c.Query("team_id"); teamIDStr != "" {
+		teamID, err := strconv.ParseUint(teamIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid team ID: " + err.Error()))
+			return
+		}
+		id := uint(teamID)
+		params.TeamID = &id
+	}
+
 	// 解析标签
 	if tagsStr := c.Query("tags"); tagsStr != "" {
 		params.Tags = strings.Split(tagsStr, ",")
@@ -184,6 +286,18 @@ func (h *ClipHandler) GetClips(c *gin.Context) {
 		}
 	}
 
+	// 解析内容子类型
+	if subtypeStr := c.Query("subtype"); subtypeStr != "" {
+		for _, subtype := range strings.Split(subtypeStr, ",") {
+			subtype = strings.TrimSpace(subtype)
+			if !models.ClipSubtype(subtype).IsValid() {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid subtype: "+subtype))
+				return
+			}
+			params.Subtypes = append(params.Subtypes, subtype)
+		}
+	}
+
 	// 解析时间范围
 	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
 		if startTime, err := time.Parse(time.RFC3339, startTimeStr); err == nil {
@@ -197,8 +311,11 @@ func (h *ClipHandler) GetClips(c *gin.Context) {
 	}
 
 	// 获取剪贴板项列表
-	clips, total, err := h.clipService.GetUserClipItems(userID.(uint), params)
+	clips, total, err := h.clips(c).GetUserClipItems(userID.(uint), params)
 	if err != nil {
+		if respondTeamError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get clip items: " + err.Error()))
 		return
 	}
@@ -206,7 +323,7 @@ func (h *ClipHandler) GetClips(c *gin.Context) {
 	// 转换为响应格式
 	clipResponses := make([]models.ClipItemResponse, len(clips))
 	for i, clip := range clips {
-		clipResponses[i] = *clip.ToResponse()
+		clipResponses[i] = *services.MaskClipResponse(clip)
 	}
 
 	response := &models.ListResponse{
@@ -233,6 +350,7 @@ func (h *ClipHandler) GetClips(c *gin.Context) {
 // @Success 200 {object} models.Response{data=[]models.ClipItemResponse} "获取成功"
 // @Failure 400 {object} models.Response "请求参数错误"
 // @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备未批准"
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /clips/recent [get]
 func (h *ClipHandler) GetRecentClips(c *gin.Context) {
@@ -247,7 +365,7 @@ func (h *ClipHandler) GetRecentClips(c *gin.Context) {
 		limit = 10
 	}
 
-	clips, err := h.clipService.GetRecentClipItems(userID.(uint), limit)
+	clips, err := h.clips(c).GetRecentClipItems(userID.(uint), limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponseWithMessage("Failed to get recent clip items", err.Error()))
 		return
@@ -256,7 +374,7 @@ func (h *ClipHandler) GetRecentClips(c *gin.Context) {
 	// 转换为响应格式
 	clipResponses := make([]models.ClipItemResponse, len(clips))
 	for i, clip := range clips {
-		clipResponses[i] = *clip.ToResponse()
+		clipResponses[i] = *services.MaskClipResponse(clip)
 	}
 
 	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Recent clip items retrieved successfully", clipResponses))
@@ -264,7 +382,7 @@ func (h *ClipHandler) GetRecentClips(c *gin.Context) {
 
 // UpdateClip 更新剪贴板项
 // @Summary 更新剪贴板项
-// @Description 更新剪贴板项信息
+// @Description 更新剪贴板项信息；团队剪贴板项可以由发布者或团队管理员修改
 // @Tags 剪贴板
 // @Accept json
 // @Produce json
@@ -274,6 +392,7 @@ func (h *ClipHandler) GetRecentClips(c *gin.Context) {
 // @Success 200 {object} models.Response{data=models.ClipItemResponse} "更新成功"
 // @Failure 400 {object} models.Response "请求参数错误"
 // @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备未批准或团队权限不足"
 // @Failure 404 {object} models.Response "剪贴板项不存在"
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /clips/{id} [put]
@@ -298,22 +417,25 @@ func (h *ClipHandler) UpdateClip(c *gin.Context) {
 	}
 
 	// 更新剪贴板项
-	clip, err := h.clipService.UpdateClipItem(userID.(uint), uint(id), &req)
+	clip, err := h.clips(c).UpdateClipItem(userID.(uint), uint(id), &req)
 	if err != nil {
 		if err == models.ErrClipNotFound {
 			c.JSON(http.StatusNotFound, models.ErrorResponse("Clip item not found"))
 			return
 		}
+		if respondTeamError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponseWithMessage("Failed to update clip item", err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Clip item updated successfully", clip.ToResponse()))
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Clip item updated successfully", services.MaskClipResponse(clip)))
 }
 
 // DeleteClip 删除剪贴板项
 // @Summary 删除剪贴板项
-// @Description 软删除指定剪贴板项
+// @Description 软删除指定剪贴板项；团队剪贴板项可以由发布者或团队管理员删除
 // @Tags 剪贴板
 // @Accept json
 // @Produce json
@@ -322,6 +444,7 @@ func (h *ClipHandler) UpdateClip(c *gin.Context) {
 // @Success 200 {object} models.Response "删除成功"
 // @Failure 400 {object} models.Response "请求参数错误"
 // @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备未批准或团队权限不足"
 // @Failure 404 {object} models.Response "剪贴板项不存在"
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /clips/{id} [delete]
@@ -340,12 +463,15 @@ func (h *ClipHandler) DeleteClip(c *gin.Context) {
 	}
 
 	// 删除剪贴板项
-	err = h.clipService.DeleteClipItem(userID.(uint), uint(id))
+	err = h.clips(c).DeleteClipItem(userID.(uint), uint(id))
 	if err != nil {
 		if err == models.ErrClipNotFound {
 			c.JSON(http.StatusNotFound, models.ErrorResponse("Clip item not found"))
 			return
 		}
+		if respondTeamError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponseWithMessage("Failed to delete clip item", err.Error()))
 		return
 	}
@@ -357,7 +483,7 @@ func (h *ClipHandler) DeleteClip(c *gin.Context) {
 
 // DeleteClips 批量删除剪贴板项
 // @Summary 批量删除剪贴板项
-// @Description 批量软删除剪贴板项
+// @Description 批量软删除个人剪贴板项，团队剪贴板项需要逐个删除
 // @Tags 剪贴板
 // @Accept json
 // @Produce json
@@ -366,6 +492,7 @@ func (h *ClipHandler) DeleteClip(c *gin.Context) {
 // @Success 200 {object} models.Response "删除成功"
 // @Failure 400 {object} models.Response "请求参数错误"
 // @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备未批准"
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /clips/batch-delete [post]
 func (h *ClipHandler) DeleteClips(c *gin.Context) {
@@ -387,7 +514,7 @@ func (h *ClipHandler) DeleteClips(c *gin.Context) {
 	}
 
 	// 批量删除剪贴板项
-	err := h.clipService.DeleteClipItems(userID.(uint), req.IDs)
+	err := h.clips(c).DeleteClipItems(userID.(uint), req.IDs)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponseWithMessage("Failed to delete clip items", err.Error()))
 		return
@@ -410,6 +537,7 @@ func (h *ClipHandler) DeleteClips(c *gin.Context) {
 // @Success 200 {object} models.Response "标记成功"
 // @Failure 400 {object} models.Response "请求参数错误"
 // @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备未批准"
 // @Failure 404 {object} models.Response "剪贴板项不存在"
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /clips/{id}/use [post]
@@ -428,7 +556,7 @@ func (h *ClipHandler) MarkAsUsed(c *gin.Context) {
 	}
 
 	// 标记为已使用
-	err = h.clipService.MarkAsUsed(userID.(uint), uint(id))
+	err = h.clips(c).MarkAsUsed(userID.(uint), uint(id))
 	if err != nil {
 		if err == models.ErrClipNotFound {
 			c.JSON(http.StatusNotFound, models.ErrorResponse("Clip item not found"))
@@ -445,7 +573,7 @@ func (h *ClipHandler) MarkAsUsed(c *gin.Context) {
 
 // SyncClips 同步剪贴板项
 // @Summary 同步剪贴板项
-// @Description 获取指定时间后更新的剪贴板项
+// @Description 获取指定时间后更新的剪贴板项，包括所在团队的剪贴板项
 // @Tags 剪贴板
 // @Accept json
 // @Produce json
@@ -456,6 +584,7 @@ func (h *ClipHandler) MarkAsUsed(c *gin.Context) {
 // @Success 200 {object} models.Response{data=services.SyncResult} "同步成功"
 // @Failure 400 {object} models.Response "请求参数错误"
 // @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备未批准"
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /clips/sync [get]
 func (h *ClipHandler) SyncClips(c *gin.Context) {
@@ -484,7 +613,7 @@ func (h *ClipHandler) SyncClips(c *gin.Context) {
 	}
 
 	// 同步剪贴板项
-	syncResult, err := h.clipService.SyncClipItems(userID.(uint), deviceID, lastSync)
+	syncResult, err := h.clips(c).SyncClipItems(userID.(uint), deviceID, lastSync)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponseWithMessage("Failed to sync clip items", err.Error()))
 		return
@@ -502,6 +631,7 @@ func (h *ClipHandler) SyncClips(c *gin.Context) {
 // @Security BearerAuth
 // @Success 200 {object} models.Response{data=services.ClipStats} "获取成功"
 // @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备未批准"
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /clips/stats [get]
 func (h *ClipHandler) GetClipStats(c *gin.Context) {
@@ -533,6 +663,7 @@ func (h *ClipHandler) GetClipStats(c *gin.Context) {
 // @Success 200 {object} models.Response{data=models.ListResponse} "搜索成功"
 // @Failure 400 {object} models.Response "请求参数错误"
 // @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备未批准"
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /clips/search [get]
 func (h *ClipHandler) SearchClips(c *gin.Context) {
@@ -564,7 +695,7 @@ func (h *ClipHandler) SearchClips(c *gin.Context) {
 	}
 
 	// 搜索剪贴板项
-	clips, pagination, err := h.clipService.SearchClipItems(userID.(uint), query, params)
+	clips, pagination, err := h.clips(c).SearchClipItems(userID.(uint), query, params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponseWithMessage("Failed to search clip items", err.Error()))
 		return
@@ -573,7 +704,7 @@ func (h *ClipHandler) SearchClips(c *gin.Context) {
 	// 转换为响应格式
 	clipResponses := make([]models.ClipItemResponse, len(clips))
 	for i, clip := range clips {
-		clipResponses[i] = *clip.ToResponse()
+		clipResponses[i] = *services.MaskClipResponse(clip)
 	}
 
 	response := &models.ListResponse{
@@ -587,7 +718,8 @@ func (h *ClipHandler) SearchClips(c *gin.Context) {
 // RegisterRoutes 注册剪贴板相关路由
 func (h *ClipHandler) RegisterRoutes(router *gin.RouterGroup) {
 	clips := router.Group("/clips")
-	clips.Use(middleware.AuthMiddleware(h.db)) // 所有剪贴板接口都需要认证
+	clips.Use(middleware.ScopedAuthMiddleware(h.db, models.ScopeClipsRead, models.ScopeClipsWrite)) // 所有剪贴板接口都需要认证
+	clips.Use(middleware.DeviceTrustMiddleware(h.db)) // 未批准的设备不能访问剪贴板
 	{
 		clips.POST("", h.CreateClip)
 		clips.GET("", h.GetClips)
@@ -600,5 +732,6 @@ func (h *ClipHandler) RegisterRoutes(router *gin.RouterGroup) {
 		clips.PUT("/:id", h.UpdateClip)
 		clips.DELETE("/:id", h.DeleteClip)
 		clips.POST("/:id/use", h.MarkAsUsed)
+		clips.POST("/:id/reveal", h.RevealClip)
 	}
 }
\ No newline at end of file
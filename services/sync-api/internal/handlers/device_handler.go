@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -29,13 +30,14 @@ func NewDeviceHandler(deviceService *services.DeviceService, db *gorm.DB) *Devic
 
 // RegisterDevice 注册设备
 // @Summary 注册设备
-// @Description 注册或更新设备信息
+// @Description 注册或更新设备信息。用户的第一台设备自动批准，之后的新设备返回 202 并处于 pending 状态，需已信任设备批准后才能访问剪贴板
 // @Tags 设备
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body models.RegisterDeviceRequest true "设备注册请求"
 // @Success 201 {object} models.Response{data=models.DeviceResponse} "注册成功"
+// @Success 202 {object} models.Response{data=models.DeviceResponse} "等待批准"
 // @Success 200 {object} models.Response{data=models.DeviceResponse} "更新成功"
 // @Failure 400 {object} models.Response "请求参数错误"
 // @Failure 401 {object} models.Response "未授权"
@@ -70,6 +72,16 @@ func (h *DeviceHandler) RegisterDevice(c *gin.Context) {
 		return
 	}
 
+	if device.IsPending() {
+		c.JSON(http.StatusAccepted, models.SuccessResponseWithMessage("Device registered, waiting for approval", device.ToResponse()))
+		return
+	}
+	// 重新注册不会恢复被拒绝、暂停或停用的设备
+	if !device.IsActive() {
+		c.JSON(http.StatusForbidden, models.ErrorResponse("Device is "+device.Status.String()))
+		return
+	}
+
 	c.JSON(http.StatusCreated, models.SuccessResponseWithMessage("Device registered successfully", device.ToResponse()))
 }
 
@@ -414,6 +426,98 @@ func (h *DeviceHandler) DeactivateDevice(c *gin.Context) {
 	}))
 }
 
+// ApproveDevice 批准设备
+// @Summary 批准设备
+// @Description 由已信任（完全信任）的设备批准等待中的新设备，或调整已批准设备的信任级别。limited 级别的设备只能读取批准之后创建的剪贴板项
+// @Tags 设备
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param device_id path string true "设备ID"
+// @Param request body models.ApproveDeviceRequest false "批准请求"
+// @Success 200 {object} models.Response{data=models.DeviceResponse} "批准成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "当前设备无权批准"
+// @Failure 404 {object} models.Response "设备不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /devices/{device_id}/approve [post]
+func (h *DeviceHandler) ApproveDevice(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var req models.ApproveDeviceRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponseWithMessage("Invalid request parameters", err.Error()))
+			return
+		}
+	}
+
+	// 批准方为令牌绑定的设备
+	approverDeviceID, _ := middleware.GetDeviceIDFromContext(c)
+
+	device, err := h.deviceService.ApproveDevice(userID, approverDeviceID, c.Param("device_id"), req.TrustLevel)
+	if err != nil {
+		h.respondDecisionError(c, err, "Failed to approve device")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Device approved successfully", device.ToResponse()))
+}
+
+// DenyDevice 拒绝设备
+// @Summary 拒绝设备
+// @Description 由已信任（完全信任）的设备拒绝等待批准的新设备，被拒绝的设备标记为已撤销
+// @Tags 设备
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param device_id path string true "设备ID"
+// @Success 200 {object} models.Response{data=models.DeviceResponse} "拒绝成功"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "当前设备无权批准"
+// @Failure 404 {object} models.Response "设备不存在"
+// @Failure 409 {object} models.Response "设备不在等待批准状态"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /devices/{device_id}/deny [post]
+func (h *DeviceHandler) DenyDevice(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	approverDeviceID, _ := middleware.GetDeviceIDFromContext(c)
+
+	device, err := h.deviceService.DenyDevice(userID, approverDeviceID, c.Param("device_id"))
+	if err != nil {
+		h.respondDecisionError(c, err, "Failed to deny device")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Device denied successfully", device.ToResponse()))
+}
+
+// respondDecisionError 返回批准或拒绝设备失败的响应
+func (h *DeviceHandler) respondDecisionError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrDeviceNotTrusted):
+		c.JSON(http.StatusForbidden, models.ErrorResponse("Only an approved device with full trust can approve devices"))
+	case errors.Is(err, models.ErrDeviceSelfApproval):
+		c.JSON(http.StatusForbidden, models.ErrorResponse("Device cannot approve itself"))
+	case errors.Is(err, models.ErrDeviceNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Device not found"))
+	case errors.Is(err, models.ErrDeviceNotPending):
+		c.JSON(http.StatusConflict, models.ErrorResponse("Device is not waiting for approval"))
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponseWithMessage(message, err.Error()))
+	}
+}
+
 // DeleteDevice 删除设备
 // @Summary 删除设备
 // @Description 软删除指定设备
@@ -515,7 +619,7 @@ func (h *DeviceHandler) GetDeviceStats(c *gin.Context) {
 // RegisterRoutes 注册设备相关路由
 func (h *DeviceHandler) RegisterRoutes(router *gin.RouterGroup) {
 	devices := router.Group("/devices")
-	devices.Use(middleware.AuthMiddleware(h.db)) // 所有设备接口都需要认证
+	devices.Use(middleware.ScopedAuthMiddleware(h.db, models.ScopeDevicesRead, "")) // 所有设备接口都需要认证，个人访问令牌只能读取
 	{
 		devices.POST("/register", h.RegisterDevice)
 		devices.GET("", h.GetDevices)
@@ -523,6 +627,8 @@ func (h *DeviceHandler) RegisterRoutes(router *gin.RouterGroup) {
 		devices.PUT("/:device_id", h.UpdateDevice)
 		devices.PUT("/:device_id/status", h.UpdateDeviceStatus)
 		devices.POST("/:device_id/deactivate", h.DeactivateDevice)
+		devices.POST("/:device_id/approve", h.ApproveDevice)
+		devices.POST("/:device_id/deny", h.DenyDevice)
 		devices.DELETE("/:device_id", h.DeleteDevice)
 		devices.GET("/:device_id/stats", h.GetDeviceStats)
 	}
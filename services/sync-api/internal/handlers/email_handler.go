@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"xpaste-sync/internal/middleware"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
+)
+
+// EmailHandler 邮箱验证和密码重置处理器
+type EmailHandler struct {
+	emailService *services.EmailService
+}
+
+// NewEmailHandler 创建邮箱验证和密码重置处理器
+func NewEmailHandler(emailService *services.EmailService) *EmailHandler {
+	return &EmailHandler{emailService: emailService}
+}
+
+// VerifyEmail 验证邮箱
+// @Summary 验证邮箱
+// @Description 提交验证邮件中的令牌，标记邮箱已验证；等待验证的账户同时激活
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.VerifyEmailRequest true "验证请求"
+// @Success 200 {object} models.Response{data=models.UserResponse} "验证成功"
+// @Failure 400 {object} models.Response "令牌无效或已过期"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/verify-email [post]
+func (h *EmailHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	user, err := h.emailService.VerifyEmail(req.Token, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, models.ErrEmailTokenInvalid) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid or expired verification token"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to verify email: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Email verified successfully", user.ToResponse()))
+}
+
+// ResendVerification 重新发送验证邮件
+// @Summary 重新发送验证邮件
+// @Description 向未验证的邮箱重新发送验证邮件；无论邮箱是否存在都返回成功，1 分钟内不会重复发送
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.EmailRequest true "邮箱"
+// @Success 200 {object} models.Response "请求已受理"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/verify-email/resend [post]
+func (h *EmailHandler) ResendVerification(c *gin.Context) {
+	var req models.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	if err := h.emailService.ResendVerification(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to send verification email: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("If the email address is registered and not yet verified, a verification email has been sent", nil))
+}
+
+// ForgotPassword 忘记密码
+// @Summary 忘记密码
+// @Description 向邮箱发送密码重置邮件；无论邮箱是否存在都返回成功，1 分钟内不会重复发送
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.EmailRequest true "邮箱"
+// @Success 200 {object} models.Response "请求已受理"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/password/forgot [post]
+func (h *EmailHandler) ForgotPassword(c *gin.Context) {
+	var req models.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	if err := h.emailService.RequestPasswordReset(req.Email, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to send password reset email: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("If the email address is registered, a password reset email has been sent", nil))
+}
+
+// ResetPassword 重置密码
+// @Summary 重置密码
+// @Description 提交重置邮件中的令牌和新密码；重置后撤销所有会话并解除登录锁定
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.ResetPasswordRequest true "重置请求"
+// @Success 200 {object} models.Response "重置成功"
+// @Failure 400 {object} models.Response "令牌无效或已过期"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/password/reset [post]
+func (h *EmailHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	user, err := h.emailService.ResetPassword(req.Token, req.NewPassword, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if errors.Is(err, models.ErrEmailTokenInvalid) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid or expired reset token"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to reset password: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Password reset successfully, please log in again", gin.H{
+		"user_id": user.ID,
+	}))
+}
+
+// RegisterRoutes 注册邮箱验证和密码重置路由（公开，按 IP 限流）
+func (h *EmailHandler) RegisterRoutes(router *gin.RouterGroup) {
+	email := router.Group("/auth")
+	email.Use(middleware.AuthRateLimitMiddleware())
+	{
+		email.POST("/verify-email", h.VerifyEmail)
+		email.POST("/verify-email/resend", h.ResendVerification)
+		email.POST("/password/forgot", h.ForgotPassword)
+		email.POST("/password/reset", h.ResetPassword)
+	}
+}
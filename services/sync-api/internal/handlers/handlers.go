@@ -9,19 +9,39 @@ import (
 
 // Handlers 处理器集合
 type Handlers struct {
-	AuthHandler    *AuthHandler
-	DeviceHandler  *DeviceHandler
-	ClipHandler    *ClipHandler
-	SettingHandler *SettingHandler
+	AuthHandler      *AuthHandler
+	DeviceHandler    *DeviceHandler
+	ClipHandler      *ClipHandler
+	SettingHandler   *SettingHandler
+	PairingHandler   *PairingHandler
+	TwoFactorHandler *TwoFactorHandler
+	AdminHandler     *AdminHandler
+	EmailHandler     *EmailHandler
+	OIDCHandler      *OIDCHandler
+	TeamHandler      *TeamHandler
+	ShareHandler     *ShareHandler
+	WebhookHandler   *WebhookHandler
+	InboundHandler   *InboundHandler
+	RuleHandler      *RuleHandler
 }
 
 // NewHandlers 创建处理器集合
 func NewHandlers(services *services.Services) *Handlers {
 	return &Handlers{
-		AuthHandler:    NewAuthHandler(services.User, services.GetDB()),
-		DeviceHandler:  NewDeviceHandler(services.Device, services.GetDB()),
-		ClipHandler:    NewClipHandler(services.Clip, services.GetDB()),
-		SettingHandler: NewSettingHandler(services.Setting),
+		AuthHandler:      NewAuthHandler(services.User, services.Token, services.AccessToken, services.TwoFactor, services.Setting, services.Email, services.GetDB()),
+		DeviceHandler:    NewDeviceHandler(services.Device, services.GetDB()),
+		ClipHandler:      NewClipHandler(services.Clip, services.GetDB()),
+		SettingHandler:   NewSettingHandler(services.Setting),
+		PairingHandler:   NewPairingHandler(services.Pairing, services.User, services.Token, services.GetDB()),
+		TwoFactorHandler: NewTwoFactorHandler(services.TwoFactor, services.User, services.Token, services.GetDB()),
+		AdminHandler:     NewAdminHandler(services.Admin, services.User, services.LoginGuard, services.Clip, services.Device, services.GetDB()),
+		EmailHandler:     NewEmailHandler(services.Email),
+		OIDCHandler:      NewOIDCHandler(services.OIDC, services.Token),
+		TeamHandler:      NewTeamHandler(services.Team, services.GetDB()),
+		ShareHandler:     NewShareHandler(services.Share, services.GetDB()),
+		WebhookHandler:   NewWebhookHandler(services.Webhook, services.GetDB()),
+		InboundHandler:   NewInboundHandler(services.Inbound, services.GetDB()),
+		RuleHandler:      NewRuleHandler(services.Rule, services.GetDB()),
 	}
 }
 
@@ -35,18 +55,51 @@ func (h *Handlers) RegisterRoutes(router *gin.Engine) {
 	{
 		// 注册认证路由（包含公开和需要认证的路由）
 		h.AuthHandler.RegisterRoutes(api)
+		h.TwoFactorHandler.RegisterRoutes(api)
+		h.EmailHandler.RegisterRoutes(api)
+		h.OIDCHandler.RegisterRoutes(api)
+
+		// 设备配对路由（新设备认领配对时尚未登录）
+		h.PairingHandler.RegisterRoutes(api)
+
+		// 设备和剪贴板路由在各自的路由组中认证，同时接受具备相应权限的个人访问令牌
+		h.DeviceHandler.RegisterRoutes(api)
+		h.ClipHandler.RegisterRoutes(api)
+
+		// 团队和成员管理，团队剪贴板项通过剪贴板接口的 team_id 访问
+		h.TeamHandler.RegisterRoutes(api)
+
+		// 分享链接管理
+		h.ShareHandler.RegisterRoutes(api)
+
+		// 出站 webhook 订阅和投递记录
+		h.WebhookHandler.RegisterRoutes(api)
+
+		// 入站 webhook 和邮件地址
+		h.InboundHandler.RegisterRoutes(api)
+
+		// 剪贴板项规则
+		h.RuleHandler.RegisterRoutes(api)
 
 		// 需要认证的路由组
 		authenticated := api.Group("")
 		authenticated.Use(middleware.AuthMiddleware(h.AuthHandler.db))
 		{
 			// 注册需要认证的模块路由
-			h.DeviceHandler.RegisterRoutes(authenticated)
-			h.ClipHandler.RegisterRoutes(authenticated)
 			h.SettingHandler.RegisterRoutes(authenticated)
 		}
+
+		// 管理员路由
+		h.AdminHandler.RegisterRoutes(api)
 	}
 
+	// 公开分享链接，不需要认证
+	h.ShareHandler.RegisterPublicRoutes(router)
+	h.InboundHandler.RegisterPublicRoutes(router)
+
+	// JWT 签名公钥
+	router.GET("/.well-known/jwks.json", h.AuthHandler.JWKS)
+
 	// 健康检查路由
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
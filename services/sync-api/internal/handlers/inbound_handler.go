@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/inbound"
+	"xpaste-sync/internal/middleware"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
+)
+
+// InboundHandler 入站 webhook 和邮件处理器
+type InboundHandler struct {
+	inboundService *services.InboundService
+	db             *gorm.DB
+}
+
+// NewInboundHandler 创建入站处理器
+func NewInboundHandler(inboundService *services.InboundService, db *gorm.DB) *InboundHandler {
+	return &InboundHandler{
+		inboundService: inboundService,
+		db:             db,
+	}
+}
+
+// baseURL 入站地址前缀，未配置时使用请求的地址
+func (h *InboundHandler) baseURL(c *gin.Context) string {
+	if base := h.inboundService.BaseURL(); base != "" {
+		return base
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// ListInboundEndpoints 获取入站地址
+// @Summary 获取入站地址
+// @Description 获取当前用户的入站 webhook 和邮件地址，不包含令牌
+// @Tags 入站
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Response{data=[]models.InboundEndpointResponse} "获取成功"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /inbound [get]
+func (h *InboundHandler) ListInboundEndpoints(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	endpoints, err := h.inboundService.ListEndpoints(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get inbound endpoints: "+err.Error()))
+		return
+	}
+
+	responses := make([]*models.InboundEndpointResponse, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		responses = append(responses, endpoint.ToResponse())
+	}
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Inbound endpoints retrieved successfully", responses))
+}
+
+// CreateInboundEndpoint 创建或轮换入站地址
+// @Summary 创建或轮换入站地址
+// @Description 为 webhook 或 email 渠道生成新的专属地址，已有的地址立即失效。响应中的地址只返回这一次
+// @Tags 入站
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "渠道" Enums(webhook, email)
+// @Param request body models.InboundEndpointRequest false "默认标签"
+// @Success 201 {object} models.Response{data=models.InboundEndpointResponse} "创建成功"
+// @Failure 400 {object} models.Response "请求参数错误或未启用邮件入站"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /inbound/{channel} [post]
+func (h *InboundHandler) CreateInboundEndpoint(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var req models.InboundEndpointRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+			return
+		}
+	}
+
+	channel := models.InboundChannel(c.Param("channel"))
+	endpoint, token, err := h.inboundService.CreateEndpoint(userID.(uint), channel, &req)
+	if err != nil {
+		respondInboundError(c, err, "Failed to create inbound endpoint")
+		return
+	}
+
+	response := endpoint.ToResponse()
+	if channel == models.InboundChannelEmail {
+		response.Address = h.inboundService.EmailAddress(token)
+	} else {
+		response.URL = h.baseURL(c) + "/in/" + token
+	}
+	c.JSON(http.StatusCreated, models.SuccessResponseWithMessage("Inbound endpoint created successfully", response))
+}
+
+// DeleteInboundEndpoint 删除入站地址
+// @Summary 删除入站地址
+// @Description 删除 webhook 或 email 渠道的入站地址，之后发送到该地址的内容被拒绝
+// @Tags 入站
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "渠道" Enums(webhook, email)
+// @Success 200 {object} models.Response "删除成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "入站地址不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /inbound/{channel} [delete]
+func (h *InboundHandler) DeleteInboundEndpoint(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	if err := h.inboundService.DeleteEndpoint(userID.(uint), models.InboundChannel(c.Param("channel"))); err != nil {
+		respondInboundError(c, err, "Failed to delete inbound endpoint")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Inbound endpoint deleted successfully", nil))
+}
+
+// ReceiveInbound 接收入站内容
+// @Summary 接收入站内容
+// @Description 外部系统向用户的专属地址提交内容，保存为剪贴板项并推送到用户的所有设备。
+// @Description 支持 JSON（content、type、title、description、tags、metadata、file_name、mime_type）、表单（content、type、title、description、tags，可上传多个文件）和原始请求体（文本保存为文本或链接，其余保存为文件）。
+// @Description 查询参数 title、tags（逗号分隔）作用于所有剪贴板项，filename 指定原始请求体的文件名
+// @Tags 入站
+// @Accept json,x-www-form-urlencoded,mpfd,plain,octet-stream
+// @Produce json
+// @Param token path string true "入站令牌"
+// @Param title query string false "标题"
+// @Param tags query string false "标签，逗号分隔"
+// @Param filename query string false "原始请求体的文件名"
+// @Success 201 {object} models.Response{data=models.InboundResponse} "接收成功"
+// @Failure 400 {object} models.Response "请求内容无效或为空"
+// @Failure 404 {object} models.Response "入站地址不存在"
+// @Failure 413 {object} models.Response "请求体过大"
+// @Failure 429 {object} models.Response "请求过于频繁"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /in/{token} [post]
+func (h *InboundHandler) ReceiveInbound(c *gin.Context) {
+	endpoint, err := h.inboundService.Authenticate(models.InboundChannelWebhook, c.Param("token"))
+	if err != nil {
+		respondInboundError(c, err, "Failed to receive content")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.inboundService.MaxSize())
+	reqs, err := inbound.ParseRequest(c.Request)
+	if err != nil {
+		respondInboundError(c, err, "Failed to read content")
+		return
+	}
+
+	clipItems, err := h.inboundService.Receive(endpoint, reqs)
+	if err != nil {
+		respondInboundError(c, err, "Failed to receive content")
+		return
+	}
+
+	response := &models.InboundResponse{Items: make([]*models.ClipItemResponse, 0, len(clipItems))}
+	for _, clipItem := range clipItems {
+		response.Items = append(response.Items, services.MaskClipResponse(clipItem))
+	}
+	c.JSON(http.StatusCreated, models.SuccessResponseWithMessage("Content received successfully", response))
+}
+
+// respondInboundError 入站操作的错误响应
+func respondInboundError(c *gin.Context, err error, message string) {
+	var maxBytes *http.MaxBytesError
+	switch {
+	case errors.Is(err, models.ErrInboundEndpointNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Inbound endpoint not found"))
+	case errors.Is(err, models.ErrInboundChannelInvalid):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid inbound channel"))
+	case errors.Is(err, models.ErrInboundEmailDisabled):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Inbound email is not enabled on this server"))
+	case errors.Is(err, models.ErrInboundEmpty):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Content is empty"))
+	case errors.Is(err, inbound.ErrInvalidRequest):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse(err.Error()))
+	case errors.As(err, &maxBytes):
+		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse("Content too large"))
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse(message+": "+err.Error()))
+	}
+}
+
+// RegisterRoutes 注册入站地址管理路由
+func (h *InboundHandler) RegisterRoutes(router *gin.RouterGroup) {
+	endpoints := router.Group("/inbound")
+	endpoints.Use(middleware.AuthMiddleware(h.db))
+	{
+		endpoints.GET("", h.ListInboundEndpoints)
+		endpoints.POST("/:channel", h.CreateInboundEndpoint)
+		endpoints.DELETE("/:channel", h.DeleteInboundEndpoint)
+	}
+}
+
+// RegisterPublicRoutes 注册公开的入站地址，令牌即凭证
+func (h *InboundHandler) RegisterPublicRoutes(router *gin.Engine) {
+	public := router.Group("/in")
+	public.Use(middleware.InboundRateLimitMiddleware())
+	{
+		public.POST("/:token", h.ReceiveInbound)
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"html"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+
+	"xpaste-sync/internal/middleware"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
+)
+
+// OIDCHandler 单点登录处理器
+type OIDCHandler struct {
+	oidcService  *services.OIDCService
+	tokenService *services.TokenService
+}
+
+// NewOIDCHandler 创建单点登录处理器
+func NewOIDCHandler(oidcService *services.OIDCService, tokenService *services.TokenService) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService:  oidcService,
+		tokenService: tokenService,
+	}
+}
+
+// GetConfig 获取单点登录配置
+// @Summary 获取单点登录配置
+// @Description 客户端据此决定是否显示单点登录按钮
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} models.Response{data=models.OIDCProviderResponse} "获取成功"
+// @Router /auth/oidc/config [get]
+func (h *OIDCHandler) GetConfig(c *gin.Context) {
+	resp := &models.OIDCProviderResponse{Enabled: h.oidcService.Enabled()}
+	if resp.Enabled {
+		resp.ProviderName = h.oidcService.ProviderName()
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse("Single sign-on config retrieved successfully", resp))
+}
+
+// Authorize 发起单点登录
+// @Summary 发起单点登录
+// @Description 返回身份提供方的授权地址和登录令牌；客户端在浏览器中打开授权地址，之后用登录令牌调用 /auth/oidc/token 兑换会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.OIDCAuthorizeRequest true "发起请求"
+// @Success 200 {object} models.Response{data=models.OIDCAuthorizeResponse} "发起成功"
+// @Failure 400 {object} models.Response "跳转地址不允许"
+// @Failure 404 {object} models.Response "未启用单点登录"
+// @Failure 502 {object} models.Response "身份提供方不可用"
+// @Router /auth/oidc/authorize [post]
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	var req models.OIDCAuthorizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	resp, err := h.oidcService.StartLogin(c.Request.Context(), req.DeviceID, req.RedirectURI)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrOIDCDisabled):
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Single sign-on is not enabled"))
+		case errors.Is(err, models.ErrOIDCRedirectNotAllowed):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Redirect URI is not allowed"))
+		default:
+			c.JSON(http.StatusBadGateway, models.ErrorResponse("Failed to start single sign-on: "+err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Single sign-on started", resp))
+}
+
+// Callback 身份提供方回调
+// @Summary 身份提供方回调
+// @Description 由浏览器从身份提供方跳转而来，完成授权码交换和账户关联；发起时指定了 redirect_uri 则跳转回客户端，否则显示结果页面
+// @Tags 认证
+// @Produce html
+// @Param state query string true "state"
+// @Param code query string false "授权码"
+// @Param error query string false "身份提供方返回的错误"
+// @Success 200 {string} string "结果页面"
+// @Success 302 {string} string "跳转回客户端"
+// @Router /auth/oidc/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	providerError := c.Query("error")
+	if desc := c.Query("error_description"); providerError != "" && desc != "" {
+		providerError += ": " + desc
+	}
+
+	loginState, err := h.oidcService.HandleCallback(c.Request.Context(), c.Query("state"), c.Query("code"), providerError, c.ClientIP(), c.Request.UserAgent())
+	if loginState == nil {
+		// state 无效，无法确定该跳转到哪里
+		renderOIDCResult(c, http.StatusBadRequest, "登录链接无效或已过期，请回到客户端重新发起登录。")
+		return
+	}
+
+	if loginState.RedirectURI != "" {
+		target, parseErr := url.Parse(loginState.RedirectURI)
+		if parseErr == nil {
+			query := target.Query()
+			if err != nil {
+				query.Set("oidc", "error")
+			} else {
+				query.Set("oidc", "success")
+			}
+			target.RawQuery = query.Encode()
+			c.Redirect(http.StatusFound, target.String())
+			return
+		}
+	}
+
+	if err != nil {
+		renderOIDCResult(c, http.StatusOK, "登录失败："+err.Error())
+		return
+	}
+	renderOIDCResult(c, http.StatusOK, "登录成功，可以关闭此页面并回到客户端。")
+}
+
+// Token 兑换单点登录会话
+// @Summary 兑换单点登录会话
+// @Description 用发起登录时返回的登录令牌兑换访问令牌和刷新令牌；浏览器中的登录尚未完成时返回 202，客户端应稍后重试
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.OIDCTokenRequest true "兑换请求"
+// @Success 200 {object} models.Response{data=models.AuthResult} "登录成功"
+// @Success 202 {object} models.Response "等待浏览器中完成登录"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "登录失败或登录令牌无效"
+// @Failure 403 {object} models.Response "设备已被撤销或账户不可用"
+// @Failure 404 {object} models.Response "未启用单点登录"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/oidc/token [post]
+func (h *OIDCHandler) Token(c *gin.Context) {
+	var req models.OIDCTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	user, deviceID, err := h.oidcService.RedeemLogin(req.LoginToken)
+	if err != nil {
+		var loginErr *services.OIDCLoginError
+		switch {
+		case errors.Is(err, models.ErrOIDCDisabled):
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Single sign-on is not enabled"))
+		case errors.Is(err, models.ErrOIDCLoginPending):
+			c.JSON(http.StatusAccepted, models.SuccessResponseWithMessage("Waiting for sign-in to complete in the browser", gin.H{"pending": true}))
+		case errors.Is(err, models.ErrOIDCLoginInvalid):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Invalid or expired login token"))
+		case errors.Is(err, models.ErrOIDCAccountUnavailable):
+			c.JSON(http.StatusForbidden, models.ErrorResponse("User account is not available"))
+		case errors.As(err, &loginErr):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse(loginErr.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Login failed: "+err.Error()))
+		}
+		return
+	}
+
+	// 身份提供方负责多因素认证，单点登录不再要求本地两步验证
+	session, refreshToken, err := h.tokenService.CreateSession(user.ID, deviceID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if errors.Is(err, models.ErrDeviceDisabled) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse("Device access has been revoked"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create session: "+err.Error()))
+		return
+	}
+
+	authResult, err := issueTokens(user, session, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate tokens: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Login successful", authResult))
+}
+
+// renderOIDCResult 显示单点登录结果页面
+func renderOIDCResult(c *gin.Context, status int, message string) {
+	page := "<!DOCTYPE html><html><head><meta charset=\"utf-8\"><title>xPaste</title></head>" +
+		"<body style=\"font-family:sans-serif;text-align:center;padding-top:4em\"><p>" +
+		html.EscapeString(message) + "</p></body></html>"
+	c.Data(status, "text/html; charset=utf-8", []byte(page))
+}
+
+// RegisterRoutes 注册单点登录路由（公开）
+func (h *OIDCHandler) RegisterRoutes(router *gin.RouterGroup) {
+	oidc := router.Group("/auth/oidc")
+	{
+		oidc.GET("/config", h.GetConfig)
+		oidc.GET("/callback", h.Callback)
+
+		limited := oidc.Group("")
+		limited.Use(middleware.AuthRateLimitMiddleware())
+		{
+			limited.POST("/authorize", h.Authorize)
+			limited.POST("/token", h.Token)
+		}
+	}
+}
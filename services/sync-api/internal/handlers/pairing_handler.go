@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/middleware"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
+)
+
+// PairingHandler 设备配对处理器
+type PairingHandler struct {
+	pairingService *services.PairingService
+	userService    *services.UserService
+	tokenService   *services.TokenService
+	db             *gorm.DB
+}
+
+// NewPairingHandler 创建设备配对处理器
+func NewPairingHandler(pairingService *services.PairingService, userService *services.UserService, tokenService *services.TokenService, db *gorm.DB) *PairingHandler {
+	return &PairingHandler{
+		pairingService: pairingService,
+		userService:    userService,
+		tokenService:   tokenService,
+		db:             db,
+	}
+}
+
+// StartPairingRequest 发起配对请求
+type StartPairingRequest struct {
+	DeviceID string `json:"device_id,omitempty"` // 发起配对的设备，未指定时使用令牌中的设备ID
+}
+
+// StartPairing 发起设备配对
+// @Summary 发起设备配对
+// @Description 由已信任的设备发起配对，返回 6 位数字配对码和包含一次性密钥的二维码内容。新设备认领后，发起设备会通过 WebSocket 收到 pairing_request 消息，回复 pairing_approve 或 pairing_deny
+// @Tags 设备配对
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body StartPairingRequest false "发起配对请求"
+// @Success 201 {object} models.Response{data=models.PairingSessionResponse} "发起成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备不可信"
+// @Failure 404 {object} models.Response "设备不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /pairing [post]
+func (h *PairingHandler) StartPairing(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var req StartPairingRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+			return
+		}
+	}
+
+	// 绑定设备的令牌只能以该设备发起配对
+	if tokenDeviceID, bound := middleware.GetDeviceIDFromContext(c); bound {
+		if req.DeviceID != "" && req.DeviceID != tokenDeviceID {
+			c.JSON(http.StatusForbidden, models.ErrorResponse("Token was issued for another device"))
+			return
+		}
+		req.DeviceID = tokenDeviceID
+	}
+	if req.DeviceID == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Pairing must be started from a registered device"))
+		return
+	}
+
+	session, secret, err := h.pairingService.CreateSession(userID, req.DeviceID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrDeviceNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Device not found"))
+		case errors.Is(err, models.ErrDeviceNotTrusted):
+			c.JSON(http.StatusForbidden, models.ErrorResponse("Device is not trusted"))
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponseWithMessage("Failed to start pairing", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponseWithMessage("Pairing started successfully", &models.PairingSessionResponse{
+		PairingID: session.PairingID,
+		Code:      session.Code,
+		QRPayload: pairingQRPayload(c, session.PairingID, secret),
+		Status:    session.Status,
+		ExpiresAt: session.ExpiresAt,
+		ExpiresIn: int(time.Until(session.ExpiresAt).Seconds()),
+	}))
+}
+
+// GetPairing 获取配对状态
+// @Summary 获取配对状态
+// @Description 发起设备查询配对会话的当前状态
+// @Tags 设备配对
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param pairing_id path string true "配对会话ID"
+// @Success 200 {object} models.Response{data=models.PairingStatusResponse} "获取成功"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "配对会话不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /pairing/{pairing_id} [get]
+func (h *PairingHandler) GetPairing(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	session, err := h.pairingService.GetSession(userID, c.Param("pairing_id"))
+	if err != nil {
+		if errors.Is(err, models.ErrPairingNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Pairing session not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponseWithMessage("Failed to get pairing session", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Pairing session retrieved successfully", session.ToStatusResponse()))
+}
+
+// ClaimPairing 认领配对
+// @Summary 认领配对
+// @Description 新设备使用数字配对码或二维码中的 pairing_id + secret 认领配对会话，返回用于换取令牌的认领凭证。认领后需等待发起设备批准
+// @Tags 设备配对
+// @Accept json
+// @Produce json
+// @Param request body models.ClaimPairingRequest true "认领配对请求"
+// @Success 200 {object} models.Response{data=models.PairingClaimResponse} "认领成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 404 {object} models.Response "配对码无效或已过期"
+// @Failure 409 {object} models.Response "配对会话已被认领"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /pairing/claim [post]
+func (h *PairingHandler) ClaimPairing(c *gin.Context) {
+	var req models.ClaimPairingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	if req.Code == "" && (req.PairingID == "" || req.Secret == "") {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Pairing code or pairing_id and secret are required"))
+		return
+	}
+
+	session, claimToken, err := h.pairingService.Claim(&req, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPairingNotFound), errors.Is(err, models.ErrPairingExpired):
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Invalid or expired pairing code"))
+		case errors.Is(err, models.ErrPairingInvalidState):
+			c.JSON(http.StatusConflict, models.ErrorResponse("Pairing session has already been claimed"))
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponseWithMessage("Failed to claim pairing", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Pairing claimed, waiting for approval", &models.PairingClaimResponse{
+		PairingID:  session.PairingID,
+		ClaimToken: claimToken,
+		Status:     session.Status,
+		ExpiresAt:  session.ExpiresAt,
+	}))
+}
+
+// RedeemPairing 换取设备令牌
+// @Summary 换取设备令牌
+// @Description 新设备使用认领凭证轮询配对结果。等待批准时返回 202，批准后注册新设备并返回绑定该设备的访问令牌
+// @Tags 设备配对
+// @Accept json
+// @Produce json
+// @Param request body models.RedeemPairingRequest true "换取令牌请求"
+// @Success 200 {object} models.Response{data=models.PairingResult} "配对完成"
+// @Success 202 {object} models.Response{data=models.PairingStatusResponse} "等待批准"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 403 {object} models.Response "配对被拒绝"
+// @Failure 404 {object} models.Response "认领凭证无效"
+// @Failure 410 {object} models.Response "配对已过期或已完成"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /pairing/redeem [post]
+func (h *PairingHandler) RedeemPairing(c *gin.Context) {
+	var req models.RedeemPairingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	session, device, err := h.pairingService.Redeem(req.ClaimToken, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPairingPending):
+			c.JSON(http.StatusAccepted, models.SuccessResponseWithMessage("Waiting for approval", session.ToStatusResponse()))
+		case errors.Is(err, models.ErrPairingNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Invalid claim token"))
+		case errors.Is(err, models.ErrPairingDenied):
+			c.JSON(http.StatusForbidden, models.ErrorResponse("Pairing was denied"))
+		case errors.Is(err, models.ErrPairingExpired), errors.Is(err, models.ErrPairingInvalidState):
+			c.JSON(http.StatusGone, models.ErrorResponse("Pairing session is no longer available"))
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponseWithMessage("Failed to complete pairing", err.Error()))
+		}
+		return
+	}
+
+	user, err := h.userService.GetUserByID(session.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get user: "+err.Error()))
+		return
+	}
+
+	// 令牌绑定配对得到的设备
+	authSession, refreshToken, err := h.tokenService.CreateSession(user.ID, device.DeviceID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create session: "+err.Error()))
+		return
+	}
+
+	authResult, err := issueTokens(user, authSession, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate tokens: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Pairing completed successfully", &models.PairingResult{
+		Device:     device.ToResponse(),
+		AuthResult: *authResult,
+	}))
+}
+
+// pairingQRPayload 生成二维码内容，包含服务地址、配对会话ID和一次性密钥
+func pairingQRPayload(c *gin.Context, pairingID, secret string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	query := url.Values{}
+	query.Set("server", fmt.Sprintf("%s://%s", scheme, c.Request.Host))
+	query.Set("id", pairingID)
+	query.Set("secret", secret)
+
+	return "xpaste://pair?" + query.Encode()
+}
+
+// RegisterRoutes 注册设备配对路由
+func (h *PairingHandler) RegisterRoutes(router *gin.RouterGroup) {
+	pairing := router.Group("/pairing")
+	{
+		// 新设备尚未登录，认领和换取令牌不需要认证，按 IP 限流防止猜测配对码
+		public := pairing.Group("")
+		public.Use(middleware.AuthRateLimitMiddleware())
+		{
+			public.POST("/claim", h.ClaimPairing)
+			public.POST("/redeem", h.RedeemPairing)
+		}
+
+		// 发起配对需要已登录的设备
+		authenticated := pairing.Group("")
+		authenticated.Use(middleware.AuthMiddleware(h.db))
+		{
+			authenticated.POST("", h.StartPairing)
+			authenticated.GET("/:pairing_id", h.GetPairing)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/middleware"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
+)
+
+// RuleHandler 剪贴板项规则处理器
+type RuleHandler struct {
+	ruleService *services.RuleService
+	db          *gorm.DB
+}
+
+// NewRuleHandler 创建规则处理器
+func NewRuleHandler(ruleService *services.RuleService, db *gorm.DB) *RuleHandler {
+	return &RuleHandler{
+		ruleService: ruleService,
+		db:          db,
+	}
+}
+
+// CreateRule 创建规则
+// @Summary 创建规则
+// @Description 新建剪贴板项时按优先级（数值小的先执行）匹配规则。条件包括类型、内容正则、设备、平台和元数据，动作包括打标签、设置过期时间、设置标题、丢弃和通知
+// @Tags 规则
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateRuleRequest true "规则"
+// @Success 201 {object} models.Response{data=models.ClipRule} "创建成功"
+// @Failure 400 {object} models.Response "请求参数错误或规则无效"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 409 {object} models.Response "规则数量已达上限"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /rules [post]
+func (h *RuleHandler) CreateRule(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var req models.CreateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	rule, err := h.ruleService.CreateRule(userID.(uint), &req)
+	if err != nil {
+		respondRuleError(c, err, "Failed to create rule")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponseWithMessage("Rule created successfully", rule))
+}
+
+// ListRules 获取规则列表
+// @Summary 获取规则列表
+// @Description 获取当前用户的规则，按执行顺序排列
+// @Tags 规则
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Response{data=[]models.ClipRule} "获取成功"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /rules [get]
+func (h *RuleHandler) ListRules(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	rules, err := h.ruleService.ListRules(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get rules: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Rules retrieved successfully", rules))
+}
+
+// GetRule 获取规则详情
+// @Summary 获取规则详情
+// @Description 获取规则的条件、动作和匹配次数
+// @Tags 规则
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "规则ID"
+// @Success 200 {object} models.Response{data=models.ClipRule} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "规则不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /rules/{id} [get]
+func (h *RuleHandler) GetRule(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	ruleID, ok := parseRuleIDParam(c)
+	if !ok {
+		return
+	}
+
+	rule, err := h.ruleService.GetRule(userID.(uint), ruleID)
+	if err != nil {
+		respondRuleError(c, err, "Failed to get rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Rule retrieved successfully", rule))
+}
+
+// UpdateRule 修改规则
+// @Summary 修改规则
+// @Description 修改规则，未提供的字段保持不变；提供 conditions 或 actions 时整体替换
+// @Tags 规则
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "规则ID"
+// @Param request body models.UpdateRuleRequest true "修改内容"
+// @Success 200 {object} models.Response{data=models.ClipRule} "修改成功"
+// @Failure 400 {object} models.Response "请求参数错误或规则无效"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "规则不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /rules/{id} [put]
+func (h *RuleHandler) UpdateRule(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	ruleID, ok := parseRuleIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	rule, err := h.ruleService.UpdateRule(userID.(uint), ruleID, &req)
+	if err != nil {
+		respondRuleError(c, err, "Failed to update rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Rule updated successfully", rule))
+}
+
+// DeleteRule 删除规则
+// @Summary 删除规则
+// @Description 删除规则，已执行过的动作不受影响
+// @Tags 规则
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "规则ID"
+// @Success 200 {object} models.Response "删除成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "规则不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /rules/{id} [delete]
+func (h *RuleHandler) DeleteRule(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	ruleID, ok := parseRuleIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ruleService.DeleteRule(userID.(uint), ruleID); err != nil {
+		respondRuleError(c, err, "Failed to delete rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Rule deleted successfully", nil))
+}
+
+// DryRunRules 试运行规则
+// @Summary 试运行规则
+// @Description 用示例剪贴板项测试规则，返回匹配的规则和执行动作后的剪贴板项，不保存剪贴板项、不推送通知、不计入匹配次数。
+// @Description 提供 rule 时只测试该规则（可以是尚未保存的规则），否则按顺序测试所有启用的规则
+// @Tags 规则
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.RuleDryRunRequest true "示例剪贴板项和可选的规则"
+// @Success 200 {object} models.Response{data=models.RuleResult} "试运行成功"
+// @Failure 400 {object} models.Response "请求参数错误或规则无效"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /rules/dry-run [post]
+func (h *RuleHandler) DryRunRules(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var req models.RuleDryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	result, err := h.ruleService.DryRun(userID.(uint), &req)
+	if err != nil {
+		respondRuleError(c, err, "Failed to run rules")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Rules evaluated successfully", result))
+}
+
+// parseRuleIDParam 解析路径中的规则ID
+func parseRuleIDParam(c *gin.Context) (uint, bool) {
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid rule ID"))
+		return 0, false
+	}
+	return uint(ruleID), true
+}
+
+// respondRuleError 规则操作的错误响应
+func respondRuleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrRuleNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Rule not found"))
+	case errors.Is(err, models.ErrRuleInvalid):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse(err.Error()))
+	case errors.Is(err, models.ErrRuleLimitReached):
+		c.JSON(http.StatusConflict, models.ErrorResponse("Rule limit reached"))
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse(message+": "+err.Error()))
+	}
+}
+
+// RegisterRoutes 注册规则路由
+func (h *RuleHandler) RegisterRoutes(router *gin.RouterGroup) {
+	rules := router.Group("/rules")
+	rules.Use(middleware.AuthMiddleware(h.db))
+	{
+		rules.POST("", h.CreateRule)
+		rules.GET("", h.ListRules)
+		rules.POST("/dry-run", h.DryRunRules)
+		rules.GET("/:id", h.GetRule)
+		rules.PUT("/:id", h.UpdateRule)
+		rules.DELETE("/:id", h.DeleteRule)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"xpaste-sync/internal/middleware"
 	"xpaste-sync/internal/models"
 	"xpaste-sync/internal/services"
 )
@@ -363,9 +364,6 @@ func (h *SettingHandler) ImportUserSettings(c *gin.Context) {
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /settings/system/{key} [get]
 func (h *SettingHandler) GetSystemSetting(c *gin.Context) {
-	// 这里应该检查管理员权限，暂时跳过
-	// TODO: 实现管理员权限检查
-
 	key := c.Param("key")
 	if key == "" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse("Setting key is required"))
@@ -399,9 +397,6 @@ func (h *SettingHandler) GetSystemSetting(c *gin.Context) {
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /settings/system [get]
 func (h *SettingHandler) GetSystemSettings(c *gin.Context) {
-	// 这里应该检查管理员权限，暂时跳过
-	// TODO: 实现管理员权限检查
-
 	category := c.Query("category")
 
 	settings, err := h.settingService.GetSystemSettings(category)
@@ -435,9 +430,6 @@ func (h *SettingHandler) GetSystemSettings(c *gin.Context) {
 // @Failure 500 {object} models.Response "服务器内部错误"
 // @Router /settings/system/{key} [put]
 func (h *SettingHandler) SetSystemSetting(c *gin.Context) {
-	// 这里应该检查管理员权限，暂时跳过
-	// TODO: 实现管理员权限检查
-
 	key := c.Param("key")
 	if key == "" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse("Setting key is required"))
@@ -513,7 +505,6 @@ func (h *SettingHandler) GetSettingsByCategory(c *gin.Context) {
 // RegisterRoutes 注册设置相关路由
 func (h *SettingHandler) RegisterRoutes(router *gin.RouterGroup) {
 	settings := router.Group("/settings")
-	// settings.Use(middleware.AuthMiddleware()) // TODO: 添加认证中间件
 	{
 		// 用户设置路由
 		userSettings := settings.Group("/user")
@@ -530,6 +521,7 @@ func (h *SettingHandler) RegisterRoutes(router *gin.RouterGroup) {
 
 		// 系统设置路由（需要管理员权限）
 		systemSettings := settings.Group("/system")
+		systemSettings.Use(middleware.RequirePermission(models.PermissionSystemSettings))
 		{
 			systemSettings.GET("", h.GetSystemSettings)
 			systemSettings.GET("/:key", h.GetSystemSetting)
@@ -0,0 +1,536 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/middleware"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
+	"xpaste-sync/internal/sharepage"
+)
+
+// ShareHandler 分享链接处理器
+type ShareHandler struct {
+	shareService *services.ShareService
+	db           *gorm.DB
+}
+
+// NewShareHandler 创建分享链接处理器
+func NewShareHandler(shareService *services.ShareService, db *gorm.DB) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+		db:           db,
+	}
+}
+
+// shares 按当前设备的信任级别限制可分享的剪贴板项
+func (h *ShareHandler) shares(c *gin.Context) *services.ShareService {
+	device, exists := middleware.GetDeviceFromContext(c)
+	if !exists {
+		return h.shareService
+	}
+	since, _ := device.ReadableSince()
+	return h.shareService.WithReadableSince(since)
+}
+
+// baseURL 分享地址前缀，未配置时使用请求的地址
+func (h *ShareHandler) baseURL(c *gin.Context) string {
+	if base := h.shareService.BaseURL(); base != "" {
+		return base
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// CreateShareLink 创建分享链接
+// @Summary 创建分享链接
+// @Description 为剪贴板项创建分享链接，可设置密码、过期时间、最大查看次数和阅后即焚；团队剪贴板项需要成员及以上角色
+// @Tags 分享
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateShareLinkRequest true "分享设置"
+// @Success 201 {object} models.Response{data=models.ShareLinkResponse} "创建成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "团队权限不足"
+// @Failure 404 {object} models.Response "剪贴板项不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /shares [post]
+func (h *ShareHandler) CreateShareLink(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var req models.CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	link, err := h.shares(c).CreateShareLink(userID.(uint), &req)
+	if err != nil {
+		if respondTeamError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, models.ErrClipItemNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Clip item not found"))
+		case errors.Is(err, models.ErrShareExpiryInvalid):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Share link expiry must be in the future"))
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create share link: "+err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponseWithMessage("Share link created successfully", link.ToResponse(h.baseURL(c))))
+}
+
+// ListShareLinks 获取分享链接
+// @Summary 获取分享链接
+// @Description 获取当前用户创建的分享链接，可按剪贴板项筛选
+// @Tags 分享
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param clip_id query int false "剪贴板项ID"
+// @Success 200 {object} models.Response{data=[]models.ShareLinkResponse} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /shares [get]
+func (h *ShareHandler) ListShareLinks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var clipID uint64
+	if value := c.Query("clip_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid clip ID"))
+			return
+		}
+		clipID = id
+	}
+
+	links, err := h.shareService.ListShareLinks(userID.(uint), uint(clipID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get share links: "+err.Error()))
+		return
+	}
+
+	baseURL := h.baseURL(c)
+	responses := make([]*models.ShareLinkResponse, 0, len(links))
+	for _, link := range links {
+		responses = append(responses, link.ToResponse(baseURL))
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Share links retrieved successfully", responses))
+}
+
+// GetShareLink 获取分享链接详情
+// @Summary 获取分享链接详情
+// @Description 获取当前用户创建的分享链接
+// @Tags 分享
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "分享链接ID"
+// @Success 200 {object} models.Response{data=models.ShareLinkResponse} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "分享链接不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /shares/{id} [get]
+func (h *ShareHandler) GetShareLink(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	linkID, ok := parseShareIDParam(c)
+	if !ok {
+		return
+	}
+
+	link, err := h.shareService.GetShareLink(userID.(uint), linkID)
+	if err != nil {
+		respondShareLinkError(c, err, "Failed to get share link")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Share link retrieved successfully", link.ToResponse(h.baseURL(c))))
+}
+
+// RevokeShareLink 撤销分享链接
+// @Summary 撤销分享链接
+// @Description 撤销分享链接，撤销后链接立即失效，访问记录保留
+// @Tags 分享
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "分享链接ID"
+// @Success 200 {object} models.Response{data=models.ShareLinkResponse} "撤销成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "分享链接不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /shares/{id} [delete]
+func (h *ShareHandler) RevokeShareLink(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	linkID, ok := parseShareIDParam(c)
+	if !ok {
+		return
+	}
+
+	link, err := h.shareService.RevokeShareLink(userID.(uint), linkID)
+	if err != nil {
+		respondShareLinkError(c, err, "Failed to revoke share link")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Share link revoked successfully", link.ToResponse(h.baseURL(c))))
+}
+
+// ListShareViews 获取分享链接访问记录
+// @Summary 获取分享链接访问记录
+// @Description 获取分享链接的访问记录，包括查看成功和密码错误的访问
+// @Tags 分享
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "分享链接ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} models.Response{data=models.ListResponse} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "分享链接不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /shares/{id}/views [get]
+func (h *ShareHandler) ListShareViews(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	linkID, ok := parseShareIDParam(c)
+	if !ok {
+		return
+	}
+
+	var params models.PaginationParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	views, pagination, err := h.shareService.ListShareViews(userID.(uint), linkID, &params)
+	if err != nil {
+		respondShareLinkError(c, err, "Failed to get share views")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Share views retrieved successfully", &models.ListResponse{
+		Items:      views,
+		Pagination: pagination,
+	}))
+}
+
+// ViewShare 查看分享内容
+// @Summary 查看分享内容
+// @Description 无需登录查看分享的剪贴板项。浏览器（Accept 包含 text/html）访问文本和链接时返回渲染后的页面：代码语法高亮、Markdown 渲染、链接预览卡片；
+// @Description 其它客户端得到纯文本。图片和文件以二进制流返回。有密码的链接通过 X-Share-Password 请求头或 POST 表单字段 password 提交密码
+// @Tags 分享
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Produce plain
+// @Produce octet-stream
+// @Param slug path string true "分享链接标识"
+// @Param X-Share-Password header string false "分享密码"
+// @Success 200 {string} string "分享内容"
+// @Failure 401 {object} models.Response "需要密码"
+// @Failure 403 {object} models.Response "密码错误"
+// @Failure 404 {object} models.Response "分享链接不存在或已撤销"
+// @Failure 410 {object} models.Response "分享链接已过期或达到查看次数上限"
+// @Failure 429 {object} models.Response "请求过于频繁"
+// @Router /s/{slug} [get]
+func (h *ShareHandler) ViewShare(c *gin.Context) {
+	link, clipItem, password, ok := h.openShare(c)
+	if !ok {
+		return
+	}
+
+	switch {
+	case clipItem.Type == models.ClipTypeImage || clipItem.Type == models.ClipTypeFile:
+		serveShareBlob(c, clipItem, false)
+	case wantsHTML(c):
+		c.Header("Content-Security-Policy", sharepage.ContentSecurityPolicy)
+		c.HTML(http.StatusOK, "share.html", sharepage.NewPage(link, clipItem, password))
+	default:
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(clipItem.Content))
+	}
+}
+
+// RawShare 查看分享的原始内容
+// @Summary 查看分享的原始内容
+// @Description 文本和链接以纯文本返回，图片和文件以二进制流返回；计入查看次数
+// @Tags 分享
+// @Accept x-www-form-urlencoded
+// @Produce plain
+// @Produce octet-stream
+// @Param slug path string true "分享链接标识"
+// @Param X-Share-Password header string false "分享密码"
+// @Success 200 {string} string "分享内容"
+// @Failure 401 {object} models.Response "需要密码"
+// @Failure 403 {object} models.Response "密码错误"
+// @Failure 404 {object} models.Response "分享链接不存在或已撤销"
+// @Failure 410 {object} models.Response "分享链接已过期或达到查看次数上限"
+// @Failure 429 {object} models.Response "请求过于频繁"
+// @Router /s/{slug}/raw [get]
+func (h *ShareHandler) RawShare(c *gin.Context) {
+	_, clipItem, _, ok := h.openShare(c)
+	if !ok {
+		return
+	}
+
+	if clipItem.Type == models.ClipTypeImage || clipItem.Type == models.ClipTypeFile {
+		serveShareBlob(c, clipItem, false)
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(clipItem.Content))
+}
+
+// DownloadShare 下载分享内容
+// @Summary 下载分享内容
+// @Description 以附件形式下载分享的剪贴板项，文本按识别出的语言使用对应的扩展名；计入查看次数
+// @Tags 分享
+// @Accept x-www-form-urlencoded
+// @Produce octet-stream
+// @Param slug path string true "分享链接标识"
+// @Param X-Share-Password header string false "分享密码"
+// @Success 200 {file} file "分享内容"
+// @Failure 401 {object} models.Response "需要密码"
+// @Failure 403 {object} models.Response "密码错误"
+// @Failure 404 {object} models.Response "分享链接不存在或已撤销"
+// @Failure 410 {object} models.Response "分享链接已过期或达到查看次数上限"
+// @Failure 429 {object} models.Response "请求过于频繁"
+// @Router /s/{slug}/download [get]
+func (h *ShareHandler) DownloadShare(c *gin.Context) {
+	link, clipItem, _, ok := h.openShare(c)
+	if !ok {
+		return
+	}
+
+	if clipItem.Type == models.ClipTypeImage || clipItem.Type == models.ClipTypeFile {
+		serveShareBlob(c, clipItem, true)
+		return
+	}
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": sharepage.DownloadFileName(link.Slug, clipItem)})
+	c.Header("Content-Disposition", disposition)
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(clipItem.Content))
+}
+
+// openShare 打开分享链接，失败时直接返回错误响应：浏览器得到密码输入页或错误页，其它客户端得到 JSON
+func (h *ShareHandler) openShare(c *gin.Context) (*models.ShareLink, *models.ClipItem, string, bool) {
+	password := c.GetHeader("X-Share-Password")
+	if password == "" && c.Request.Method == http.MethodPost {
+		password = c.PostForm("password")
+	}
+
+	link, clipItem, err := h.shareService.OpenShareLink(c.Param("slug"), password, &services.ShareVisitor{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Referer:   c.Request.Referer(),
+	})
+	if err != nil {
+		html := wantsHTML(c)
+		if html {
+			c.Header("Content-Security-Policy", sharepage.ContentSecurityPolicy)
+		}
+		switch {
+		case errors.Is(err, models.ErrSharePasswordRequired):
+			if html {
+				c.HTML(http.StatusUnauthorized, "share_password.html", sharepage.NewPasswordPage(c.Request.URL.Path, false))
+			} else {
+				c.JSON(http.StatusUnauthorized, models.ErrorResponse("Share link password required"))
+			}
+		case errors.Is(err, models.ErrSharePasswordInvalid):
+			if html {
+				c.HTML(http.StatusForbidden, "share_password.html", sharepage.NewPasswordPage(c.Request.URL.Path, true))
+			} else {
+				c.JSON(http.StatusForbidden, models.ErrorResponse("Invalid share link password"))
+			}
+		case errors.Is(err, models.ErrShareLinkNotFound):
+			if html {
+				c.HTML(http.StatusNotFound, "share_error.html", sharepage.NewErrorPage("Not found", "This share link does not exist or has been revoked."))
+			} else {
+				c.JSON(http.StatusNotFound, models.ErrorResponse("Share link not found"))
+			}
+		case errors.Is(err, models.ErrShareLinkGone):
+			if html {
+				c.HTML(http.StatusGone, "share_error.html", sharepage.NewErrorPage("Link expired", "This share link has expired or reached its view limit."))
+			} else {
+				c.JSON(http.StatusGone, models.ErrorResponse("Share link has expired"))
+			}
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to open share link"))
+		}
+		return nil, nil, "", false
+	}
+
+	// 阅后即焚和限次链接的内容不能被缓存
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex")
+	return link, clipItem, password, true
+}
+
+// wantsHTML 请求是否来自浏览器
+func wantsHTML(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), "text/html")
+}
+
+// inlineShareTypes 允许在浏览器中直接显示的内容类型，只包含不能执行脚本的位图格式
+var inlineShareTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// shareBlobCSP 二进制内容的内容安全策略，即使浏览器按文档解析也不能执行脚本或加载资源
+const shareBlobCSP = "sandbox; default-src 'none'"
+
+// serveShareBlob 以二进制流返回图片和文件，内容为 data URL 或 base64 编码
+// 内容类型来自分享者，只有内容与声明一致的位图才内联显示，其余一律作为附件下载
+func serveShareBlob(c *gin.Context, clipItem *models.ClipItem, download bool) {
+	contentType, reader := decodeClipBlob(clipItem.Content)
+	if contentType == "" {
+		contentType, _ = clipItem.Metadata["mime_type"].(string)
+	}
+
+	buffered := bufio.NewReader(reader)
+	head, err := buffered.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		// 不是有效的编码内容，按原样返回
+		buffered = bufio.NewReader(strings.NewReader(clipItem.Content))
+		head, _ = buffered.Peek(512)
+	}
+
+	sniffed := http.DetectContentType(head)
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = strings.ToLower(mediaType)
+	} else {
+		contentType = sniffed
+	}
+
+	disposition := "inline"
+	if download || clipItem.Type == models.ClipTypeFile || !inlineShareTypes[contentType] || contentType != sniffed {
+		disposition = "attachment"
+		contentType = "application/octet-stream"
+	}
+	if name, _ := clipItem.Metadata["file_name"].(string); name != "" {
+		disposition = mime.FormatMediaType(disposition, map[string]string{"filename": name})
+	}
+
+	c.DataFromReader(http.StatusOK, -1, contentType, buffered, map[string]string{
+		"Content-Disposition":     disposition,
+		"Content-Security-Policy": shareBlobCSP,
+		"X-Content-Type-Options":  "nosniff",
+	})
+}
+
+// decodeClipBlob 解码剪贴板项的二进制内容，data URL 同时返回其中的内容类型
+func decodeClipBlob(content string) (string, io.Reader) {
+	if !strings.HasPrefix(content, "data:") {
+		return "", base64.NewDecoder(base64.StdEncoding, strings.NewReader(content))
+	}
+
+	header, data, found := strings.Cut(content[len("data:"):], ",")
+	if !found {
+		return "", strings.NewReader(content)
+	}
+	mediaType, isBase64 := strings.CutSuffix(header, ";base64")
+	if isBase64 {
+		return mediaType, base64.NewDecoder(base64.StdEncoding, strings.NewReader(data))
+	}
+	decoded, err := url.PathUnescape(data)
+	if err != nil {
+		return mediaType, strings.NewReader(data)
+	}
+	return mediaType, strings.NewReader(decoded)
+}
+
+// parseShareIDParam 解析路径中的分享链接ID，失败时直接返回 400
+func parseShareIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid share link ID"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondShareLinkError 分享链接管理接口的错误响应
+func respondShareLinkError(c *gin.Context, err error, message string) {
+	if errors.Is(err, models.ErrShareLinkNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Share link not found"))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse(message+": "+err.Error()))
+}
+
+// RegisterRoutes 注册分享链接管理路由
+func (h *ShareHandler) RegisterRoutes(router *gin.RouterGroup) {
+	shares := router.Group("/shares")
+	shares.Use(middleware.ScopedAuthMiddleware(h.db, models.ScopeClipsRead, models.ScopeClipsWrite))
+	shares.Use(middleware.DeviceTrustMiddleware(h.db)) // 未批准的设备不能分享剪贴板
+	{
+		shares.POST("", h.CreateShareLink)
+		shares.GET("", h.ListShareLinks)
+		shares.GET("/:id", h.GetShareLink)
+		shares.DELETE("/:id", h.RevokeShareLink)
+		shares.GET("/:id/views", h.ListShareViews)
+	}
+}
+
+// RegisterPublicRoutes 注册公开的分享访问路由和分享页样式表
+func (h *ShareHandler) RegisterPublicRoutes(router *gin.Engine) {
+	router.SetHTMLTemplate(sharepage.Templates())
+	router.StaticFileFS(sharepage.StylesheetPath, "share.css", http.FS(sharepage.Static()))
+
+	public := router.Group("/s")
+	public.Use(middleware.ShareRateLimitMiddleware())
+	{
+		public.GET("/:slug", h.ViewShare)
+		public.POST("/:slug", h.ViewShare)
+		public.GET("/:slug/raw", h.RawShare)
+		public.POST("/:slug/raw", h.RawShare)
+		public.GET("/:slug/download", h.DownloadShare)
+		public.POST("/:slug/download", h.DownloadShare)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"xpaste-sync/internal/models"
+)
+
+// testPNG 1x1 像素的 PNG 图片
+const testPNG = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII="
+
+func TestServeShareBlob(t *testing.T) {
+	html := base64.StdEncoding.EncodeToString([]byte("<html><script>alert(1)</script></html>"))
+	svg := base64.StdEncoding.EncodeToString([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`))
+
+	tests := []struct {
+		name            string
+		clipItem        *models.ClipItem
+		download        bool
+		wantType        string
+		wantDisposition string
+	}{
+		{"png data url inline", &models.ClipItem{Type: models.ClipTypeImage, Content: "data:image/png;base64," + testPNG}, false, "image/png", "inline"},
+		{"png from metadata inline", &models.ClipItem{Type: models.ClipTypeImage, Content: testPNG, Metadata: models.JSON{"mime_type": "image/png"}}, false, "image/png", "inline"},
+		{"png download", &models.ClipItem{Type: models.ClipTypeImage, Content: "data:image/png;base64," + testPNG}, true, "application/octet-stream", "attachment"},
+		{"html data url", &models.ClipItem{Type: models.ClipTypeImage, Content: "data:text/html;base64," + html}, false, "application/octet-stream", "attachment"},
+		{"svg data url", &models.ClipItem{Type: models.ClipTypeImage, Content: "data:image/svg+xml;base64," + svg}, false, "application/octet-stream", "attachment"},
+		{"javascript metadata", &models.ClipItem{Type: models.ClipTypeFile, Content: base64.StdEncoding.EncodeToString([]byte("alert(1)")), Metadata: models.JSON{"mime_type": "application/javascript"}}, false, "application/octet-stream", "attachment"},
+		{"html claiming png", &models.ClipItem{Type: models.ClipTypeImage, Content: "data:image/png;base64," + html}, false, "application/octet-stream", "attachment"},
+		{"sniffed html", &models.ClipItem{Type: models.ClipTypeImage, Content: html}, false, "application/octet-stream", "attachment"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/s/abc", nil)
+
+			serveShareBlob(c, tt.clipItem, tt.download)
+
+			if got := w.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if got := w.Header().Get("Content-Disposition"); !strings.HasPrefix(got, tt.wantDisposition) {
+				t.Errorf("Content-Disposition = %q, want %s", got, tt.wantDisposition)
+			}
+			if got := w.Header().Get("Content-Security-Policy"); got != "sandbox; default-src 'none'" {
+				t.Errorf("Content-Security-Policy = %q", got)
+			}
+		})
+	}
+}
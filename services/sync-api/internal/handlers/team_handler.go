@@ -0,0 +1,575 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/middleware"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
+)
+
+// TeamHandler 团队处理器
+type TeamHandler struct {
+	teamService *services.TeamService
+	db          *gorm.DB
+}
+
+// NewTeamHandler 创建团队处理器
+func NewTeamHandler(teamService *services.TeamService, db *gorm.DB) *TeamHandler {
+	return &TeamHandler{
+		teamService: teamService,
+		db:          db,
+	}
+}
+
+// CreateTeam 创建团队
+// @Summary 创建团队
+// @Description 创建团队，创建者成为团队所有者
+// @Tags 团队
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateTeamRequest true "团队信息"
+// @Success 201 {object} models.Response{data=models.TeamResponse} "创建成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /teams [post]
+func (h *TeamHandler) CreateTeam(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var req models.CreateTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	team, err := h.teamService.CreateTeam(userID.(uint), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create team: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponseWithMessage("Team created successfully", team))
+}
+
+// ListTeams 获取所在团队
+// @Summary 获取所在团队
+// @Description 获取当前用户所在的团队及其在团队中的角色
+// @Tags 团队
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Response{data=[]models.TeamResponse} "获取成功"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /teams [get]
+func (h *TeamHandler) ListTeams(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	teams, err := h.teamService.ListTeams(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get teams: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Teams retrieved successfully", teams))
+}
+
+// GetTeam 获取团队详情
+// @Summary 获取团队详情
+// @Description 获取团队信息和成员列表，只有团队成员可以查看
+// @Tags 团队
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Success 200 {object} models.Response{data=models.TeamDetailResponse} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "团队不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /teams/{id} [get]
+func (h *TeamHandler) GetTeam(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	teamID, ok := parseTeamIDParam(c)
+	if !ok {
+		return
+	}
+
+	team, err := h.teamService.GetTeam(teamID, userID.(uint))
+	if err != nil {
+		respondTeamMemberError(c, err, "Failed to get team")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Team retrieved successfully", team))
+}
+
+// UpdateTeam 修改团队
+// @Summary 修改团队
+// @Description 修改团队名称或描述，需要团队管理员及以上角色
+// @Tags 团队
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Param request body models.UpdateTeamRequest true "团队信息"
+// @Success 200 {object} models.Response{data=models.TeamResponse} "修改成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "团队权限不足"
+// @Failure 404 {object} models.Response "团队不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /teams/{id} [put]
+func (h *TeamHandler) UpdateTeam(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	teamID, ok := parseTeamIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	team, err := h.teamService.UpdateTeam(teamID, userID.(uint), &req)
+	if err != nil {
+		respondTeamMemberError(c, err, "Failed to update team")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Team updated successfully", team))
+}
+
+// DeleteTeam 删除团队
+// @Summary 删除团队
+// @Description 删除团队及其所有团队剪贴板项，只有团队所有者可以删除
+// @Tags 团队
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Success 200 {object} models.Response "删除成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "团队权限不足"
+// @Failure 404 {object} models.Response "团队不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /teams/{id} [delete]
+func (h *TeamHandler) DeleteTeam(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	teamID, ok := parseTeamIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.teamService.DeleteTeam(teamID, userID.(uint)); err != nil {
+		respondTeamMemberError(c, err, "Failed to delete team")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Team deleted successfully", gin.H{
+		"id": teamID,
+	}))
+}
+
+// InviteMember 邀请团队成员
+// @Summary 邀请团队成员
+// @Description 按用户名或邮箱邀请成员，默认角色为 member，被邀请人接受后才加入团队；管理员可以邀请成员和只读成员，只有所有者可以邀请管理员。无论地址是否对应已注册的用户，响应都相同
+// @Tags 团队
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Param request body models.AddTeamMemberRequest true "邀请信息"
+// @Success 202 {object} models.Response{data=models.TeamInvitationResponse} "已发出邀请"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "团队权限不足"
+// @Failure 404 {object} models.Response "团队不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /teams/{id}/members [post]
+func (h *TeamHandler) InviteMember(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	teamID, ok := parseTeamIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AddTeamMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	invitation, err := h.teamService.InviteMember(teamID, userID.(uint), &req)
+	if err != nil {
+		respondTeamMemberError(c, err, "Failed to invite team member")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.SuccessResponseWithMessage("Invitation sent if the user exists", invitation.ToResponse()))
+}
+
+// ListTeamInvitations 获取团队邀请
+// @Summary 获取团队邀请
+// @Description 获取团队未过期的邀请，需要团队管理员及以上角色
+// @Tags 团队
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Success 200 {object} models.Response{data=[]models.TeamInvitationResponse} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "团队权限不足"
+// @Failure 404 {object} models.Response "团队不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /teams/{id}/invitations [get]
+func (h *TeamHandler) ListTeamInvitations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	teamID, ok := parseTeamIDParam(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.teamService.ListTeamInvitations(teamID, userID.(uint))
+	if err != nil {
+		respondTeamMemberError(c, err, "Failed to get team invitations")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Team invitations retrieved successfully", invitationResponses(invitations)))
+}
+
+// RevokeInvitation 撤销团队邀请
+// @Summary 撤销团队邀请
+// @Description 撤销未接受的邀请，管理员可以撤销成员和只读成员的邀请，只有所有者可以撤销管理员的邀请
+// @Tags 团队
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Param invitation_id path int true "邀请ID"
+// @Success 200 {object} models.Response "撤销成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "团队权限不足"
+// @Failure 404 {object} models.Response "团队或邀请不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /teams/{id}/invitations/{invitation_id} [delete]
+func (h *TeamHandler) RevokeInvitation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	teamID, ok := parseTeamIDParam(c)
+	if !ok {
+		return
+	}
+	invitationID, ok := parseInvitationIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.teamService.RevokeInvitation(teamID, userID.(uint), invitationID); err != nil {
+		respondTeamMemberError(c, err, "Failed to revoke team invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Team invitation revoked successfully", gin.H{
+		"id": invitationID,
+	}))
+}
+
+// ListInvitations 获取收到的团队邀请
+// @Summary 获取收到的团队邀请
+// @Description 获取当前用户收到的未过期团队邀请
+// @Tags 团队
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Response{data=[]models.TeamInvitationResponse} "获取成功"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /teams/invitations [get]
+func (h *TeamHandler) ListInvitations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	invitations, err := h.teamService.ListInvitations(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get team invitations: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Team invitations retrieved successfully", invitationResponses(invitations)))
+}
+
+// AcceptInvitation 接受团队邀请
+// @Summary 接受团队邀请
+// @Description 接受邀请加入团队，以邀请中的角色成为成员
+// @Tags 团队
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param invitation_id path int true "邀请ID"
+// @Success 200 {object} models.Response{data=models.TeamResponse} "已加入团队"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "邀请不存在或已过期"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /teams/invitations/{invitation_id}/accept [post]
+func (h *TeamHandler) AcceptInvitation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	invitationID, ok := parseInvitationIDParam(c)
+	if !ok {
+		return
+	}
+
+	team, err := h.teamService.AcceptInvitation(invitationID, userID.(uint))
+	if err != nil {
+		respondTeamMemberError(c, err, "Failed to accept team invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Team joined successfully", team))
+}
+
+// DeclineInvitation 拒绝团队邀请
+// @Summary 拒绝团队邀请
+// @Description 拒绝并删除收到的团队邀请
+// @Tags 团队
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param invitation_id path int true "邀请ID"
+// @Success 200 {object} models.Response "已拒绝"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "邀请不存在或已过期"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /teams/invitations/{invitation_id} [delete]
+func (h *TeamHandler) DeclineInvitation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	invitationID, ok := parseInvitationIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.teamService.DeclineInvitation(invitationID, userID.(uint)); err != nil {
+		respondTeamMemberError(c, err, "Failed to decline team invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Team invitation declined successfully", gin.H{
+		"id": invitationID,
+	}))
+}
+
+// UpdateMember 修改团队成员角色
+// @Summary 修改团队成员角色
+// @Description 管理员可以在成员和只读成员之间调整，只有所有者可以授予或撤销管理员
+// @Tags 团队
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Param user_id path int true "成员用户ID"
+// @Param request body models.UpdateTeamMemberRequest true "角色"
+// @Success 200 {object} models.Response{data=models.TeamMemberResponse} "修改成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "团队权限不足"
+// @Failure 404 {object} models.Response "团队或成员不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /teams/{id}/members/{user_id} [put]
+func (h *TeamHandler) UpdateMember(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	teamID, ok := parseTeamIDParam(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid user ID"))
+		return
+	}
+
+	var req models.UpdateTeamMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	member, err := h.teamService.UpdateMember(teamID, userID.(uint), uint(memberID), models.TeamRole(req.Role))
+	if err != nil {
+		respondTeamMemberError(c, err, "Failed to update team member")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Team member updated successfully", member.ToResponse()))
+}
+
+// RemoveMember 移除团队成员
+// @Summary 移除团队成员
+// @Description 移除团队成员，成员可以移除自己以退出团队；所有者不能退出
+// @Tags 团队
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Param user_id path int true "成员用户ID"
+// @Success 200 {object} models.Response "移除成功"
+// @Failure 400 {object} models.Response "请求参数错误或所有者不能退出"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "团队权限不足"
+// @Failure 404 {object} models.Response "团队或成员不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /teams/{id}/members/{user_id} [delete]
+func (h *TeamHandler) RemoveMember(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	teamID, ok := parseTeamIDParam(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid user ID"))
+		return
+	}
+
+	if err := h.teamService.RemoveMember(teamID, userID.(uint), uint(memberID)); err != nil {
+		respondTeamMemberError(c, err, "Failed to remove team member")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Team member removed successfully", gin.H{
+		"team_id": teamID,
+		"user_id": memberID,
+	}))
+}
+
+// parseTeamIDParam 解析路径中的团队ID，无效时写入 400 响应
+func parseTeamIDParam(c *gin.Context) (uint, bool) {
+	teamID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid team ID"))
+		return 0, false
+	}
+	return uint(teamID), true
+}
+
+// parseInvitationIDParam 解析路径中的邀请ID，无效时写入 400 响应
+func parseInvitationIDParam(c *gin.Context) (uint, bool) {
+	invitationID, err := strconv.ParseUint(c.Param("invitation_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid invitation ID"))
+		return 0, false
+	}
+	return uint(invitationID), true
+}
+
+// invitationResponses 转换邀请列表为响应格式
+func invitationResponses(invitations []*models.TeamInvitation) []*models.TeamInvitationResponse {
+	responses := make([]*models.TeamInvitationResponse, len(invitations))
+	for i, invitation := range invitations {
+		responses[i] = invitation.ToResponse()
+	}
+	return responses
+}
+
+// respondTeamMemberError 团队操作的错误响应
+func respondTeamMemberError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrTeamNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Team not found"))
+	case errors.Is(err, models.ErrTeamMemberNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Team member not found"))
+	case errors.Is(err, models.ErrTeamInvitationNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Team invitation not found"))
+	case errors.Is(err, models.ErrTeamPermissionDenied):
+		c.JSON(http.StatusForbidden, models.ErrorResponse("Insufficient team permission"))
+	case errors.Is(err, models.ErrTeamOwnerCannotLeave):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Team owner cannot leave the team"))
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse(message+": "+err.Error()))
+	}
+}
+
+// RegisterRoutes 注册团队路由
+func (h *TeamHandler) RegisterRoutes(router *gin.RouterGroup) {
+	teams := router.Group("/teams")
+	teams.Use(middleware.AuthMiddleware(h.db))
+	{
+		teams.POST("", h.CreateTeam)
+		teams.GET("", h.ListTeams)
+		teams.GET("/invitations", h.ListInvitations)
+		teams.POST("/invitations/:invitation_id/accept", h.AcceptInvitation)
+		teams.DELETE("/invitations/:invitation_id", h.DeclineInvitation)
+		teams.GET("/:id", h.GetTeam)
+		teams.PUT("/:id", h.UpdateTeam)
+		teams.DELETE("/:id", h.DeleteTeam)
+		teams.POST("/:id/members", h.InviteMember)
+		teams.PUT("/:id/members/:user_id", h.UpdateMember)
+		teams.DELETE("/:id/members/:user_id", h.RemoveMember)
+		teams.GET("/:id/invitations", h.ListTeamInvitations)
+		teams.DELETE("/:id/invitations/:invitation_id", h.RevokeInvitation)
+	}
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/middleware"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
+)
+
+// TwoFactorHandler 两步验证处理器
+type TwoFactorHandler struct {
+	twoFactorService *services.TwoFactorService
+	userService      *services.UserService
+	tokenService     *services.TokenService
+	db               *gorm.DB
+}
+
+// NewTwoFactorHandler 创建两步验证处理器
+func NewTwoFactorHandler(twoFactorService *services.TwoFactorService, userService *services.UserService, tokenService *services.TokenService, db *gorm.DB) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		userService:      userService,
+		tokenService:     tokenService,
+		db:               db,
+	}
+}
+
+// GetStatus 获取两步验证状态
+// @Summary 获取两步验证状态
+// @Description 获取当前用户是否开启两步验证、剩余恢复码数量和受信任设备数量
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Response{data=models.TwoFactorStatusResponse} "获取成功"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/2fa [get]
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	status, err := h.twoFactorService.GetStatus(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get two-factor status: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Two-factor status retrieved successfully", status))
+}
+
+// Setup 生成 TOTP 密钥
+// @Summary 生成 TOTP 密钥
+// @Description 生成新的 TOTP 密钥和 otpauth:// 地址（用于生成二维码），需调用 enable 确认后才生效
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Response{data=models.TwoFactorSetupResponse} "生成成功"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 409 {object} models.Response "已开启两步验证"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/2fa/setup [post]
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	setup, err := h.twoFactorService.Setup(user)
+	if err != nil {
+		if errors.Is(err, models.ErrTwoFactorAlreadyEnabled) {
+			c.JSON(http.StatusConflict, models.ErrorResponse("Two-factor authentication is already enabled"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to set up two-factor: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Two-factor secret generated", setup))
+}
+
+// Enable 开启两步验证
+// @Summary 开启两步验证
+// @Description 提交验证器中的验证码确认密钥，开启两步验证并返回一次性恢复码
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorCodeRequest true "验证码"
+// @Success 200 {object} models.Response{data=models.RecoveryCodesResponse} "开启成功"
+// @Failure 400 {object} models.Response "请求参数错误或尚未生成密钥"
+// @Failure 401 {object} models.Response "未授权或验证码错误"
+// @Failure 409 {object} models.Response "已开启两步验证"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/2fa/enable [post]
+func (h *TwoFactorHandler) Enable(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	codes, err := h.twoFactorService.Enable(userID, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "Failed to enable two-factor: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Two-factor authentication enabled", &models.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	}))
+}
+
+// Disable 关闭两步验证
+// @Summary 关闭两步验证
+// @Description 提交密码和验证码（或恢复码）关闭两步验证，恢复码和受信任设备同时失效
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.DisableTwoFactorRequest true "密码和验证码"
+// @Success 200 {object} models.Response "关闭成功"
+// @Failure 400 {object} models.Response "请求参数错误或未开启两步验证"
+// @Failure 401 {object} models.Response "未授权、密码或验证码错误"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var req models.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	if err := h.userService.VerifyPassword(userID, req.Password); err != nil {
+		if errors.Is(err, models.ErrInvalidPassword) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Invalid password"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to verify password: "+err.Error()))
+		return
+	}
+
+	if err := h.twoFactorService.Disable(userID, req.Code); err != nil {
+		respondTwoFactorError(c, err, "Failed to disable two-factor: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Two-factor authentication disabled", nil))
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 提交验证码（或恢复码）重新生成恢复码，旧恢复码全部失效
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorCodeRequest true "验证码"
+// @Success 200 {object} models.Response{data=models.RecoveryCodesResponse} "生成成功"
+// @Failure 400 {object} models.Response "请求参数错误或未开启两步验证"
+// @Failure 401 {object} models.Response "未授权或验证码错误"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "Failed to regenerate recovery codes: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Recovery codes regenerated", &models.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	}))
+}
+
+// Verify 完成两步验证登录
+// @Summary 完成两步验证登录
+// @Description 使用登录返回的挑战令牌和验证码（或恢复码）完成登录；remember_device 为 true 时返回信任令牌，有效期内该设备登录跳过两步验证；验证码错误与密码错误共用账户和 IP 的退避和锁定
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param request body models.VerifyTwoFactorRequest true "挑战令牌和验证码"
+// @Success 200 {object} models.Response{data=models.AuthResult} "登录成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "挑战令牌无效或验证码错误"
+// @Failure 403 {object} models.Response "设备已被撤销"
+// @Failure 429 {object} models.Response "验证码错误次数过多，或账户和 IP 退避、锁定中"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /auth/2fa/verify [post]
+func (h *TwoFactorHandler) Verify(c *gin.Context) {
+	var req models.VerifyTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	challenge, err := h.twoFactorService.CompleteChallenge(req.ChallengeToken, req.Code, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondTwoFactorError(c, err, "Failed to verify two-factor: ")
+		return
+	}
+
+	user, err := h.userService.GetUserByID(challenge.UserID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse("User not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get user: "+err.Error()))
+		return
+	}
+	if !user.IsActive() {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("User account is inactive"))
+		return
+	}
+
+	// 创建绑定设备的会话并生成令牌
+	session, refreshToken, err := h.tokenService.CreateSession(user.ID, challenge.DeviceID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if errors.Is(err, models.ErrDeviceDisabled) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse("Device access has been revoked"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to create session: "+err.Error()))
+		return
+	}
+
+	authResult, err := issueTokens(user, session, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to generate tokens: "+err.Error()))
+		return
+	}
+
+	if req.RememberDevice {
+		trustToken, err := h.twoFactorService.TrustDevice(user.ID, challenge.DeviceID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to trust device: "+err.Error()))
+			return
+		}
+		authResult.TrustToken = trustToken
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Login successful", authResult))
+}
+
+// respondTwoFactorError 两步验证错误响应
+func respondTwoFactorError(c *gin.Context, err error, prefix string) {
+	var throttled *models.LoginThrottledError
+	if errors.As(err, &throttled) {
+		respondLoginThrottled(c, throttled)
+		return
+	}
+
+	switch {
+	case errors.Is(err, models.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Invalid two-factor code"))
+	case errors.Is(err, models.ErrChallengeInvalid):
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Invalid or expired challenge"))
+	case errors.Is(err, models.ErrChallengeAttempts):
+		c.JSON(http.StatusTooManyRequests, models.ErrorResponse("Too many failed attempts, please log in again"))
+	case errors.Is(err, models.ErrTwoFactorNotSetup):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Two-factor authentication has not been set up"))
+	case errors.Is(err, models.ErrTwoFactorNotEnabled):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Two-factor authentication is not enabled"))
+	case errors.Is(err, models.ErrTwoFactorAlreadyEnabled):
+		c.JSON(http.StatusConflict, models.ErrorResponse("Two-factor authentication is already enabled"))
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse(prefix+err.Error()))
+	}
+}
+
+// RegisterRoutes 注册两步验证路由
+func (h *TwoFactorHandler) RegisterRoutes(router *gin.RouterGroup) {
+	twoFactor := router.Group("/auth/2fa")
+	{
+		// 登录第二步（凭挑战令牌，不需要认证）
+		twoFactor.POST("/verify", h.Verify)
+
+		authenticated := twoFactor.Group("")
+		authenticated.Use(middleware.AuthMiddleware(h.db))
+		{
+			authenticated.GET("", h.GetStatus)
+			authenticated.POST("/setup", h.Setup)
+			authenticated.POST("/enable", h.Enable)
+			authenticated.POST("/disable", h.Disable)
+			authenticated.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+		}
+	}
+}
@@ -0,0 +1,455 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/middleware"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
+)
+
+// WebhookHandler 出站 webhook 处理器
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+	db             *gorm.DB
+}
+
+// NewWebhookHandler 创建出站 webhook 处理器
+func NewWebhookHandler(webhookService *services.WebhookService, db *gorm.DB) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		db:             db,
+	}
+}
+
+// CreateWebhook 创建 webhook
+// @Summary 创建 webhook
+// @Description 订阅剪贴板项和设备事件，可按标签、类型和设备筛选。响应中的签名密钥只返回这一次
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateWebhookRequest true "webhook 信息"
+// @Success 201 {object} models.Response{data=models.WebhookResponse} "创建成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 409 {object} models.Response "webhook 数量已达上限"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	hook, err := h.webhookService.CreateWebhook(userID.(uint), &req)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to create webhook")
+		return
+	}
+
+	response := hook.ToResponse()
+	response.Secret = hook.Secret
+	c.JSON(http.StatusCreated, models.SuccessResponseWithMessage("Webhook created successfully", response))
+}
+
+// ListWebhooks 获取 webhook 列表
+// @Summary 获取 webhook 列表
+// @Description 获取当前用户的 webhook 订阅，不包含签名密钥
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Response{data=[]models.WebhookResponse} "获取成功"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	hooks, err := h.webhookService.ListWebhooks(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get webhooks: "+err.Error()))
+		return
+	}
+
+	responses := make([]*models.WebhookResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		responses = append(responses, hook.ToResponse())
+	}
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Webhooks retrieved successfully", responses))
+}
+
+// GetWebhook 获取 webhook 详情
+// @Summary 获取 webhook 详情
+// @Description 获取 webhook 订阅，不包含签名密钥
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "webhook ID"
+// @Success 200 {object} models.Response{data=models.WebhookResponse} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "webhook 不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	webhookID, ok := parseWebhookIDParam(c)
+	if !ok {
+		return
+	}
+
+	hook, err := h.webhookService.GetWebhook(userID.(uint), webhookID)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to get webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Webhook retrieved successfully", hook.ToResponse()))
+}
+
+// UpdateWebhook 修改 webhook
+// @Summary 修改 webhook
+// @Description 修改地址、订阅的事件、筛选条件或启用状态，未提供的字段保持不变。重新启用时清零连续失败次数
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "webhook ID"
+// @Param request body models.UpdateWebhookRequest true "webhook 信息"
+// @Success 200 {object} models.Response{data=models.WebhookResponse} "修改成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "webhook 不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	webhookID, ok := parseWebhookIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	hook, err := h.webhookService.UpdateWebhook(userID.(uint), webhookID, &req)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to update webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Webhook updated successfully", hook.ToResponse()))
+}
+
+// DeleteWebhook 删除 webhook
+// @Summary 删除 webhook
+// @Description 删除 webhook 订阅及其投递记录，未完成的投递不再发送
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "webhook ID"
+// @Success 200 {object} models.Response "删除成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "webhook 不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	webhookID, ok := parseWebhookIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(userID.(uint), webhookID); err != nil {
+		respondWebhookError(c, err, "Failed to delete webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Webhook deleted successfully", nil))
+}
+
+// RotateWebhookSecret 轮换签名密钥
+// @Summary 轮换签名密钥
+// @Description 生成新的签名密钥并立即生效，旧密钥作废。响应中的新密钥只返回这一次
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "webhook ID"
+// @Success 200 {object} models.Response{data=models.WebhookResponse} "轮换成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "webhook 不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /webhooks/{id}/rotate-secret [post]
+func (h *WebhookHandler) RotateWebhookSecret(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	webhookID, ok := parseWebhookIDParam(c)
+	if !ok {
+		return
+	}
+
+	hook, err := h.webhookService.RotateSecret(userID.(uint), webhookID)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to rotate webhook secret")
+		return
+	}
+
+	response := hook.ToResponse()
+	response.Secret = hook.Secret
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Webhook secret rotated successfully", response))
+}
+
+// PingWebhook 发送测试事件
+// @Summary 发送测试事件
+// @Description 向 webhook 地址发送一个 ping 事件，投递结果记录在投递记录中
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "webhook ID"
+// @Success 202 {object} models.Response{data=models.WebhookDelivery} "已加入投递队列"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "webhook 不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /webhooks/{id}/ping [post]
+func (h *WebhookHandler) PingWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	webhookID, ok := parseWebhookIDParam(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.Ping(userID.(uint), webhookID)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to ping webhook")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.SuccessResponseWithMessage("Ping queued", delivery))
+}
+
+// ListWebhookDeliveries 获取投递记录
+// @Summary 获取投递记录
+// @Description 分页获取 webhook 的投递记录，按创建时间倒序
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "webhook ID"
+// @Param status query string false "投递状态" Enums(pending, succeeded, failed)
+// @Param event query string false "事件类型"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} models.Response{data=models.ListResponse} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "webhook 不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	webhookID, ok := parseWebhookIDParam(c)
+	if !ok {
+		return
+	}
+
+	var filter models.WebhookDeliveryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+	var params models.PaginationParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+		return
+	}
+
+	deliveries, pagination, err := h.webhookService.ListDeliveries(userID.(uint), webhookID, &filter, &params)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to get webhook deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Webhook deliveries retrieved successfully", &models.ListResponse{
+		Items:      deliveries,
+		Pagination: pagination,
+	}))
+}
+
+// GetWebhookDelivery 获取投递详情
+// @Summary 获取投递详情
+// @Description 获取投递的请求内容和最后一次尝试的响应
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "webhook ID"
+// @Param delivery_id path int true "投递ID"
+// @Success 200 {object} models.Response{data=models.WebhookDelivery} "获取成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "投递记录不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /webhooks/{id}/deliveries/{delivery_id} [get]
+func (h *WebhookHandler) GetWebhookDelivery(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	webhookID, deliveryID, ok := parseWebhookDeliveryParams(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(userID.(uint), webhookID, deliveryID)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to get webhook delivery")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMessage("Webhook delivery retrieved successfully", delivery))
+}
+
+// RedeliverWebhook 重新投递
+// @Summary 重新投递
+// @Description 以相同的事件ID和请求内容创建新的投递，使用当前的签名密钥
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "webhook ID"
+// @Param delivery_id path int true "投递ID"
+// @Success 202 {object} models.Response{data=models.WebhookDelivery} "已加入投递队列"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "投递记录不存在"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) RedeliverWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+	webhookID, deliveryID, ok := parseWebhookDeliveryParams(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(userID.(uint), webhookID, deliveryID)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to redeliver webhook")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.SuccessResponseWithMessage("Redelivery queued", delivery))
+}
+
+// parseWebhookIDParam 解析路径中的 webhook ID，失败时直接返回 400
+func parseWebhookIDParam(c *gin.Context) (uint, bool) {
+	webhookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid webhook ID"))
+		return 0, false
+	}
+	return uint(webhookID), true
+}
+
+// parseWebhookDeliveryParams 解析路径中的 webhook ID 和投递ID
+func parseWebhookDeliveryParams(c *gin.Context) (uint, uint, bool) {
+	webhookID, ok := parseWebhookIDParam(c)
+	if !ok {
+		return 0, 0, false
+	}
+	deliveryID, err := strconv.ParseUint(c.Param("delivery_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid delivery ID"))
+		return 0, 0, false
+	}
+	return webhookID, uint(deliveryID), true
+}
+
+// respondWebhookError webhook 操作的错误响应
+func respondWebhookError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrWebhookNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Webhook not found"))
+	case errors.Is(err, models.ErrWebhookDeliveryNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse("Webhook delivery not found"))
+	case errors.Is(err, models.ErrWebhookEventInvalid), errors.Is(err, models.ErrWebhookURLInvalid):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse(err.Error()))
+	case errors.Is(err, models.ErrWebhookLimitReached):
+		c.JSON(http.StatusConflict, models.ErrorResponse("Webhook limit reached"))
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse(message+": "+err.Error()))
+	}
+}
+
+// RegisterRoutes 注册 webhook 路由
+func (h *WebhookHandler) RegisterRoutes(router *gin.RouterGroup) {
+	webhooks := router.Group("/webhooks")
+	webhooks.Use(middleware.AuthMiddleware(h.db))
+	{
+		webhooks.POST("", h.CreateWebhook)
+		webhooks.GET("", h.ListWebhooks)
+		webhooks.GET("/:id", h.GetWebhook)
+		webhooks.PUT("/:id", h.UpdateWebhook)
+		webhooks.DELETE("/:id", h.DeleteWebhook)
+		webhooks.POST("/:id/rotate-secret", h.RotateWebhookSecret)
+		webhooks.POST("/:id/ping", h.PingWebhook)
+		webhooks.GET("/:id/deliveries", h.ListWebhookDeliveries)
+		webhooks.GET("/:id/deliveries/:delivery_id", h.GetWebhookDelivery)
+		webhooks.POST("/:id/deliveries/:delivery_id/redeliver", h.RedeliverWebhook)
+	}
+}
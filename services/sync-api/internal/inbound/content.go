@@ -0,0 +1,95 @@
+// Package inbound 把入站 webhook 请求和收到的邮件转换为剪贴板项，并提供可选的 SMTP 收件服务
+package inbound
+
+import (
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"xpaste-sync/internal/models"
+)
+
+// TextClip 把文本转换为剪贴板项，内容只有一个 http(s) 链接时识别为链接类型
+func TextClip(text string, title string) *models.CreateClipRequest {
+	text = strings.TrimSpace(text)
+	clipType := models.ClipTypeText
+	if isURL(text) {
+		clipType = models.ClipTypeURL
+	}
+	return &models.CreateClipRequest{
+		Type:    string(clipType),
+		Content: text,
+		Title:   title,
+	}
+}
+
+// FileClip 把二进制内容转换为剪贴板项，内容保存为 data URL，图片识别为图片类型
+func FileClip(name string, mimeType string, data []byte, title string) *models.CreateClipRequest {
+	mimeType = fileMimeType(name, mimeType, data)
+	clipType := models.ClipTypeFile
+	if strings.HasPrefix(mimeType, "image/") {
+		clipType = models.ClipTypeImage
+	}
+
+	metadata := models.JSON{
+		"mime_type": mimeType,
+		"size":      len(data),
+	}
+	if name != "" {
+		metadata["file_name"] = name
+	}
+	return &models.CreateClipRequest{
+		Type:     string(clipType),
+		Content:  "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data),
+		Title:    title,
+		Metadata: metadata,
+	}
+}
+
+// fileMimeType 确定文件的内容类型：依次使用声明的类型、扩展名和内容特征
+func fileMimeType(name string, declared string, data []byte) string {
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+	if name != "" {
+		if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); byExt != "" {
+			mediaType, _, _ := mime.ParseMediaType(byExt)
+			return mediaType
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return mediaType
+}
+
+// isURL 内容是否只有一个 http(s) 链接
+func isURL(s string) bool {
+	if s == "" || strings.ContainsAny(s, " \t\r\n") {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// appendTags 追加标签，忽略空标签和重复的标签
+func appendTags(tags []string, extra ...string) []string {
+	for _, tag := range extra {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		exists := false
+		for _, t := range tags {
+			if t == tag {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,219 @@
+package inbound
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"xpaste-sync/internal/models"
+)
+
+// maxMIMEDepth multipart 嵌套的最大层数
+const maxMIMEDepth = 10
+
+var (
+	htmlDropPattern  = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	htmlBreakPattern = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/tr|/h[1-6])\b[^>]*>`)
+	htmlTagPattern   = regexp.MustCompile(`<[^>]*>`)
+	blankLines       = regexp.MustCompile(`\n{3,}`)
+)
+
+// Mail 解析后的邮件
+type Mail struct {
+	From        string
+	Subject     string
+	Text        string // 正文，优先使用纯文本，没有时从 HTML 提取
+	Attachments []Attachment
+}
+
+// Attachment 邮件附件
+type Attachment struct {
+	FileName string
+	MimeType string
+	Data     []byte
+}
+
+// ParseMail 解析邮件，支持 multipart、base64 和 quoted-printable 编码
+func ParseMail(r io.Reader) (*Mail, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+
+	decoder := &mime.WordDecoder{CharsetReader: charsetReader}
+	m := &Mail{}
+	if subject, err := decoder.DecodeHeader(msg.Header.Get("Subject")); err == nil {
+		m.Subject = strings.TrimSpace(subject)
+	} else {
+		m.Subject = strings.TrimSpace(msg.Header.Get("Subject"))
+	}
+	if from, err := msg.Header.AddressList("From"); err == nil && len(from) > 0 {
+		m.From = from[0].Address
+	}
+
+	p := &mailParser{mail: m}
+	if err := p.part(textproto.MIMEHeader(msg.Header), msg.Body, 0); err != nil {
+		return nil, err
+	}
+
+	m.Text = strings.TrimSpace(p.plain.String())
+	if m.Text == "" {
+		m.Text = htmlToText(p.html.String())
+	}
+	m.Text = stripSignature(m.Text)
+	return m, nil
+}
+
+// Clips 把邮件转换为剪贴板项：正文保存为文本或链接，每个附件保存为一个文件剪贴板项，标题使用邮件主题
+// 只有主题的邮件把主题作为内容
+func (m *Mail) Clips() []*models.CreateClipRequest {
+	var reqs []*models.CreateClipRequest
+	switch {
+	case m.Text != "":
+		reqs = append(reqs, TextClip(m.Text, m.Subject))
+	case len(m.Attachments) == 0 && m.Subject != "":
+		reqs = append(reqs, TextClip(m.Subject, ""))
+	}
+	for _, attachment := range m.Attachments {
+		reqs = append(reqs, FileClip(attachment.FileName, attachment.MimeType, attachment.Data, m.Subject))
+	}
+
+	for _, req := range reqs {
+		if m.From == "" {
+			continue
+		}
+		if req.Metadata == nil {
+			req.Metadata = models.JSON{}
+		}
+		req.Metadata["email_from"] = m.From
+	}
+	return reqs
+}
+
+// mailParser 遍历邮件的 MIME 结构
+type mailParser struct {
+	mail  *Mail
+	plain strings.Builder
+	html  strings.Builder
+}
+
+// part 处理一个 MIME 部分
+func (p *mailParser) part(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxMIMEDepth {
+			return fmt.Errorf("message nested too deeply")
+		}
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read message part: %w", err)
+			}
+			if err := p.part(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("failed to decode message part: %w", err)
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	fileName := dispositionParams["filename"]
+	if fileName == "" {
+		fileName = params["name"]
+	}
+	if fileName != "" {
+		if decoded, err := (&mime.WordDecoder{CharsetReader: charsetReader}).DecodeHeader(fileName); err == nil {
+			fileName = decoded
+		}
+	}
+
+	switch {
+	case disposition == "attachment" || fileName != "":
+		p.mail.Attachments = append(p.mail.Attachments, Attachment{FileName: fileName, MimeType: mediaType, Data: data})
+	case mediaType == "text/plain":
+		p.plain.WriteString(toUTF8(data, params["charset"]))
+		p.plain.WriteString("\n")
+	case mediaType == "text/html":
+		p.html.WriteString(toUTF8(data, params["charset"]))
+		p.html.WriteString("\n")
+	}
+	// 其它没有文件名的内嵌部分（如 HTML 中引用的图片）忽略
+	return nil
+}
+
+// decodeTransfer 按 Content-Transfer-Encoding 解码
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+// charsetReader 支持 UTF-8、US-ASCII 和 ISO-8859-1，其它字符集按原样读取
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(toUTF8(data, charset)), nil
+}
+
+// toUTF8 转换为 UTF-8 字符串
+func toUTF8(data []byte, charset string) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+	return string(bytes.ToValidUTF8(data, []byte("�")))
+}
+
+// htmlToText 从 HTML 正文中提取文本
+func htmlToText(s string) string {
+	s = htmlDropPattern.ReplaceAllString(s, "")
+	s = htmlBreakPattern.ReplaceAllString(s, "\n")
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// stripSignature 去掉签名分隔符（"-- "）之后的内容
+func stripSignature(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if i := strings.LastIndex(s, "\n-- \n"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
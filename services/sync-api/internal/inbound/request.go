@@ -0,0 +1,242 @@
+package inbound
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"xpaste-sync/internal/models"
+)
+
+// multipartMemory 解析 multipart 表单时保存在内存中的大小，超出部分写入临时文件
+const multipartMemory = 1 << 20
+
+// ErrInvalidRequest 请求体无法解析
+var ErrInvalidRequest = errors.New("invalid inbound request")
+
+// ParseRequest 把入站 webhook 请求转换为剪贴板项，调用方负责限制请求体大小
+//
+//   - application/json：{"content": "...", "type": "...", "title": "...", "tags": [...]}，没有 content 字段时整个请求体保存为文本
+//   - application/x-www-form-urlencoded、multipart/form-data：字段 content、type、title、description、tags（逗号分隔），每个上传的文件保存为一个剪贴板项
+//   - 其它：文本类型的请求体保存为文本或链接，其余保存为文件，文件名取自 filename 查询参数或 Content-Disposition
+//
+// 查询参数 title、tags 作用于所有剪贴板项
+func ParseRequest(r *http.Request) ([]*models.CreateClipRequest, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+
+	var reqs []*models.CreateClipRequest
+	switch mediaType {
+	case "application/json":
+		reqs, err = parseJSON(r.Body)
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		reqs, err = parseForm(r, mediaType)
+	default:
+		reqs, err = parseRaw(r, mediaType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(reqs) == 0 {
+		return nil, models.ErrInboundEmpty
+	}
+
+	query := r.URL.Query()
+	tags := splitTags(query.Get("tags"))
+	for _, req := range reqs {
+		if req.Title == "" {
+			req.Title = query.Get("title")
+		}
+		req.Tags = appendTags(req.Tags, tags...)
+	}
+	return reqs, nil
+}
+
+// parseJSON 解析 JSON 请求体
+func parseJSON(body io.Reader) ([]*models.CreateClipRequest, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) == nil && fields["content"] != nil {
+		var in models.InboundClipRequest
+		if err := json.Unmarshal(data, &in); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		req, err := fromInboundRequest(&in)
+		if err != nil {
+			return nil, err
+		}
+		return []*models.CreateClipRequest{req}, nil
+	}
+
+	// 其它 JSON 原样保存为文本
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("%w: malformed JSON", ErrInvalidRequest)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	req := TextClip(string(data), "")
+	req.Metadata = models.JSON{"language": "json"}
+	return []*models.CreateClipRequest{req}, nil
+}
+
+// parseForm 解析表单请求体
+func parseForm(r *http.Request, mediaType string) ([]*models.CreateClipRequest, error) {
+	var form *multipart.Form
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(multipartMemory); err != nil {
+			return nil, formError(err)
+		}
+		form = r.MultipartForm
+		defer form.RemoveAll()
+	} else if err := r.ParseForm(); err != nil {
+		return nil, formError(err)
+	}
+
+	var reqs []*models.CreateClipRequest
+	title := r.PostForm.Get("title")
+	if content := r.PostForm.Get("content"); content != "" {
+		req, err := fromInboundRequest(&models.InboundClipRequest{
+			Type:        r.PostForm.Get("type"),
+			Content:     content,
+			Title:       title,
+			Description: r.PostForm.Get("description"),
+			Tags:        splitTags(r.PostForm.Get("tags")),
+		})
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+
+	if form != nil {
+		for _, files := range form.File {
+			for _, fh := range files {
+				file, err := fh.Open()
+				if err != nil {
+					return nil, err
+				}
+				data, err := io.ReadAll(file)
+				file.Close()
+				if err != nil {
+					return nil, err
+				}
+				req := FileClip(fh.Filename, fh.Header.Get("Content-Type"), data, title)
+				req.Tags = splitTags(r.PostForm.Get("tags"))
+				reqs = append(reqs, req)
+			}
+		}
+	}
+	return reqs, nil
+}
+
+// formError 保留请求体过大的错误，其它解析错误视为请求无效
+func formError(err error) error {
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+}
+
+// parseRaw 解析原始请求体
+func parseRaw(r *http.Request, mediaType string) ([]*models.CreateClipRequest, error) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	fileName := r.URL.Query().Get("filename")
+	if fileName == "" {
+		if _, params, err := mime.ParseMediaType(r.Header.Get("Content-Disposition")); err == nil {
+			fileName = params["filename"]
+		}
+	}
+
+	if fileName == "" && isTextType(mediaType) && utf8.Valid(data) {
+		return []*models.CreateClipRequest{TextClip(string(data), "")}, nil
+	}
+	return []*models.CreateClipRequest{FileClip(fileName, mediaType, data, "")}, nil
+}
+
+// fromInboundRequest 把 JSON 或表单中的字段转换为创建剪贴板项请求
+func fromInboundRequest(in *models.InboundClipRequest) (*models.CreateClipRequest, error) {
+	if strings.TrimSpace(in.Content) == "" {
+		return nil, models.ErrInboundEmpty
+	}
+
+	var req *models.CreateClipRequest
+	switch models.ClipType(in.Type) {
+	case "":
+		req = TextClip(in.Content, in.Title)
+	case models.ClipTypeText, models.ClipTypeURL:
+		req = &models.CreateClipRequest{Type: in.Type, Content: in.Content, Title: in.Title}
+	case models.ClipTypeImage, models.ClipTypeFile:
+		if !strings.HasPrefix(in.Content, "data:") {
+			data, err := base64.StdEncoding.DecodeString(in.Content)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s content must be base64 or a data URL", ErrInvalidRequest, in.Type)
+			}
+			req = FileClip(in.FileName, in.MimeType, data, in.Title)
+			req.Type = in.Type
+		} else {
+			req = &models.CreateClipRequest{Type: in.Type, Content: in.Content, Title: in.Title, Metadata: models.JSON{}}
+			if in.FileName != "" {
+				req.Metadata["file_name"] = in.FileName
+			}
+			if in.MimeType != "" {
+				req.Metadata["mime_type"] = in.MimeType
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported type %q", ErrInvalidRequest, in.Type)
+	}
+
+	req.Description = in.Description
+	req.Tags = appendTags(nil, in.Tags...)
+	for key, value := range in.Metadata {
+		if req.Metadata == nil {
+			req.Metadata = models.JSON{}
+		}
+		if _, exists := req.Metadata[key]; !exists {
+			req.Metadata[key] = value
+		}
+	}
+	return req, nil
+}
+
+// isTextType 是否按文本保存，未声明类型时也按文本处理
+func isTextType(mediaType string) bool {
+	switch {
+	case mediaType == "", strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/xml", mediaType == "application/yaml", mediaType == "application/x-yaml":
+		return true
+	}
+	return false
+}
+
+// splitTags 解析逗号分隔的标签
+func splitTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return appendTags(nil, strings.Split(s, ",")...)
+}
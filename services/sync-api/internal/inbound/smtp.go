@@ -0,0 +1,360 @@
+package inbound
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"xpaste-sync/internal/logger"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
+)
+
+// SMTPOptions SMTP 收件服务配置
+type SMTPOptions struct {
+	Addr           string        // 监听地址，例如 :2525
+	Hostname       string        // 问候语和 EHLO 响应中的主机名
+	MaxSize        int64         // 单封邮件的最大字节数
+	MaxRecipients  int           // 单封邮件的最大收件人数
+	MaxConnections int           // 最大并发连接数
+	Timeout        time.Duration // 每条命令的读写超时
+}
+
+// SMTPServer 最小化的 SMTP 收件服务，只接受发送到 <令牌>@<收件域名> 的邮件
+// 不支持 TLS 和认证，收件地址中的令牌即凭证，建议放在 MTA 之后或只在内网开放
+type SMTPServer struct {
+	opts    SMTPOptions
+	inbound *services.InboundService
+
+	listener net.Listener
+	slots    chan struct{}
+	wg       sync.WaitGroup
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+// NewSMTPServer 创建 SMTP 收件服务
+func NewSMTPServer(opts SMTPOptions, inbound *services.InboundService) *SMTPServer {
+	if opts.Hostname == "" {
+		opts.Hostname = "localhost"
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = inbound.MaxSize()
+	}
+	if opts.MaxRecipients <= 0 {
+		opts.MaxRecipients = 20
+	}
+	if opts.MaxConnections <= 0 {
+		opts.MaxConnections = 50
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	return &SMTPServer{
+		opts:    opts,
+		inbound: inbound,
+		slots:   make(chan struct{}, opts.MaxConnections),
+		conns:   make(map[net.Conn]struct{}),
+	}
+}
+
+// Start 开始监听
+func (s *SMTPServer) Start() error {
+	listener, err := net.Listen("tcp", s.opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.opts.Addr, err)
+	}
+	s.listener = listener
+	logger.Infof("Inbound SMTP server listening on %s", listener.Addr())
+
+	s.wg.Add(1)
+	go s.serve()
+	return nil
+}
+
+// Addr 实际监听的地址
+func (s *SMTPServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Shutdown 停止接受新连接，等待进行中的会话结束，超时后关闭剩余连接
+func (s *SMTPServer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	if s.listener != nil {
+		s.listener.Close()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for conn := range s.conns {
+			conn.Close()
+		}
+		s.mu.Unlock()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// serve 接受连接
+func (s *SMTPServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			logger.Warnf("Inbound SMTP accept error: %v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			conn.Write([]byte("421 4.3.2 Too many connections, try again later\r\n"))
+			conn.Close()
+			continue
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			<-s.slots
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() { <-s.slots }()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			(&smtpSession{server: s, conn: conn, text: textproto.NewConn(conn)}).run()
+		}()
+	}
+}
+
+// smtpRecipient 已验证的收件人
+type smtpRecipient struct {
+	endpoint *models.InboundEndpoint
+	tags     []string // 地址中 + 之后的部分作为标签
+}
+
+// smtpSession 一个 SMTP 会话
+type smtpSession struct {
+	server *SMTPServer
+	conn   net.Conn
+	text   *textproto.Conn
+
+	helo       bool
+	from       *string
+	recipients []smtpRecipient
+}
+
+// reply 发送响应
+func (c *smtpSession) reply(code int, format string, args ...interface{}) error {
+	c.conn.SetWriteDeadline(time.Now().Add(c.server.opts.Timeout))
+	return c.text.PrintfLine("%d %s", code, fmt.Sprintf(format, args...))
+}
+
+// reset 清除当前邮件的发件人和收件人
+func (c *smtpSession) reset() {
+	c.from = nil
+	c.recipients = nil
+}
+
+// run 处理命令直到 QUIT 或连接断开
+func (c *smtpSession) run() {
+	if c.reply(220, "%s ESMTP xPaste inbound", c.server.opts.Hostname) != nil {
+		return
+	}
+
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(c.server.opts.Timeout))
+		line, err := c.text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		verb = strings.ToUpper(verb)
+		arg = strings.TrimSpace(arg)
+
+		switch verb {
+		case "HELO":
+			c.helo = true
+			c.reset()
+			err = c.reply(250, "%s", c.server.opts.Hostname)
+		case "EHLO":
+			c.helo = true
+			c.reset()
+			c.conn.SetWriteDeadline(time.Now().Add(c.server.opts.Timeout))
+			err = c.text.PrintfLine("250-%s\r\n250-SIZE %d\r\n250-8BITMIME\r\n250 PIPELINING", c.server.opts.Hostname, c.server.opts.MaxSize)
+		case "MAIL":
+			err = c.mail(arg)
+		case "RCPT":
+			err = c.rcpt(arg)
+		case "DATA":
+			err = c.data()
+		case "RSET":
+			c.reset()
+			err = c.reply(250, "2.0.0 OK")
+		case "NOOP":
+			err = c.reply(250, "2.0.0 OK")
+		case "VRFY":
+			err = c.reply(252, "2.5.0 Cannot verify user")
+		case "QUIT":
+			c.reply(221, "2.0.0 Bye")
+			return
+		default:
+			err = c.reply(502, "5.5.2 Command not implemented")
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// mail 处理 MAIL FROM
+func (c *smtpSession) mail(arg string) error {
+	if !c.helo {
+		return c.reply(503, "5.5.1 Send HELO/EHLO first")
+	}
+	if c.from != nil {
+		return c.reply(503, "5.5.1 Sender already specified")
+	}
+	address, params, ok := parsePath(arg, "FROM:")
+	if !ok {
+		return c.reply(501, "5.5.4 Syntax: MAIL FROM:<address>")
+	}
+	for _, param := range params {
+		if name, value, _ := strings.Cut(param, "="); strings.EqualFold(name, "SIZE") {
+			if size, err := strconv.ParseInt(value, 10, 64); err == nil && size > c.server.opts.MaxSize {
+				return c.reply(552, "5.3.4 Message size exceeds fixed limit")
+			}
+		}
+	}
+	c.from = &address
+	return c.reply(250, "2.1.0 OK")
+}
+
+// rcpt 处理 RCPT TO，只接受收件域名下有效令牌的地址
+func (c *smtpSession) rcpt(arg string) error {
+	if c.from == nil {
+		return c.reply(503, "5.5.1 Send MAIL first")
+	}
+	if len(c.recipients) >= c.server.opts.MaxRecipients {
+		return c.reply(452, "4.5.3 Too many recipients")
+	}
+	address, _, ok := parsePath(arg, "TO:")
+	if !ok || address == "" {
+		return c.reply(501, "5.5.4 Syntax: RCPT TO:<address>")
+	}
+
+	local, domain, found := strings.Cut(address, "@")
+	if !found || !strings.EqualFold(domain, c.server.inbound.EmailDomain()) {
+		return c.reply(550, "5.1.1 Mailbox unavailable")
+	}
+	token, detail, _ := strings.Cut(local, "+")
+	endpoint, err := c.server.inbound.Authenticate(models.InboundChannelEmail, token)
+	if err != nil {
+		if !errors.Is(err, models.ErrInboundEndpointNotFound) {
+			logger.Errorf("Inbound SMTP failed to look up recipient: %v", err)
+			return c.reply(451, "4.3.0 Temporary failure, try again later")
+		}
+		return c.reply(550, "5.1.1 Mailbox unavailable")
+	}
+
+	c.recipients = append(c.recipients, smtpRecipient{endpoint: endpoint, tags: splitTags(strings.ReplaceAll(detail, "+", ","))})
+	return c.reply(250, "2.1.5 OK")
+}
+
+// data 接收邮件内容并为每个收件人创建剪贴板项
+func (c *smtpSession) data() error {
+	if len(c.recipients) == 0 {
+		return c.reply(503, "5.5.1 Send RCPT first")
+	}
+	if err := c.reply(354, "Start mail input; end with <CRLF>.<CRLF>"); err != nil {
+		return err
+	}
+
+	c.conn.SetReadDeadline(time.Now().Add(c.server.opts.Timeout))
+	reader := c.text.DotReader()
+	data, err := io.ReadAll(io.LimitReader(reader, c.server.opts.MaxSize+1))
+	if err != nil {
+		return err
+	}
+	recipients := c.recipients
+	c.reset()
+	if int64(len(data)) > c.server.opts.MaxSize {
+		if _, err := io.Copy(io.Discard, reader); err != nil {
+			return err
+		}
+		return c.reply(552, "5.3.4 Message size exceeds fixed limit")
+	}
+
+	for _, recipient := range recipients {
+		// 每个收件人单独解析，避免共享同一组剪贴板项请求
+		message, err := ParseMail(bytes.NewReader(data))
+		if err != nil {
+			return c.reply(554, "5.6.0 Malformed message")
+		}
+		reqs := message.Clips()
+		for _, req := range reqs {
+			req.Tags = appendTags(req.Tags, recipient.tags...)
+		}
+		if _, err := c.server.inbound.Receive(recipient.endpoint, reqs); err != nil {
+			if errors.Is(err, models.ErrInboundEmpty) {
+				return c.reply(554, "5.6.0 Message has no content")
+			}
+			logger.Errorf("Inbound SMTP failed to save message for user %d: %v", recipient.endpoint.UserID, err)
+			return c.reply(451, "4.3.0 Temporary failure, try again later")
+		}
+	}
+	return c.reply(250, "2.0.0 OK: queued")
+}
+
+// parsePath 解析 "FROM:<address> PARAM=VALUE" 形式的参数
+func parsePath(arg string, prefix string) (string, []string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", nil, false
+	}
+	fields := strings.Fields(strings.TrimSpace(arg[len(prefix):]))
+	if len(fields) == 0 {
+		return "", nil, false
+	}
+	path := fields[0]
+	if !strings.HasPrefix(path, "<") || !strings.HasSuffix(path, ">") {
+		return "", nil, false
+	}
+	return path[1 : len(path)-1], fields[1:], true
+}
@@ -0,0 +1,198 @@
+package inbound
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	_ "modernc.org/sqlite"
+
+	"xpaste-sync/internal/logger"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
+)
+
+const testEmailDomain = "in.example.com"
+
+func TestMain(m *testing.M) {
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// smtpFixture 监听 127.0.0.1 随机端口的 SMTP 收件服务和内存数据库
+type smtpFixture struct {
+	db       *gorm.DB
+	services *services.Services
+	server   *SMTPServer
+}
+
+func newSMTPFixture(t *testing.T) *smtpFixture {
+	t.Helper()
+
+	sqlDB, err := sql.Open("sqlite", ":memory:?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(sqlite.Dialector{Conn: sqlDB}, &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open gorm: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{}, &models.Device{}, &models.ClipItem{}, &models.Setting{},
+		&models.Team{}, &models.TeamMember{}, &models.Webhook{}, &models.WebhookDelivery{},
+		&models.InboundEndpoint{}, &models.ClipRule{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	svc := services.NewServices(db)
+	svc.Inbound.Configure(services.InboundOptions{EmailDomain: testEmailDomain})
+
+	server := NewSMTPServer(SMTPOptions{Addr: "127.0.0.1:0", Timeout: 5 * time.Second}, svc.Inbound)
+	if err := server.Start(); err != nil {
+		t.Fatalf("start smtp server: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	})
+
+	return &smtpFixture{db: db, services: svc, server: server}
+}
+
+// createUser 创建用户和邮件入站地址，返回用户和收件地址的令牌
+func (f *smtpFixture) createUser(t *testing.T, username string) (*models.User, string) {
+	t.Helper()
+	user := &models.User{Username: username, Email: username + "@example.com", PasswordHash: "x", Status: models.UserStatusActive}
+	if err := f.db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	_, token, err := f.services.Inbound.CreateEndpoint(user.ID, models.InboundChannelEmail, &models.InboundEndpointRequest{Tags: []string{"mail"}})
+	if err != nil {
+		t.Fatalf("create endpoint: %v", err)
+	}
+	return user, token
+}
+
+func (f *smtpFixture) clips(t *testing.T, userID uint) []models.ClipItem {
+	t.Helper()
+	var clipItems []models.ClipItem
+	if err := f.db.Where("user_id = ?", userID).Find(&clipItems).Error; err != nil {
+		t.Fatalf("find clips: %v", err)
+	}
+	return clipItems
+}
+
+func TestSMTPServerCreatesClipForRecipient(t *testing.T) {
+	f := newSMTPFixture(t)
+	alice, aliceToken := f.createUser(t, "alice")
+	bob, _ := f.createUser(t, "bob")
+
+	message := strings.Join([]string{
+		"From: Sender <sender@example.org>",
+		"To: " + aliceToken + "@" + testEmailDomain,
+		"Subject: Build notes",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		"deploy at 5pm",
+		"",
+	}, "\r\n")
+	// 地址中的令牌不区分大小写，+ 之后的部分作为标签
+	recipient := strings.ToUpper(aliceToken) + "+work@" + testEmailDomain
+	if err := smtp.SendMail(f.server.Addr().String(), nil, "sender@example.org", []string{recipient}, []byte(message)); err != nil {
+		t.Fatalf("SendMail: %v", err)
+	}
+
+	clipItems := f.clips(t, alice.ID)
+	if len(clipItems) != 1 {
+		t.Fatalf("alice has %d clips, want 1", len(clipItems))
+	}
+	clipItem := clipItems[0]
+	if clipItem.Content != "deploy at 5pm" || clipItem.Title != "Build notes" {
+		t.Errorf("clip content = %q, title = %q", clipItem.Content, clipItem.Title)
+	}
+	if strings.Join(clipItem.Tags, ",") != "work,mail" && strings.Join(clipItem.Tags, ",") != "mail,work" {
+		t.Errorf("clip tags = %v, want mail and work", clipItem.Tags)
+	}
+	if clipItem.Metadata["source"] != "inbound_email" || clipItem.Metadata["email_from"] != "sender@example.org" {
+		t.Errorf("clip metadata = %v", clipItem.Metadata)
+	}
+
+	if got := f.clips(t, bob.ID); len(got) != 0 {
+		t.Errorf("bob has %d clips, want 0", len(got))
+	}
+}
+
+func TestSMTPServerRejectsUnknownRecipient(t *testing.T) {
+	f := newSMTPFixture(t)
+	alice, aliceToken := f.createUser(t, "alice")
+
+	client, err := smtp.Dial(f.server.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+	if err := client.Mail("sender@example.org"); err != nil {
+		t.Fatalf("MAIL: %v", err)
+	}
+
+	for _, recipient := range []string{
+		"unknowntoken@" + testEmailDomain, // 令牌不存在
+		aliceToken + "@other.example.com", // 收件域名不匹配
+		alice.Email,                       // 普通邮箱地址
+	} {
+		err := client.Rcpt(recipient)
+		var smtpErr *textproto.Error
+		if !errors.As(err, &smtpErr) || smtpErr.Code != 550 {
+			t.Errorf("RCPT %s = %v, want 550", recipient, err)
+		}
+	}
+
+	// 没有有效收件人时不能发送内容
+	if _, err := client.Data(); err == nil {
+		t.Error("DATA without valid recipients succeeded")
+	}
+	client.Quit()
+
+	if got := f.clips(t, alice.ID); len(got) != 0 {
+		t.Errorf("alice has %d clips, want 0", len(got))
+	}
+}
+
+func TestSMTPServerRejectsRevokedAddress(t *testing.T) {
+	f := newSMTPFixture(t)
+	alice, oldToken := f.createUser(t, "alice")
+
+	// 轮换令牌后旧地址立即失效
+	if _, _, err := f.services.Inbound.CreateEndpoint(alice.ID, models.InboundChannelEmail, nil); err != nil {
+		t.Fatalf("rotate endpoint: %v", err)
+	}
+
+	err := smtp.SendMail(f.server.Addr().String(), nil, "sender@example.org",
+		[]string{oldToken + "@" + testEmailDomain}, []byte("Subject: hi\r\n\r\nhello\r\n"))
+	var smtpErr *textproto.Error
+	if !errors.As(err, &smtpErr) || smtpErr.Code != 550 {
+		t.Fatalf("SendMail to rotated address = %v, want 550", err)
+	}
+	if got := f.clips(t, alice.ID); len(got) != 0 {
+		t.Errorf("alice has %d clips, want 0", len(got))
+	}
+}
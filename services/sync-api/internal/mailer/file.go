@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"xpaste-sync/internal/logger"
+)
+
+// FileMailer 将邮件保存为 .eml 文件，用于本地开发和测试
+type FileMailer struct {
+	from *mail.Address
+	dir  string
+}
+
+// NewFileMailer 创建文件邮件发送器
+func NewFileMailer(dir string, from *mail.Address) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &FileMailer{from: from, dir: dir}, nil
+}
+
+// Send 将邮件写入目录，文件名包含时间和收件人
+func (m *FileMailer) Send(msg *Message) error {
+	data, err := buildMessage(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_", " ", "_").Replace(msg.To)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), recipient)
+	if err := os.WriteFile(filepath.Join(m.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
+
+// LogMailer 只把邮件写入日志，不实际发送
+type LogMailer struct {
+	from *mail.Address
+}
+
+// NewLogMailer 创建日志邮件发送器
+func NewLogMailer(from *mail.Address) *LogMailer {
+	return &LogMailer{from: from}
+}
+
+// Send 记录邮件内容
+func (m *LogMailer) Send(msg *Message) error {
+	logger.WithField("to", msg.To).
+		WithField("subject", msg.Subject).
+		Infof("mail (not sent, MAIL_DRIVER=log):\n%s", msg.Body)
+	return nil
+}
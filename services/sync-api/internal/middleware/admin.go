@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"xpaste-sync/internal/models"
+)
+
+var (
+	adminMu    sync.RWMutex
+	adminUsers = map[string]bool{}
+)
+
+// ConfigureAdmins 设置管理员用户名列表，列表中的用户不论角色都视为管理员
+func ConfigureAdmins(usernames []string) {
+	admins := make(map[string]bool, len(usernames))
+	for _, name := range usernames {
+		if name = strings.TrimSpace(name); name != "" {
+			admins[name] = true
+		}
+	}
+
+	adminMu.Lock()
+	adminUsers = admins
+	adminMu.Unlock()
+}
+
+// EffectiveRole 用户的有效角色：AUTH_ADMIN_USERS 列表中的用户视为管理员，其他用户使用账户上的角色
+func EffectiveRole(user *models.User) string {
+	if user == nil {
+		return ""
+	}
+	adminMu.RLock()
+	listed := adminUsers[user.Username]
+	adminMu.RUnlock()
+	if listed {
+		return models.RoleAdmin
+	}
+	return user.Role
+}
+
+// IsAdmin 用户是否为管理员：角色为 admin，或在 AUTH_ADMIN_USERS 列表中
+func IsAdmin(user *models.User) bool {
+	return EffectiveRole(user) == models.RoleAdmin
+}
+
+// HasPermission 用户的有效角色是否具有指定权限
+func HasPermission(user *models.User, permission models.Permission) bool {
+	return models.RoleHasPermission(EffectiveRole(user), permission)
+}
+
+// AdminMiddleware 管理员中间件，需放在 AuthMiddleware 之后
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := GetUserFromContext(c)
+		if !exists || !IsAdmin(user) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse("Admin privileges required"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission 权限中间件，需放在 AuthMiddleware 之后；用户的角色必须具有全部指定权限
+func RequirePermission(permissions ...models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := GetUserFromContext(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+			c.Abort()
+			return
+		}
+		for _, permission := range permissions {
+			if !HasPermission(user, permission) {
+				c.JSON(http.StatusForbidden, models.ErrorResponse("Permission required: "+string(permission)))
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
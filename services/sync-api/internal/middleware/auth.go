@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -11,21 +11,31 @@ import (
 	"gorm.io/gorm"
 
 	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
 )
 
-// JWT 密钥，实际使用时应该从环境变量或配置文件读取
-var jwtSecret = []byte("xpaste-secret-key-change-in-production")
-
 // JWT Claims
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	DeviceID string `json:"device_id,omitempty"`
+	// SessionID 登录会话ID，令牌随会话或设备撤销立即失效
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// AuthMiddleware 认证中间件
+// AuthMiddleware 认证中间件，只接受登录会话签发的 JWT
 func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return authMiddleware(db, "", "")
+}
+
+// ScopedAuthMiddleware 认证中间件，同时接受具备相应权限的个人访问令牌
+// 读请求（GET、HEAD）需要 readScope，其他请求需要 writeScope，为空表示该类请求不接受个人访问令牌
+func ScopedAuthMiddleware(db *gorm.DB, readScope string, writeScope string) gin.HandlerFunc {
+	return authMiddleware(db, readScope, writeScope)
+}
+
+func authMiddleware(db *gorm.DB, readScope string, writeScope string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从 Header 中获取 Authorization
 		authHeader := c.GetHeader("Authorization")
@@ -43,101 +53,143 @@ func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// 解析 JWT 令牌
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			// 验证签名方法
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		// 个人访问令牌
+		if strings.HasPrefix(tokenString, models.AccessTokenPrefix) {
+			scope := writeScope
+			if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+				scope = readScope
 			}
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Invalid token"))
-			c.Abort()
+			authenticateAccessToken(c, db, tokenString, scope)
 			return
 		}
 
-		// 检查令牌是否过期
-		if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(time.Now()) {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Token expired"))
+		// 解析 JWT 令牌，校验签名、签发者、受众和有效期
+		// 旧版 JWT 刷新令牌的签发者不同，不能用作访问令牌
+		claims, err := parseClaims(tokenString)
+		if err != nil {
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				c.JSON(http.StatusUnauthorized, models.ErrorResponse("Token expired"))
+			} else {
+				c.JSON(http.StatusUnauthorized, models.ErrorResponse("Invalid token"))
+			}
 			c.Abort()
 			return
 		}
 
 		// 验证用户是否存在且状态正常
-		var user models.User
-		if err := db.First(&user, claims.UserID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusUnauthorized, models.ErrorResponse("User not found"))
-			} else {
-				c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error"))
-			}
-			c.Abort()
+		user, ok := loadActiveUser(c, db, claims.UserID)
+		if !ok {
 			return
 		}
 
-		// 检查用户状态
-		if !user.IsActive() {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse("User account is inactive"))
+		// 检查会话和令牌绑定的设备是否已被撤销
+		if _, err := services.NewTokenService(db).ValidateSession(claims.UserID, claims.SessionID, claims.DeviceID, c.ClientIP()); err != nil {
+			status, message := sessionErrorResponse(err)
+			c.JSON(status, models.ErrorResponse(message))
 			c.Abort()
 			return
 		}
 
 		// 将用户信息存储到上下文中
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("user", &user)
-		if claims.DeviceID != "" {
-			c.Set("device_id", claims.DeviceID)
-		}
+		setAuthContext(c, claims, user)
 
 		c.Next()
 	}
 }
 
+// authenticateAccessToken 个人访问令牌认证，scope 为当前请求需要的权限
+func authenticateAccessToken(c *gin.Context, db *gorm.DB, tokenString string, scope string) {
+	if scope == "" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse("Personal access tokens are not allowed for this endpoint"))
+		c.Abort()
+		return
+	}
+
+	token, err := services.NewAccessTokenService(db).ValidateToken(tokenString, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrAccessTokenRevoked):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Access token has been revoked"))
+		case errors.Is(err, models.ErrAccessTokenExpired):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Access token expired"))
+		case errors.Is(err, models.ErrInvalidToken):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse("Invalid token"))
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error"))
+		}
+		c.Abort()
+		return
+	}
+
+	user, ok := loadActiveUser(c, db, token.UserID)
+	if !ok {
+		return
+	}
+
+	if !token.HasScope(scope) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse("Access token is missing required scope: "+scope))
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", user.ID)
+	c.Set("username", user.Username)
+	c.Set("user", user)
+	c.Set("access_token_id", token.ID)
+	c.Set("token_scopes", token.ScopeList())
+
+	c.Next()
+}
+
+// loadActiveUser 加载令牌所属用户，用户不存在或未激活时写入错误响应
+func loadActiveUser(c *gin.Context, db *gorm.DB, userID uint) (*models.User, bool) {
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse("User not found"))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error"))
+		}
+		c.Abort()
+		return nil, false
+	}
+
+	// 检查用户状态
+	if !user.IsActive() {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("User account is inactive"))
+		c.Abort()
+		return nil, false
+	}
+
+	return &user, true
+}
+
 // OptionalAuthMiddleware 可选认证中间件（不强制要求认证）
 func OptionalAuthMiddleware(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 首先尝试从 Authorization 头获取 token
+		// 从 Authorization 头获取 token
+		// 不再接受 URL 参数中的 token，WebSocket 握手请使用一次性连接票据
 		authHeader := c.GetHeader("Authorization")
 		var tokenString string
-		
+
 		if authHeader != "" {
 			tokenString = strings.TrimPrefix(authHeader, "Bearer ")
 			if tokenString == authHeader {
 				tokenString = ""
 			}
 		}
-		
-		// 如果 Authorization 头中没有 token，尝试从 URL 参数获取（用于 WebSocket）
-		if tokenString == "" {
-			tokenString = c.Query("token")
-		}
-		
+
 		// 如果没有找到 token，直接继续
 		if tokenString == "" {
 			c.Next()
 			return
 		}
 
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return jwtSecret, nil
-		})
-
-		if err == nil && token.Valid && claims.ExpiresAt != nil && claims.ExpiresAt.Time.After(time.Now()) {
+		if claims, err := parseClaims(tokenString); err == nil {
 			var user models.User
 			if err := db.First(&user, claims.UserID).Error; err == nil && user.IsActive() {
-				c.Set("user_id", claims.UserID)
-				c.Set("username", claims.Username)
-				c.Set("user", &user)
-				if claims.DeviceID != "" {
-					c.Set("device_id", claims.DeviceID)
+				if _, err := services.NewTokenService(db).ValidateSession(claims.UserID, claims.SessionID, claims.DeviceID, c.ClientIP()); err == nil {
+					setAuthContext(c, claims, &user)
 				}
 			}
 		}
@@ -146,78 +198,124 @@ func OptionalAuthMiddleware(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// GenerateToken 生成 JWT 令牌
-func GenerateToken(userID uint, username string, deviceID string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // 24小时过期
-
-	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		DeviceID: deviceID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "xpaste-sync-api",
-		},
+// setAuthContext 将令牌中的用户、设备和会话信息存储到上下文中
+func setAuthContext(c *gin.Context, claims *Claims, user *models.User) {
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("user", user)
+	c.Set("session_id", claims.SessionID)
+	if claims.DeviceID != "" {
+		c.Set("device_id", claims.DeviceID)
 	}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtSecret)
-	if err != nil {
-		return "", err
+// sessionErrorResponse 会话校验失败时的响应状态和消息
+func sessionErrorResponse(err error) (int, string) {
+	switch {
+	case errors.Is(err, models.ErrSessionRevoked):
+		return http.StatusUnauthorized, "Session has been revoked"
+	case errors.Is(err, models.ErrSessionExpired):
+		return http.StatusUnauthorized, "Session expired"
+	case errors.Is(err, models.ErrDeviceDisabled):
+		return http.StatusUnauthorized, "Device access has been revoked"
+	case errors.Is(err, models.ErrInvalidToken):
+		return http.StatusUnauthorized, "Invalid token"
+	default:
+		return http.StatusInternalServerError, "Database error"
 	}
-
-	return tokenString, nil
 }
 
-// GenerateRefreshToken 生成刷新令牌
-func GenerateRefreshToken(userID uint, username string) (string, error) {
-	expirationTime := time.Now().Add(7 * 24 * time.Hour) // 7天过期
+// DeviceTrustMiddleware 设备信任中间件，需放在 AuthMiddleware 之后
+// 登录会话必须绑定已注册且已批准的设备：令牌未绑定设备、设备未注册或已删除时与等待批准的设备一样拒绝访问
+// 个人访问令牌不绑定设备，由令牌的权限范围限制；只有完全信任的设备可以创建令牌（见 FullTrustDeviceMiddleware）
+func DeviceTrustMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isAccessToken := c.Get("access_token_id"); isAccessToken {
+			c.Next()
+			return
+		}
 
-	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "xpaste-sync-api-refresh",
-		},
-	}
+		device, ok := loadSessionDevice(c, db)
+		if !ok {
+			return
+		}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtSecret)
-	if err != nil {
-		return "", err
-	}
+		if _, ok := device.ReadableSince(); !ok {
+			if device.IsPending() {
+				c.JSON(http.StatusForbidden, models.ErrorResponse("Device is waiting for approval"))
+			} else {
+				c.JSON(http.StatusForbidden, models.ErrorResponse("Device is "+device.Status.String()))
+			}
+			c.Abort()
+			return
+		}
 
-	return tokenString, nil
+		c.Set("device", device)
+		c.Next()
+	}
 }
 
-// ValidateRefreshToken 验证刷新令牌
-func ValidateRefreshToken(tokenString string) (*Claims, error) {
-	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+// FullTrustDeviceMiddleware 完全信任设备中间件，需放在 AuthMiddleware 之后
+// 用于创建个人访问令牌、撤销其他会话等会扩大或转移访问权限的操作：
+// 个人访问令牌不绑定设备，等待批准或有限信任的设备不能借此绕过剪贴板的读取限制
+func FullTrustDeviceMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		device, ok := loadSessionDevice(c, db)
+		if !ok {
+			return
+		}
+
+		if !device.CanApproveDevices() {
+			c.JSON(http.StatusForbidden, models.ErrorResponse("This action requires a fully trusted device"))
+			c.Abort()
+			return
 		}
-		return jwtSecret, nil
-	})
 
-	if err != nil || !token.Valid {
-		return nil, fmt.Errorf("invalid refresh token")
+		c.Set("device", device)
+		c.Next()
 	}
+}
 
-	// 检查是否是刷新令牌
-	if claims.Issuer != "xpaste-sync-api-refresh" {
-		return nil, fmt.Errorf("not a refresh token")
+// loadSessionDevice 加载登录会话绑定的设备，未绑定设备、设备未注册或已删除时写入 403 响应
+func loadSessionDevice(c *gin.Context, db *gorm.DB) (*models.Device, bool) {
+	deviceID, exists := GetDeviceIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusForbidden, models.ErrorResponse("Session is not bound to a registered device"))
+		c.Abort()
+		return nil, false
 	}
 
-	// 检查是否过期
-	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(time.Now()) {
-		return nil, fmt.Errorf("refresh token expired")
+	var device models.Device
+	if err := db.Where("user_id = ? AND device_id = ?", c.GetUint("user_id"), deviceID).First(&device).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusForbidden, models.ErrorResponse("Device is not registered"))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Database error"))
+		}
+		c.Abort()
+		return nil, false
 	}
+	return &device, true
+}
 
-	return claims, nil
+// GenerateToken 生成 JWT 令牌，令牌绑定会话和会话所属的设备
+// 有效期、签发者、受众和签名密钥由 ConfigureJWT 设置
+func GenerateToken(userID uint, username string, session *models.AuthSession) (string, error) {
+	now := time.Now()
+	expirationTime := now.Add(AccessTokenTTL())
+
+	claims := &Claims{
+		UserID:    userID,
+		Username:  username,
+		DeviceID:  session.DeviceID,
+		SessionID: session.SessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	return signClaims(claims)
 }
 
 // GetUserFromContext 从上下文中获取用户信息
@@ -245,4 +343,22 @@ func GetDeviceIDFromContext(c *gin.Context) (string, bool) {
 		return "", false
 	}
 	return deviceID.(string), true
-}
\ No newline at end of file
+}
+
+// GetDeviceFromContext 从上下文中获取令牌绑定的设备（经过 DeviceTrustMiddleware）
+func GetDeviceFromContext(c *gin.Context) (*models.Device, bool) {
+	device, exists := c.Get("device")
+	if !exists {
+		return nil, false
+	}
+	return device.(*models.Device), true
+}
+
+// GetSessionIDFromContext 从上下文中获取令牌所属的会话ID
+func GetSessionIDFromContext(c *gin.Context) (string, bool) {
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		return "", false
+	}
+	return sessionID.(string), true
+}
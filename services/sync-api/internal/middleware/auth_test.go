@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	_ "modernc.org/sqlite"
+
+	"xpaste-sync/internal/models"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	sqlDB, err := sql.Open("sqlite", ":memory:?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(sqlite.Dialector{Conn: sqlDB}, &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open gorm: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.Device{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestDeviceTrustMiddleware(t *testing.T) {
+	db := newTestDB(t)
+	user := &models.User{Username: "alice", Email: "alice@example.com", PasswordHash: "x", Status: models.UserStatusActive}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	approvedAt := time.Now()
+	for _, device := range []*models.Device{
+		{UserID: user.ID, DeviceID: "approved", Name: "laptop", Status: models.DeviceStatusActive, TrustLevel: models.TrustLevelFull, ApprovedAt: &approvedAt},
+		{UserID: user.ID, DeviceID: "pending", Name: "phone", Status: models.DeviceStatusPending, TrustLevel: models.TrustLevelNone},
+		{UserID: user.ID, DeviceID: "deleted", Name: "old", Status: models.DeviceStatusActive, TrustLevel: models.TrustLevelFull, ApprovedAt: &approvedAt},
+	} {
+		if err := db.Create(device).Error; err != nil {
+			t.Fatalf("create device: %v", err)
+		}
+	}
+	if err := db.Where("device_id = ?", "deleted").Delete(&models.Device{}).Error; err != nil {
+		t.Fatalf("delete device: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		deviceID    string // 为空表示令牌未绑定设备
+		accessToken bool
+		want        int
+	}{
+		{"approved device", "approved", false, http.StatusOK},
+		{"pending device", "pending", false, http.StatusForbidden},
+		{"session without device", "", false, http.StatusForbidden},
+		{"unregistered device", "made-up", false, http.StatusForbidden},
+		{"deleted device", "deleted", false, http.StatusForbidden},
+		{"personal access token", "", true, http.StatusOK},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/clips", func(c *gin.Context) {
+				c.Set("user_id", user.ID)
+				if tt.deviceID != "" {
+					c.Set("device_id", tt.deviceID)
+				}
+				if tt.accessToken {
+					c.Set("access_token_id", uint(1))
+				}
+			}, DeviceTrustMiddleware(db), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/clips", nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestFullTrustDeviceMiddleware(t *testing.T) {
+	db := newTestDB(t)
+	user := &models.User{Username: "alice", Email: "alice@example.com", PasswordHash: "x", Status: models.UserStatusActive}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	approvedAt := time.Now()
+	for _, device := range []*models.Device{
+		{UserID: user.ID, DeviceID: "full", Name: "laptop", Status: models.DeviceStatusActive, TrustLevel: models.TrustLevelFull, ApprovedAt: &approvedAt},
+		{UserID: user.ID, DeviceID: "limited", Name: "tablet", Status: models.DeviceStatusActive, TrustLevel: models.TrustLevelLimited, ApprovedAt: &approvedAt},
+		{UserID: user.ID, DeviceID: "pending", Name: "phone", Status: models.DeviceStatusPending, TrustLevel: models.TrustLevelNone},
+	} {
+		if err := db.Create(device).Error; err != nil {
+			t.Fatalf("create device: %v", err)
+		}
+	}
+
+	tests := []struct {
+		deviceID string
+		want     int
+	}{
+		{"full", http.StatusOK},
+		{"limited", http.StatusForbidden},
+		{"pending", http.StatusForbidden},
+		{"made-up", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		router := gin.New()
+		router.POST("/auth/tokens", func(c *gin.Context) {
+			c.Set("user_id", user.ID)
+			if tt.deviceID != "" {
+				c.Set("device_id", tt.deviceID)
+			}
+		}, FullTrustDeviceMiddleware(db), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/auth/tokens", nil))
+		if w.Code != tt.want {
+			t.Errorf("device %q: status = %d, want %d", tt.deviceID, w.Code, tt.want)
+		}
+	}
+}
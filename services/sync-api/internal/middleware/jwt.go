@@ -0,0 +1,319 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"xpaste-sync/internal/config"
+)
+
+// defaultKeyID 未配置密钥列表时，由 JWT Secret 生成的 HS256 密钥的 kid；不带 kid 的旧令牌也按它验证
+const defaultKeyID = "default"
+
+// signingKey 一个签名密钥，私钥为空时只用于验证
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+}
+
+// keySet 当前生效的 JWT 配置和密钥
+type keySet struct {
+	issuer    string
+	audience  string
+	accessTTL time.Duration
+	signing   *signingKey
+	keys      map[string]*signingKey
+}
+
+var (
+	jwtMu   sync.RWMutex
+	jwtKeys = mustDefaultKeySet()
+)
+
+// ConfigureJWT 按配置加载签名密钥、签发者、受众和访问令牌有效期，启动时调用
+// 签发新令牌只使用 SigningKeyID 指定的密钥，验证接受列表中的所有密钥，便于轮换密钥而不让已登录用户失效
+func ConfigureJWT(cfg *config.JWTConfig) error {
+	set, err := newKeySet(cfg)
+	if err != nil {
+		return err
+	}
+
+	jwtMu.Lock()
+	jwtKeys = set
+	jwtMu.Unlock()
+	return nil
+}
+
+// AccessTokenTTL 访问令牌有效期
+func AccessTokenTTL() time.Duration {
+	return currentKeySet().accessTTL
+}
+
+func currentKeySet() *keySet {
+	jwtMu.RLock()
+	defer jwtMu.RUnlock()
+	return jwtKeys
+}
+
+// mustDefaultKeySet 未调用 ConfigureJWT 时使用的默认配置
+func mustDefaultKeySet() *keySet {
+	defaults := config.JWTConfig{
+		Secret:         config.DefaultJWTSecret,
+		AccessTokenTTL: 24 * time.Hour,
+		Issuer:         "xpaste",
+		Audience:       "xpaste-users",
+	}
+	set, err := newKeySet(&defaults)
+	if err != nil {
+		panic(err)
+	}
+	return set
+}
+
+func newKeySet(cfg *config.JWTConfig) (*keySet, error) {
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, fmt.Errorf("JWT issuer and audience are required")
+	}
+	if cfg.AccessTokenTTL <= 0 {
+		return nil, fmt.Errorf("JWT access token TTL must be positive")
+	}
+
+	keyConfigs := cfg.Keys
+	if len(keyConfigs) == 0 {
+		keyConfigs = []config.JWTKeyConfig{{ID: defaultKeyID, Algorithm: "HS256", Secret: cfg.Secret}}
+	}
+
+	set := &keySet{
+		issuer:    cfg.Issuer,
+		audience:  cfg.Audience,
+		accessTTL: cfg.AccessTokenTTL,
+		keys:      make(map[string]*signingKey, len(keyConfigs)),
+	}
+	for _, keyConfig := range keyConfigs {
+		key, err := parseSigningKey(keyConfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT key %q: %w", keyConfig.ID, err)
+		}
+		if _, exists := set.keys[key.id]; exists {
+			return nil, fmt.Errorf("duplicate JWT key id %q", key.id)
+		}
+		set.keys[key.id] = key
+	}
+
+	signingKeyID := cfg.SigningKeyID
+	if signingKeyID == "" {
+		signingKeyID = keyConfigs[0].ID
+	}
+	set.signing = set.keys[signingKeyID]
+	if set.signing == nil {
+		return nil, fmt.Errorf("JWT signing key %q not found", signingKeyID)
+	}
+	if set.signing.private == nil {
+		return nil, fmt.Errorf("JWT signing key %q has no private key", signingKeyID)
+	}
+
+	return set, nil
+}
+
+// parseSigningKey 解析单个密钥配置
+func parseSigningKey(cfg config.JWTKeyConfig) (*signingKey, error) {
+	if cfg.ID == "" {
+		return nil, fmt.Errorf("kid is required")
+	}
+	key := &signingKey{id: cfg.ID}
+
+	switch cfg.Algorithm {
+	case "HS256":
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("secret is required for HS256")
+		}
+		key.method = jwt.SigningMethodHS256
+		key.private = []byte(cfg.Secret)
+		key.public = key.private
+		return key, nil
+	case "EdDSA":
+		key.method = jwt.SigningMethodEdDSA
+	case "RS256":
+		key.method = jwt.SigningMethodRS256
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	if cfg.PrivateKeyFile != "" {
+		block, err := readPEM(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		private, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", private)
+		}
+		key.private = private
+		key.public = signer.Public()
+	} else if cfg.PublicKeyFile != "" {
+		block, err := readPEM(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		key.public = public
+	} else {
+		return nil, fmt.Errorf("private_key_file or public_key_file is required for %s", cfg.Algorithm)
+	}
+
+	// 密钥类型必须与算法一致
+	switch key.public.(type) {
+	case ed25519.PublicKey:
+		if key.method != jwt.SigningMethodEdDSA {
+			return nil, fmt.Errorf("key type Ed25519 cannot be used with %s", cfg.Algorithm)
+		}
+	case *rsa.PublicKey:
+		if key.method != jwt.SigningMethodRS256 {
+			return nil, fmt.Errorf("key type RSA cannot be used with %s", cfg.Algorithm)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key.public)
+	}
+
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	return block, nil
+}
+
+// parsePrivateKey 支持 PKCS#8 和 PKCS#1（RSA）格式
+func parsePrivateKey(block *pem.Block) (interface{}, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("failed to parse private key")
+}
+
+// signClaims 使用当前签名密钥签发令牌，并写入签发者和受众
+func signClaims(claims *Claims) (string, error) {
+	set := currentKeySet()
+	claims.Issuer = set.issuer
+	claims.Audience = jwt.ClaimStrings{set.audience}
+
+	token := jwt.NewWithClaims(set.signing.method, claims)
+	token.Header["kid"] = set.signing.id
+	return token.SignedString(set.signing.private)
+}
+
+// parseClaims 验证令牌签名、签发者、受众和有效期，按令牌头中的 kid 选择密钥
+func parseClaims(tokenString string) (*Claims, error) {
+	set := currentKeySet()
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			kid = defaultKeyID
+		}
+		key, ok := set.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %s", kid)
+		}
+		// 算法必须与密钥一致，防止算法混淆
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.public, nil
+	},
+		jwt.WithIssuer(set.issuer),
+		jwt.WithAudience(set.audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+// JWK 公钥的 JSON Web Key 表示
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKSet JWKS 响应
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS 返回所有非对称密钥的公钥，HS256 密钥不会公开
+func PublicJWKS() *JWKSet {
+	set := currentKeySet()
+	jwks := &JWKSet{Keys: []JWK{}}
+
+	// 签名密钥排在最前，其余按 kid 排序保证输出稳定
+	ids := make([]string, 0, len(set.keys))
+	for id := range set.keys {
+		if id != set.signing.id {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	ids = append([]string{set.signing.id}, ids...)
+
+	for _, id := range ids {
+		key := set.keys[id]
+		jwk := JWK{KeyID: key.id, Use: "sig", Algorithm: key.method.Alg()}
+		switch public := key.public.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
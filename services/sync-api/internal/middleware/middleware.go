@@ -61,7 +61,7 @@ func SetupWebSocketMiddlewares(r *gin.RouterGroup, db *gorm.DB) {
 		return fmt.Sprintf("ws:ip:%s", c.ClientIP())
 	}))
 
-	// 可选认证中间件（WebSocket可能需要在连接后进行认证）
+	// 可选认证中间件：握手路由也接受一次性票据，由 WebSocket 处理器自行校验
 	r.Use(OptionalAuthMiddleware(db))
 }
 
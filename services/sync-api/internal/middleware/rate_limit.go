@@ -223,6 +223,20 @@ func UploadRateLimitMiddleware() gin.HandlerFunc {
 	return CreateRateLimitMiddleware(50, 5, UserIPKeyFunc) // 50个令牌，每秒补充5个
 }
 
+// ShareRateLimitMiddleware 公开分享链接限流中间件，限制猜测链接和密码
+func ShareRateLimitMiddleware() gin.HandlerFunc {
+	return CreateRateLimitMiddleware(30, 1, func(c *gin.Context) string {
+		return fmt.Sprintf("share:%s", c.ClientIP())
+	})
+}
+
+// InboundRateLimitMiddleware 入站 webhook 限流中间件，按令牌限流
+func InboundRateLimitMiddleware() gin.HandlerFunc {
+	return CreateRateLimitMiddleware(60, 1, func(c *gin.Context) string {
+		return fmt.Sprintf("inbound:%s", c.Param("token"))
+	})
+}
+
 // WebSocketRateLimitMiddleware WebSocket连接限流中间件
 func WebSocketRateLimitMiddleware() gin.HandlerFunc {
 	return CreateRateLimitMiddleware(5, 1, func(c *gin.Context) string {
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// AccessTokenPrefix 个人访问令牌前缀，用于和 JWT 区分
+const AccessTokenPrefix = "xpat_"
+
+// 个人访问令牌权限
+const (
+	ScopeClipsRead   = "clips:read"
+	ScopeClipsWrite  = "clips:write"
+	ScopeDevicesRead = "devices:read"
+)
+
+// AccessTokenScopes 所有可授予的权限
+var AccessTokenScopes = []string{ScopeClipsRead, ScopeClipsWrite, ScopeDevicesRead}
+
+// PersonalAccessToken 个人访问令牌，供脚本、CLI 和 CI 使用
+// 令牌不绑定会话和设备，只能访问授予权限的接口；只保存哈希，明文仅在创建时返回一次
+type PersonalAccessToken struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID    uint   `json:"user_id" gorm:"not null;index"`
+	Name      string `json:"name" gorm:"not null;size:100"`
+	TokenHash string `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	Prefix    string `json:"prefix" gorm:"size:20"`  // 令牌开头几位，便于识别
+	Scopes    string `json:"scopes" gorm:"size:255"` // 空格分隔
+
+	ExpiresAt  *time.Time `json:"expires_at"` // 为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip" gorm:"size:45"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// TableName 指定表名
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+// IsExpired 令牌是否已过期
+func (t *PersonalAccessToken) IsExpired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
+
+// IsRevoked 令牌是否已撤销
+func (t *PersonalAccessToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// ScopeList 权限列表
+func (t *PersonalAccessToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+// HasScope 是否具备指定权限
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	for _, s := range t.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAccessTokenRequest 创建个人访问令牌请求
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=clips:read clips:write devices:read"`
+	ExpiresInDays *int     `json:"expires_in_days" binding:"omitempty,min=1,max=3650"` // 为空表示永不过期
+}
+
+// AccessTokenResponse 个人访问令牌信息
+type AccessTokenResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	Expired    bool       `json:"expired"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAccessTokenResponse 创建个人访问令牌响应，token 只返回这一次
+type CreateAccessTokenResponse struct {
+	*AccessTokenResponse
+	Token string `json:"token"`
+}
+
+// ToResponse 转换为响应格式
+func (t *PersonalAccessToken) ToResponse() *AccessTokenResponse {
+	return &AccessTokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		Scopes:     t.ScopeList(),
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		LastUsedIP: t.LastUsedIP,
+		Expired:    t.IsExpired(),
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+// 个人访问令牌相关错误
+var (
+	ErrAccessTokenNotFound = errors.New("access token not found")
+	ErrAccessTokenExpired  = errors.New("access token expired")
+	ErrAccessTokenRevoked  = errors.New("access token has been revoked")
+	ErrTooManyAccessTokens = errors.New("too many access tokens")
+)
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// AdminUserFilter 管理员用户列表筛选条件
+type AdminUserFilter struct {
+	Query  string `form:"q"`
+	Status string `form:"status" binding:"omitempty,oneof=inactive active suspended banned"`
+	Role   string `form:"role" binding:"omitempty,oneof=user admin"`
+}
+
+// AdminUserResponse 管理员查看的用户信息，包含状态原因和登录IP
+type AdminUserResponse struct {
+	*UserResponse
+	StatusReason    string     `json:"status_reason,omitempty"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+	LoginIP         string     `json:"login_ip,omitempty"`
+}
+
+// ToAdminResponse 转换为管理员响应格式
+func (u *User) ToAdminResponse() *AdminUserResponse {
+	return &AdminUserResponse{
+		UserResponse:    u.ToResponse(),
+		StatusReason:    u.StatusReason,
+		StatusChangedAt: u.StatusChangedAt,
+		LoginIP:         u.LoginIP,
+	}
+}
+
+// UserStatusRequest 暂停、封禁或恢复用户的请求
+type UserStatusRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// AdminResetPasswordRequest 管理员重置密码请求；不传新密码时向用户发送重置邮件
+type AdminResetPasswordRequest struct {
+	NewPassword string `json:"new_password" binding:"omitempty,min=6"`
+}
+
+// AuditLogFilter 审计日志筛选条件
+type AuditLogFilter struct {
+	UserID  uint   `form:"user_id"`
+	ActorID uint   `form:"actor_id"`
+	Event   string `form:"event"`
+}
+
+// 用户管理相关错误
+var (
+	ErrCannotManageSelf = errors.New("cannot perform this action on your own account")
+	ErrReasonRequired   = errors.New("reason is required")
+)
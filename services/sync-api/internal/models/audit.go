@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditLog 安全审计日志：登录失败、账户锁定、解锁等安全相关事件
+type AuditLog struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	UserID    *uint  `json:"user_id" gorm:"index"` // 事件关联的用户，登录不存在的用户时为空
+	ActorID   *uint  `json:"actor_id"`             // 操作人，管理员操作时记录管理员ID
+	Event     string `json:"event" gorm:"not null;size:50;index"`
+	Username  string `json:"username" gorm:"size:255"` // 登录时提交的用户名或邮箱
+	IP        string `json:"ip" gorm:"size:45;index"`
+	UserAgent string `json:"user_agent" gorm:"size:500"`
+	Detail    string `json:"detail" gorm:"size:500"`
+}
+
+// TableName 指定表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// 审计事件类型
+const (
+	AuditLoginFailed    = "login_failed"    // 登录失败（用户不存在或密码错误）
+	AuditLoginThrottled = "login_throttled" // 锁定期间的登录请求被拒绝
+	AuditAccountLocked  = "account_locked"  // 账户因连续登录失败被临时锁定
+	AuditIPLocked       = "ip_locked"       // IP 因连续登录失败被临时锁定
+	AuditAccountUnlock  = "account_unlocked"
+	AuditIPUnlock       = "ip_unlocked"
+
+	AuditTwoFactorFailed   = "two_factor_failed"   // 两步验证码或恢复码错误
+	AuditTwoFactorVerified = "two_factor_verified" // 两步验证通过，登录完成
+
+	AuditEmailVerified         = "email_verified"
+	AuditPasswordResetRequest  = "password_reset_requested"
+	AuditPasswordResetComplete = "password_reset"
+
+	AuditSSOLogin       = "sso_login"        // 单点登录成功
+	AuditSSOLoginFailed = "sso_login_failed" // 单点登录失败（身份校验或账户关联失败）
+	AuditSSOLinked      = "sso_identity_linked"
+	AuditSSOProvisioned = "sso_user_provisioned"
+	AuditRoleChanged    = "role_changed"
+
+	AuditUserSuspended      = "user_suspended"
+	AuditUserBanned         = "user_banned"
+	AuditUserActivated      = "user_activated"
+	AuditUserForceLogout    = "user_force_logout"
+	AuditAdminPasswordReset = "admin_password_reset"
+)
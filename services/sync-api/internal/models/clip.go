@@ -45,6 +45,31 @@ const (
 	ClipTypeURL   ClipType = "url"
 )
 
+// ClipSubtype 服务端识别的内容子类型，细分文本和链接剪贴板项
+type ClipSubtype string
+
+const (
+	ClipSubtypeCode  ClipSubtype = "code"
+	ClipSubtypeJSON  ClipSubtype = "json"
+	ClipSubtypeEmail ClipSubtype = "email"
+	ClipSubtypePhone ClipSubtype = "phone"
+	ClipSubtypeColor ClipSubtype = "color"
+	ClipSubtypePath  ClipSubtype = "path"
+	ClipSubtypeDate  ClipSubtype = "date"
+	ClipSubtypeOTP   ClipSubtype = "otp"
+	ClipSubtypeURL   ClipSubtype = "url"
+)
+
+// IsValid 是否为已知的子类型
+func (s ClipSubtype) IsValid() bool {
+	switch s {
+	case ClipSubtypeCode, ClipSubtypeJSON, ClipSubtypeEmail, ClipSubtypePhone, ClipSubtypeColor,
+		ClipSubtypePath, ClipSubtypeDate, ClipSubtypeOTP, ClipSubtypeURL:
+		return true
+	}
+	return false
+}
+
 // ClipStatus 剪贴板项状态
 type ClipStatus string
 
@@ -53,16 +78,26 @@ const (
 	ClipStatusExpired ClipStatus = "expired"
 )
 
+// ClipScope 剪贴板项的归属范围
+type ClipScope string
+
+const (
+	ClipScopeUser ClipScope = "user" // 个人剪贴板
+	ClipScopeTeam ClipScope = "team" // 团队共享剪贴板
+)
+
 // ClipItem 剪贴板项模型
 type ClipItem struct {
 	ID          uint        `json:"id" gorm:"primaryKey"`
-	UserID      uint        `json:"user_id" gorm:"not null;index"`
+	UserID      uint        `json:"user_id" gorm:"not null;index"` // 个人剪贴板项的所有者，团队剪贴板项的发布者
+	Scope       ClipScope   `json:"scope" gorm:"size:10;not null;default:'user';index"`
+	TeamID      *uint       `json:"team_id" gorm:"index"` // 团队剪贴板项所属的团队
 	DeviceID    string      `json:"device_id" gorm:"size:255;not null;index"`
 	Type        ClipType    `json:"type" gorm:"size:20;not null;index"`
 	Content     string      `json:"content" gorm:"type:text;not null"`
 	Title       string      `json:"title" gorm:"size:255"`
 	Description string      `json:"description" gorm:"type:text"`
-	Tags        []string    `json:"tags" gorm:"type:json"`
+	Tags        []string    `json:"tags" gorm:"type:json;serializer:json"`
 	Metadata     JSON        `json:"metadata" gorm:"type:json"`
 	Status      ClipStatus  `json:"status" gorm:"size:20;not null;default:'active';index"`
 	ViewCount   int         `json:"view_count" gorm:"default:0"`
@@ -72,6 +107,16 @@ type ClipItem struct {
 	CreatedAt   time.Time   `json:"created_at" gorm:"index"`
 	UpdatedAt   time.Time   `json:"updated_at"`
 
+	// 敏感数据，创建时按用户设置识别
+	Sensitive         bool             `json:"sensitive" gorm:"default:false;index"`
+	SensitiveMatches  []SensitiveMatch `json:"-" gorm:"type:json;serializer:json"`
+	Masked            bool             `json:"masked" gorm:"default:false"`                         // 列表和详情响应中遮盖敏感数据，需要显式查看原文
+	ExcludedPlatforms []string         `json:"excluded_platforms" gorm:"type:json;serializer:json"` // 不同步到这些平台的设备
+
+	// 内容子类型，创建时由服务端识别
+	Subtype        ClipSubtype         `json:"subtype" gorm:"size:20;index"`
+	Classification *ClipClassification `json:"classification" gorm:"type:json;serializer:json"`
+
 	// 关联
 	User User `json:"-" gorm:"foreignKey:UserID"`
 	// Device Device `json:"-" gorm:"foreignKey:DeviceID;references:DeviceID"` // 暂时移除设备关联以避免循环引用
@@ -85,26 +130,87 @@ func (ClipItem) TableName() string {
 // ToResponse 转换为响应格式
 func (c *ClipItem) ToResponse() *ClipItemResponse {
 	return &ClipItemResponse{
-		ID:          c.ID,
-		Type:        string(c.Type),
-		Content:     c.Content,
-		Title:       c.Title,
-		Description: c.Description,
-		Tags:        c.Tags,
-		Metadata:    c.Metadata,
-		Status:      string(c.Status),
-		ViewCount:   c.ViewCount,
-		UsedAt:      c.UsedAt,
-		LastUsedAt:  c.LastUsedAt,
-		ExpiresAt:   c.ExpiresAt,
-		CreatedAt:   c.CreatedAt,
-		UpdatedAt:   c.UpdatedAt,
+		ID:             c.ID,
+		UserID:         c.UserID,
+		Scope:          string(c.Scope),
+		TeamID:         c.TeamID,
+		Type:           string(c.Type),
+		Content:        c.Content,
+		Title:          c.Title,
+		Description:    c.Description,
+		Tags:           c.Tags,
+		Metadata:       c.Metadata,
+		Status:         string(c.Status),
+		ViewCount:      c.ViewCount,
+		UsedAt:         c.UsedAt,
+		LastUsedAt:     c.LastUsedAt,
+		ExpiresAt:      c.ExpiresAt,
+		CreatedAt:      c.CreatedAt,
+		UpdatedAt:      c.UpdatedAt,
+		Sensitive:      c.SensitiveInfo(),
+		Subtype:        string(c.Subtype),
+		Classification: c.Classification,
+	}
+}
+
+// SensitiveInfo 敏感数据摘要，不包含敏感数据时返回 nil
+func (c *ClipItem) SensitiveInfo() *ClipSensitiveInfo {
+	if !c.Sensitive {
+		return nil
+	}
+	info := &ClipSensitiveInfo{Kinds: []string{}, ExcludedPlatforms: c.ExcludedPlatforms}
+	for _, match := range c.SensitiveMatches {
+		if !containsKind(info.Kinds, match.Kind) {
+			info.Kinds = append(info.Kinds, match.Kind)
+		}
+	}
+	return info
+}
+
+// containsKind 类别是否已存在
+func containsKind(kinds []string, kind string) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
 	}
+	return false
+}
+
+// SensitiveMatch 剪贴板项中的一处敏感数据，Start、End 为内容中的字节偏移
+type SensitiveMatch struct {
+	Detector string `json:"detector"`
+	Kind     string `json:"kind"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+// ClipSensitiveInfo 剪贴板项响应中的敏感数据摘要
+type ClipSensitiveInfo struct {
+	Kinds             []string `json:"kinds"`                        // 例如 credit_card、aws_access_key、jwt、private_key、high_entropy
+	Masked            bool     `json:"masked"`                       // 响应中的内容已遮盖，原文通过 POST /clips/:id/reveal 获取
+	ExcludedPlatforms []string `json:"excluded_platforms,omitempty"` // 不同步到这些平台的设备
+}
+
+// ClipClassification 内容识别结果，只填写与子类型相关的字段
+type ClipClassification struct {
+	Subtype   ClipSubtype `json:"subtype"`
+	Language  string      `json:"language,omitempty"`   // code：语言，与分享页的语言标识一致
+	URL       string      `json:"url,omitempty"`        // url：规范化后的链接
+	Host      string      `json:"host,omitempty"`       // url：域名
+	Email     string      `json:"email,omitempty"`      // email：域名转为小写的邮箱地址
+	Phone     string      `json:"phone,omitempty"`      // phone：只保留数字和开头的 +
+	Color     string      `json:"color,omitempty"`      // color：#rrggbb，带透明度时为 #rrggbbaa
+	Path      string      `json:"path,omitempty"`       // path：文件路径
+	PathStyle string      `json:"path_style,omitempty"` // path：unix 或 windows
+	Date      string      `json:"date,omitempty"`       // date：RFC3339，只有日期时为 2006-01-02
+	OTP       string      `json:"otp,omitempty"`        // otp：验证码
 }
 
 // CreateClipRequest 创建剪贴板项请求
 type CreateClipRequest struct {
 	DeviceID    string      `json:"device_id,omitempty"`
+	TeamID      *uint       `json:"team_id,omitempty"` // 设置时发布到团队剪贴板
 	Type        string      `json:"type" binding:"required,oneof=text image file url"`
 	Content     string      `json:"content" binding:"required"`
 	Title       string      `json:"title,omitempty"`
@@ -125,20 +231,26 @@ type UpdateClipRequest struct {
 
 // ClipItemResponse 剪贴板项响应
 type ClipItemResponse struct {
-	ID          uint        `json:"id"`
-	Type        string      `json:"type"`
-	Content     string      `json:"content"`
-	Title       string      `json:"title"`
-	Description string      `json:"description"`
-	Tags        []string    `json:"tags"`
-	Metadata    interface{} `json:"metadata"`
-	Status      string      `json:"status"`
-	ViewCount   int         `json:"view_count"`
-	UsedAt      *time.Time  `json:"used_at"`
-	LastUsedAt  *time.Time  `json:"last_used_at"`
-	ExpiresAt   *time.Time  `json:"expires_at"`
-	CreatedAt   time.Time   `json:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at"`
+	ID             uint                `json:"id"`
+	UserID         uint                `json:"user_id"`
+	Scope          string              `json:"scope"`
+	TeamID         *uint               `json:"team_id,omitempty"`
+	Type           string              `json:"type"`
+	Content        string              `json:"content"`
+	Title          string              `json:"title"`
+	Description    string              `json:"description"`
+	Tags           []string            `json:"tags"`
+	Metadata       interface{}         `json:"metadata"`
+	Status         string              `json:"status"`
+	ViewCount      int                 `json:"view_count"`
+	UsedAt         *time.Time          `json:"used_at"`
+	LastUsedAt     *time.Time          `json:"last_used_at"`
+	ExpiresAt      *time.Time          `json:"expires_at"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+	Sensitive      *ClipSensitiveInfo  `json:"sensitive,omitempty"`      // 包含敏感数据时返回
+	Subtype        string              `json:"subtype,omitempty"`        // 识别到的内容子类型
+	Classification *ClipClassification `json:"classification,omitempty"` // 子类型的详细信息，供客户端提供快捷操作
 }
 
 // ClipSyncRequest 剪贴板同步请求
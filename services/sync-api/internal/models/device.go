@@ -35,6 +35,16 @@ type Device struct {
 	IsOnline     bool         `json:"is_online" gorm:"default:false"`
 	LastSyncAt   *time.Time   `json:"last_sync_at"`
 
+	// 批准与信任级别，新设备需要已信任设备批准后才能访问剪贴板
+	TrustLevel DeviceTrustLevel `json:"trust_level" gorm:"size:20;default:full"`
+	ApprovedAt *time.Time       `json:"approved_at"`
+	ApprovedBy string           `json:"approved_by" gorm:"size:100"` // 批准该设备的设备ID
+
+	// 在线状态（presence），IsOnline 只由 WebSocket 连接决定；离线设备通过 REST 上报的状态保存在 Presence 中，展示时仍为 offline
+	Presence          PresenceState `json:"presence" gorm:"size:20;default:offline;index"`
+	AppCategory       string        `json:"app_category" gorm:"size:50"` // 前台应用类别
+	PresenceUpdatedAt *time.Time    `json:"presence_updated_at"`
+
 	// 设备特性
 	Capabilities DeviceCapabilities `json:"capabilities" gorm:"type:text"`
 	Settings     map[string]interface{} `json:"settings" gorm:"type:text;serializer:json"`
@@ -63,6 +73,7 @@ const (
 	DeviceStatusActive   DeviceStatus = 1 // 正常
 	DeviceStatusSuspended DeviceStatus = 2 // 暂停
 	DeviceStatusRevoked  DeviceStatus = 3 // 已撤销
+	DeviceStatusPending  DeviceStatus = 4 // 等待批准
 )
 
 // String 返回设备状态的字符串表示
@@ -76,11 +87,42 @@ func (s DeviceStatus) String() string {
 		return "suspended"
 	case DeviceStatusRevoked:
 		return "revoked"
+	case DeviceStatusPending:
+		return "pending"
 	default:
 		return "unknown"
 	}
 }
 
+// DeviceTrustLevel 设备信任级别
+type DeviceTrustLevel string
+
+const (
+	TrustLevelNone    DeviceTrustLevel = "none"    // 未批准，不能访问剪贴板
+	TrustLevelLimited DeviceTrustLevel = "limited" // 只能读取批准之后创建的剪贴板项
+	TrustLevelFull    DeviceTrustLevel = "full"    // 完全信任，可以批准其他设备
+)
+
+// PresenceState 设备在线状态
+type PresenceState string
+
+const (
+	PresenceOffline PresenceState = "offline" // 离线
+	PresenceActive  PresenceState = "active"  // 正在使用
+	PresenceIdle    PresenceState = "idle"    // 空闲
+	PresenceLocked  PresenceState = "locked"  // 已锁屏
+	PresenceDND     PresenceState = "dnd"     // 勿扰
+)
+
+// IsValid 是否为客户端可以上报的状态（offline 只能由服务端设置）
+func (s PresenceState) IsValid() bool {
+	switch s {
+	case PresenceActive, PresenceIdle, PresenceLocked, PresenceDND:
+		return true
+	}
+	return false
+}
+
 // DeviceCapabilities 设备能力
 type DeviceCapabilities struct {
 	ClipboardRead  bool `json:"clipboard_read"`
@@ -133,17 +175,75 @@ func (d *Device) IsActive() bool {
 	return d.Status == DeviceStatusActive
 }
 
+// IsPending 设备是否在等待批准
+func (d *Device) IsPending() bool {
+	return d.Status == DeviceStatusPending
+}
+
+// CanApproveDevices 设备是否可以批准其他设备（正常且完全信任）
+func (d *Device) CanApproveDevices() bool {
+	return d.IsActive() && d.TrustLevel == TrustLevelFull
+}
+
+// ReadableSince 按信任级别返回设备可读取的剪贴板项起始时间
+// 返回 nil 表示不限制；ok 为 false 表示设备不能读取剪贴板
+func (d *Device) ReadableSince() (since *time.Time, ok bool) {
+	if !d.IsActive() {
+		return nil, false
+	}
+	switch d.TrustLevel {
+	case TrustLevelFull:
+		return nil, true
+	case TrustLevelLimited:
+		if d.ApprovedAt != nil {
+			return d.ApprovedAt, true
+		}
+		return &d.CreatedAt, true
+	default:
+		return nil, false
+	}
+}
+
+// Approve 批准设备并设置信任级别，已批准的设备只调整信任级别
+func (d *Device) Approve(trustLevel DeviceTrustLevel, approvedBy string) {
+	if !d.IsActive() || d.ApprovedAt == nil {
+		now := time.Now()
+		d.ApprovedAt = &now
+	}
+	d.Status = DeviceStatusActive
+	d.TrustLevel = trustLevel
+	d.ApprovedBy = approvedBy
+}
+
 // UpdateLastSeen 更新最后在线时间和IP
 func (d *Device) UpdateLastSeen(ip string) {
 	now := time.Now()
 	d.LastSeen = &now
-	d.LastIP = ip
-	d.IsOnline = true
+	if ip != "" {
+		d.LastIP = ip
+	}
+	if !d.IsOnline || d.Presence == "" || d.Presence == PresenceOffline {
+		d.SetPresence(PresenceActive, "")
+	}
 }
 
 // SetOffline 设置设备离线
 func (d *Device) SetOffline() {
-	d.IsOnline = false
+	d.SetPresence(PresenceOffline, "")
+}
+
+// SetPresence 设置在线状态，并同步 IsOnline
+func (d *Device) SetPresence(state PresenceState, appCategory string) {
+	now := time.Now()
+	d.Presence = state
+	d.AppCategory = appCategory
+	d.PresenceUpdatedAt = &now
+	d.IsOnline = state != PresenceOffline
+}
+
+// IsDoNotDisturb 设备是否处于勿扰状态
+func (d *Device) IsDoNotDisturb() bool {
+	return d.IsOnline && d.Presence == PresenceDND
 }
 
 // UpdateSyncTime 更新同步时间
@@ -183,8 +283,12 @@ type DeviceResponse struct {
 	Model        string              `json:"model"`
 	OSVersion    string              `json:"os_version"`
 	Status       string              `json:"status"`
+	TrustLevel   DeviceTrustLevel    `json:"trust_level"`
+	ApprovedAt   *time.Time          `json:"approved_at,omitempty"`
 	LastSeen     *time.Time          `json:"last_seen"`
 	IsOnline     bool                `json:"is_online"`
+	Presence     PresenceState       `json:"presence"`
+	AppCategory  string              `json:"app_category,omitempty"`
 	LastSyncAt   *time.Time          `json:"last_sync_at"`
 	Capabilities DeviceCapabilities `json:"capabilities"`
 	RegisteredAt time.Time           `json:"registered_at"`
@@ -201,10 +305,91 @@ func (d *Device) ToResponse() *DeviceResponse {
 		Model:        d.Model,
 		OSVersion:    d.OSVersion,
 		Status:       d.Status.String(),
+		TrustLevel:   d.TrustLevel,
+		ApprovedAt:   d.ApprovedAt,
 		LastSeen:     d.LastSeen,
 		IsOnline:     d.IsOnline,
+		Presence:     d.presenceState(),
+		AppCategory:  d.AppCategory,
 		LastSyncAt:   d.LastSyncAt,
 		Capabilities: d.Capabilities,
 		RegisteredAt: d.CreatedAt,
 	}
+}
+
+// ApproveDeviceRequest 批准设备请求
+type ApproveDeviceRequest struct {
+	TrustLevel DeviceTrustLevel `json:"trust_level" binding:"omitempty,oneof=full limited"` // 默认 full
+}
+
+// DeviceApprovalRequestEvent 推送给已批准设备的待批准设备（device_approval_request 消息数据）
+type DeviceApprovalRequestEvent struct {
+	DeviceID     string           `json:"device_id"`
+	Name         string           `json:"name"`
+	Platform     DevicePlatform   `json:"platform"`
+	Model        string           `json:"model,omitempty"`
+	OSVersion    string           `json:"os_version,omitempty"`
+	Status       string           `json:"status"`
+	TrustLevel   DeviceTrustLevel `json:"trust_level"`
+	LastIP       string           `json:"last_ip,omitempty"`
+	RegisteredAt time.Time        `json:"registered_at"`
+}
+
+// ToApprovalRequestEvent 转换为待批准设备推送
+func (d *Device) ToApprovalRequestEvent() *DeviceApprovalRequestEvent {
+	return &DeviceApprovalRequestEvent{
+		DeviceID:     d.DeviceID,
+		Name:         d.Name,
+		Platform:     d.Platform,
+		Model:        d.Model,
+		OSVersion:    d.OSVersion,
+		Status:       d.Status.String(),
+		TrustLevel:   d.TrustLevel,
+		LastIP:       d.LastIP,
+		RegisteredAt: d.CreatedAt,
+	}
+}
+
+// presenceState 兼容旧数据：没有 presence 记录时按 IsOnline 推断
+func (d *Device) presenceState() PresenceState {
+	if !d.IsOnline {
+		return PresenceOffline
+	}
+	if d.Presence == "" || d.Presence == PresenceOffline {
+		return PresenceActive
+	}
+	return d.Presence
+}
+
+// UpdatePresenceRequest 更新在线状态请求
+type UpdatePresenceRequest struct {
+	DeviceID    string        `json:"device_id,omitempty"` // 未指定时使用令牌中的设备ID
+	State       PresenceState `json:"state" binding:"required,oneof=active idle locked dnd"`
+	AppCategory string        `json:"app_category" binding:"max=50"`
+}
+
+// DevicePresence 设备在线状态
+type DevicePresence struct {
+	DeviceID    string         `json:"device_id"`
+	Name        string         `json:"name,omitempty"`
+	Platform    DevicePlatform `json:"platform,omitempty"`
+	State       PresenceState  `json:"state"`
+	AppCategory string         `json:"app_category,omitempty"`
+	IsOnline    bool           `json:"is_online"`
+	LastSeen    *time.Time     `json:"last_seen,omitempty"`
+	UpdatedAt   *time.Time     `json:"updated_at,omitempty"`
+}
+
+// ToPresence 转换为在线状态
+func (d *Device) ToPresence() *DevicePresence {
+	return &DevicePresence{
+		DeviceID:    d.DeviceID,
+		Name:        d.Name,
+		Platform:    d.Platform,
+		State:       d.presenceState(),
+		AppCategory: d.AppCategory,
+		IsOnline:    d.IsOnline,
+		LastSeen:    d.LastSeen,
+		UpdatedAt:   d.PresenceUpdatedAt,
+	}
 }
\ No newline at end of file
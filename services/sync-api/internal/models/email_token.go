@@ -0,0 +1,62 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// EmailTokenPurpose 邮件令牌用途
+type EmailTokenPurpose string
+
+const (
+	EmailTokenVerifyEmail   EmailTokenPurpose = "verify_email"   // 验证注册邮箱
+	EmailTokenResetPassword EmailTokenPurpose = "reset_password" // 重置密码
+)
+
+// EmailToken 通过邮件发送的一次性令牌，只保存哈希
+type EmailToken struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	TokenHash string            `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	UserID    uint              `json:"user_id" gorm:"not null;index"`
+	Purpose   EmailTokenPurpose `json:"purpose" gorm:"not null;size:20"`
+	Email     string            `json:"email" gorm:"not null;size:255"` // 发送时的邮箱，邮箱变更后令牌失效
+	ExpiresAt time.Time         `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time        `json:"used_at"`
+}
+
+// TableName 指定表名
+func (EmailToken) TableName() string {
+	return "email_tokens"
+}
+
+// VerifyEmailRequest 验证邮箱请求
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// EmailRequest 只包含邮箱的请求（重新发送验证邮件、忘记密码）
+type EmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest 重置密码请求
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// VerificationPendingResponse 注册成功但需要先验证邮箱，不返回令牌
+type VerificationPendingResponse struct {
+	User                 *UserResponse `json:"user"`
+	VerificationRequired bool          `json:"verification_required"`
+}
+
+// 邮件令牌相关错误
+var (
+	ErrEmailTokenInvalid    = errors.New("invalid or expired token")
+	ErrEmailNotVerified     = errors.New("email address has not been verified")
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+	ErrRegistrationDisabled = errors.New("registration is disabled")
+)
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// InboundTokenPrefix 入站 webhook 令牌前缀
+const InboundTokenPrefix = "xpin_"
+
+// InboundChannel 入站渠道
+type InboundChannel string
+
+const (
+	InboundChannelWebhook InboundChannel = "webhook" // 通过 /in/:token 提交
+	InboundChannelEmail   InboundChannel = "email"   // 发送邮件到 <token>@<域名>
+)
+
+// IsValid 是否是支持的渠道
+func (c InboundChannel) IsValid() bool {
+	return c == InboundChannelWebhook || c == InboundChannelEmail
+}
+
+// InboundEndpoint 用户的入站地址，每个用户每个渠道一个
+// 令牌只保存哈希，地址明文只在创建和轮换时返回一次
+type InboundEndpoint struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID    uint           `json:"user_id" gorm:"not null;uniqueIndex:idx_inbound_user_channel"`
+	Channel   InboundChannel `json:"channel" gorm:"size:20;not null;uniqueIndex:idx_inbound_user_channel"`
+	TokenHash string         `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	Prefix    string         `json:"prefix" gorm:"size:20"`                 // 令牌开头几位，便于识别
+	Tags      []string       `json:"tags" gorm:"type:text;serializer:json"` // 添加到收到的剪贴板项上的标签
+
+	LastReceivedAt *time.Time `json:"last_received_at"`
+	ReceivedCount  int        `json:"received_count" gorm:"default:0"`
+}
+
+// TableName 指定表名
+func (InboundEndpoint) TableName() string {
+	return "inbound_endpoints"
+}
+
+// InboundEndpointRequest 创建或轮换入站地址请求
+type InboundEndpointRequest struct {
+	Tags []string `json:"tags,omitempty"`
+}
+
+// InboundEndpointResponse 入站地址信息，URL 和 Address 只在创建和轮换时返回
+type InboundEndpointResponse struct {
+	Channel        InboundChannel `json:"channel"`
+	Prefix         string         `json:"prefix"`
+	URL            string         `json:"url,omitempty"`     // webhook 渠道的提交地址
+	Address        string         `json:"address,omitempty"` // email 渠道的收件地址
+	Tags           []string       `json:"tags"`
+	LastReceivedAt *time.Time     `json:"last_received_at"`
+	ReceivedCount  int            `json:"received_count"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// ToResponse 转换为响应格式（不包含地址）
+func (e *InboundEndpoint) ToResponse() *InboundEndpointResponse {
+	return &InboundEndpointResponse{
+		Channel:        e.Channel,
+		Prefix:         e.Prefix,
+		Tags:           e.Tags,
+		LastReceivedAt: e.LastReceivedAt,
+		ReceivedCount:  e.ReceivedCount,
+		CreatedAt:      e.CreatedAt,
+	}
+}
+
+// InboundClipRequest 入站 webhook 的 JSON 请求体
+// 图片和文件的 content 为 base64 或 data URL
+type InboundClipRequest struct {
+	Type        string   `json:"type,omitempty"` // 为空时按内容识别为 text 或 url
+	Content     string   `json:"content"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Metadata    JSON     `json:"metadata,omitempty"`
+	FileName    string   `json:"file_name,omitempty"`
+	MimeType    string   `json:"mime_type,omitempty"`
+}
+
+// InboundResponse 入站提交结果
+type InboundResponse struct {
+	Items []*ClipItemResponse `json:"items"`
+}
+
+// 入站相关错误
+var (
+	ErrInboundChannelInvalid   = errors.New("invalid inbound channel")
+	ErrInboundEndpointNotFound = errors.New("inbound endpoint not found")
+	ErrInboundEmailDisabled    = errors.New("inbound email is not enabled")
+	ErrInboundEmpty            = errors.New("inbound content is empty")
+)
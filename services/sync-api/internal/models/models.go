@@ -15,6 +15,7 @@ type AuthResult struct {
 	RefreshToken string        `json:"refresh_token"`
 	TokenType    string        `json:"token_type"`
 	ExpiresIn    int           `json:"expires_in"`
+	TrustToken   string        `json:"trust_token,omitempty"` // 两步验证时选择记住设备后返回，下次登录携带可跳过两步验证
 }
 
 // LoginRequest 登录请求
@@ -22,6 +23,8 @@ type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 	DeviceID string `json:"device_id,omitempty"`
+	// TrustToken 完成两步验证时记住设备得到的信任令牌
+	TrustToken string `json:"trust_token,omitempty"`
 }
 
 // 注册请求
@@ -194,8 +197,10 @@ var (
 	ErrTokenExpired      = errors.New("token expired")
 	ErrInvalidToken      = errors.New("invalid token")
 	ErrDeviceNotFound    = errors.New("device not found")
+	ErrDeviceNotPending  = errors.New("device is not waiting for approval")
+	ErrDeviceSelfApproval = errors.New("device cannot approve itself")
 	ErrClipItemNotFound  = errors.New("clip item not found")
-	ErrClipNotFound      = errors.New("clip item not found")
+	ErrClipNotFound      = ErrClipItemNotFound // 别名，处理器与服务层使用同一个错误
 	ErrClipItemExpired   = errors.New("clip item expired")
 	ErrSettingNotFound   = errors.New("setting not found")
 	ErrSettingReadOnly   = errors.New("setting is read-only")
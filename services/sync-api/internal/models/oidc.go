@@ -0,0 +1,85 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// UserIdentity 用户关联的外部身份，按签发者和 sub 唯一
+type UserIdentity struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Issuer      string     `json:"issuer" gorm:"not null;size:255;uniqueIndex:idx_identity_issuer_subject"`
+	Subject     string     `json:"subject" gorm:"not null;size:255;uniqueIndex:idx_identity_issuer_subject"`
+	Email       string     `json:"email" gorm:"size:255"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// TableName 指定表名
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OIDCLoginState 单点登录流程状态
+// 客户端发起登录时创建，浏览器在身份提供方完成认证后回调写入结果，客户端再用登录令牌兑换会话
+type OIDCLoginState struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	StateHash      string `json:"-" gorm:"uniqueIndex;not null;size:64"` // 授权请求 state 参数的哈希
+	LoginTokenHash string `json:"-" gorm:"uniqueIndex;not null;size:64"` // 客户端兑换会话用的登录令牌哈希
+	Nonce          string `json:"-" gorm:"not null;size:64"`
+	CodeVerifier   string `json:"-" gorm:"not null;size:128"` // PKCE 验证码
+	DeviceID       string `json:"device_id" gorm:"size:100"`
+	RedirectURI    string `json:"redirect_uri" gorm:"size:500"` // 回调完成后浏览器跳转的客户端地址
+
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null;index"`
+	UserID      *uint      `json:"user_id"`
+	Error       string     `json:"error" gorm:"size:255"`
+	CompletedAt *time.Time `json:"completed_at"` // 回调处理完成（成功或失败）
+	ConsumedAt  *time.Time `json:"consumed_at"`  // 登录令牌已兑换
+}
+
+// TableName 指定表名
+func (OIDCLoginState) TableName() string {
+	return "oidc_login_states"
+}
+
+// OIDCProviderResponse 单点登录配置
+type OIDCProviderResponse struct {
+	Enabled      bool   `json:"enabled"`
+	ProviderName string `json:"provider_name,omitempty"`
+}
+
+// OIDCAuthorizeRequest 发起单点登录请求
+type OIDCAuthorizeRequest struct {
+	DeviceID    string `json:"device_id"`
+	RedirectURI string `json:"redirect_uri"` // 可选，需在 OIDC_ALLOWED_REDIRECT_URIS 中
+}
+
+// OIDCAuthorizeResponse 发起单点登录响应，客户端在浏览器中打开 AuthorizationURL 后用 LoginToken 轮询结果
+type OIDCAuthorizeResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	LoginToken       string `json:"login_token"`
+	ExpiresIn        int    `json:"expires_in"`
+}
+
+// OIDCTokenRequest 用登录令牌兑换会话
+type OIDCTokenRequest struct {
+	LoginToken string `json:"login_token" binding:"required"`
+}
+
+// 单点登录相关错误
+var (
+	ErrOIDCDisabled           = errors.New("single sign-on is not enabled")
+	ErrOIDCLoginInvalid       = errors.New("invalid or expired login")
+	ErrOIDCLoginPending       = errors.New("login has not been completed yet")
+	ErrOIDCRedirectNotAllowed = errors.New("redirect uri is not allowed")
+	ErrOIDCEmailNotVerified   = errors.New("identity provider did not verify the email address")
+	ErrOIDCDomainNotAllowed   = errors.New("email domain is not allowed")
+	ErrOIDCProvisionDisabled  = errors.New("no account is linked to this identity")
+	ErrOIDCAccountUnavailable = errors.New("account is suspended or banned")
+)
@@ -0,0 +1,194 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PairingSession 设备配对会话
+// 已信任设备发起配对，新设备凭数字配对码或二维码中的一次性密钥认领，发起设备批准后新设备才能换取令牌
+type PairingSession struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 发起方
+	UserID            uint   `json:"user_id" gorm:"not null;index"`
+	InitiatorDeviceID string `json:"initiator_device_id" gorm:"not null;size:100"`
+
+	// 凭证（密钥只保存哈希）
+	PairingID      string `json:"pairing_id" gorm:"uniqueIndex;not null;size:64"`
+	Code           string `json:"-" gorm:"not null;size:10;index"`
+	SecretHash     string `json:"-" gorm:"not null;size:64"`
+	ClaimTokenHash string `json:"-" gorm:"size:64;index"`
+
+	// 状态
+	Status    PairingStatus `json:"status" gorm:"size:20;not null;default:pending;index"`
+	ExpiresAt time.Time     `json:"expires_at" gorm:"not null"`
+	ClaimedAt *time.Time    `json:"claimed_at"`
+	DecidedAt *time.Time    `json:"decided_at"`
+	ClaimIP   string        `json:"claim_ip" gorm:"size:45"`
+
+	// 批准时指定的新设备信任级别
+	TrustLevel DeviceTrustLevel `json:"trust_level" gorm:"size:20"`
+
+	// 新设备信息（认领时提交）
+	DeviceID  string         `json:"device_id" gorm:"size:100"`
+	Name      string         `json:"name" gorm:"size:100"`
+	Platform  DevicePlatform `json:"platform" gorm:"size:20"`
+	Version   string         `json:"version" gorm:"size:50"`
+	Model     string         `json:"model" gorm:"size:100"`
+	OSVersion string         `json:"os_version" gorm:"size:50"`
+}
+
+// PairingStatus 配对会话状态
+type PairingStatus string
+
+const (
+	PairingStatusPending   PairingStatus = "pending"   // 等待新设备认领
+	PairingStatusClaimed   PairingStatus = "claimed"   // 已认领，等待发起设备批准
+	PairingStatusApproved  PairingStatus = "approved"  // 已批准，等待新设备换取令牌
+	PairingStatusDenied    PairingStatus = "denied"    // 已拒绝
+	PairingStatusCompleted PairingStatus = "completed" // 新设备已取得令牌
+	PairingStatusExpired   PairingStatus = "expired"   // 已过期
+)
+
+// TableName 指定表名
+func (PairingSession) TableName() string {
+	return "pairing_sessions"
+}
+
+// IsExpired 会话是否已过期
+func (p *PairingSession) IsExpired() bool {
+	return time.Now().After(p.ExpiresAt)
+}
+
+// CurrentStatus 当前状态，未结束的会话超过有效期后视为过期
+func (p *PairingSession) CurrentStatus() PairingStatus {
+	switch p.Status {
+	case PairingStatusDenied, PairingStatusCompleted:
+		return p.Status
+	}
+	if p.IsExpired() {
+		return PairingStatusExpired
+	}
+	return p.Status
+}
+
+// ToDeviceRequest 转换为新设备的注册请求
+func (p *PairingSession) ToDeviceRequest() *RegisterDeviceRequest {
+	return &RegisterDeviceRequest{
+		DeviceID:  p.DeviceID,
+		Name:      p.Name,
+		Platform:  p.Platform,
+		Version:   p.Version,
+		Model:     p.Model,
+		OSVersion: p.OSVersion,
+	}
+}
+
+// ClaimPairingRequest 认领配对请求
+// 使用数字配对码（code）或二维码中的 pairing_id + secret 二选一
+type ClaimPairingRequest struct {
+	Code      string         `json:"code" binding:"omitempty,numeric,len=6"`
+	PairingID string         `json:"pairing_id" binding:"omitempty,max=64"`
+	Secret    string         `json:"secret" binding:"omitempty,max=128"`
+	DeviceID  string         `json:"device_id,omitempty" binding:"max=100"`
+	Name      string         `json:"name" binding:"required,min=1,max=100"`
+	Platform  DevicePlatform `json:"platform" binding:"required"`
+	Version   string         `json:"version" binding:"max=50"`
+	Model     string         `json:"model" binding:"max=100"`
+	OSVersion string         `json:"os_version" binding:"max=50"`
+}
+
+// RedeemPairingRequest 换取令牌请求
+type RedeemPairingRequest struct {
+	ClaimToken string `json:"claim_token" binding:"required"`
+}
+
+// PairingDecision 批准或拒绝配对（pairing_approve / pairing_deny 消息数据）
+type PairingDecision struct {
+	PairingID  string           `json:"pairing_id"`
+	TrustLevel DeviceTrustLevel `json:"trust_level,omitempty"` // 批准时可选 full 或 limited，默认 full
+}
+
+// PairingSessionResponse 发起配对响应
+type PairingSessionResponse struct {
+	PairingID string        `json:"pairing_id"`
+	Code      string        `json:"code"`
+	QRPayload string        `json:"qr_payload"`
+	Status    PairingStatus `json:"status"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	ExpiresIn int           `json:"expires_in"`
+}
+
+// PairingClaimResponse 认领配对响应
+type PairingClaimResponse struct {
+	PairingID  string        `json:"pairing_id"`
+	ClaimToken string        `json:"claim_token"`
+	Status     PairingStatus `json:"status"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+}
+
+// PairingStatusResponse 配对状态
+type PairingStatusResponse struct {
+	PairingID string         `json:"pairing_id"`
+	Status    PairingStatus  `json:"status"`
+	Name      string         `json:"name,omitempty"`
+	Platform  DevicePlatform `json:"platform,omitempty"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// ToStatusResponse 转换为状态响应
+func (p *PairingSession) ToStatusResponse() *PairingStatusResponse {
+	return &PairingStatusResponse{
+		PairingID: p.PairingID,
+		Status:    p.CurrentStatus(),
+		Name:      p.Name,
+		Platform:  p.Platform,
+		ExpiresAt: p.ExpiresAt,
+	}
+}
+
+// PairingRequestEvent 推送给发起设备的配对请求（pairing_request 消息数据）
+type PairingRequestEvent struct {
+	PairingID string         `json:"pairing_id"`
+	Name      string         `json:"name"`
+	Platform  DevicePlatform `json:"platform"`
+	Model     string         `json:"model,omitempty"`
+	OSVersion string         `json:"os_version,omitempty"`
+	IP        string         `json:"ip,omitempty"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// ToRequestEvent 转换为配对请求推送
+func (p *PairingSession) ToRequestEvent() *PairingRequestEvent {
+	return &PairingRequestEvent{
+		PairingID: p.PairingID,
+		Name:      p.Name,
+		Platform:  p.Platform,
+		Model:     p.Model,
+		OSVersion: p.OSVersion,
+		IP:        p.ClaimIP,
+		ExpiresAt: p.ExpiresAt,
+	}
+}
+
+// PairingResult 配对结果（新设备换取令牌成功后返回）
+type PairingResult struct {
+	Device *DeviceResponse `json:"device"`
+	AuthResult
+}
+
+// 配对相关错误
+var (
+	ErrPairingNotFound     = errors.New("pairing session not found")
+	ErrPairingExpired      = errors.New("pairing session expired")
+	ErrPairingInvalidState = errors.New("pairing session is not in a valid state")
+	ErrPairingPending      = errors.New("pairing is waiting for approval")
+	ErrPairingDenied       = errors.New("pairing was denied")
+	ErrDeviceNotTrusted    = errors.New("device is not trusted")
+)
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+)
+
+// 用户角色
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Permission 管理权限，按角色授予
+type Permission string
+
+const (
+	PermissionSystemSettings    Permission = "system:settings"    // 读取和修改系统设置
+	PermissionSystemStats       Permission = "system:stats"       // 查看连接统计
+	PermissionSystemMaintenance Permission = "system:maintenance" // 执行清理等维护任务
+	PermissionUsersRead         Permission = "users:read"         // 查看和搜索用户
+	PermissionUsersManage       Permission = "users:manage"       // 修改用户角色和状态、强制下线、重置密码、解除锁定
+	PermissionAuditRead         Permission = "audit:read"         // 查看审计日志
+)
+
+// roleRank 角色权限高低，用于在多个角色中取最高的一个
+var roleRank = map[string]int{
+	RoleUser:  1,
+	RoleAdmin: 2,
+}
+
+// rolePermissions 各角色具有的权限，普通用户只能访问自己的数据
+var rolePermissions = map[string][]Permission{
+	RoleUser: {},
+	RoleAdmin: {
+		PermissionSystemSettings,
+		PermissionSystemStats,
+		PermissionSystemMaintenance,
+		PermissionUsersRead,
+		PermissionUsersManage,
+		PermissionAuditRead,
+	},
+}
+
+// IsValidRole 是否为已知角色
+func IsValidRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+// HigherRole 返回两个角色中权限更高的一个
+func HigherRole(a, b string) string {
+	if roleRank[b] > roleRank[a] {
+		return b
+	}
+	return a
+}
+
+// RolePermissions 角色具有的权限
+func RolePermissions(role string) []Permission {
+	return rolePermissions[role]
+}
+
+// RoleHasPermission 角色是否具有指定权限
+func RoleHasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateUserRoleRequest 修改用户角色请求
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin"`
+}
+
+// 权限相关错误
+var (
+	ErrInvalidRole    = errors.New("invalid role")
+	ErrCannotEditSelf = errors.New("cannot change your own role")
+)
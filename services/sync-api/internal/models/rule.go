@@ -0,0 +1,111 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ClipRule 用户的剪贴板项规则，新建剪贴板项时按优先级依次匹配并执行动作
+type ClipRule struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID   uint   `json:"user_id" gorm:"not null;index"`
+	Name     string `json:"name" gorm:"not null;size:100"`
+	Enabled  bool   `json:"enabled" gorm:"default:true"`
+	Priority int    `json:"priority" gorm:"default:0"` // 数值小的先执行
+	Stop     bool   `json:"stop"`                      // 匹配后不再执行后续规则
+
+	Conditions RuleConditions `json:"conditions" gorm:"type:text;serializer:json"`
+	Actions    RuleActions    `json:"actions" gorm:"type:text;serializer:json"`
+
+	MatchCount    int        `json:"match_count" gorm:"default:0"`
+	LastMatchedAt *time.Time `json:"last_matched_at"`
+}
+
+// TableName 指定表名
+func (ClipRule) TableName() string {
+	return "clip_rules"
+}
+
+// RuleConditions 规则条件，所有设置了的条件都满足时匹配；列表中的值之间为“或”
+type RuleConditions struct {
+	Types        []string          `json:"types,omitempty" binding:"omitempty,dive,oneof=text image file url"`
+	ContentRegex string            `json:"content_regex,omitempty" binding:"omitempty,max=1000"` // RE2 语法，只对文本和链接生效
+	DeviceIDs    []string          `json:"device_ids,omitempty"`
+	Platforms    []string          `json:"platforms,omitempty" binding:"omitempty,dive,oneof=windows macos linux android ios web"`
+	Metadata     map[string]string `json:"metadata,omitempty"` // 元数据字段等于指定值，值为 "*" 时只要求字段存在
+}
+
+// RuleActions 规则动作
+type RuleActions struct {
+	AddTags   []string `json:"add_tags,omitempty"`
+	ExpiresIn string   `json:"expires_in,omitempty"` // Go 时长格式，例如 "2m"、"24h"
+	SetTitle  string   `json:"set_title,omitempty"`  // 可以使用 $1、${name} 引用正则捕获组
+	Drop      bool     `json:"drop,omitempty"`       // 不保存剪贴板项
+	Notify    string   `json:"notify,omitempty"`     // 向用户的在线设备推送通知，可以引用捕获组
+}
+
+// IsEmpty 是否没有任何动作
+func (a *RuleActions) IsEmpty() bool {
+	return len(a.AddTags) == 0 && a.ExpiresIn == "" && a.SetTitle == "" && !a.Drop && a.Notify == ""
+}
+
+// CreateRuleRequest 创建规则请求
+type CreateRuleRequest struct {
+	Name       string         `json:"name" binding:"required,max=100"`
+	Enabled    *bool          `json:"enabled,omitempty"` // 默认启用
+	Priority   int            `json:"priority"`
+	Stop       bool           `json:"stop"`
+	Conditions RuleConditions `json:"conditions"`
+	Actions    RuleActions    `json:"actions"`
+}
+
+// UpdateRuleRequest 修改规则请求，未提供的字段保持不变
+type UpdateRuleRequest struct {
+	Name       *string         `json:"name,omitempty" binding:"omitempty,max=100"`
+	Enabled    *bool           `json:"enabled,omitempty"`
+	Priority   *int            `json:"priority,omitempty"`
+	Stop       *bool           `json:"stop,omitempty"`
+	Conditions *RuleConditions `json:"conditions,omitempty"`
+	Actions    *RuleActions    `json:"actions,omitempty"`
+}
+
+// RuleDryRunRequest 规则试运行请求：用示例剪贴板项测试规则，不保存任何数据
+// 提供 Rule 时只测试该规则（可以是尚未保存的规则），否则测试用户所有启用的规则
+type RuleDryRunRequest struct {
+	Clip CreateClipRequest  `json:"clip" binding:"required"`
+	Rule *CreateRuleRequest `json:"rule,omitempty"`
+}
+
+// RuleMatch 匹配的规则
+type RuleMatch struct {
+	RuleID uint   `json:"rule_id"` // 试运行未保存的规则时为 0
+	Name   string `json:"name"`
+}
+
+// RuleNotification 规则通知，推送给用户的在线设备
+type RuleNotification struct {
+	RuleID  uint              `json:"rule_id"`
+	Name    string            `json:"name"`
+	Message string            `json:"message"`
+	Clip    *ClipItemResponse `json:"clip,omitempty"` // 被丢弃的剪贴板项不包含 ID
+}
+
+// RuleResult 规则执行结果
+type RuleResult struct {
+	Matched       []RuleMatch         `json:"matched"`
+	Dropped       bool                `json:"dropped"`
+	DroppedBy     *RuleMatch          `json:"dropped_by,omitempty"`
+	Notifications []*RuleNotification `json:"notifications"`
+	Clip          *ClipItemResponse   `json:"clip"` // 执行动作后的剪贴板项
+}
+
+// 规则相关错误
+var (
+	ErrRuleNotFound      = errors.New("rule not found")
+	ErrRuleInvalid       = errors.New("invalid rule")
+	ErrRuleLimitReached  = errors.New("rule limit reached")
+	ErrClipDroppedByRule = errors.New("clip item dropped by rule")
+)
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// AuthSession 登录会话
+// 每次登录（或配对完成）创建一个会话，访问令牌携带会话ID，会话下的刷新令牌构成一个令牌家族；
+// 会话绑定签发时的设备，撤销会话或设备后对应令牌立即失效
+type AuthSession struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SessionID string `json:"session_id" gorm:"uniqueIndex;not null;size:64"`
+	UserID    uint   `json:"user_id" gorm:"not null;index"`
+	DeviceID  string `json:"device_id" gorm:"size:100;index"` // 为空表示未绑定设备的账户级会话
+
+	IP         string     `json:"ip" gorm:"size:45"`
+	UserAgent  string     `json:"user_agent" gorm:"size:255"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+
+	RevokedAt    *time.Time `json:"revoked_at"`
+	RevokeReason string     `json:"revoke_reason,omitempty" gorm:"size:100"`
+}
+
+// TableName 指定表名
+func (AuthSession) TableName() string {
+	return "auth_sessions"
+}
+
+// IsRevoked 会话是否已撤销
+func (s *AuthSession) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+// IsExpired 会话是否已过期
+func (s *AuthSession) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+// RefreshToken 刷新令牌（不透明字符串，只保存哈希）
+// 每次使用后轮换，同一会话的刷新令牌属于同一家族；已轮换的令牌再次使用视为泄露，撤销整个家族
+type RefreshToken struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	SessionID string     `json:"session_id" gorm:"not null;size:64;index"` // 令牌家族
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	RotatedAt *time.Time `json:"rotated_at"` // 已换取新令牌
+}
+
+// TableName 指定表名
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// SessionResponse 会话信息
+type SessionResponse struct {
+	SessionID  string         `json:"session_id"`
+	DeviceID   string         `json:"device_id,omitempty"`
+	DeviceName string         `json:"device_name,omitempty"`
+	Platform   DevicePlatform `json:"platform,omitempty"`
+	IP         string         `json:"ip"`
+	UserAgent  string         `json:"user_agent,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	ExpiresAt  time.Time      `json:"expires_at"`
+	Current    bool           `json:"current"` // 是否为发起请求的会话
+}
+
+// ToResponse 转换为响应格式，device 为会话绑定的设备（可为空）
+func (s *AuthSession) ToResponse(device *Device, currentSessionID string) *SessionResponse {
+	response := &SessionResponse{
+		SessionID:  s.SessionID,
+		DeviceID:   s.DeviceID,
+		IP:         s.IP,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    s.SessionID == currentSessionID,
+	}
+	if device != nil {
+		response.DeviceName = device.Name
+		response.Platform = device.Platform
+	}
+	return response
+}
+
+// SecurityAlert 安全告警（security_alert 消息数据）
+type SecurityAlert struct {
+	Type      string     `json:"type"`
+	SessionID string     `json:"session_id,omitempty"`
+	DeviceID  string     `json:"device_id,omitempty"`
+	IP        string     `json:"ip,omitempty"`
+	Until     *time.Time `json:"until,omitempty"` // 账户锁定的截止时间
+	Time      time.Time  `json:"time"`
+}
+
+// 安全告警类型
+const (
+	AlertRefreshTokenReuse = "refresh_token_reuse" // 已轮换的刷新令牌被再次使用，会话已撤销
+	AlertAccountLocked     = "account_locked"      // 连续登录失败，账户已被临时锁定
+)
+
+// 会话撤销原因
+const (
+	RevokeReasonLogout            = "logout"
+	RevokeReasonDeviceDeactivated = "device_deactivated"
+	RevokeReasonDeviceDeleted     = "device_deleted"
+	RevokeReasonDeviceDenied      = "device_denied"
+	RevokeReasonDeviceDisabled    = "device_disabled"
+	RevokeReasonUserRevoked       = "user_revoked"
+	RevokeReasonTokenReuse        = "refresh_token_reuse"
+	RevokeReasonPasswordReset     = "password_reset"
+	RevokeReasonAdmin             = "admin_revoked"
+)
+
+// 会话相关错误
+var (
+	ErrSessionRevoked     = errors.New("session has been revoked")
+	ErrSessionExpired     = errors.New("session expired")
+	ErrDeviceDisabled     = errors.New("device access has been revoked")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+)
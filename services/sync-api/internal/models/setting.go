@@ -109,6 +109,7 @@ const (
 	SettingKeyMaxClipItems      = "system.max_clip_items"
 	SettingKeyRetentionDays     = "system.retention_days"
 	SettingKeyAllowRegistration = "system.allow_registration"
+	SettingKeyRequireEmailVerification = "system.require_email_verification"
 
 	// 用户设置
 	SettingKeyUserTheme          = "user.theme"
@@ -121,6 +122,13 @@ const (
 	SettingKeyUserOCRLanguage    = "user.ocr_language"
 	SettingKeyUserNotifications  = "user.notifications"
 	SettingKeyUserHotkeys        = "user.hotkeys"
+
+	// 敏感数据识别（用户设置）
+	SettingKeyUserSensitiveDetection        = "user.sensitive_detection"         // 是否识别敏感数据，默认 true
+	SettingKeyUserSensitiveDetectors        = "user.sensitive_detectors"         // 启用的识别器，逗号分隔或 JSON 数组，默认全部
+	SettingKeyUserSensitiveMask             = "user.sensitive_mask"              // 是否在响应中遮盖，默认 true
+	SettingKeyUserSensitiveExpiresIn        = "user.sensitive_expires_in"        // 自动过期时间，例如 10m，默认不过期
+	SettingKeyUserSensitiveExcludePlatforms = "user.sensitive_exclude_platforms" // 不同步到这些平台，逗号分隔或 JSON 数组
 )
 
 // CreateSettingRequest 创建设置请求
@@ -258,5 +266,19 @@ func GetDefaultSystemSettings() []Setting {
 				InputType:   "checkbox",
 			},
 		},
+		{
+			Key:          SettingKeyRequireEmailVerification,
+			Value:        "false",
+			Type:         SettingTypeBoolean,
+			Category:     "system",
+			Description:  "注册后需要验证邮箱才能登录",
+			DefaultValue: "false",
+			Metadata: SettingMetadata{
+				DisplayName: "注册需验证邮箱",
+				Group:       "安全设置",
+				Order:       2,
+				InputType:   "checkbox",
+			},
+		},
 	}
 }
\ No newline at end of file
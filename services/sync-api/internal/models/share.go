@@ -0,0 +1,123 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ShareLink 剪贴板项的分享链接，持有链接的人不需要账户即可查看
+type ShareLink struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Slug       string `json:"slug" gorm:"uniqueIndex;not null;size:32"`
+	ClipItemID uint   `json:"clip_item_id" gorm:"not null;index"`
+	UserID     uint   `json:"user_id" gorm:"not null;index"` // 创建者
+
+	PasswordHash     string     `json:"-" gorm:"size:255"` // 为空表示不需要密码
+	ExpiresAt        *time.Time `json:"expires_at" gorm:"index"`
+	MaxViews         int        `json:"max_views" gorm:"default:0"` // 0 表示不限制
+	ViewCount        int        `json:"view_count" gorm:"default:0"`
+	BurnAfterReading bool       `json:"burn_after_reading"` // 第一次查看后失效
+	LastViewedAt     *time.Time `json:"last_viewed_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+
+	ClipItem ClipItem `json:"-" gorm:"foreignKey:ClipItemID"`
+}
+
+// TableName 指定表名
+func (ShareLink) TableName() string {
+	return "share_links"
+}
+
+// HasPassword 是否需要密码
+func (l *ShareLink) HasPassword() bool {
+	return l.PasswordHash != ""
+}
+
+// IsExpired 是否已过期或达到最大查看次数
+func (l *ShareLink) IsExpired() bool {
+	if l.ExpiresAt != nil && time.Now().After(*l.ExpiresAt) {
+		return true
+	}
+	return l.MaxViews > 0 && l.ViewCount >= l.MaxViews
+}
+
+// ShareViewResult 分享链接访问结果
+type ShareViewResult string
+
+const (
+	ShareViewViewed        ShareViewResult = "viewed"         // 查看成功
+	ShareViewWrongPassword ShareViewResult = "wrong_password" // 密码错误
+)
+
+// ShareView 分享链接访问记录
+type ShareView struct {
+	ID          uint            `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time       `json:"created_at" gorm:"index"`
+	ShareLinkID uint            `json:"share_link_id" gorm:"not null;index"`
+	Result      ShareViewResult `json:"result" gorm:"size:20;not null"`
+	IP          string          `json:"ip" gorm:"size:45"`
+	UserAgent   string          `json:"user_agent" gorm:"size:500"`
+	Referer     string          `json:"referer" gorm:"size:500"`
+}
+
+// TableName 指定表名
+func (ShareView) TableName() string {
+	return "share_views"
+}
+
+// CreateShareLinkRequest 创建分享链接请求
+type CreateShareLinkRequest struct {
+	ClipID           uint       `json:"clip_id" binding:"required"`
+	Password         string     `json:"password,omitempty" binding:"omitempty,min=4,max=128"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	MaxViews         int        `json:"max_views,omitempty" binding:"min=0,max=10000"`
+	BurnAfterReading bool       `json:"burn_after_reading,omitempty"`
+}
+
+// ShareLinkResponse 分享链接响应
+type ShareLinkResponse struct {
+	ID               uint       `json:"id"`
+	Slug             string     `json:"slug"`
+	URL              string     `json:"url"`
+	ClipItemID       uint       `json:"clip_item_id"`
+	HasPassword      bool       `json:"has_password"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	MaxViews         int        `json:"max_views"`
+	ViewCount        int        `json:"view_count"`
+	BurnAfterReading bool       `json:"burn_after_reading"`
+	Active           bool       `json:"active"` // 未撤销、未过期且未达到查看次数上限
+	LastViewedAt     *time.Time `json:"last_viewed_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// ToResponse 转换为响应格式，baseURL 为分享地址前缀
+func (l *ShareLink) ToResponse(baseURL string) *ShareLinkResponse {
+	return &ShareLinkResponse{
+		ID:               l.ID,
+		Slug:             l.Slug,
+		URL:              baseURL + "/s/" + l.Slug,
+		ClipItemID:       l.ClipItemID,
+		HasPassword:      l.HasPassword(),
+		ExpiresAt:        l.ExpiresAt,
+		MaxViews:         l.MaxViews,
+		ViewCount:        l.ViewCount,
+		BurnAfterReading: l.BurnAfterReading,
+		Active:           l.RevokedAt == nil && !l.IsExpired(),
+		LastViewedAt:     l.LastViewedAt,
+		RevokedAt:        l.RevokedAt,
+		CreatedAt:        l.CreatedAt,
+	}
+}
+
+// 分享链接相关错误
+var (
+	ErrShareLinkNotFound     = errors.New("share link not found")
+	ErrShareLinkGone         = errors.New("share link has expired or reached its view limit")
+	ErrSharePasswordRequired = errors.New("share link password required")
+	ErrSharePasswordInvalid  = errors.New("invalid share link password")
+	ErrShareExpiryInvalid    = errors.New("share link expiry must be in the future")
+)
@@ -0,0 +1,222 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TeamRole 团队成员角色
+type TeamRole string
+
+const (
+	TeamRoleOwner  TeamRole = "owner"  // 所有者：管理成员和管理员，修改或删除团队
+	TeamRoleAdmin  TeamRole = "admin"  // 管理员：管理普通成员，删除任何团队剪贴板项
+	TeamRoleMember TeamRole = "member" // 成员：读取、发布和删除自己发布的剪贴板项
+	TeamRoleViewer TeamRole = "viewer" // 只读成员：只能读取
+)
+
+// teamRoleRank 团队角色高低
+var teamRoleRank = map[TeamRole]int{
+	TeamRoleViewer: 1,
+	TeamRoleMember: 2,
+	TeamRoleAdmin:  3,
+	TeamRoleOwner:  4,
+}
+
+// IsValid 是否为已知团队角色
+func (r TeamRole) IsValid() bool {
+	_, ok := teamRoleRank[r]
+	return ok
+}
+
+// AtLeast 角色是否不低于指定角色
+func (r TeamRole) AtLeast(role TeamRole) bool {
+	return teamRoleRank[r] >= teamRoleRank[role]
+}
+
+// CanPost 是否可以向团队剪贴板发布内容
+func (r TeamRole) CanPost() bool {
+	return r.AtLeast(TeamRoleMember)
+}
+
+// CanDelete 是否可以删除或修改团队剪贴板项，成员只能处理自己发布的剪贴板项
+func (r TeamRole) CanDelete(own bool) bool {
+	if own {
+		return r.AtLeast(TeamRoleMember)
+	}
+	return r.AtLeast(TeamRoleAdmin)
+}
+
+// CanManage 是否可以添加、修改或移除具有指定角色的成员
+// 管理员只能管理成员和只读成员，所有者可以管理管理员；所有者角色不能授予或移除
+func (r TeamRole) CanManage(role TeamRole) bool {
+	if role == TeamRoleOwner {
+		return false
+	}
+	if role == TeamRoleAdmin {
+		return r == TeamRoleOwner
+	}
+	return r.AtLeast(TeamRoleAdmin)
+}
+
+// Team 团队，成员共享一个剪贴板空间
+type Team struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Name        string `json:"name" gorm:"not null;size:100"`
+	Description string `json:"description" gorm:"size:500"`
+	OwnerID     uint   `json:"owner_id" gorm:"not null;index"`
+
+	Members []TeamMember `json:"members,omitempty" gorm:"foreignKey:TeamID"`
+}
+
+// TableName 指定表名
+func (Team) TableName() string {
+	return "teams"
+}
+
+// TeamMember 团队成员
+type TeamMember struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	TeamID uint     `json:"team_id" gorm:"not null;uniqueIndex:idx_team_member"`
+	UserID uint     `json:"user_id" gorm:"not null;uniqueIndex:idx_team_member;index"`
+	Role   TeamRole `json:"role" gorm:"size:20;not null;default:'member'"`
+
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// TableName 指定表名
+func (TeamMember) TableName() string {
+	return "team_members"
+}
+
+// DefaultTeamInvitationTTL 团队邀请有效期
+const DefaultTeamInvitationTTL = 7 * 24 * time.Hour
+
+// TeamInvitation 团队邀请，被邀请人接受后才成为成员
+// 无论填写的用户名或邮箱是否对应已注册的用户都会保存邀请，邀请结果不暴露账户是否存在
+type TeamInvitation struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	TeamID    uint      `json:"team_id" gorm:"not null;index"`
+	InviterID uint      `json:"inviter_id" gorm:"not null"`
+	Invitee   string    `json:"invitee" gorm:"not null;size:255"` // 邀请时填写的用户名或邮箱
+	UserID    *uint     `json:"-" gorm:"index"`                   // 被邀请的用户，没有可以接受邀请的用户时为空
+	Role      TeamRole  `json:"role" gorm:"size:20;not null;default:'member'"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+
+	Team    Team `json:"-" gorm:"foreignKey:TeamID"`
+	Inviter User `json:"-" gorm:"foreignKey:InviterID"`
+}
+
+// TableName 指定表名
+func (TeamInvitation) TableName() string {
+	return "team_invitations"
+}
+
+// TeamInvitationResponse 团队邀请响应
+type TeamInvitationResponse struct {
+	ID              uint      `json:"id"`
+	TeamID          uint      `json:"team_id"`
+	TeamName        string    `json:"team_name"`
+	InviterUsername string    `json:"inviter_username"`
+	Invitee         string    `json:"invitee"`
+	Role            TeamRole  `json:"role"`
+	CreatedAt       time.Time `json:"created_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// ToResponse 转换为响应格式
+func (i *TeamInvitation) ToResponse() *TeamInvitationResponse {
+	return &TeamInvitationResponse{
+		ID:              i.ID,
+		TeamID:          i.TeamID,
+		TeamName:        i.Team.Name,
+		InviterUsername: i.Inviter.Username,
+		Invitee:         i.Invitee,
+		Role:            i.Role,
+		CreatedAt:       i.CreatedAt,
+		ExpiresAt:       i.ExpiresAt,
+	}
+}
+
+// CreateTeamRequest 创建团队请求
+type CreateTeamRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=100"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// UpdateTeamRequest 修改团队请求
+type UpdateTeamRequest struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description,omitempty" binding:"omitempty,max=500"`
+}
+
+// AddTeamMemberRequest 邀请团队成员请求
+type AddTeamMemberRequest struct {
+	Username string `json:"username" binding:"required"` // 用户名或邮箱
+	Role     string `json:"role" binding:"omitempty,oneof=admin member viewer"`
+}
+
+// UpdateTeamMemberRequest 修改团队成员角色请求
+type UpdateTeamMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin member viewer"`
+}
+
+// TeamResponse 团队响应
+type TeamResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	OwnerID     uint      `json:"owner_id"`
+	Role        TeamRole  `json:"role"` // 当前用户在团队中的角色
+	MemberCount int64     `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TeamMemberResponse 团队成员响应
+type TeamMemberResponse struct {
+	UserID      uint      `json:"user_id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Role        TeamRole  `json:"role"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// ToResponse 转换为响应格式
+func (m *TeamMember) ToResponse() *TeamMemberResponse {
+	return &TeamMemberResponse{
+		UserID:      m.UserID,
+		Username:    m.User.Username,
+		DisplayName: m.User.DisplayName,
+		Role:        m.Role,
+		JoinedAt:    m.CreatedAt,
+	}
+}
+
+// TeamDetailResponse 团队详情响应
+type TeamDetailResponse struct {
+	*TeamResponse
+	Members []*TeamMemberResponse `json:"members"`
+}
+
+// 团队相关错误
+var (
+	ErrTeamNotFound         = errors.New("team not found")
+	ErrTeamMemberNotFound   = errors.New("team member not found")
+	ErrTeamPermissionDenied = errors.New("team permission denied")
+	ErrTeamOwnerCannotLeave = errors.New("team owner cannot leave the team")
+
+	ErrTeamInvitationNotFound = errors.New("team invitation not found")
+)
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// UserTOTP 用户的 TOTP 两步验证密钥（RFC 6238）
+// 开启流程：setup 生成密钥（未确认），用验证器中的验证码 enable 后才生效
+type UserTOTP struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID      uint       `json:"user_id" gorm:"uniqueIndex;not null"`
+	Secret      string     `json:"-" gorm:"not null;size:64"` // Base32 编码
+	ConfirmedAt *time.Time `json:"confirmed_at"`              // 为空表示尚未开启
+	LastStep    int64      `json:"-"`                         // 最近一次通过验证的时间步，防止验证码重放
+}
+
+// TableName 指定表名
+func (UserTOTP) TableName() string {
+	return "user_totps"
+}
+
+// IsEnabled 两步验证是否已开启
+func (t *UserTOTP) IsEnabled() bool {
+	return t.ConfirmedAt != nil
+}
+
+// RecoveryCode 一次性恢复码，只保存哈希
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null;size:64"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// TableName 指定表名
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+// LoginChallenge 两步验证挑战：密码验证通过后签发，凭挑战令牌和验证码完成登录
+type LoginChallenge struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	DeviceID  string     `json:"device_id" gorm:"size:100"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	Attempts  int        `json:"attempts" gorm:"default:0"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// TableName 指定表名
+func (LoginChallenge) TableName() string {
+	return "login_challenges"
+}
+
+// TwoFactorTrust 受信任设备：完成两步验证时选择记住设备，有效期内该设备登录跳过两步验证
+// 客户端登录时需携带信任令牌，且令牌只对签发时的设备有效
+type TwoFactorTrust struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	TokenHash string    `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	DeviceID  string    `json:"device_id" gorm:"not null;size:100"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+}
+
+// TableName 指定表名
+func (TwoFactorTrust) TableName() string {
+	return "two_factor_trusts"
+}
+
+// TwoFactorSetupResponse 生成 TOTP 密钥的响应，otpauth_uri 用于生成二维码
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeRequest 提交验证码（TOTP 验证码或恢复码）
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+// DisableTwoFactorRequest 关闭两步验证请求
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
+// RecoveryCodesResponse 恢复码，只在生成时返回一次
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorStatusResponse 两步验证状态
+type TwoFactorStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+	TrustedDevices         int64      `json:"trusted_devices"`
+}
+
+// TwoFactorChallengeResponse 登录需要两步验证时的响应
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`
+}
+
+// VerifyTwoFactorRequest 完成两步验证登录请求
+type VerifyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required,max=32"`
+	RememberDevice bool   `json:"remember_device"` // 记住设备，有效期内跳过两步验证
+}
+
+// 两步验证相关错误
+var (
+	ErrTwoFactorNotSetup       = errors.New("two-factor authentication has not been set up")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrChallengeInvalid        = errors.New("invalid or expired challenge")
+	ErrChallengeAttempts       = errors.New("too many failed attempts")
+)
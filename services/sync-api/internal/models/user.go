@@ -20,10 +20,17 @@ type User struct {
 	DisplayName  string `json:"display_name" gorm:"size:100"`
 	Avatar       string `json:"avatar" gorm:"size:500"`
 
+	// 角色
+	Role string `json:"role" gorm:"size:20;default:'user'"`
+
 	// 状态信息
-	Status    UserStatus `json:"status" gorm:"default:1"`
-	LastLogin *time.Time `json:"last_login"`
-	LoginIP   string     `json:"login_ip" gorm:"size:45"`
+	Status              UserStatus `json:"status" gorm:"default:1"`
+	StatusReason        string     `json:"status_reason" gorm:"size:500"` // 管理员暂停或封禁的原因
+	StatusChangedAt     *time.Time `json:"status_changed_at"`
+	EmailVerifiedAt     *time.Time `json:"email_verified_at"`                         // 为空表示邮箱尚未验证
+	PendingVerification bool       `json:"pending_verification" gorm:"default:false"` // 注册时要求验证邮箱，验证后自动激活
+	LastLogin           *time.Time `json:"last_login"`
+	LoginIP             string     `json:"login_ip" gorm:"size:45"`
 
 	// 设置信息
 	Timezone string `json:"timezone" gorm:"size:50;default:'UTC'"`
@@ -39,10 +46,10 @@ type User struct {
 type UserStatus int
 
 const (
-	UserStatusInactive UserStatus = 0 // 未激活
-	UserStatusActive   UserStatus = 1 // 正常
+	UserStatusInactive  UserStatus = 0 // 未激活
+	UserStatusActive    UserStatus = 1 // 正常
 	UserStatusSuspended UserStatus = 2 // 暂停
-	UserStatusBanned   UserStatus = 3 // 封禁
+	UserStatusBanned    UserStatus = 3 // 封禁
 )
 
 // String 返回用户状态的字符串表示
@@ -61,6 +68,16 @@ func (s UserStatus) String() string {
 	}
 }
 
+// ParseUserStatus 解析用户状态字符串
+func ParseUserStatus(value string) (UserStatus, bool) {
+	for _, status := range []UserStatus{UserStatusInactive, UserStatusActive, UserStatusSuspended, UserStatusBanned} {
+		if status.String() == value {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
 // TableName 指定表名
 func (User) TableName() string {
 	return "users"
@@ -79,6 +96,27 @@ func (u *User) IsActive() bool {
 	return u.Status == UserStatusActive
 }
 
+// IsAdmin 是否为管理员角色
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// HasPermission 用户角色是否具有指定权限
+func (u *User) HasPermission(permission Permission) bool {
+	return RoleHasPermission(u.Role, permission)
+}
+
+// IsEmailVerified 邮箱是否已验证
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsPendingVerification 是否为注册后等待验证邮箱的账户，只有这类账户在验证邮箱后自动激活
+// 管理员或用户主动停用的账户即使邮箱未验证也不属于此类
+func (u *User) IsPendingVerification() bool {
+	return u.Status == UserStatusInactive && u.PendingVerification
+}
+
 // UpdateLastLogin 更新最后登录时间和IP
 func (u *User) UpdateLastLogin(ip string) {
 	now := time.Now()
@@ -94,36 +132,38 @@ type CreateUserRequest struct {
 	DisplayName string `json:"display_name" binding:"max=100"`
 }
 
-
-
 // UserResponse 用户响应
 type UserResponse struct {
-	ID          uint       `json:"id"`
-	Username    string     `json:"username"`
-	Email       string     `json:"email"`
-	DisplayName string     `json:"display_name"`
-	Avatar      string     `json:"avatar"`
-	Status      string     `json:"status"`
-	LastLogin   *time.Time `json:"last_login"`
-	Timezone    string     `json:"timezone"`
-	Language    string     `json:"language"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID            uint       `json:"id"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email"`
+	DisplayName   string     `json:"display_name"`
+	Avatar        string     `json:"avatar"`
+	Role          string     `json:"role"`
+	Status        string     `json:"status"`
+	EmailVerified bool       `json:"email_verified"`
+	LastLogin     *time.Time `json:"last_login"`
+	Timezone      string     `json:"timezone"`
+	Language      string     `json:"language"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 // ToResponse 转换为响应格式
 func (u *User) ToResponse() *UserResponse {
 	return &UserResponse{
-		ID:          u.ID,
-		Username:    u.Username,
-		Email:       u.Email,
-		DisplayName: u.DisplayName,
-		Avatar:      u.Avatar,
-		Status:      u.Status.String(),
-		LastLogin:   u.LastLogin,
-		Timezone:    u.Timezone,
-		Language:    u.Language,
-		CreatedAt:   u.CreatedAt,
-		UpdatedAt:   u.UpdatedAt,
+		ID:            u.ID,
+		Username:      u.Username,
+		Email:         u.Email,
+		DisplayName:   u.DisplayName,
+		Avatar:        u.Avatar,
+		Role:          u.Role,
+		Status:        u.Status.String(),
+		EmailVerified: u.EmailVerifiedAt != nil,
+		LastLogin:     u.LastLogin,
+		Timezone:      u.Timezone,
+		Language:      u.Language,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
 	}
-}
\ No newline at end of file
+}
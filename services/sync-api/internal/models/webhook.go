@@ -0,0 +1,203 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// WebhookEvent 出站 webhook 事件类型
+type WebhookEvent string
+
+const (
+	WebhookEventClipCreated   WebhookEvent = "clip.created"
+	WebhookEventClipUpdated   WebhookEvent = "clip.updated"
+	WebhookEventClipDeleted   WebhookEvent = "clip.deleted"
+	WebhookEventDeviceOnline  WebhookEvent = "device.online"
+	WebhookEventDeviceOffline WebhookEvent = "device.offline"
+	WebhookEventPing          WebhookEvent = "ping" // 手动测试，发送给指定的订阅，不需要订阅
+)
+
+// WebhookEvents 可以订阅的事件
+var WebhookEvents = []WebhookEvent{
+	WebhookEventClipCreated,
+	WebhookEventClipUpdated,
+	WebhookEventClipDeleted,
+	WebhookEventDeviceOnline,
+	WebhookEventDeviceOffline,
+}
+
+// IsValid 是否是可以订阅的事件
+func (e WebhookEvent) IsValid() bool {
+	for _, event := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClipEvent 是否是剪贴板项事件（标签和类型筛选只作用于剪贴板项事件）
+func (e WebhookEvent) IsClipEvent() bool {
+	return e == WebhookEventClipCreated || e == WebhookEventClipUpdated || e == WebhookEventClipDeleted
+}
+
+// Webhook 用户配置的出站 webhook 订阅
+// 密钥用于对请求签名，需要保存明文；只在创建和轮换时返回
+type Webhook struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID uint   `json:"user_id" gorm:"not null;index"`
+	Name   string `json:"name" gorm:"size:100"`
+	URL    string `json:"url" gorm:"not null;size:2048"`
+	Secret string `json:"-" gorm:"not null;size:64"`
+	Active bool   `json:"active" gorm:"default:true"`
+
+	// 事件筛选：Events 必填；其余为空表示不限制，多个值之间为“或”
+	Events    []WebhookEvent `json:"events" gorm:"type:text;serializer:json"`
+	Tags      []string       `json:"tags" gorm:"type:text;serializer:json"`       // 剪贴板项包含任一标签
+	ClipTypes []string       `json:"clip_types" gorm:"type:text;serializer:json"` // 剪贴板项类型
+	DeviceIDs []string       `json:"device_ids" gorm:"type:text;serializer:json"` // 产生事件的设备
+
+	LastDeliveryAt *time.Time `json:"last_delivery_at"`
+	FailureCount   int        `json:"failure_count" gorm:"default:0"` // 连续投递失败次数，成功后清零
+}
+
+// TableName 指定表名
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Subscribes 是否订阅了事件
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus 投递状态
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"   // 等待投递或重试
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded" // 收到 2xx 响应
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"    // 重试次数用完
+)
+
+// WebhookDelivery 投递记录，保存请求内容和最后一次尝试的结果
+type WebhookDelivery struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	WebhookID     uint                  `json:"webhook_id" gorm:"not null;index"`
+	EventID       string                `json:"event_id" gorm:"size:36;index"` // 同一事件的重新投递共用事件ID，接收方可据此去重
+	Event         WebhookEvent          `json:"event" gorm:"size:50;not null"`
+	Payload       string                `json:"payload" gorm:"type:text"`
+	Status        WebhookDeliveryStatus `json:"status" gorm:"size:20;not null;index"`
+	Attempts      int                   `json:"attempts" gorm:"default:0"`
+	NextAttemptAt *time.Time            `json:"next_attempt_at" gorm:"index"`
+	RedeliveryOf  *uint                 `json:"redelivery_of"` // 手动重新投递时指向原投递记录
+
+	ResponseStatus int        `json:"response_status"`
+	ResponseBody   string     `json:"response_body" gorm:"type:text"` // 截断保存
+	Error          string     `json:"error" gorm:"size:500"`
+	DurationMs     int64      `json:"duration_ms"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookPayload 投递的请求体
+type WebhookPayload struct {
+	ID        string       `json:"id"` // 事件ID
+	Event     WebhookEvent `json:"event"`
+	CreatedAt time.Time    `json:"created_at"`
+	Data      WebhookData  `json:"data"`
+}
+
+// WebhookData 事件数据，剪贴板项事件包含 clip，设备事件包含 device
+type WebhookData struct {
+	Clip    *ClipItemResponse `json:"clip,omitempty"`
+	Device  *DeviceResponse   `json:"device,omitempty"`
+	Webhook *WebhookResponse  `json:"webhook,omitempty"` // ping 事件
+}
+
+// CreateWebhookRequest 创建 webhook 请求
+type CreateWebhookRequest struct {
+	Name      string         `json:"name" binding:"max=100"`
+	URL       string         `json:"url" binding:"required,url,max=2048"`
+	Events    []WebhookEvent `json:"events" binding:"required,min=1"`
+	Tags      []string       `json:"tags,omitempty"`
+	ClipTypes []string       `json:"clip_types,omitempty" binding:"omitempty,dive,oneof=text image file url"`
+	DeviceIDs []string       `json:"device_ids,omitempty"`
+	Active    *bool          `json:"active,omitempty"` // 默认启用
+}
+
+// UpdateWebhookRequest 修改 webhook 请求，未提供的字段保持不变
+type UpdateWebhookRequest struct {
+	Name      *string        `json:"name,omitempty" binding:"omitempty,max=100"`
+	URL       *string        `json:"url,omitempty" binding:"omitempty,url,max=2048"`
+	Events    []WebhookEvent `json:"events,omitempty" binding:"omitempty,min=1"`
+	Tags      *[]string      `json:"tags,omitempty"`
+	ClipTypes *[]string      `json:"clip_types,omitempty" binding:"omitempty,dive,oneof=text image file url"`
+	DeviceIDs *[]string      `json:"device_ids,omitempty"`
+	Active    *bool          `json:"active,omitempty"`
+}
+
+// WebhookResponse webhook 响应，Secret 只在创建和轮换密钥时返回
+type WebhookResponse struct {
+	ID             uint           `json:"id"`
+	Name           string         `json:"name"`
+	URL            string         `json:"url"`
+	Secret         string         `json:"secret,omitempty"`
+	Active         bool           `json:"active"`
+	Events         []WebhookEvent `json:"events"`
+	Tags           []string       `json:"tags"`
+	ClipTypes      []string       `json:"clip_types"`
+	DeviceIDs      []string       `json:"device_ids"`
+	LastDeliveryAt *time.Time     `json:"last_delivery_at"`
+	FailureCount   int            `json:"failure_count"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// ToResponse 转换为响应格式（不包含密钥）
+func (w *Webhook) ToResponse() *WebhookResponse {
+	return &WebhookResponse{
+		ID:             w.ID,
+		Name:           w.Name,
+		URL:            w.URL,
+		Active:         w.Active,
+		Events:         w.Events,
+		Tags:           w.Tags,
+		ClipTypes:      w.ClipTypes,
+		DeviceIDs:      w.DeviceIDs,
+		LastDeliveryAt: w.LastDeliveryAt,
+		FailureCount:   w.FailureCount,
+		CreatedAt:      w.CreatedAt,
+		UpdatedAt:      w.UpdatedAt,
+	}
+}
+
+// WebhookDeliveryFilter 投递记录筛选条件
+type WebhookDeliveryFilter struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+	Event  string `form:"event"`
+}
+
+// webhook 相关错误
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookEventInvalid     = errors.New("invalid webhook event")
+	ErrWebhookURLInvalid       = errors.New("webhook URL must use http or https")
+	ErrWebhookLimitReached     = errors.New("webhook limit reached")
+)
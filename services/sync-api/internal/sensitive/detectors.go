@@ -0,0 +1,220 @@
+package sensitive
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// 识别器名称
+const (
+	DetectorCard     = "card"
+	DetectorCloudKey = "cloud_key"
+	DetectorJWT      = "jwt"
+	DetectorPEM      = "pem"
+	DetectorEntropy  = "entropy"
+)
+
+var (
+	cardPattern    = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	jwtPattern     = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*`)
+	pemPattern     = regexp.MustCompile(`(?s)-----BEGIN ([A-Z0-9 ]+)-----.*?-----END ([A-Z0-9 ]+)-----`)
+	entropyPattern = regexp.MustCompile(`[A-Za-z0-9+/=_-]+`)
+)
+
+// cloudKeyPatterns 常见云服务和 SaaS 平台的密钥格式
+var cloudKeyPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"aws_access_key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"github_token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{82})\b`)},
+	{"gitlab_token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20}\b`)},
+	{"slack_token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"stripe_key", regexp.MustCompile(`\b[sr]k_live_[A-Za-z0-9]{24,}\b`)},
+	{"azure_storage_key", regexp.MustCompile(`AccountKey=[A-Za-z0-9+/]{86}==`)},
+}
+
+// CardDetector 通过 Luhn 校验且发卡行前缀有效的银行卡号，允许空格和连字符分隔
+type CardDetector struct{}
+
+// Name 识别器名称
+func (CardDetector) Name() string { return DetectorCard }
+
+// Detect 识别银行卡号
+func (CardDetector) Detect(content string) []Finding {
+	var findings []Finding
+	for _, loc := range cardPattern.FindAllStringIndex(content, -1) {
+		digits := make([]byte, 0, 19)
+		for i := loc[0]; i < loc[1]; i++ {
+			if c := content[i]; c >= '0' && c <= '9' {
+				digits = append(digits, c)
+			}
+		}
+		if len(digits) >= 13 && len(digits) <= 19 && validCardPrefix(digits) && luhnValid(digits) {
+			findings = append(findings, Finding{Kind: "credit_card", Start: loc[0], End: loc[1]})
+		}
+	}
+	return findings
+}
+
+// validCardPrefix 常见卡组织的号段，排除时间戳等恰好通过 Luhn 校验的数字
+func validCardPrefix(digits []byte) bool {
+	prefix := string(digits[:2])
+	switch {
+	case digits[0] == '4': // Visa
+		return true
+	case prefix >= "51" && prefix <= "55", prefix >= "22" && prefix <= "27": // Mastercard
+		return true
+	case prefix == "34" || prefix == "37": // American Express
+		return len(digits) == 15
+	case prefix == "30" || prefix == "36" || prefix == "38" || prefix == "35": // Diners Club、JCB
+		return true
+	case digits[0] == '6': // Discover、银联
+		return true
+	}
+	return false
+}
+
+// luhnValid Luhn 校验
+func luhnValid(digits []byte) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// CloudKeyDetector AWS、Google、GitHub、GitLab、Slack、Stripe、Azure 等平台的密钥
+type CloudKeyDetector struct{}
+
+// Name 识别器名称
+func (CloudKeyDetector) Name() string { return DetectorCloudKey }
+
+// Detect 识别云服务密钥
+func (CloudKeyDetector) Detect(content string) []Finding {
+	var findings []Finding
+	for _, p := range cloudKeyPatterns {
+		for _, loc := range p.pattern.FindAllStringIndex(content, -1) {
+			findings = append(findings, Finding{Kind: p.kind, Start: loc[0], End: loc[1]})
+		}
+	}
+	return findings
+}
+
+// JWTDetector 头部可以解码为包含 alg 字段的 JSON 的 JWT
+type JWTDetector struct{}
+
+// Name 识别器名称
+func (JWTDetector) Name() string { return DetectorJWT }
+
+// Detect 识别 JWT
+func (JWTDetector) Detect(content string) []Finding {
+	var findings []Finding
+	for _, loc := range jwtPattern.FindAllStringIndex(content, -1) {
+		header, _, _ := strings.Cut(content[loc[0]:loc[1]], ".")
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(header, "="))
+		if err != nil {
+			continue
+		}
+		var fields map[string]interface{}
+		if json.Unmarshal(data, &fields) != nil || fields["alg"] == nil {
+			continue
+		}
+		findings = append(findings, Finding{Kind: "jwt", Start: loc[0], End: loc[1]})
+	}
+	return findings
+}
+
+// PEMDetector PEM 块，私钥的类别为 private_key，证书等其它块为 pem
+type PEMDetector struct{}
+
+// Name 识别器名称
+func (PEMDetector) Name() string { return DetectorPEM }
+
+// Detect 识别 PEM 块
+func (PEMDetector) Detect(content string) []Finding {
+	var findings []Finding
+	for _, m := range pemPattern.FindAllStringSubmatchIndex(content, -1) {
+		begin, end := content[m[2]:m[3]], content[m[4]:m[5]]
+		if begin != end {
+			continue
+		}
+		kind := "pem"
+		if strings.Contains(begin, "PRIVATE KEY") {
+			kind = "private_key"
+		}
+		findings = append(findings, Finding{Kind: kind, Start: m[0], End: m[1]})
+	}
+	return findings
+}
+
+// EntropyDetector 高熵字符串（疑似密码、令牌），只检查包含至少两类字符（大写、小写、数字）的长单词
+// 纯十六进制字符串的熵不超过 4，默认阈值下不会被识别，避免把哈希值和提交 ID 当作密钥
+type EntropyDetector struct {
+	MinLength int     // 最短长度
+	Threshold float64 // 每个字符的香农熵阈值（比特）
+}
+
+// Name 识别器名称
+func (*EntropyDetector) Name() string { return DetectorEntropy }
+
+// Detect 识别高熵字符串
+func (d *EntropyDetector) Detect(content string) []Finding {
+	var findings []Finding
+	for _, loc := range entropyPattern.FindAllStringIndex(content, -1) {
+		token := content[loc[0]:loc[1]]
+		if len(token) < d.MinLength || charClasses(token) < 2 {
+			continue
+		}
+		if shannonEntropy(token) >= d.Threshold {
+			findings = append(findings, Finding{Kind: "high_entropy", Start: loc[0], End: loc[1]})
+		}
+	}
+	return findings
+}
+
+// charClasses 包含的字符类别数（大写、小写、数字）
+func charClasses(s string) int {
+	var upper, lower, digit int
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c >= 'A' && c <= 'Z':
+			upper = 1
+		case c >= 'a' && c <= 'z':
+			lower = 1
+		case c >= '0' && c <= '9':
+			digit = 1
+		}
+	}
+	return upper + lower + digit
+}
+
+// shannonEntropy 每个字符的香农熵
+func shannonEntropy(s string) float64 {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(len(s))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
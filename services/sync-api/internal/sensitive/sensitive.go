@@ -0,0 +1,154 @@
+// Package sensitive 识别剪贴板内容中的敏感数据（银行卡号、云服务密钥、JWT、PEM 私钥和高熵字符串）
+//
+// 识别由一组 Detector 组成的 Pipeline 完成，可以注册自定义的识别器；结果只包含位置和类别，不保存匹配到的内容
+package sensitive
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxScanSize 只扫描内容的开头部分，避免超长文本拖慢请求
+const maxScanSize = 1 << 20
+
+// Finding 一处敏感数据，Start、End 为内容中的字节偏移
+type Finding struct {
+	Detector string `json:"detector"`
+	Kind     string `json:"kind"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+// Detector 敏感数据识别器
+type Detector interface {
+	// Name 识别器名称，用户设置中按名称启用
+	Name() string
+	// Detect 返回内容中的敏感数据，不需要处理与其它识别器结果的重叠
+	Detect(content string) []Finding
+}
+
+// Pipeline 按注册顺序执行识别器，先注册的识别器结果优先，与之重叠的后续结果被丢弃
+type Pipeline struct {
+	detectors []Detector
+}
+
+// NewPipeline 创建识别流水线
+func NewPipeline(detectors ...Detector) *Pipeline {
+	return &Pipeline{detectors: detectors}
+}
+
+// Default 内置识别器组成的流水线，高熵字符串最后执行，避免重复报告已识别的密钥
+func Default() *Pipeline {
+	return NewPipeline(
+		PEMDetector{},
+		JWTDetector{},
+		CloudKeyDetector{},
+		CardDetector{},
+		&EntropyDetector{MinLength: 20, Threshold: 4.0},
+	)
+}
+
+// Register 注册识别器
+func (p *Pipeline) Register(detector Detector) {
+	p.detectors = append(p.detectors, detector)
+}
+
+// Names 已注册的识别器名称
+func (p *Pipeline) Names() []string {
+	names := make([]string, 0, len(p.detectors))
+	for _, detector := range p.detectors {
+		names = append(names, detector.Name())
+	}
+	return names
+}
+
+// Scan 执行识别器，enabled 为空时执行所有识别器，否则只执行名称在其中的识别器
+// 结果按位置排序且互不重叠
+func (p *Pipeline) Scan(content string, enabled []string) []Finding {
+	if len(content) > maxScanSize {
+		content = content[:maxScanSize]
+	}
+
+	var findings []Finding
+	for _, detector := range p.detectors {
+		if len(enabled) > 0 && !contains(enabled, detector.Name()) {
+			continue
+		}
+		for _, finding := range detector.Detect(content) {
+			finding.Detector = detector.Name()
+			if !overlaps(findings, finding) {
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Start < findings[j].Start })
+	return findings
+}
+
+// Kinds 去重后的敏感数据类别
+func Kinds(findings []Finding) []string {
+	var kinds []string
+	for _, finding := range findings {
+		if !contains(kinds, finding.Kind) {
+			kinds = append(kinds, finding.Kind)
+		}
+	}
+	return kinds
+}
+
+// Mask 遮盖内容中的敏感数据：较长的值保留最后 4 个字符，其余替换为 •
+func Mask(content string, findings []Finding) string {
+	var b strings.Builder
+	last := 0
+	for _, finding := range findings {
+		if finding.Start < last || finding.End > len(content) || finding.Start >= finding.End {
+			continue
+		}
+		b.WriteString(content[last:finding.Start])
+		b.WriteString(maskValue(content[finding.Start:finding.End]))
+		last = finding.End
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// maskValue 遮盖单个值，保留换行以维持原有的行结构（如 PEM 块）
+func maskValue(value string) string {
+	keep := 0
+	if len(value) >= 12 {
+		keep = 4
+	}
+	var b strings.Builder
+	for i, r := range value {
+		switch {
+		case r == '\n' || r == '\r':
+			b.WriteRune(r)
+		case i >= len(value)-keep:
+			b.WriteRune(r)
+		default:
+			b.WriteString("•")
+		}
+	}
+	return b.String()
+}
+
+// overlaps 是否与已有结果重叠
+func overlaps(findings []Finding, finding Finding) bool {
+	for _, existing := range findings {
+		if finding.Start < existing.End && existing.Start < finding.End {
+			return true
+		}
+	}
+	return false
+}
+
+// contains 字符串切片是否包含指定值
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
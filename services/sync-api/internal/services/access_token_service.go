@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/models"
+)
+
+const (
+	accessTokenSize        = 32 // 令牌随机字节数
+	accessTokenPrefixLen   = 12 // 保存用于识别的令牌开头长度（含前缀）
+	maxAccessTokensPerUser = 50
+)
+
+// AccessTokenService 个人访问令牌服务
+type AccessTokenService struct {
+	db *gorm.DB
+}
+
+// NewAccessTokenService 创建个人访问令牌服务
+func NewAccessTokenService(db *gorm.DB) *AccessTokenService {
+	return &AccessTokenService{db: db}
+}
+
+// CreateToken 创建个人访问令牌，返回令牌记录和明文令牌
+func (s *AccessTokenService) CreateToken(userID uint, req *models.CreateAccessTokenRequest) (*models.PersonalAccessToken, string, error) {
+	var count int64
+	if err := s.db.Model(&models.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).Count(&count).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to count access tokens: %w", err)
+	}
+	if count >= maxAccessTokensPerUser {
+		return nil, "", models.ErrTooManyAccessTokens
+	}
+
+	secret, err := randomToken(accessTokenSize)
+	if err != nil {
+		return nil, "", err
+	}
+	plain := models.AccessTokenPrefix + secret
+
+	token := &models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		TokenHash: hashToken(plain),
+		Prefix:    plain[:accessTokenPrefixLen],
+		Scopes:    strings.Join(uniqueScopes(req.Scopes), " "),
+	}
+	if req.ExpiresInDays != nil {
+		expiresAt := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.db.Create(token).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	return token, plain, nil
+}
+
+// ListTokens 列出用户未撤销的个人访问令牌
+func (s *AccessTokenService) ListTokens(userID uint) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	if err := s.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, fmt.Errorf("failed to get access tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeToken 撤销个人访问令牌
+func (s *AccessTokenService) RevokeToken(userID uint, tokenID uint) error {
+	result := s.db.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke access token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+// ValidateToken 校验明文令牌：存在、未撤销、未过期；校验通过时按间隔记录最后使用时间和IP
+func (s *AccessTokenService) ValidateToken(plain string, clientIP string) (*models.PersonalAccessToken, error) {
+	if !strings.HasPrefix(plain, models.AccessTokenPrefix) {
+		return nil, models.ErrInvalidToken
+	}
+
+	var token models.PersonalAccessToken
+	if err := s.db.Where("token_hash = ?", hashToken(plain)).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	if token.IsRevoked() {
+		return nil, models.ErrAccessTokenRevoked
+	}
+	if token.IsExpired() {
+		return nil, models.ErrAccessTokenExpired
+	}
+
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > sessionTouchInterval || token.LastUsedIP != clientIP {
+		if err := s.db.Model(&token).Updates(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": clientIP,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("failed to update access token: %w", err)
+		}
+	}
+
+	return &token, nil
+}
+
+// uniqueScopes 去除重复的权限，保持请求中的顺序
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/models"
+)
+
+// AdminService 管理员用户管理服务，所有变更操作记录到审计日志
+type AdminService struct {
+	db     *gorm.DB
+	audit  *AuditService
+	users  *UserService
+	tokens *TokenService
+	email  *EmailService
+	guard  *LoginGuardService
+}
+
+// NewAdminService 创建用户管理服务
+func NewAdminService(db *gorm.DB, audit *AuditService, users *UserService, tokens *TokenService, email *EmailService, guard *LoginGuardService) *AdminService {
+	return &AdminService{
+		db:     db,
+		audit:  audit,
+		users:  users,
+		tokens: tokens,
+		email:  email,
+		guard:  guard,
+	}
+}
+
+// AdminUserDetail 用户详情：账户信息、存储用量和有效会话数
+type AdminUserDetail struct {
+	User           *models.AdminUserResponse `json:"user"`
+	Stats          *UserStats                `json:"stats"`
+	ActiveSessions int64                     `json:"active_sessions"`
+	Locked         bool                      `json:"locked"` // 是否因登录失败处于退避或锁定中
+}
+
+// ListUsers 按关键词、状态和角色筛选用户，按注册时间倒序
+func (s *AdminService) ListUsers(filter *models.AdminUserFilter, params *models.PaginationParams) ([]*models.User, *models.PaginationResponse, error) {
+	query := s.db.Model(&models.User{})
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("username LIKE ? OR email LIKE ? OR display_name LIKE ?", like, like, like)
+	}
+	if filter.Status != "" {
+		status, ok := models.ParseUserStatus(filter.Status)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid status %q", filter.Status)
+		}
+		query = query.Where("status = ?", status)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	var users []*models.User
+	if err := query.Order("created_at DESC").Offset(params.GetOffset()).Limit(params.GetLimit()).Find(&users).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	pagination := &models.PaginationResponse{
+		Page:     params.Page,
+		PageSize: params.PageSize,
+		Total:    total,
+	}
+	pagination.CalculateTotalPages()
+
+	return users, pagination, nil
+}
+
+// GetUserDetail 获取用户详情
+func (s *AdminService) GetUserDetail(userID uint) (*AdminUserDetail, error) {
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.users.GetUserStats(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions int64
+	if err := s.db.Model(&models.AuthSession{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Count(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	detail := &AdminUserDetail{
+		User:           user.ToAdminResponse(),
+		Stats:          stats,
+		ActiveSessions: sessions,
+	}
+	if s.guard != nil {
+		detail.Locked = s.guard.CheckAccount(userID) != nil
+	}
+	return detail, nil
+}
+
+// GetUserDevices 获取用户的所有设备
+func (s *AdminService) GetUserDevices(userID uint) ([]*models.Device, error) {
+	if _, err := s.users.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+
+	var devices []*models.Device
+	if err := s.db.Where("user_id = ?", userID).Order("last_seen DESC").Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get devices: %w", err)
+	}
+	return devices, nil
+}
+
+// SetStatus 暂停、封禁或恢复用户；暂停和封禁需要原因，并立即撤销用户的所有会话
+func (s *AdminService) SetStatus(userID uint, status models.UserStatus, reason string, actorID uint, actorIP string) (*models.User, error) {
+	if userID == actorID {
+		return nil, models.ErrCannotManageSelf
+	}
+	reason = strings.TrimSpace(reason)
+	if status != models.UserStatusActive && reason == "" {
+		return nil, models.ErrReasonRequired
+	}
+
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	previous := user.Status
+	// 管理员设置的状态优先，账户不再因验证邮箱而自动激活
+	if err := s.db.Model(user).Updates(map[string]interface{}{
+		"status":               status,
+		"status_reason":        reason,
+		"status_changed_at":    now,
+		"pending_verification": false,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update user status: %w", err)
+	}
+
+	var event string
+	switch status {
+	case models.UserStatusSuspended:
+		event = models.AuditUserSuspended
+	case models.UserStatusBanned:
+		event = models.AuditUserBanned
+	default:
+		event = models.AuditUserActivated
+	}
+
+	if status != models.UserStatusActive && s.tokens != nil {
+		if _, err := s.tokens.RevokeUserSessions(userID, models.RevokeReasonAdmin); err != nil {
+			return nil, err
+		}
+	}
+
+	detail := fmt.Sprintf("%s -> %s", previous, status)
+	if reason != "" {
+		detail += ": " + reason
+	}
+	s.record(userID, actorID, event, actorIP, detail)
+	return user, nil
+}
+
+// ForceLogout 撤销用户的所有会话并断开实时连接，返回撤销的会话数
+func (s *AdminService) ForceLogout(userID uint, actorID uint, actorIP string) (int64, error) {
+	if _, err := s.users.GetUserByID(userID); err != nil {
+		return 0, err
+	}
+
+	revoked, err := s.tokens.RevokeUserSessions(userID, models.RevokeReasonAdmin)
+	if err != nil {
+		return 0, err
+	}
+
+	s.record(userID, actorID, models.AuditUserForceLogout, actorIP, fmt.Sprintf("%d sessions revoked", revoked))
+	return revoked, nil
+}
+
+// ResetPassword 重置用户密码：指定新密码时直接设置，撤销所有会话并清除登录锁定；否则向用户发送密码重置邮件
+// 返回是否发送了重置邮件
+func (s *AdminService) ResetPassword(userID uint, newPassword string, actorID uint, actorIP string) (bool, error) {
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return false, err
+	}
+
+	if newPassword == "" {
+		if s.email == nil {
+			return false, errors.New("email service is not configured")
+		}
+		if err := s.email.RequestPasswordReset(user.Email, actorIP, ""); err != nil {
+			return false, err
+		}
+		s.record(userID, actorID, models.AuditAdminPasswordReset, actorIP, "reset email sent")
+		return true, nil
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash password: %w", err)
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password_hash", string(hashedPassword)).Error; err != nil {
+			return fmt.Errorf("failed to update password: %w", err)
+		}
+		// 未使用的重置令牌一并作废
+		return tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, models.EmailTokenResetPassword).
+			Delete(&models.EmailToken{}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := s.tokens.RevokeUserSessions(userID, models.RevokeReasonPasswordReset); err != nil {
+		return false, err
+	}
+	if s.guard != nil {
+		if err := s.guard.RecordSuccess(userID); err != nil {
+			return false, err
+		}
+	}
+
+	s.record(userID, actorID, models.AuditAdminPasswordReset, actorIP, "password set by admin")
+	return false, nil
+}
+
+// ListAuditLogs 分页查询审计日志，按时间倒序
+func (s *AdminService) ListAuditLogs(filter *models.AuditLogFilter, params *models.PaginationParams) ([]*models.AuditLog, *models.PaginationResponse, error) {
+	query := s.db.Model(&models.AuditLog{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	var logs []*models.AuditLog
+	if err := query.Order("id DESC").Offset(params.GetOffset()).Limit(params.GetLimit()).Find(&logs).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get audit logs: %w", err)
+	}
+
+	pagination := &models.PaginationResponse{
+		Page:     params.Page,
+		PageSize: params.PageSize,
+		Total:    total,
+	}
+	pagination.CalculateTotalPages()
+
+	return logs, pagination, nil
+}
+
+// record 写入管理操作审计日志
+func (s *AdminService) record(userID, actorID uint, event, actorIP, detail string) {
+	if s.audit == nil {
+		return
+	}
+	_ = s.audit.Record(&models.AuditLog{
+		UserID:  &userID,
+		ActorID: &actorID,
+		Event:   event,
+		IP:      actorIP,
+		Detail:  truncate(detail, 500),
+	})
+}
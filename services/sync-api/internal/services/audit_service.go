@@ -0,0 +1,33 @@
+package services
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/models"
+)
+
+// AuditService 安全审计日志服务
+type AuditService struct {
+	db *gorm.DB
+}
+
+// NewAuditService 创建审计日志服务
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Record 写入一条审计日志
+func (s *AuditService) Record(entry *models.AuditLog) error {
+	if len(entry.UserAgent) > 500 {
+		entry.UserAgent = entry.UserAgent[:500]
+	}
+	if len(entry.Username) > 255 {
+		entry.Username = entry.Username[:255]
+	}
+	if err := s.db.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
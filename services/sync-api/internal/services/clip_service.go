@@ -4,17 +4,33 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
 	"gorm.io/gorm"
 
+	"xpaste-sync/internal/classify"
 	"xpaste-sync/internal/models"
 )
 
 // ClipService 剪贴板服务
 type ClipService struct {
 	db *gorm.DB
+
+	// readableSince 受限设备只能访问该时间之后创建的剪贴板项，nil 表示不限制
+	readableSince *time.Time
+
+	// platform 请求设备的平台，排除了该平台的敏感剪贴板项不可访问，空表示不限制
+	platform models.DevicePlatform
+
+	notifier Notifier // 向团队成员的设备推送团队剪贴板变化
+
+	webhooks *WebhookService // 向用户配置的 webhook 发布剪贴板项事件
+
+	rules *RuleService // 新建剪贴板项时执行用户的规则
+
+	sensitive *SensitiveService // 新建剪贴板项时识别敏感数据
 }
 
 // NewClipService 创建剪贴板服务
@@ -22,11 +38,126 @@ func NewClipService(db *gorm.DB) *ClipService {
 	return &ClipService{db: db}
 }
 
+// WithReadableSince 返回只能访问指定时间之后创建的剪贴板项的服务副本（按设备信任级别限制）
+func (s *ClipService) WithReadableSince(since *time.Time) *ClipService {
+	clone := *s
+	clone.readableSince = since
+	return &clone
+}
+
+// WithPlatform 返回按设备平台排除敏感剪贴板项的服务副本
+func (s *ClipService) WithPlatform(platform models.DevicePlatform) *ClipService {
+	clone := *s
+	clone.platform = platform
+	return &clone
+}
+
+// scoped 按设备信任级别和平台限制可访问的剪贴板项
+func (s *ClipService) scoped(query *gorm.DB) *gorm.DB {
+	if s.readableSince != nil {
+		query = query.Where("created_at >= ?", *s.readableSince)
+	}
+	if s.platform != "" {
+		query = query.Where("excluded_platforms IS NULL OR excluded_platforms NOT LIKE ?", `%"`+string(s.platform)+`"%`)
+	}
+	return query
+}
+
+// personal 只包含用户的个人剪贴板项
+func (s *ClipService) personal(query *gorm.DB, userID uint) *gorm.DB {
+	return query.Where("user_id = ? AND scope = ?", userID, models.ClipScopeUser)
+}
+
+// visible 包含用户的个人剪贴板项和所在团队的剪贴板项
+func (s *ClipService) visible(query *gorm.DB, userID uint) *gorm.DB {
+	return query.Where("(scope = ? AND user_id = ?) OR (scope = ? AND team_id IN (?))",
+		models.ClipScopeUser, userID, models.ClipScopeTeam, memberTeamIDs(s.db, userID))
+}
+
+// owned 按归属范围筛选剪贴板项：user 个人（默认）、team 所在团队、all 两者；指定团队时必须是团队成员
+func (s *ClipService) owned(query *gorm.DB, userID uint, scope string, teamID *uint) (*gorm.DB, error) {
+	if teamID != nil {
+		if _, err := teamRole(s.db, *teamID, userID); err != nil {
+			return nil, err
+		}
+		return query.Where("scope = ? AND team_id = ?", models.ClipScopeTeam, *teamID), nil
+	}
+
+	switch scope {
+	case "", string(models.ClipScopeUser):
+		return s.personal(query, userID), nil
+	case string(models.ClipScopeTeam):
+		return query.Where("scope = ? AND team_id IN (?)", models.ClipScopeTeam, memberTeamIDs(s.db, userID)), nil
+	case ClipScopeAll:
+		return s.visible(query, userID), nil
+	default:
+		return nil, ErrInvalidClipScope
+	}
+}
+
+// findVisible 获取用户可访问的剪贴板项，团队剪贴板项同时返回用户在团队中的角色
+func (s *ClipService) findVisible(userID uint, clipID uint) (*models.ClipItem, models.TeamRole, error) {
+	var clipItem models.ClipItem
+	if err := s.scoped(s.visible(s.db.Where("id = ?", clipID), userID)).First(&clipItem).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", models.ErrClipItemNotFound
+		}
+		return nil, "", fmt.Errorf("database error: %w", err)
+	}
+
+	if clipItem.Scope != models.ClipScopeTeam || clipItem.TeamID == nil {
+		return &clipItem, "", nil
+	}
+	role, err := teamRole(s.db, *clipItem.TeamID, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrTeamNotFound) {
+			return nil, "", models.ErrClipItemNotFound
+		}
+		return nil, "", err
+	}
+	return &clipItem, role, nil
+}
+
+// findModifiable 获取用户可以修改或删除的剪贴板项
+// 个人剪贴板项只能由所有者处理，团队剪贴板项按成员角色判断
+func (s *ClipService) findModifiable(userID uint, clipID uint) (*models.ClipItem, error) {
+	clipItem, role, err := s.findVisible(userID, clipID)
+	if err != nil {
+		return nil, err
+	}
+	if clipItem.Scope == models.ClipScopeTeam && !role.CanDelete(clipItem.UserID == userID) {
+		return nil, models.ErrTeamPermissionDenied
+	}
+	return clipItem, nil
+}
+
+// publish 向团队所有成员的在线设备推送团队剪贴板变化，个人剪贴板项不推送
+func (s *ClipService) publish(clipItem *models.ClipItem, event string, excludeDeviceID string, data interface{}) {
+	if s.notifier == nil || clipItem.Scope != models.ClipScopeTeam || clipItem.TeamID == nil {
+		return
+	}
+	userIDs, err := teamMemberIDs(s.db, *clipItem.TeamID)
+	if err != nil {
+		log.Printf("Failed to fan out team clip %d: %v", clipItem.ID, err)
+		return
+	}
+	s.notifier.NotifyUsers(userIDs, excludeDeviceID, event, data)
+}
+
+// emit 向发布者的 webhook 订阅发布剪贴板项事件
+func (s *ClipService) emit(event models.WebhookEvent, clipItem *models.ClipItem) {
+	if s.webhooks == nil {
+		return
+	}
+	s.webhooks.PublishClipEvent(event, clipItem)
+}
+
 // CreateClipItem 创建剪贴板项
 func (s *ClipService) CreateClipItem(userID uint, req *models.CreateClipRequest) (*models.ClipItem, error) {
 	// 创建新的剪贴板项
 	clipItem := &models.ClipItem{
 		UserID:      userID,
+		Scope:       models.ClipScopeUser,
 		DeviceID:    req.DeviceID,
 		Type:        models.ClipType(req.Type),
 		Content:     req.Content,
@@ -37,26 +168,74 @@ func (s *ClipService) CreateClipItem(userID uint, req *models.CreateClipRequest)
 		Status:      models.ClipStatusActive,
 	}
 
+	// 发布到团队剪贴板需要成员及以上角色
+	if req.TeamID != nil {
+		role, err := teamRole(s.db, *req.TeamID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !role.CanPost() {
+			return nil, models.ErrTeamPermissionDenied
+		}
+		clipItem.Scope = models.ClipScopeTeam
+		clipItem.TeamID = req.TeamID
+	}
+
 	// 设置过期时间
 	if req.ExpiresAt != nil {
 		clipItem.ExpiresAt = req.ExpiresAt
 	}
 
+	// 执行用户的规则，可能修改标签、标题、过期时间或丢弃剪贴板项
+	var ruleResult *models.RuleResult
+	if s.rules != nil {
+		result, err := s.rules.Apply(userID, clipItem)
+		if err != nil {
+			return nil, err
+		}
+		ruleResult = result
+		if ruleResult.Dropped {
+			s.rules.Notify(userID, ruleResult, clipItem)
+			return nil, models.ErrClipDroppedByRule
+		}
+	}
+
+	// 识别内容子类型，客户端可以通过 metadata.language、metadata.file_name 提示代码的语言
+	if clipItem.Type == models.ClipTypeText || clipItem.Type == models.ClipTypeURL {
+		hint, _ := clipItem.Metadata["language"].(string)
+		fileName, _ := clipItem.Metadata["file_name"].(string)
+		if classification := classify.Classify(clipItem.Content, hint, fileName); classification != nil {
+			clipItem.Subtype = classification.Subtype
+			clipItem.Classification = classification
+		}
+	}
+
+	// 识别敏感数据，按用户设置标记、遮盖、缩短过期时间和排除同步平台
+	if s.sensitive != nil {
+		if err := s.sensitive.Inspect(userID, clipItem); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.db.Create(clipItem).Error; err != nil {
 		return nil, fmt.Errorf("failed to create clip item: %w", err)
 	}
 
+	if ruleResult != nil {
+		s.rules.Notify(userID, ruleResult, clipItem)
+	}
+
+	s.publish(clipItem, EventClipNew, req.DeviceID, MaskClipResponse(clipItem))
+	s.emit(models.WebhookEventClipCreated, clipItem)
+
 	return clipItem, nil
 }
 
-// GetClipItem 根据ID获取剪贴板项
+// GetClipItem 根据ID获取剪贴板项，包括用户所在团队的剪贴板项
 func (s *ClipService) GetClipItem(userID uint, clipID uint) (*models.ClipItem, error) {
-	var clipItem models.ClipItem
-	if err := s.db.Where("id = ? AND user_id = ?", clipID, userID).First(&clipItem).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, models.ErrClipItemNotFound
-		}
-		return nil, fmt.Errorf("database error: %w", err)
+	clipItem, _, err := s.findVisible(userID, clipID)
+	if err != nil {
+		return nil, err
 	}
 
 	// 检查是否过期
@@ -66,24 +245,39 @@ func (s *ClipService) GetClipItem(userID uint, clipID uint) (*models.ClipItem, e
 
 	// 增加查看次数
 	clipItem.ViewCount++
-	s.db.Save(&clipItem)
+	s.db.Save(clipItem)
 
-	return &clipItem, nil
+	return clipItem, nil
 }
 
-// GetUserClipItems 获取用户的剪贴板项列表
+// GetUserClipItems 获取用户的剪贴板项列表，按 Scope 和 TeamID 筛选个人或团队剪贴板项
 func (s *ClipService) GetUserClipItems(userID uint, params *ClipListParams) ([]*models.ClipItem, int64, error) {
 	var clipItems []*models.ClipItem
 	var total int64
 
 	// 构建查询条件
-	query := s.db.Model(&models.ClipItem{}).Where("user_id = ?", userID)
+	var scope string
+	var teamID *uint
+	if params != nil {
+		scope, teamID = params.Scope, params.TeamID
+	}
+	query, err := s.owned(s.db.Model(&models.ClipItem{}), userID, scope, teamID)
+	if err != nil {
+		return nil, 0, err
+	}
+	query = s.scoped(query)
 
 	// 过滤条件
 	if params != nil {
 		if params.Type != "" {
 			query = query.Where("type = ?", params.Type)
 		}
+		if len(params.Subtypes) > 0 {
+			query = query.Where("subtype IN ?", params.Subtypes)
+		}
+		if params.Language != "" {
+			query = query.Where("JSON_EXTRACT(classification, '$.language') = ?", params.Language)
+		}
 		if params.DeviceID != "" {
 			query = query.Where("device_id = ?", params.DeviceID)
 		}
@@ -137,7 +331,7 @@ func (s *ClipService) GetUserClipItems(userID uint, params *ClipListParams) ([]*
 // GetRecentClipItems 获取最近的剪贴板项
 func (s *ClipService) GetRecentClipItems(userID uint, limit int) ([]*models.ClipItem, error) {
 	var clipItems []*models.ClipItem
-	query := s.db.Where("user_id = ? AND status = ?", userID, models.ClipStatusActive)
+	query := s.scoped(s.personal(s.db.Where("status = ?", models.ClipStatusActive), userID))
 	query = query.Where("expires_at IS NULL OR expires_at > ?", time.Now())
 	query = query.Order("last_used DESC").Limit(limit)
 
@@ -150,12 +344,9 @@ func (s *ClipService) GetRecentClipItems(userID uint, limit int) ([]*models.Clip
 
 // UpdateClipItem 更新剪贴板项
 func (s *ClipService) UpdateClipItem(userID uint, clipID uint, req *models.UpdateClipRequest) (*models.ClipItem, error) {
-	var clipItem models.ClipItem
-	if err := s.db.Where("id = ? AND user_id = ?", clipID, userID).First(&clipItem).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, models.ErrClipItemNotFound
-		}
-		return nil, fmt.Errorf("database error: %w", err)
+	clipItem, err := s.findModifiable(userID, clipID)
+	if err != nil {
+		return nil, err
 	}
 
 	// 更新字段
@@ -169,24 +360,37 @@ func (s *ClipService) UpdateClipItem(userID uint, clipID uint, req *models.Updat
 		clipItem.ExpiresAt = req.ExpiresAt
 	}
 
-	if err := s.db.Save(&clipItem).Error; err != nil {
+	if err := s.db.Save(clipItem).Error; err != nil {
 		return nil, fmt.Errorf("failed to update clip item: %w", err)
 	}
 
-	return &clipItem, nil
+	s.publish(clipItem, EventClipUpdate, "", MaskClipResponse(clipItem))
+	s.emit(models.WebhookEventClipUpdated, clipItem)
+
+	return clipItem, nil
 }
 
 // DeleteClipItem 删除剪贴板项（软删除）
 func (s *ClipService) DeleteClipItem(userID uint, clipID uint) error {
-	if err := s.db.Where("id = ? AND user_id = ?", clipID, userID).Delete(&models.ClipItem{}).Error; err != nil {
+	clipItem, err := s.findModifiable(userID, clipID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(clipItem).Error; err != nil {
 		return fmt.Errorf("failed to delete clip item: %w", err)
 	}
+
+	s.publish(clipItem, EventClipDelete, "", map[string]interface{}{
+		"clip_id": clipItem.ID,
+		"team_id": clipItem.TeamID,
+	})
+	s.emit(models.WebhookEventClipDeleted, clipItem)
 	return nil
 }
 
-// BatchDeleteClipItems 批量删除剪贴板项
+// BatchDeleteClipItems 批量删除个人剪贴板项，团队剪贴板项需要逐个删除
 func (s *ClipService) BatchDeleteClipItems(userID uint, clipIDs []uint) error {
-	if err := s.db.Where("id IN ? AND user_id = ?", clipIDs, userID).Delete(&models.ClipItem{}).Error; err != nil {
+	if err := s.scoped(s.personal(s.db.Where("id IN ?", clipIDs), userID)).Delete(&models.ClipItem{}).Error; err != nil {
 		return fmt.Errorf("failed to batch delete clip items: %w", err)
 	}
 	return nil
@@ -194,19 +398,16 @@ func (s *ClipService) BatchDeleteClipItems(userID uint, clipIDs []uint) error {
 
 // MarkClipItemAsUsed 标记剪贴板项为已使用
 func (s *ClipService) MarkClipItemAsUsed(userID uint, clipID uint) error {
-	var clipItem models.ClipItem
-	if err := s.db.Where("id = ? AND user_id = ?", clipID, userID).First(&clipItem).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return models.ErrClipItemNotFound
-		}
-		return fmt.Errorf("database error: %w", err)
+	clipItem, _, err := s.findVisible(userID, clipID)
+	if err != nil {
+		return err
 	}
 
 // 标记为已使用
 	now := time.Now()
 	clipItem.LastUsedAt = &now
 	clipItem.ViewCount++
-	if err := s.db.Save(&clipItem).Error; err != nil {
+	if err := s.db.Save(clipItem).Error; err != nil {
 		return fmt.Errorf("failed to mark clip item as used: %w", err)
 	}
 
@@ -219,12 +420,12 @@ func (s *ClipService) GetClipItemsByDevice(userID uint, deviceID string, params
 	var total int64
 
 	// 计算总数
-	if err := s.db.Model(&models.ClipItem{}).Where("user_id = ? AND device_id = ?", userID, deviceID).Count(&total).Error; err != nil {
+	if err := s.scoped(s.personal(s.db.Model(&models.ClipItem{}).Where("device_id = ?", deviceID), userID)).Count(&total).Error; err != nil {
 		return nil, nil, fmt.Errorf("failed to count clip items: %w", err)
 	}
 
 	// 获取列表
-	query := s.db.Where("user_id = ? AND device_id = ?", userID, deviceID).Order("created_at DESC")
+	query := s.scoped(s.personal(s.db.Where("device_id = ?", deviceID), userID)).Order("created_at DESC")
 	if params != nil {
 		query = query.Offset(params.GetOffset()).Limit(params.GetLimit())
 	}
@@ -251,8 +452,8 @@ func (s *ClipService) GetClipItemsByDevice(userID uint, deviceID string, params
 func (s *ClipService) SyncClipItems(userID uint, deviceID string, lastSyncTime *time.Time) (*SyncResult, error) {
 	var result SyncResult
 
-	// 获取需要同步的剪贴板项（在lastSyncTime之后更新的）
-	query := s.db.Where("user_id = ?", userID)
+	// 获取需要同步的剪贴板项（在lastSyncTime之后更新的），包括所在团队的剪贴板项
+	query := s.scoped(s.visible(s.db.Model(&models.ClipItem{}), userID))
 	if lastSyncTime != nil {
 		query = query.Where("updated_at > ?", *lastSyncTime)
 	}
@@ -262,7 +463,11 @@ func (s *ClipService) SyncClipItems(userID uint, deviceID string, lastSyncTime *
 		return nil, fmt.Errorf("failed to get clip items for sync: %w", err)
 	}
 
-	result.ClipItems = clipItems
+	// 需要遮盖的剪贴板项只返回遮盖后的内容，原文通过 /clips/:id/reveal 获取
+	result.ClipItems = make([]*models.ClipItemResponse, 0, len(clipItems))
+	for _, clipItem := range clipItems {
+		result.ClipItems = append(result.ClipItems, MaskClipResponse(clipItem))
+	}
 	result.Count = len(clipItems)
 	result.LastSyncTime = time.Now()
 
@@ -333,7 +538,7 @@ func (s *ClipService) SearchClipItems(userID uint, query string, params *models.
 	var total int64
 
 	searchTerm := "%" + strings.ToLower(query) + "%"
-	dbQuery := s.db.Model(&models.ClipItem{}).Where("user_id = ?", userID)
+	dbQuery := s.scoped(s.personal(s.db.Model(&models.ClipItem{}), userID))
 	dbQuery = dbQuery.Where("LOWER(title) LIKE ? OR LOWER(content) LIKE ?", searchTerm, searchTerm)
 
 	// 计算总数
@@ -368,7 +573,11 @@ func (s *ClipService) SearchClipItems(userID uint, query string, params *models.
 // ClipListParams 剪贴板列表查询参数
 type ClipListParams struct {
 	*models.PaginationParams
+	Scope          string     `json:"scope"`   // user（默认）、team 或 all
+	TeamID         *uint      `json:"team_id"` // 只返回指定团队的剪贴板项
 	Type           string     `json:"type"`
+	Subtypes       []string   `json:"subtypes"` // 内容子类型，满足其一即可
+	Language       string     `json:"language"` // 代码的语言
 	DeviceID       string     `json:"device_id"`
 	Status         string     `json:"status"`
 	Search         string     `json:"search"`
@@ -379,11 +588,17 @@ type ClipListParams struct {
 	IncludeExpired *bool      `json:"include_expired"`
 }
 
+// ClipScopeAll 列表同时包含个人和团队剪贴板项
+const ClipScopeAll = "all"
+
+// ErrInvalidClipScope 未知的剪贴板归属范围
+var ErrInvalidClipScope = errors.New("invalid clip scope")
+
 // SyncResult 同步结果
 type SyncResult struct {
-	ClipItems    []*models.ClipItem `json:"clip_items"`
-	Count        int                `json:"count"`
-	LastSyncTime time.Time          `json:"last_sync_time"`
+	ClipItems    []*models.ClipItemResponse `json:"clip_items"`
+	Count        int                        `json:"count"`
+	LastSyncTime time.Time                  `json:"last_sync_time"`
 }
 
 // ClipStats 剪贴板统计信息
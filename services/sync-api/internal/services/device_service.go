@@ -13,7 +13,8 @@ import (
 
 // DeviceService 设备服务
 type DeviceService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	notifier Notifier
 }
 
 // NewDeviceService 创建设备服务
@@ -22,7 +23,58 @@ func NewDeviceService(db *gorm.DB) *DeviceService {
 }
 
 // RegisterDevice 注册设备
+// 用户的第一台设备自动批准，之后的新设备需要已信任设备批准；已存在的设备只更新信息，不改变批准状态
 func (s *DeviceService) RegisterDevice(userID uint, req *models.RegisterDeviceRequest, clientIP string) (*models.Device, error) {
+	device, created, err := s.saveDevice(userID, req, clientIP, func(device *models.Device) error {
+		var approvers int64
+		if err := s.db.Model(&models.Device{}).
+			Where("user_id = ? AND status = ? AND trust_level = ?", userID, models.DeviceStatusActive, models.TrustLevelFull).
+			Count(&approvers).Error; err != nil {
+			return fmt.Errorf("failed to count trusted devices: %w", err)
+		}
+
+		if approvers == 0 {
+			device.Approve(models.TrustLevelFull, "")
+		} else {
+			device.Status = models.DeviceStatusPending
+			device.TrustLevel = models.TrustLevelNone
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 通知已批准的设备处理新设备
+	if created && device.IsPending() {
+		s.notify(userID, EventDeviceApprovalRequest, device.ToApprovalRequestEvent())
+	}
+
+	return device, nil
+}
+
+// RegisterApprovedDevice 注册已由其他设备批准的设备（配对完成时使用）
+func (s *DeviceService) RegisterApprovedDevice(userID uint, req *models.RegisterDeviceRequest, clientIP string, trustLevel models.DeviceTrustLevel, approvedBy string) (*models.Device, error) {
+	device, created, err := s.saveDevice(userID, req, clientIP, func(device *models.Device) error {
+		device.Approve(trustLevel, approvedBy)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !created {
+		device.Approve(trustLevel, approvedBy)
+		if err := s.db.Save(device).Error; err != nil {
+			return nil, fmt.Errorf("failed to approve device: %w", err)
+		}
+	}
+
+	return device, nil
+}
+
+// saveDevice 创建设备或更新已存在设备的信息，initNew 在创建新设备前设置批准状态
+func (s *DeviceService) saveDevice(userID uint, req *models.RegisterDeviceRequest, clientIP string, initNew func(device *models.Device) error) (*models.Device, bool, error) {
 	// 优先使用前端传递的设备ID，如果没有则生成一个
 	var deviceID string
 	if req.DeviceID != "" {
@@ -34,7 +86,7 @@ func (s *DeviceService) RegisterDevice(userID uint, req *models.RegisterDeviceRe
 	// 检查设备是否已存在
 	var existingDevice models.Device
 	if err := s.db.Where("user_id = ? AND device_id = ?", userID, deviceID).First(&existingDevice).Error; err == nil {
-		// 设备已存在，更新信息
+		// 设备已存在，更新信息（重新注册不能恢复被拒绝或停用的设备）
 		existingDevice.Name = req.Name
 		existingDevice.Platform = req.Platform
 		existingDevice.Version = req.Version
@@ -44,14 +96,13 @@ func (s *DeviceService) RegisterDevice(userID uint, req *models.RegisterDeviceRe
 		existingDevice.LastSeen = &now
 		existingDevice.LastIP = clientIP
 		existingDevice.IsOnline = true
-		existingDevice.Status = models.DeviceStatusActive
 		existingDevice.Capabilities = req.Capabilities
 		if err := s.db.Save(&existingDevice).Error; err != nil {
-			return nil, fmt.Errorf("failed to update device: %w", err)
+			return nil, false, fmt.Errorf("failed to update device: %w", err)
 		}
-		return &existingDevice, nil
+		return &existingDevice, false, nil
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, fmt.Errorf("failed to check existing device: %w", err)
+		return nil, false, fmt.Errorf("failed to check existing device: %w", err)
 	}
 
 	// 创建新设备
@@ -67,15 +118,103 @@ func (s *DeviceService) RegisterDevice(userID uint, req *models.RegisterDeviceRe
 		LastSeen:     &now,
 		LastIP:       clientIP,
 		IsOnline:     true,
-		Status:       models.DeviceStatusActive,
 		Capabilities: req.Capabilities,
 	}
+	if err := initNew(&device); err != nil {
+		return nil, false, err
+	}
 
 	if err := s.db.Create(&device).Error; err != nil {
-		return nil, fmt.Errorf("failed to create device: %w", err)
+		return nil, false, fmt.Errorf("failed to create device: %w", err)
 	}
 
-	return &device, nil
+	return &device, true, nil
+}
+
+// ApproveDevice 由已信任设备批准新设备，或调整已批准设备的信任级别
+func (s *DeviceService) ApproveDevice(userID uint, approverDeviceID string, deviceID string, trustLevel models.DeviceTrustLevel) (*models.Device, error) {
+	device, err := s.decisionTarget(userID, approverDeviceID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if trustLevel == "" {
+		trustLevel = models.TrustLevelFull
+	}
+	device.Approve(trustLevel, approverDeviceID)
+	if err := s.db.Save(device).Error; err != nil {
+		return nil, fmt.Errorf("failed to approve device: %w", err)
+	}
+
+	s.notify(userID, EventDeviceUpdate, device.ToResponse())
+	return device, nil
+}
+
+// DenyDevice 由已信任设备拒绝等待批准的设备，被拒绝的设备标记为已撤销
+func (s *DeviceService) DenyDevice(userID uint, approverDeviceID string, deviceID string) (*models.Device, error) {
+	device, err := s.decisionTarget(userID, approverDeviceID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if !device.IsPending() {
+		return nil, models.ErrDeviceNotPending
+	}
+
+	device.Status = models.DeviceStatusRevoked
+	device.TrustLevel = models.TrustLevelNone
+	if err := s.db.Save(device).Error; err != nil {
+		return nil, fmt.Errorf("failed to deny device: %w", err)
+	}
+	if err := s.revokeAccess(userID, deviceID, models.RevokeReasonDeviceDenied); err != nil {
+		return nil, err
+	}
+
+	s.notify(userID, EventDeviceUpdate, device.ToResponse())
+	return device, nil
+}
+
+// decisionTarget 校验批准设备的信任级别并返回被处理的设备
+func (s *DeviceService) decisionTarget(userID uint, approverDeviceID string, deviceID string) (*models.Device, error) {
+	if approverDeviceID == "" {
+		return nil, models.ErrDeviceNotTrusted
+	}
+	if approverDeviceID == deviceID {
+		return nil, models.ErrDeviceSelfApproval
+	}
+
+	approver, err := s.GetDeviceByDeviceID(userID, approverDeviceID)
+	if err != nil {
+		if errors.Is(err, models.ErrDeviceNotFound) {
+			return nil, models.ErrDeviceNotTrusted
+		}
+		return nil, err
+	}
+	if !approver.CanApproveDevices() {
+		return nil, models.ErrDeviceNotTrusted
+	}
+
+	return s.GetDeviceByDeviceID(userID, deviceID)
+}
+
+// revokeAccess 撤销设备的所有会话和两步验证信任并断开其实时连接，已签发的令牌立即失效
+func (s *DeviceService) revokeAccess(userID uint, deviceID string, reason string) error {
+	if _, err := NewTokenService(s.db).RevokeDeviceSessions(userID, deviceID, reason); err != nil {
+		return err
+	}
+	if err := NewTwoFactorService(s.db).RevokeDeviceTrust(userID, deviceID); err != nil {
+		return err
+	}
+	if s.notifier != nil {
+		s.notifier.DisconnectDevice(userID, deviceID, reason)
+	}
+	return nil
+}
+
+// notify 推送实时事件，未设置通知器时忽略
+func (s *DeviceService) notify(userID uint, event string, data interface{}) {
+	if s.notifier != nil {
+		s.notifier.NotifyUser(userID, event, data)
+	}
 }
 
 // GetDeviceByID 根据ID获取设备
@@ -215,6 +354,75 @@ func (s *DeviceService) UpdateDeviceOnlineStatus(userID uint, deviceID string, i
 	return nil
 }
 
+// UpdateDevicePresence 更新设备在线状态（presence），offline 表示设备离线
+func (s *DeviceService) UpdateDevicePresence(userID uint, deviceID string, state models.PresenceState, appCategory string, clientIP string) (*models.Device, error) {
+	var device models.Device
+	if err := s.db.Where("user_id = ? AND device_id = ?", userID, deviceID).First(&device).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrDeviceNotFound
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	if state == models.PresenceOffline {
+		device.SetOffline()
+	} else {
+		device.UpdateLastSeen(clientIP)
+		device.SetPresence(state, appCategory)
+	}
+
+	if err := s.db.Save(&device).Error; err != nil {
+		return nil, fmt.Errorf("failed to update device presence: %w", err)
+	}
+
+	return &device, nil
+}
+
+// UpdateDetachedPresence 保存没有 WebSocket 连接的设备上报的状态和勿扰设置
+// 不修改在线标记和最后在线时间，在线与否只由连接决定
+func (s *DeviceService) UpdateDetachedPresence(userID uint, deviceID string, state models.PresenceState, appCategory string) error {
+	result := s.db.Model(&models.Device{}).
+		Where("user_id = ? AND device_id = ?", userID, deviceID).
+		Updates(map[string]interface{}{
+			"presence":            state,
+			"app_category":        appCategory,
+			"presence_updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update device presence: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrDeviceNotFound
+	}
+	return nil
+}
+
+// GetUserPresence 获取用户所有正常设备的在线状态
+func (s *DeviceService) GetUserPresence(userID uint) ([]*models.Device, error) {
+	var devices []*models.Device
+	if err := s.db.Where("user_id = ? AND status = ?", userID, models.DeviceStatusActive).
+		Order("is_online DESC, last_seen DESC").Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get device presence: %w", err)
+	}
+	return devices, nil
+}
+
+// ResetPresence 将所有设备标记为离线
+// 服务启动时调用，避免上次异常退出时遗留的在线状态
+func (s *DeviceService) ResetPresence() error {
+	now := time.Now()
+	if err := s.db.Model(&models.Device{}).Where("is_online = ? OR presence <> ?", true, models.PresenceOffline).
+		Updates(map[string]interface{}{
+			"is_online":           false,
+			"presence":            models.PresenceOffline,
+			"app_category":        "",
+			"presence_updated_at": now,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to reset device presence: %w", err)
+	}
+	return nil
+}
+
 // UpdateDeviceSyncTime 更新设备同步时间
 func (s *DeviceService) UpdateDeviceSyncTime(userID uint, deviceID string) error {
 	var device models.Device
@@ -249,7 +457,7 @@ func (s *DeviceService) DeactivateDevice(userID uint, deviceID string) error {
 		return fmt.Errorf("failed to deactivate device: %w", err)
 	}
 
-	return nil
+	return s.revokeAccess(userID, deviceID, models.RevokeReasonDeviceDeactivated)
 }
 
 // DeleteDevice 删除设备（软删除）
@@ -257,7 +465,7 @@ func (s *DeviceService) DeleteDevice(userID uint, deviceID string) error {
 	if err := s.db.Where("user_id = ? AND device_id = ?", userID, deviceID).Delete(&models.Device{}).Error; err != nil {
 		return fmt.Errorf("failed to delete device: %w", err)
 	}
-	return nil
+	return s.revokeAccess(userID, deviceID, models.RevokeReasonDeviceDeleted)
 }
 
 // GetDeviceStats 获取设备统计信息
@@ -336,6 +544,15 @@ func (s *DeviceService) BulkUpdateDeviceStatus(userID uint, deviceIDs []string,
 	if err := s.db.Model(&models.Device{}).Where("user_id = ? AND device_id IN ?", userID, deviceIDs).Update("status", status).Error; err != nil {
 		return fmt.Errorf("failed to bulk update device status: %w", err)
 	}
+
+	if status == models.DeviceStatusActive || status == models.DeviceStatusPending {
+		return nil
+	}
+	for _, deviceID := range deviceIDs {
+		if err := s.revokeAccess(userID, deviceID, models.RevokeReasonDeviceDisabled); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
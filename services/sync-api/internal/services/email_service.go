@@ -0,0 +1,333 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/mailer"
+	"xpaste-sync/internal/models"
+)
+
+const (
+	// DefaultEmailVerificationTTL 默认邮箱验证链接有效期
+	DefaultEmailVerificationTTL = 24 * time.Hour
+	// DefaultPasswordResetTTL 默认密码重置链接有效期
+	DefaultPasswordResetTTL = time.Hour
+
+	emailTokenSize    = 32
+	emailSendCooldown = time.Minute // 同一用途的邮件最短发送间隔
+)
+
+// EmailService 邮箱验证和密码重置服务
+type EmailService struct {
+	db     *gorm.DB
+	audit  *AuditService
+	tokens *TokenService
+	guard  *LoginGuardService
+
+	mailer      mailer.Mailer
+	linkBaseURL string
+	verifyTTL   time.Duration
+	resetTTL    time.Duration
+}
+
+// NewEmailService 创建邮件服务，需调用 Configure 设置邮件发送器后才能发送邮件
+func NewEmailService(db *gorm.DB, audit *AuditService, tokens *TokenService, guard *LoginGuardService) *EmailService {
+	return &EmailService{
+		db:        db,
+		audit:     audit,
+		tokens:    tokens,
+		guard:     guard,
+		verifyTTL: DefaultEmailVerificationTTL,
+		resetTTL:  DefaultPasswordResetTTL,
+	}
+}
+
+// Configure 设置邮件发送器、链接地址前缀和令牌有效期，非正数的有效期保持默认值
+func (s *EmailService) Configure(m mailer.Mailer, linkBaseURL string, verifyTTL, resetTTL time.Duration) {
+	s.mailer = m
+	s.linkBaseURL = strings.TrimRight(linkBaseURL, "/")
+	if verifyTTL > 0 {
+		s.verifyTTL = verifyTTL
+	}
+	if resetTTL > 0 {
+		s.resetTTL = resetTTL
+	}
+}
+
+// SendVerification 发送邮箱验证邮件，邮箱已验证时不发送；冷却时间内重复请求会被忽略
+func (s *EmailService) SendVerification(user *models.User) error {
+	if user.IsEmailVerified() {
+		return models.ErrEmailAlreadyVerified
+	}
+
+	token, sent, err := s.issueToken(user, models.EmailTokenVerifyEmail, s.verifyTTL)
+	if err != nil || !sent {
+		return err
+	}
+
+	return s.send(&mailer.Message{
+		To:      user.Email,
+		Subject: "验证你的 xPaste 邮箱",
+		Body: fmt.Sprintf("你好 %s：\n\n请打开以下链接验证邮箱（%s 内有效）：\n\n%s\n\n如果链接无法打开，可在客户端中输入验证码：\n\n%s\n\n如果这不是你的操作，请忽略本邮件。\n",
+			user.DisplayName, formatTTL(s.verifyTTL), s.link("/verify-email", token), token),
+	})
+}
+
+// ResendVerification 按邮箱重新发送验证邮件；邮箱不存在或已验证时同样返回成功，避免泄露账户信息
+func (s *EmailService) ResendVerification(email string) error {
+	user, err := s.findByEmail(email)
+	if err != nil || user == nil {
+		return err
+	}
+	if err := s.SendVerification(user); err != nil && !errors.Is(err, models.ErrEmailAlreadyVerified) {
+		return err
+	}
+	return nil
+}
+
+// VerifyEmail 校验邮箱验证令牌，标记邮箱已验证；注册时等待验证的账户同时激活
+func (s *EmailService) VerifyEmail(plain string, clientIP string) (*models.User, error) {
+	var user *models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		_, u, err := s.consumeToken(tx, plain, models.EmailTokenVerifyEmail)
+		if err != nil {
+			return err
+		}
+		if err := markEmailVerified(tx, u); err != nil {
+			return err
+		}
+		user = u
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(&models.AuditLog{UserID: &user.ID, Event: models.AuditEmailVerified, IP: clientIP})
+	return user, nil
+}
+
+// RequestPasswordReset 按邮箱发送密码重置邮件；邮箱不存在时同样返回成功，避免泄露账户信息
+func (s *EmailService) RequestPasswordReset(email string, clientIP string, userAgent string) error {
+	user, err := s.findByEmail(email)
+	if err != nil || user == nil {
+		return err
+	}
+	if user.Status == models.UserStatusSuspended || user.Status == models.UserStatusBanned {
+		return nil
+	}
+
+	token, sent, err := s.issueToken(user, models.EmailTokenResetPassword, s.resetTTL)
+	if err != nil || !sent {
+		return err
+	}
+
+	s.record(&models.AuditLog{
+		UserID:    &user.ID,
+		Event:     models.AuditPasswordResetRequest,
+		IP:        clientIP,
+		UserAgent: userAgent,
+	})
+
+	return s.send(&mailer.Message{
+		To:      user.Email,
+		Subject: "重置你的 xPaste 密码",
+		Body: fmt.Sprintf("你好 %s：\n\n我们收到了重置密码的请求（来自 %s）。请打开以下链接设置新密码（%s 内有效）：\n\n%s\n\n重置令牌：\n\n%s\n\n重置后所有设备需要重新登录。如果这不是你的操作，请忽略本邮件，你的密码不会改变。\n",
+			user.DisplayName, clientIP, formatTTL(s.resetTTL), s.link("/reset-password", token), token),
+	})
+}
+
+// ResetPassword 校验重置令牌并设置新密码：撤销用户的所有会话、清除登录锁定，邮箱视为已验证
+func (s *EmailService) ResetPassword(plain string, newPassword string, clientIP string, userAgent string) (*models.User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	var user *models.User
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		_, u, err := s.consumeToken(tx, plain, models.EmailTokenResetPassword)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(u).Update("password_hash", string(hashedPassword)).Error; err != nil {
+			return fmt.Errorf("failed to update password: %w", err)
+		}
+		// 其他未使用的重置令牌一并作废
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", u.ID, models.EmailTokenResetPassword).
+			Delete(&models.EmailToken{}).Error; err != nil {
+			return fmt.Errorf("failed to delete reset tokens: %w", err)
+		}
+		if err := markEmailVerified(tx, u); err != nil {
+			return err
+		}
+		user = u
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.tokens != nil {
+		if _, err := s.tokens.RevokeUserSessions(user.ID, models.RevokeReasonPasswordReset); err != nil {
+			return nil, err
+		}
+	}
+	if s.guard != nil {
+		if err := s.guard.RecordSuccess(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.record(&models.AuditLog{
+		UserID:    &user.ID,
+		Event:     models.AuditPasswordResetComplete,
+		IP:        clientIP,
+		UserAgent: userAgent,
+	})
+	return user, nil
+}
+
+// issueToken 签发邮件令牌并作废同用途的旧令牌；冷却时间内已发送过时不签发，sent 为 false
+func (s *EmailService) issueToken(user *models.User, purpose models.EmailTokenPurpose, ttl time.Duration) (string, bool, error) {
+	var recent int64
+	if err := s.db.Model(&models.EmailToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL AND created_at > ?", user.ID, purpose, time.Now().Add(-emailSendCooldown)).
+		Count(&recent).Error; err != nil {
+		return "", false, fmt.Errorf("database error: %w", err)
+	}
+	if recent > 0 {
+		return "", false, nil
+	}
+
+	plain, err := randomToken(emailTokenSize)
+	if err != nil {
+		return "", false, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, purpose).
+			Delete(&models.EmailToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EmailToken{
+			TokenHash: hashToken(plain),
+			UserID:    user.ID,
+			Purpose:   purpose,
+			Email:     user.Email,
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create email token: %w", err)
+	}
+	return plain, true, nil
+}
+
+// consumeToken 校验并使用令牌：存在、用途匹配、未使用、未过期，且用户邮箱未变更
+func (s *EmailService) consumeToken(tx *gorm.DB, plain string, purpose models.EmailTokenPurpose) (*models.EmailToken, *models.User, error) {
+	var token models.EmailToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", hashToken(strings.TrimSpace(plain)), purpose).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.ErrEmailTokenInvalid
+		}
+		return nil, nil, fmt.Errorf("database error: %w", err)
+	}
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, nil, models.ErrEmailTokenInvalid
+	}
+
+	var user models.User
+	if err := tx.First(&user, token.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.ErrEmailTokenInvalid
+		}
+		return nil, nil, fmt.Errorf("database error: %w", err)
+	}
+	if !strings.EqualFold(user.Email, token.Email) {
+		return nil, nil, models.ErrEmailTokenInvalid
+	}
+
+	// 条件更新防止并发重复使用
+	result := tx.Model(&models.EmailToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, nil, fmt.Errorf("failed to use email token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, models.ErrEmailTokenInvalid
+	}
+
+	return &token, &user, nil
+}
+
+// markEmailVerified 标记邮箱已验证，只有注册时标记为等待验证的账户同时激活
+// 被停用、暂停或封禁的账户保持原状态
+func markEmailVerified(tx *gorm.DB, user *models.User) error {
+	if user.IsEmailVerified() && !user.PendingVerification {
+		return nil
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"email_verified_at": now, "pending_verification": false}
+	if user.IsPendingVerification() {
+		updates["status"] = models.UserStatusActive
+		user.Status = models.UserStatusActive
+	}
+	if err := tx.Model(user).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+	user.EmailVerifiedAt = &now
+	user.PendingVerification = false
+	return nil
+}
+
+// findByEmail 按邮箱查找用户，不存在时返回 nil
+func (s *EmailService) findByEmail(email string) (*models.User, error) {
+	var user models.User
+	if err := s.db.Where("email = ?", strings.TrimSpace(email)).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &user, nil
+}
+
+// send 发送邮件，未配置邮件发送器时返回错误
+func (s *EmailService) send(msg *mailer.Message) error {
+	if s.mailer == nil {
+		return fmt.Errorf("mailer is not configured")
+	}
+	if err := s.mailer.Send(msg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// link 生成邮件中的链接
+func (s *EmailService) link(path string, token string) string {
+	return s.linkBaseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func (s *EmailService) record(entry *models.AuditLog) {
+	if s.audit != nil {
+		_ = s.audit.Record(entry)
+	}
+}
+
+// formatTTL 以小时或分钟描述有效期
+func formatTTL(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		return fmt.Sprintf("%d 小时", int(ttl.Hours()))
+	}
+	return fmt.Sprintf("%d 分钟", int(ttl.Minutes()))
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"xpaste-sync/internal/models"
+)
+
+func newTestEmailService(t *testing.T) (*EmailService, *UserService) {
+	t.Helper()
+	db := newTestDB(t, &models.User{}, &models.EmailToken{}, &models.AuditLog{})
+	return NewEmailService(db, NewAuditService(db), nil, nil), NewUserService(db)
+}
+
+// issueTestToken 签发邮件令牌，返回令牌明文
+func issueTestToken(t *testing.T, s *EmailService, user *models.User, purpose models.EmailTokenPurpose) string {
+	t.Helper()
+	token, sent, err := s.issueToken(user, purpose, DefaultPasswordResetTTL)
+	if err != nil || !sent {
+		t.Fatalf("issueToken: sent=%v err=%v", sent, err)
+	}
+	return token
+}
+
+func TestVerifyEmailActivatesPendingRegistration(t *testing.T) {
+	emails, users := newTestEmailService(t)
+
+	user, err := users.Register(&models.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "secret123"}, true)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if !user.IsPendingVerification() {
+		t.Fatalf("registered user status = %v, pending = %v", user.Status, user.PendingVerification)
+	}
+	if _, err := users.Login(&models.LoginRequest{Username: "alice", Password: "secret123"}, "127.0.0.1", "test"); !errors.Is(err, models.ErrEmailNotVerified) {
+		t.Errorf("Login before verification = %v, want ErrEmailNotVerified", err)
+	}
+
+	verified, err := emails.VerifyEmail(issueTestToken(t, emails, user, models.EmailTokenVerifyEmail), "127.0.0.1")
+	if err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if !verified.IsActive() || !verified.IsEmailVerified() || verified.PendingVerification {
+		t.Errorf("verified user status = %v, verified = %v, pending = %v", verified.Status, verified.IsEmailVerified(), verified.PendingVerification)
+	}
+}
+
+func TestPasswordResetDoesNotReactivateDeactivatedUser(t *testing.T) {
+	emails, users := newTestEmailService(t)
+
+	// 邮箱从未验证的老用户被停用
+	user, err := users.Register(&models.RegisterRequest{Username: "bob", Email: "bob@example.com", Password: "secret123"}, false)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := users.DeactivateUser(user.ID); err != nil {
+		t.Fatalf("DeactivateUser: %v", err)
+	}
+	user, _ = users.GetUserByID(user.ID)
+
+	reset, err := emails.ResetPassword(issueTestToken(t, emails, user, models.EmailTokenResetPassword), "newsecret123", "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if reset.Status != models.UserStatusInactive {
+		t.Errorf("status after reset = %v, want inactive", reset.Status)
+	}
+	if _, err := users.Login(&models.LoginRequest{Username: "bob", Password: "newsecret123"}, "127.0.0.1", "test"); !errors.Is(err, models.ErrUserInactive) {
+		t.Errorf("Login after reset = %v, want ErrUserInactive", err)
+	}
+}
+
+func TestDeactivatingPendingUserClearsPendingVerification(t *testing.T) {
+	emails, users := newTestEmailService(t)
+
+	user, err := users.Register(&models.RegisterRequest{Username: "carol", Email: "carol@example.com", Password: "secret123"}, true)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := users.DeactivateUser(user.ID); err != nil {
+		t.Fatalf("DeactivateUser: %v", err)
+	}
+	user, _ = users.GetUserByID(user.ID)
+
+	verified, err := emails.VerifyEmail(issueTestToken(t, emails, user, models.EmailTokenVerifyEmail), "127.0.0.1")
+	if err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if verified.Status != models.UserStatusInactive || !verified.IsEmailVerified() {
+		t.Errorf("status = %v, verified = %v, want inactive and verified", verified.Status, verified.IsEmailVerified())
+	}
+}
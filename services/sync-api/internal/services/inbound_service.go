@@ -0,0 +1,211 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/models"
+)
+
+// inboundPrefixLen 保存用于识别的令牌开头长度
+const inboundPrefixLen = 10
+
+// InboundOptions 入站渠道配置
+type InboundOptions struct {
+	BaseURL     string // 入站 webhook 地址前缀，为空时由处理器按请求地址生成
+	EmailDomain string // 收件域名，为空表示未启用邮件入站
+	MaxSize     int64  // 单次提交或单封邮件的最大字节数
+}
+
+// InboundService 入站服务：外部系统通过专属地址或邮件向用户的剪贴板历史添加内容
+type InboundService struct {
+	db       *gorm.DB
+	clips    *ClipService
+	notifier Notifier // 把收到的内容推送到用户的在线设备
+	opts     InboundOptions
+}
+
+// NewInboundService 创建入站服务
+func NewInboundService(db *gorm.DB, clips *ClipService) *InboundService {
+	return &InboundService{
+		db:    db,
+		clips: clips,
+		opts:  InboundOptions{MaxSize: 10 * 1024 * 1024},
+	}
+}
+
+// Configure 设置入站渠道配置
+func (s *InboundService) Configure(opts InboundOptions) {
+	opts.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+	opts.EmailDomain = strings.ToLower(opts.EmailDomain)
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = s.opts.MaxSize
+	}
+	s.opts = opts
+}
+
+// BaseURL 入站 webhook 地址前缀
+func (s *InboundService) BaseURL() string {
+	return s.opts.BaseURL
+}
+
+// EmailDomain 收件域名，为空表示未启用邮件入站
+func (s *InboundService) EmailDomain() string {
+	return s.opts.EmailDomain
+}
+
+// MaxSize 单次提交的最大字节数
+func (s *InboundService) MaxSize() int64 {
+	return s.opts.MaxSize
+}
+
+// EmailAddress 根据令牌生成收件地址
+func (s *InboundService) EmailAddress(token string) string {
+	return token + "@" + s.opts.EmailDomain
+}
+
+// ListEndpoints 获取用户的入站地址
+func (s *InboundService) ListEndpoints(userID uint) ([]*models.InboundEndpoint, error) {
+	var endpoints []*models.InboundEndpoint
+	if err := s.db.Where("user_id = ?", userID).Order("channel").Find(&endpoints).Error; err != nil {
+		return nil, fmt.Errorf("failed to get inbound endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+// CreateEndpoint 创建入站地址，已存在时轮换令牌，旧地址立即失效；返回令牌明文
+func (s *InboundService) CreateEndpoint(userID uint, channel models.InboundChannel, req *models.InboundEndpointRequest) (*models.InboundEndpoint, string, error) {
+	if !channel.IsValid() {
+		return nil, "", models.ErrInboundChannelInvalid
+	}
+	if channel == models.InboundChannelEmail && s.opts.EmailDomain == "" {
+		return nil, "", models.ErrInboundEmailDisabled
+	}
+
+	var token string
+	var err error
+	if channel == models.InboundChannelEmail {
+		// 邮件地址不区分大小写，使用小写 base32
+		token, err = randomAddressToken(15)
+	} else {
+		token, err = randomToken(24)
+		token = models.InboundTokenPrefix + token
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var endpoint models.InboundEndpoint
+	err = s.db.Where("user_id = ? AND channel = ?", userID, channel).First(&endpoint).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", fmt.Errorf("failed to get inbound endpoint: %w", err)
+	}
+
+	endpoint.UserID = userID
+	endpoint.Channel = channel
+	endpoint.TokenHash = hashToken(token)
+	endpoint.Prefix = token[:inboundPrefixLen]
+	if req != nil && req.Tags != nil {
+		endpoint.Tags = req.Tags
+	}
+	if err := s.db.Save(&endpoint).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to save inbound endpoint: %w", err)
+	}
+	return &endpoint, token, nil
+}
+
+// DeleteEndpoint 删除入站地址
+func (s *InboundService) DeleteEndpoint(userID uint, channel models.InboundChannel) error {
+	if !channel.IsValid() {
+		return models.ErrInboundChannelInvalid
+	}
+	result := s.db.Where("user_id = ? AND channel = ?", userID, channel).Delete(&models.InboundEndpoint{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete inbound endpoint: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrInboundEndpointNotFound
+	}
+	return nil
+}
+
+// Authenticate 根据令牌查找入站地址，用户不可用时视为不存在
+func (s *InboundService) Authenticate(channel models.InboundChannel, token string) (*models.InboundEndpoint, error) {
+	if channel == models.InboundChannelEmail {
+		token = strings.ToLower(token)
+	}
+	if token == "" {
+		return nil, models.ErrInboundEndpointNotFound
+	}
+
+	var endpoint models.InboundEndpoint
+	if err := s.db.Where("token_hash = ? AND channel = ?", hashToken(token), channel).First(&endpoint).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrInboundEndpointNotFound
+		}
+		return nil, fmt.Errorf("failed to get inbound endpoint: %w", err)
+	}
+
+	var user models.User
+	if err := s.db.First(&user, endpoint.UserID).Error; err != nil || !user.IsActive() {
+		return nil, models.ErrInboundEndpointNotFound
+	}
+	return &endpoint, nil
+}
+
+// Receive 把收到的内容保存为剪贴板项，并推送到用户的所有设备
+func (s *InboundService) Receive(endpoint *models.InboundEndpoint, reqs []*models.CreateClipRequest) ([]*models.ClipItem, error) {
+	if len(reqs) == 0 {
+		return nil, models.ErrInboundEmpty
+	}
+
+	clipItems := make([]*models.ClipItem, 0, len(reqs))
+	for _, req := range reqs {
+		for _, tag := range endpoint.Tags {
+			if !containsString(req.Tags, tag) {
+				req.Tags = append(req.Tags, tag)
+			}
+		}
+		if req.Metadata == nil {
+			req.Metadata = models.JSON{}
+		}
+		req.Metadata["source"] = "inbound_" + string(endpoint.Channel)
+		// 入站内容不来自任何设备，推送给用户的所有设备
+		req.DeviceID = ""
+		req.TeamID = nil
+
+		clipItem, err := s.clips.CreateClipItem(endpoint.UserID, req)
+		if errors.Is(err, models.ErrClipDroppedByRule) {
+			continue
+		}
+		if err != nil {
+			return clipItems, err
+		}
+		clipItems = append(clipItems, clipItem)
+		if s.notifier != nil {
+			s.notifier.NotifyUser(endpoint.UserID, EventClipNew, MaskClipResponse(clipItem))
+		}
+	}
+
+	now := time.Now()
+	s.db.Model(endpoint).UpdateColumns(map[string]interface{}{
+		"last_received_at": now,
+		"received_count":   gorm.Expr("received_count + ?", len(clipItems)),
+	})
+	return clipItems, nil
+}
+
+// randomAddressToken 生成可以用作邮件地址本地部分的小写随机令牌
+func randomAddressToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)), nil
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/models"
+)
+
+const (
+	// DefaultAccountLockoutThreshold 默认账户连续失败多少次后锁定
+	DefaultAccountLockoutThreshold = 10
+	// DefaultIPLockoutThreshold 默认同一 IP 连续失败多少次后锁定（多个用户可能共用出口 IP，阈值更高）
+	DefaultIPLockoutThreshold = 50
+	// DefaultLockoutDuration 默认首次锁定时长，之后每次失败翻倍
+	DefaultLockoutDuration = 15 * time.Minute
+	// DefaultFailureWindow 默认失败计数的保留时长，超过后重新计数
+	DefaultFailureWindow = time.Hour
+
+	maxLockoutDuration = 24 * time.Hour
+	maxBackoffShift    = 20
+)
+
+// LoginGuardService 登录防暴力破解：按账户和 IP 记录连续失败次数
+// 失败次数达到阈值的一半后开始指数退避（1s、2s、4s…），达到阈值后临时锁定，锁定时长随后续失败翻倍
+// 锁定期间的请求直接拒绝且不计入失败次数，两步验证失败同样计数，账户完成登录后清零账户计数
+type LoginGuardService struct {
+	db       *gorm.DB
+	audit    *AuditService
+	notifier Notifier
+
+	accountThreshold int
+	ipThreshold      int
+	lockoutDuration  time.Duration
+	failureWindow    time.Duration
+}
+
+// NewLoginGuardService 创建登录保护服务
+func NewLoginGuardService(db *gorm.DB, audit *AuditService) *LoginGuardService {
+	return &LoginGuardService{
+		db:               db,
+		audit:            audit,
+		accountThreshold: DefaultAccountLockoutThreshold,
+		ipThreshold:      DefaultIPLockoutThreshold,
+		lockoutDuration:  DefaultLockoutDuration,
+		failureWindow:    DefaultFailureWindow,
+	}
+}
+
+// Configure 设置锁定阈值、首次锁定时长和失败计数保留时长，非正数保持默认值
+func (s *LoginGuardService) Configure(accountThreshold, ipThreshold int, lockoutDuration, failureWindow time.Duration) {
+	if accountThreshold > 0 {
+		s.accountThreshold = accountThreshold
+	}
+	if ipThreshold > 0 {
+		s.ipThreshold = ipThreshold
+	}
+	if lockoutDuration > 0 {
+		s.lockoutDuration = lockoutDuration
+	}
+	if failureWindow > 0 {
+		s.failureWindow = failureWindow
+	}
+}
+
+// CheckIP 检查 IP 是否处于退避或锁定状态
+func (s *LoginGuardService) CheckIP(clientIP string) error {
+	return s.check(ipKey(clientIP), s.ipThreshold)
+}
+
+// CheckAccount 检查账户是否处于退避或锁定状态
+func (s *LoginGuardService) CheckAccount(userID uint) error {
+	return s.check(accountKey(userID), s.accountThreshold)
+}
+
+// RecordThrottled 记录锁定期间被拒绝的登录请求
+func (s *LoginGuardService) RecordThrottled(userID *uint, username, clientIP, userAgent string, err error) {
+	s.record(&models.AuditLog{
+		UserID:    userID,
+		Event:     models.AuditLoginThrottled,
+		Username:  username,
+		IP:        clientIP,
+		UserAgent: userAgent,
+		Detail:    err.Error(),
+	})
+}
+
+// RecordFailure 记录一次登录失败；user 为空表示用户不存在，只计入 IP
+func (s *LoginGuardService) RecordFailure(user *models.User, username, clientIP, userAgent string) error {
+	return s.recordFailure(user, username, clientIP, userAgent, models.AuditLoginFailed, "")
+}
+
+// RecordTwoFactorFailure 记录一次两步验证失败，与密码错误共用账户和 IP 的失败计数
+// 密码正确但验证码错误同样计入，避免反复发起挑战绕过验证码的尝试次数限制
+func (s *LoginGuardService) RecordTwoFactorFailure(user *models.User, clientIP, userAgent, method string) error {
+	return s.recordFailure(user, user.Username, clientIP, userAgent, models.AuditTwoFactorFailed, method)
+}
+
+// RecordTwoFactorSuccess 两步验证通过：写入审计并清零账户的失败计数
+func (s *LoginGuardService) RecordTwoFactorSuccess(user *models.User, clientIP, userAgent, method string) error {
+	s.record(&models.AuditLog{
+		UserID:    &user.ID,
+		Event:     models.AuditTwoFactorVerified,
+		Username:  user.Username,
+		IP:        clientIP,
+		UserAgent: userAgent,
+		Detail:    method,
+	})
+	return s.RecordSuccess(user.ID)
+}
+
+// recordFailure 写入失败审计并增加 IP 和账户的失败计数
+func (s *LoginGuardService) recordFailure(user *models.User, username, clientIP, userAgent, event, detail string) error {
+	var userID *uint
+	if user != nil {
+		userID = &user.ID
+	}
+	s.record(&models.AuditLog{
+		UserID:    userID,
+		Event:     event,
+		Username:  username,
+		IP:        clientIP,
+		UserAgent: userAgent,
+		Detail:    detail,
+	})
+
+	ipThrottle, ipLocked, err := s.fail(ipKey(clientIP), s.ipThreshold)
+	if err != nil {
+		return err
+	}
+	if ipLocked {
+		s.record(&models.AuditLog{
+			Event:     models.AuditIPLocked,
+			IP:        clientIP,
+			UserAgent: userAgent,
+			Detail:    fmt.Sprintf("%d failed attempts, locked until %s", ipThrottle.Failures, ipThrottle.LockedUntil.Format(time.RFC3339)),
+		})
+	}
+
+	if user == nil {
+		return nil
+	}
+
+	throttle, locked, err := s.fail(accountKey(user.ID), s.accountThreshold)
+	if err != nil {
+		return err
+	}
+	if locked {
+		s.record(&models.AuditLog{
+			UserID:    userID,
+			Event:     models.AuditAccountLocked,
+			Username:  username,
+			IP:        clientIP,
+			UserAgent: userAgent,
+			Detail:    fmt.Sprintf("%d failed attempts, locked until %s", throttle.Failures, throttle.LockedUntil.Format(time.RFC3339)),
+		})
+		if s.notifier != nil {
+			s.notifier.NotifyUser(user.ID, EventSecurityAlert, &models.SecurityAlert{
+				Type:  models.AlertAccountLocked,
+				IP:    clientIP,
+				Until: throttle.LockedUntil,
+				Time:  time.Now(),
+			})
+		}
+	}
+	return nil
+}
+
+// RecordSuccess 登录完全成功（含两步验证）后清零账户的失败计数；IP 计数按保留时长自然过期
+func (s *LoginGuardService) RecordSuccess(userID uint) error {
+	if err := s.db.Where("throttle_key = ?", accountKey(userID)).Delete(&models.LoginThrottle{}).Error; err != nil {
+		return fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+	return nil
+}
+
+// UnlockAccount 管理员解除账户锁定，clientIP 不为空时同时解除该 IP 的锁定
+func (s *LoginGuardService) UnlockAccount(userID uint, clientIP string, actorID uint, actorIP string) error {
+	if err := s.db.Where("throttle_key = ?", accountKey(userID)).Delete(&models.LoginThrottle{}).Error; err != nil {
+		return fmt.Errorf("failed to unlock account: %w", err)
+	}
+	s.record(&models.AuditLog{
+		UserID:  &userID,
+		ActorID: &actorID,
+		Event:   models.AuditAccountUnlock,
+		IP:      actorIP,
+	})
+
+	if clientIP == "" {
+		return nil
+	}
+	if err := s.db.Where("throttle_key = ?", ipKey(clientIP)).Delete(&models.LoginThrottle{}).Error; err != nil {
+		return fmt.Errorf("failed to unlock ip: %w", err)
+	}
+	s.record(&models.AuditLog{
+		UserID:  &userID,
+		ActorID: &actorID,
+		Event:   models.AuditIPUnlock,
+		IP:      actorIP,
+		Detail:  clientIP,
+	})
+	return nil
+}
+
+// check 检查指定键是否仍在退避或锁定期内
+func (s *LoginGuardService) check(key string, threshold int) error {
+	var throttle models.LoginThrottle
+	if err := s.db.Where("throttle_key = ?", key).First(&throttle).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("database error: %w", err)
+	}
+
+	if retryAfter := throttle.RetryAfter(time.Now()); retryAfter > 0 {
+		return &models.LoginThrottledError{
+			RetryAfter: retryAfter,
+			Locked:     throttle.Failures >= threshold,
+		}
+	}
+	return nil
+}
+
+// fail 增加失败次数并计算新的退避或锁定时间，返回本次是否触发锁定
+func (s *LoginGuardService) fail(key string, threshold int) (*models.LoginThrottle, bool, error) {
+	var throttle models.LoginThrottle
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Where(models.LoginThrottle{Key: key}).FirstOrCreate(&throttle).Error; err != nil {
+			return err
+		}
+
+		// 超过保留时长没有失败且不在锁定期内，重新计数
+		if throttle.LastFailureAt != nil && now.Sub(*throttle.LastFailureAt) > s.failureWindow && throttle.RetryAfter(now) == 0 {
+			throttle.Failures = 0
+		}
+
+		throttle.Failures++
+		throttle.LastFailureAt = &now
+		if delay := s.delay(throttle.Failures, threshold); delay > 0 {
+			lockedUntil := now.Add(delay)
+			throttle.LockedUntil = &lockedUntil
+		}
+
+		return tx.Model(&throttle).Updates(map[string]interface{}{
+			"failures":        throttle.Failures,
+			"last_failure_at": throttle.LastFailureAt,
+			"locked_until":    throttle.LockedUntil,
+		}).Error
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	return &throttle, throttle.Failures >= threshold, nil
+}
+
+// delay 第 failures 次失败后需要等待的时间
+// 阈值一半之前不限制；之后指数退避，最长不超过首次锁定时长；达到阈值后锁定，时长每次翻倍，最长 24 小时
+func (s *LoginGuardService) delay(failures, threshold int) time.Duration {
+	backoffAfter := threshold / 2
+	if failures < backoffAfter || failures == 0 {
+		return 0
+	}
+
+	if failures < threshold {
+		shift := failures - backoffAfter
+		if shift > maxBackoffShift {
+			shift = maxBackoffShift
+		}
+		delay := time.Second << uint(shift)
+		if delay > s.lockoutDuration {
+			delay = s.lockoutDuration
+		}
+		return delay
+	}
+
+	shift := failures - threshold
+	if shift > maxBackoffShift {
+		shift = maxBackoffShift
+	}
+	delay := s.lockoutDuration << uint(shift)
+	if delay > maxLockoutDuration || delay <= 0 {
+		delay = maxLockoutDuration
+	}
+	return delay
+}
+
+// record 写入审计日志，失败只影响审计不影响登录流程
+func (s *LoginGuardService) record(entry *models.AuditLog) {
+	if s.audit == nil {
+		return
+	}
+	_ = s.audit.Record(entry)
+}
+
+func accountKey(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func ipKey(clientIP string) string {
+	return "ip:" + clientIP
+}
@@ -0,0 +1,31 @@
+package services
+
+// 推送给设备的实时事件
+const (
+	EventPairingRequest = "pairing_request" // 新设备认领了配对会话，等待发起设备批准
+	EventPairingResult  = "pairing_result"  // 配对状态变化
+
+	EventDeviceApprovalRequest = "device_approval_request" // 新设备注册后等待批准
+	EventDeviceUpdate          = "device_update"           // 设备状态或信任级别变化
+
+	EventSecurityAlert = "security_alert" // 安全告警，例如刷新令牌被重用
+
+	EventClipNew    = "clip_new"    // 团队剪贴板新增剪贴板项，或通过入站地址收到内容
+	EventClipUpdate = "clip_update" // 团队剪贴板项被修改
+	EventClipDelete = "clip_delete" // 团队剪贴板项被删除
+
+	EventRuleNotification = "rule_notification" // 剪贴板项规则的通知动作
+)
+
+// Notifier 实时通知接口，由 WebSocket 服务实现
+// 服务层不直接依赖 WebSocket 包，未设置时通知会被忽略
+type Notifier interface {
+	// NotifyDevice 向用户的指定设备推送事件，设备不在线时返回 false
+	NotifyDevice(userID uint, deviceID string, event string, data interface{}) bool
+	// NotifyUser 向用户的所有在线设备推送事件
+	NotifyUser(userID uint, event string, data interface{})
+	// NotifyUsers 向多个用户的所有在线设备推送事件（团队剪贴板扇出），跳过 excludeDeviceID 指定的设备
+	NotifyUsers(userIDs []uint, excludeDeviceID string, event string, data interface{})
+	// DisconnectDevice 断开设备的实时连接（设备被撤销时调用），设备不在线时返回 false
+	DisconnectDevice(userID uint, deviceID string, reason string) bool
+}
@@ -0,0 +1,498 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/oidc"
+)
+
+const (
+	// DefaultOIDCLoginTTL 默认单点登录流程有效期（从发起到兑换会话）
+	DefaultOIDCLoginTTL = 10 * time.Minute
+
+	oidcTokenSize = 32
+)
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// OIDCOptions 单点登录选项，Provider 为空表示未启用
+type OIDCOptions struct {
+	Provider             *oidc.Provider
+	ProviderName         string
+	RoleMapping          map[string]string // 组 -> 角色，为空时不改变用户角色
+	DefaultRole          string
+	AutoProvision        bool
+	AllowedDomains       []string
+	RequireVerifiedEmail bool
+	AllowedRedirectURIs  []string
+	LoginTTL             time.Duration
+}
+
+// OIDCService OpenID Connect 单点登录服务
+// 客户端发起登录后在浏览器中打开授权地址，身份提供方回调服务端完成授权码交换和身份关联，
+// 客户端再用发起时拿到的登录令牌兑换会话，授权码和 ID 令牌不经过客户端
+type OIDCService struct {
+	db    *gorm.DB
+	audit *AuditService
+	opts  OIDCOptions
+}
+
+// NewOIDCService 创建单点登录服务，需调用 Configure 设置身份提供方后才启用
+func NewOIDCService(db *gorm.DB, audit *AuditService) *OIDCService {
+	return &OIDCService{
+		db:    db,
+		audit: audit,
+		opts:  OIDCOptions{LoginTTL: DefaultOIDCLoginTTL},
+	}
+}
+
+// Configure 设置单点登录选项
+func (s *OIDCService) Configure(opts OIDCOptions) {
+	if opts.LoginTTL <= 0 {
+		opts.LoginTTL = DefaultOIDCLoginTTL
+	}
+	if !models.IsValidRole(opts.DefaultRole) {
+		opts.DefaultRole = models.RoleUser
+	}
+	s.opts = opts
+}
+
+// Enabled 是否启用单点登录
+func (s *OIDCService) Enabled() bool {
+	return s.opts.Provider != nil
+}
+
+// ProviderName 身份提供方显示名称
+func (s *OIDCService) ProviderName() string {
+	return s.opts.ProviderName
+}
+
+// StartLogin 发起单点登录，返回授权地址和用于兑换会话的登录令牌
+func (s *OIDCService) StartLogin(ctx context.Context, deviceID, redirectURI string) (*models.OIDCAuthorizeResponse, error) {
+	if !s.Enabled() {
+		return nil, models.ErrOIDCDisabled
+	}
+	if redirectURI != "" && !s.redirectAllowed(redirectURI) {
+		return nil, models.ErrOIDCRedirectNotAllowed
+	}
+
+	state, err := randomToken(oidcTokenSize)
+	if err != nil {
+		return nil, err
+	}
+	loginToken, err := randomToken(oidcTokenSize)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomToken(oidcTokenSize)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := s.opts.Provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	loginState := &models.OIDCLoginState{
+		StateHash:      hashToken(state),
+		LoginTokenHash: hashToken(loginToken),
+		Nonce:          nonce,
+		CodeVerifier:   verifier,
+		DeviceID:       deviceID,
+		RedirectURI:    redirectURI,
+		ExpiresAt:      now.Add(s.opts.LoginTTL),
+	}
+	if err := s.db.Create(loginState).Error; err != nil {
+		return nil, fmt.Errorf("failed to create login state: %w", err)
+	}
+	s.db.Where("expires_at < ?", now).Delete(&models.OIDCLoginState{})
+
+	return &models.OIDCAuthorizeResponse{
+		AuthorizationURL: authURL,
+		LoginToken:       loginToken,
+		ExpiresIn:        int(s.opts.LoginTTL.Seconds()),
+	}, nil
+}
+
+// HandleCallback 处理身份提供方的回调：交换授权码、校验 ID 令牌并关联本地用户
+// state 无效时返回 ErrOIDCLoginInvalid 且登录状态为空；其他失败写入登录状态，客户端兑换时得到错误原因
+func (s *OIDCService) HandleCallback(ctx context.Context, state, code, providerError, clientIP, userAgent string) (*models.OIDCLoginState, error) {
+	if !s.Enabled() {
+		return nil, models.ErrOIDCDisabled
+	}
+
+	var loginState models.OIDCLoginState
+	if err := s.db.Where("state_hash = ?", hashToken(state)).First(&loginState).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrOIDCLoginInvalid
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if loginState.CompletedAt != nil || time.Now().After(loginState.ExpiresAt) {
+		return nil, models.ErrOIDCLoginInvalid
+	}
+
+	user, claims, err := s.authenticate(ctx, &loginState, code, providerError, clientIP, userAgent)
+
+	now := time.Now()
+	updates := map[string]interface{}{"completed_at": now}
+	if err != nil {
+		updates["error"] = truncate(err.Error(), 255)
+		entry := &models.AuditLog{Event: models.AuditSSOLoginFailed, IP: clientIP, UserAgent: userAgent, Detail: truncate(err.Error(), 500)}
+		if claims != nil {
+			entry.Username = claims.Email
+		}
+		if user != nil {
+			entry.UserID = &user.ID
+		}
+		s.record(entry)
+	} else {
+		updates["user_id"] = user.ID
+	}
+
+	// 条件更新防止同一 state 被并发回调处理两次
+	result := s.db.Model(&models.OIDCLoginState{}).
+		Where("id = ? AND completed_at IS NULL", loginState.ID).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update login state: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, models.ErrOIDCLoginInvalid
+	}
+
+	if err != nil {
+		return &loginState, err
+	}
+	s.record(&models.AuditLog{UserID: &user.ID, Event: models.AuditSSOLogin, Username: claims.Email, IP: clientIP, UserAgent: userAgent, Detail: claims.Issuer})
+	return &loginState, nil
+}
+
+// RedeemLogin 用登录令牌兑换已完成的单点登录，返回用户和发起登录时的设备ID；登录令牌只能兑换一次
+// 回调尚未完成时返回 ErrOIDCLoginPending，客户端应稍后重试
+func (s *OIDCService) RedeemLogin(loginToken string) (*models.User, string, error) {
+	if !s.Enabled() {
+		return nil, "", models.ErrOIDCDisabled
+	}
+
+	var loginState models.OIDCLoginState
+	if err := s.db.Where("login_token_hash = ?", hashToken(loginToken)).First(&loginState).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", models.ErrOIDCLoginInvalid
+		}
+		return nil, "", fmt.Errorf("database error: %w", err)
+	}
+	if loginState.ConsumedAt != nil || time.Now().After(loginState.ExpiresAt) {
+		return nil, "", models.ErrOIDCLoginInvalid
+	}
+	if loginState.CompletedAt == nil {
+		return nil, "", models.ErrOIDCLoginPending
+	}
+
+	result := s.db.Model(&models.OIDCLoginState{}).
+		Where("id = ? AND consumed_at IS NULL", loginState.ID).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return nil, "", fmt.Errorf("failed to consume login state: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, "", models.ErrOIDCLoginInvalid
+	}
+
+	if loginState.Error != "" || loginState.UserID == nil {
+		return nil, "", &OIDCLoginError{Reason: loginState.Error}
+	}
+
+	var user models.User
+	if err := s.db.First(&user, *loginState.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", models.ErrOIDCLoginInvalid
+		}
+		return nil, "", fmt.Errorf("database error: %w", err)
+	}
+	if !user.IsActive() {
+		return nil, "", models.ErrOIDCAccountUnavailable
+	}
+	return &user, loginState.DeviceID, nil
+}
+
+// OIDCLoginError 回调阶段失败的原因，兑换登录令牌时返回给客户端
+type OIDCLoginError struct {
+	Reason string
+}
+
+func (e *OIDCLoginError) Error() string {
+	if e.Reason == "" {
+		return "single sign-on failed"
+	}
+	return "single sign-on failed: " + e.Reason
+}
+
+// authenticate 交换授权码、校验 ID 令牌并解析出本地用户
+func (s *OIDCService) authenticate(ctx context.Context, loginState *models.OIDCLoginState, code, providerError, clientIP, userAgent string) (*models.User, *oidc.Claims, error) {
+	if providerError != "" {
+		return nil, nil, fmt.Errorf("identity provider returned error: %s", providerError)
+	}
+	if code == "" {
+		return nil, nil, errors.New("missing authorization code")
+	}
+
+	token, err := s.opts.Provider.Exchange(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		return nil, nil, err
+	}
+	claims, err := s.opts.Provider.VerifyIDToken(ctx, token.IDToken, loginState.Nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.resolveUser(claims, clientIP, userAgent)
+	return user, claims, err
+}
+
+// resolveUser 按外部身份查找本地用户：已关联的身份直接使用，否则按已验证的邮箱关联已有账户或自动创建账户
+// 配置了组角色映射时，每次登录按身份提供方返回的组同步用户角色
+func (s *OIDCService) resolveUser(claims *oidc.Claims, clientIP, userAgent string) (*models.User, error) {
+	if len(s.opts.AllowedDomains) > 0 && !s.domainAllowed(claims.Email) {
+		return nil, models.ErrOIDCDomainNotAllowed
+	}
+
+	var user models.User
+	var audits []*models.AuditLog
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var identity models.UserIdentity
+		err := tx.Where("issuer = ? AND subject = ?", claims.Issuer, claims.Subject).First(&identity).Error
+		switch {
+		case err == nil:
+			if err := tx.First(&user, identity.UserID).Error; err != nil {
+				return fmt.Errorf("failed to load linked user: %w", err)
+			}
+			if err := tx.Model(&identity).Updates(map[string]interface{}{"email": claims.Email, "last_login_at": now}).Error; err != nil {
+				return fmt.Errorf("failed to update identity: %w", err)
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			created, err := s.linkOrProvision(tx, claims, &user)
+			if err != nil {
+				return err
+			}
+			identity = models.UserIdentity{
+				UserID:      user.ID,
+				Issuer:      claims.Issuer,
+				Subject:     claims.Subject,
+				Email:       claims.Email,
+				LastLoginAt: &now,
+			}
+			if err := tx.Create(&identity).Error; err != nil {
+				return fmt.Errorf("failed to link identity: %w", err)
+			}
+			event := models.AuditSSOLinked
+			if created {
+				event = models.AuditSSOProvisioned
+			}
+			audits = append(audits, &models.AuditLog{UserID: &user.ID, Event: event, Username: claims.Email, IP: clientIP, UserAgent: userAgent, Detail: claims.Issuer})
+		default:
+			return fmt.Errorf("database error: %w", err)
+		}
+
+		// 等待验证邮箱的账户由身份提供方验证同一邮箱后激活，其他非正常状态拒绝登录
+		if !user.IsActive() {
+			if !user.IsPendingVerification() || !claims.EmailVerified || !strings.EqualFold(claims.Email, user.Email) {
+				return models.ErrOIDCAccountUnavailable
+			}
+			if err := markEmailVerified(tx, &user); err != nil {
+				return err
+			}
+		}
+
+		if role := s.mapRole(claims.Groups); role != "" && role != user.Role {
+			previous := user.Role
+			if err := tx.Model(&user).Update("role", role).Error; err != nil {
+				return fmt.Errorf("failed to update role: %w", err)
+			}
+			audits = append(audits, &models.AuditLog{UserID: &user.ID, Event: models.AuditRoleChanged, IP: clientIP, UserAgent: userAgent, Detail: fmt.Sprintf("%s -> %s (sso groups)", previous, role)})
+		}
+
+		user.UpdateLastLogin(clientIP)
+		return tx.Model(&user).Updates(map[string]interface{}{"last_login": user.LastLogin, "login_ip": user.LoginIP}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range audits {
+		s.record(entry)
+	}
+	return &user, nil
+}
+
+// linkOrProvision 没有已关联身份时按已验证的邮箱关联已有账户，没有账户时自动创建；返回是否新建了账户
+// RequireVerifiedEmail 只影响自动创建，关联已有账户总是要求邮箱已验证
+func (s *OIDCService) linkOrProvision(tx *gorm.DB, claims *oidc.Claims, user *models.User) (bool, error) {
+	if claims.Email == "" {
+		return false, errors.New("identity provider did not return an email address")
+	}
+	if s.opts.RequireVerifiedEmail && !claims.EmailVerified {
+		return false, models.ErrOIDCEmailNotVerified
+	}
+
+	err := tx.Where("LOWER(email) = LOWER(?)", claims.Email).First(user).Error
+	if err == nil {
+		// 关联已有账户始终要求身份提供方验证过邮箱，否则任何人都能在提供方填写他人邮箱接管账户
+		if !claims.EmailVerified {
+			return false, models.ErrOIDCEmailNotVerified
+		}
+		return false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, fmt.Errorf("database error: %w", err)
+	}
+	if !s.opts.AutoProvision {
+		return false, models.ErrOIDCProvisionDisabled
+	}
+
+	username, err := s.uniqueUsername(tx, claims)
+	if err != nil {
+		return false, err
+	}
+
+	// 单点登录用户没有本地密码，使用随机密码占位，可通过重置密码设置本地密码
+	password, err := randomToken(oidcTokenSize)
+	if err != nil {
+		return false, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	displayName := claims.Name
+	if displayName == "" {
+		displayName = username
+	}
+
+	*user = models.User{
+		Username:     username,
+		Email:        claims.Email,
+		PasswordHash: string(hashedPassword),
+		DisplayName:  truncate(displayName, 100),
+		Role:         s.opts.DefaultRole,
+		Status:       models.UserStatusActive,
+		Timezone:     "UTC",
+		Language:     "en",
+	}
+	if claims.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := tx.Create(user).Error; err != nil {
+		return false, fmt.Errorf("failed to create user: %w", err)
+	}
+	return true, nil
+}
+
+// uniqueUsername 根据 preferred_username 或邮箱前缀生成不重复的用户名
+func (s *OIDCService) uniqueUsername(tx *gorm.DB, claims *oidc.Claims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = usernameInvalidChars.ReplaceAllString(base, "")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", fmt.Errorf("database error: %w", err)
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		suffix, err := randomToken(3)
+		if err != nil {
+			return "", err
+		}
+		candidate = base + "_" + strings.ToLower(usernameInvalidChars.ReplaceAllString(suffix, ""))
+	}
+	return "", errors.New("failed to generate a unique username")
+}
+
+// mapRole 按组映射计算角色：取匹配的组中权限最高的角色，没有匹配时使用默认角色；未配置映射时返回空
+func (s *OIDCService) mapRole(groups []string) string {
+	if len(s.opts.RoleMapping) == 0 {
+		return ""
+	}
+	role := ""
+	for _, group := range groups {
+		mapped, ok := s.opts.RoleMapping[group]
+		if !ok || !models.IsValidRole(mapped) {
+			continue
+		}
+		role = models.HigherRole(role, mapped)
+	}
+	if role == "" {
+		role = s.opts.DefaultRole
+	}
+	return role
+}
+
+// domainAllowed 邮箱域名是否在允许列表中
+func (s *OIDCService) domainAllowed(email string) bool {
+	_, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return false
+	}
+	for _, allowed := range s.opts.AllowedDomains {
+		if strings.EqualFold(strings.TrimSpace(allowed), domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// redirectAllowed 客户端跳转地址是否匹配允许的前缀
+func (s *OIDCService) redirectAllowed(redirectURI string) bool {
+	for _, prefix := range s.opts.AllowedRedirectURIs {
+		if prefix = strings.TrimSpace(prefix); prefix != "" && strings.HasPrefix(redirectURI, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// record 写入审计日志，失败不影响登录流程
+func (s *OIDCService) record(entry *models.AuditLog) {
+	if s.audit == nil {
+		return
+	}
+	_ = s.audit.Record(entry)
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
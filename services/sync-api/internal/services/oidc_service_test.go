@@ -0,0 +1,378 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/oidc"
+)
+
+const (
+	testOIDCClientID = "xpaste"
+	testOIDCKeyID    = "test-key"
+)
+
+// mockIdP 测试用的身份提供方：发现文档、JWKS 和令牌端点
+// 授权码由测试通过 issue 直接签发，令牌端点校验 PKCE 后返回对应的 ID 令牌
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockAuthCode
+}
+
+// mockAuthCode 授权码对应的 PKCE 质询、ID 令牌声明和签名密钥
+type mockAuthCode struct {
+	challenge string
+	claims    jwt.MapClaims
+	key       *rsa.PrivateKey
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	idp := &mockIdP{key: key, codes: make(map[string]mockAuthCode)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": testOIDCKeyID,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// issue 模拟用户在身份提供方完成授权：根据授权地址签发授权码
+// claims 覆盖默认声明，值为 nil 时删除该声明；signer 为空时使用提供方的密钥签名
+func (idp *mockIdP) issue(t *testing.T, authURL string, claims jwt.MapClaims, signer *rsa.PrivateKey) (state, code string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse authorization url: %v", err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != testOIDCClientID {
+		t.Fatalf("unexpected authorization request: %s", authURL)
+	}
+
+	now := time.Now()
+	merged := jwt.MapClaims{
+		"iss":   idp.server.URL,
+		"aud":   testOIDCClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": q.Get("nonce"),
+	}
+	for name, value := range claims {
+		if value == nil {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = value
+	}
+	if signer == nil {
+		signer = idp.key
+	}
+
+	code = randomTestString(t)
+	idp.mu.Lock()
+	idp.codes[code] = mockAuthCode{challenge: q.Get("code_challenge"), claims: merged, key: signer}
+	idp.mu.Unlock()
+	return q.Get("state"), code
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	idp.mu.Lock()
+	code, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+	if !ok || oidc.CodeChallengeS256(r.PostForm.Get("code_verifier")) != code.challenge {
+		writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, code.claims)
+	token.Header["kid"] = testOIDCKeyID
+	idToken, err := token.SignedString(code.key)
+	if err != nil {
+		writeTestJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeTestJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomTestString(t *testing.T) string {
+	t.Helper()
+	s, err := oidc.RandomString(16)
+	if err != nil {
+		t.Fatalf("random string: %v", err)
+	}
+	return s
+}
+
+// newTestOIDCService 创建连接到模拟身份提供方的单点登录服务
+func newTestOIDCService(t *testing.T, idp *mockIdP, configure func(*OIDCOptions)) (*OIDCService, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t, &models.User{}, &models.UserIdentity{}, &models.OIDCLoginState{}, &models.AuditLog{})
+	opts := OIDCOptions{
+		Provider: oidc.NewProvider(oidc.Config{
+			Issuer:      idp.server.URL,
+			ClientID:    testOIDCClientID,
+			RedirectURL: "http://localhost/api/v1/auth/oidc/callback",
+		}),
+		AutoProvision:        true,
+		RequireVerifiedEmail: true,
+	}
+	if configure != nil {
+		configure(&opts)
+	}
+	service := NewOIDCService(db, NewAuditService(db))
+	service.Configure(opts)
+	return service, db
+}
+
+// login 走完整个单点登录流程：发起、身份提供方签发授权码、回调、兑换
+func login(t *testing.T, service *OIDCService, idp *mockIdP, claims jwt.MapClaims, signer *rsa.PrivateKey) (*models.User, error) {
+	t.Helper()
+	ctx := context.Background()
+	start, err := service.StartLogin(ctx, "device-1", "")
+	if err != nil {
+		t.Fatalf("StartLogin: %v", err)
+	}
+	state, code := idp.issue(t, start.AuthorizationURL, claims, signer)
+
+	if _, err := service.HandleCallback(ctx, state, code, "", "127.0.0.1", "test"); err != nil {
+		// 回调阶段的失败会记录在登录状态中，兑换时返回给客户端
+		if _, _, redeemErr := service.RedeemLogin(start.LoginToken); redeemErr == nil {
+			t.Fatalf("RedeemLogin succeeded after failed callback: %v", err)
+		}
+		return nil, err
+	}
+	user, deviceID, err := service.RedeemLogin(start.LoginToken)
+	if err != nil {
+		return nil, err
+	}
+	if deviceID != "device-1" {
+		t.Errorf("deviceID = %q, want device-1", deviceID)
+	}
+	return user, nil
+}
+
+func countAudit(t *testing.T, db *gorm.DB, event string) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&models.AuditLog{}).Where("event = ?", event).Count(&count).Error; err != nil {
+		t.Fatalf("count audit logs: %v", err)
+	}
+	return count
+}
+
+func TestOIDCLoginProvisionsUser(t *testing.T) {
+	idp := newMockIdP(t)
+	service, db := newTestOIDCService(t, idp, func(opts *OIDCOptions) {
+		opts.RoleMapping = map[string]string{"admins": models.RoleAdmin}
+	})
+
+	claims := jwt.MapClaims{"sub": "idp|alice", "email": "alice@example.com", "email_verified": true, "preferred_username": "alice", "groups": []string{"admins"}}
+	user, err := login(t, service, idp, claims, nil)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if user.Username != "alice" || user.Email != "alice@example.com" || user.Role != models.RoleAdmin || !user.IsEmailVerified() {
+		t.Errorf("provisioned user = %+v", user)
+	}
+
+	// 再次登录使用已关联的身份，不重复创建账户
+	again, err := login(t, service, idp, claims, nil)
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if again.ID != user.ID {
+		t.Errorf("second login user = %d, want %d", again.ID, user.ID)
+	}
+	if got := countAudit(t, db, models.AuditSSOProvisioned); got != 1 {
+		t.Errorf("provisioned audit entries = %d, want 1", got)
+	}
+}
+
+func TestOIDCCallbackStateMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+	service, db := newTestOIDCService(t, idp, nil)
+	ctx := context.Background()
+
+	start, err := service.StartLogin(ctx, "device-1", "")
+	if err != nil {
+		t.Fatalf("StartLogin: %v", err)
+	}
+	state, code := idp.issue(t, start.AuthorizationURL, jwt.MapClaims{"sub": "idp|alice", "email": "alice@example.com", "email_verified": true}, nil)
+
+	if _, err := service.HandleCallback(ctx, state+"x", code, "", "127.0.0.1", "test"); !errors.Is(err, models.ErrOIDCLoginInvalid) {
+		t.Fatalf("HandleCallback with wrong state = %v, want ErrOIDCLoginInvalid", err)
+	}
+	if _, _, err := service.RedeemLogin(start.LoginToken); !errors.Is(err, models.ErrOIDCLoginPending) {
+		t.Errorf("RedeemLogin = %v, want ErrOIDCLoginPending", err)
+	}
+
+	// 正确的 state 只能使用一次
+	if _, err := service.HandleCallback(ctx, state, code, "", "127.0.0.1", "test"); err != nil {
+		t.Fatalf("HandleCallback: %v", err)
+	}
+	if _, err := service.HandleCallback(ctx, state, code, "", "127.0.0.1", "test"); !errors.Is(err, models.ErrOIDCLoginInvalid) {
+		t.Errorf("replayed callback = %v, want ErrOIDCLoginInvalid", err)
+	}
+
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 1 {
+		t.Errorf("users = %d, want 1", count)
+	}
+}
+
+func TestOIDCCallbackRejectsInvalidIDToken(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	base := jwt.MapClaims{"sub": "idp|alice", "email": "alice@example.com", "email_verified": true}
+	with := func(name string, value interface{}) jwt.MapClaims {
+		claims := jwt.MapClaims{}
+		for k, v := range base {
+			claims[k] = v
+		}
+		claims[name] = value
+		return claims
+	}
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		signer *rsa.PrivateKey
+	}{
+		{"nonce mismatch", with("nonce", "attacker-nonce"), nil},
+		{"missing nonce", with("nonce", nil), nil},
+		{"bad signature", base, otherKey},
+		{"wrong audience", with("aud", "other-client"), nil},
+		{"wrong issuer", with("iss", "https://evil.example.com"), nil},
+		{"expired", with("exp", time.Now().Add(-time.Hour).Unix()), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			service, db := newTestOIDCService(t, idp, nil)
+
+			_, err := login(t, service, idp, tt.claims, tt.signer)
+			if !errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Fatalf("login = %v, want ErrInvalidIDToken", err)
+			}
+			var count int64
+			db.Model(&models.User{}).Count(&count)
+			if count != 0 {
+				t.Errorf("users = %d, want 0", count)
+			}
+			if got := countAudit(t, db, models.AuditSSOLoginFailed); got != 1 {
+				t.Errorf("failed login audit entries = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestOIDCLinkExistingAccountRequiresVerifiedEmail(t *testing.T) {
+	for _, requireVerified := range []bool{true, false} {
+		idp := newMockIdP(t)
+		service, db := newTestOIDCService(t, idp, func(opts *OIDCOptions) {
+			opts.RequireVerifiedEmail = requireVerified
+		})
+		existing := &models.User{Username: "alice", Email: "Alice@example.com", PasswordHash: "x", Status: models.UserStatusActive}
+		mustCreate(t, db, existing)
+
+		// 身份提供方未验证邮箱时不能关联已有账户，即使没有要求验证邮箱
+		_, err := login(t, service, idp, jwt.MapClaims{"sub": "idp|mallory", "email": "alice@example.com", "email_verified": false}, nil)
+		if !errors.Is(err, models.ErrOIDCEmailNotVerified) {
+			t.Fatalf("requireVerified=%v: unverified link = %v, want ErrOIDCEmailNotVerified", requireVerified, err)
+		}
+		var identities int64
+		db.Model(&models.UserIdentity{}).Count(&identities)
+		if identities != 0 {
+			t.Fatalf("requireVerified=%v: identities = %d, want 0", requireVerified, identities)
+		}
+
+		// 邮箱已验证时关联到同一账户（邮箱不区分大小写）
+		user, err := login(t, service, idp, jwt.MapClaims{"sub": "idp|alice", "email": "alice@example.com", "email_verified": true}, nil)
+		if err != nil {
+			t.Fatalf("requireVerified=%v: verified link: %v", requireVerified, err)
+		}
+		if user.ID != existing.ID {
+			t.Errorf("requireVerified=%v: linked user = %d, want %d", requireVerified, user.ID, existing.ID)
+		}
+		if got := countAudit(t, db, models.AuditSSOLinked); got != 1 {
+			t.Errorf("requireVerified=%v: linked audit entries = %d, want 1", requireVerified, got)
+		}
+	}
+}
+
+func TestOIDCProvisionRequiresVerifiedEmail(t *testing.T) {
+	idp := newMockIdP(t)
+	service, db := newTestOIDCService(t, idp, nil)
+
+	_, err := login(t, service, idp, jwt.MapClaims{"sub": "idp|bob", "email": "bob@example.com", "email_verified": false}, nil)
+	if !errors.Is(err, models.ErrOIDCEmailNotVerified) {
+		t.Fatalf("login = %v, want ErrOIDCEmailNotVerified", err)
+	}
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Errorf("users = %d, want 0", count)
+	}
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/models"
+)
+
+const (
+	// PairingTTL 配对码有效期，批准后新设备需在同样时长内换取令牌
+	PairingTTL = 5 * time.Minute
+
+	pairingCodeDigits    = 6
+	pairingCodeRetries   = 5
+	pairingRetentionTime = 24 * time.Hour // 结束的会话保留一段时间便于排查
+)
+
+// PairingService 设备配对服务
+type PairingService struct {
+	db            *gorm.DB
+	deviceService *DeviceService
+	notifier      Notifier
+}
+
+// NewPairingService 创建设备配对服务
+func NewPairingService(db *gorm.DB, deviceService *DeviceService) *PairingService {
+	return &PairingService{
+		db:            db,
+		deviceService: deviceService,
+	}
+}
+
+// CreateSession 由已信任设备发起配对，返回会话和二维码中的一次性密钥（明文密钥只返回这一次）
+func (s *PairingService) CreateSession(userID uint, initiatorDeviceID string) (*models.PairingSession, string, error) {
+	initiator, err := s.deviceService.GetDeviceByDeviceID(userID, initiatorDeviceID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !initiator.CanApproveDevices() {
+		return nil, "", models.ErrDeviceNotTrusted
+	}
+
+	now := time.Now()
+
+	// 清理早已结束的会话
+	if err := s.db.Unscoped().Where("expires_at < ?", now.Add(-pairingRetentionTime)).
+		Delete(&models.PairingSession{}).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to cleanup pairing sessions: %w", err)
+	}
+
+	// 同一设备只保留一个未认领的配对码
+	if err := s.db.Model(&models.PairingSession{}).
+		Where("user_id = ? AND initiator_device_id = ? AND status = ?", userID, initiatorDeviceID, models.PairingStatusPending).
+		Update("status", models.PairingStatusExpired).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to expire previous pairing sessions: %w", err)
+	}
+
+	code, err := s.generateCode(now)
+	if err != nil {
+		return nil, "", err
+	}
+	pairingID, err := randomToken(16)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	session := &models.PairingSession{
+		UserID:            userID,
+		InitiatorDeviceID: initiatorDeviceID,
+		PairingID:         pairingID,
+		Code:              code,
+		SecretHash:        hashToken(secret),
+		Status:            models.PairingStatusPending,
+		ExpiresAt:         now.Add(PairingTTL),
+	}
+	if err := s.db.Create(session).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to create pairing session: %w", err)
+	}
+
+	return session, secret, nil
+}
+
+// Claim 新设备认领配对会话，并通知发起设备等待批准
+// 返回的认领凭证用于之后换取令牌
+func (s *PairingService) Claim(req *models.ClaimPairingRequest, clientIP string) (*models.PairingSession, string, error) {
+	session, err := s.findClaimable(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	claimToken, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":           models.PairingStatusClaimed,
+		"claim_token_hash": hashToken(claimToken),
+		"claimed_at":       now,
+		"claim_ip":         clientIP,
+		"device_id":        req.DeviceID,
+		"name":             req.Name,
+		"platform":         req.Platform,
+		"version":          req.Version,
+		"model":            req.Model,
+		"os_version":       req.OSVersion,
+	}
+
+	// 只有第一个认领者生效
+	result := s.db.Model(session).Where("status = ?", models.PairingStatusPending).Updates(updates)
+	if result.Error != nil {
+		return nil, "", fmt.Errorf("failed to claim pairing session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, "", models.ErrPairingInvalidState
+	}
+
+	if err := s.db.First(session, session.ID).Error; err != nil {
+		return nil, "", fmt.Errorf("database error: %w", err)
+	}
+
+	s.notify(session.UserID, session.InitiatorDeviceID, EventPairingRequest, session.ToRequestEvent())
+
+	return session, claimToken, nil
+}
+
+// Decide 发起设备批准或拒绝配对，批准时指定新设备的信任级别
+func (s *PairingService) Decide(userID uint, deviceID string, pairingID string, approve bool, trustLevel models.DeviceTrustLevel) (*models.PairingSession, error) {
+	session, err := s.GetSession(userID, pairingID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只有发起配对的设备可以批准
+	if session.InitiatorDeviceID != deviceID {
+		return nil, models.ErrPairingNotFound
+	}
+
+	// 发起设备在配对期间可能被降级
+	initiator, err := s.deviceService.GetDeviceByDeviceID(userID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if !initiator.CanApproveDevices() {
+		return nil, models.ErrDeviceNotTrusted
+	}
+
+	switch session.CurrentStatus() {
+	case models.PairingStatusClaimed:
+	case models.PairingStatusExpired:
+		return nil, models.ErrPairingExpired
+	default:
+		return nil, models.ErrPairingInvalidState
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":     models.PairingStatusDenied,
+		"decided_at": now,
+	}
+	if approve {
+		if trustLevel == "" {
+			trustLevel = models.TrustLevelFull
+		}
+		updates["status"] = models.PairingStatusApproved
+		updates["expires_at"] = now.Add(PairingTTL)
+		updates["trust_level"] = trustLevel
+	}
+
+	result := s.db.Model(session).Where("status = ?", models.PairingStatusClaimed).Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update pairing session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, models.ErrPairingInvalidState
+	}
+
+	if err := s.db.First(session, session.ID).Error; err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return session, nil
+}
+
+// Redeem 新设备凭认领凭证换取设备，配对批准后以批准时的信任级别注册新设备并结束会话
+func (s *PairingService) Redeem(claimToken string, clientIP string) (*models.PairingSession, *models.Device, error) {
+	var session models.PairingSession
+	if err := s.db.Where("claim_token_hash = ?", hashToken(claimToken)).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.ErrPairingNotFound
+		}
+		return nil, nil, fmt.Errorf("database error: %w", err)
+	}
+
+	switch session.CurrentStatus() {
+	case models.PairingStatusApproved:
+	case models.PairingStatusClaimed:
+		return &session, nil, models.ErrPairingPending
+	case models.PairingStatusDenied:
+		return &session, nil, models.ErrPairingDenied
+	case models.PairingStatusExpired:
+		return &session, nil, models.ErrPairingExpired
+	default:
+		return &session, nil, models.ErrPairingInvalidState
+	}
+
+	var device *models.Device
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&session).Where("status = ?", models.PairingStatusApproved).
+			Update("status", models.PairingStatusCompleted)
+		if result.Error != nil {
+			return fmt.Errorf("failed to complete pairing session: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrPairingInvalidState
+		}
+
+		var err error
+		trustLevel := session.TrustLevel
+		if trustLevel == "" {
+			trustLevel = models.TrustLevelFull
+		}
+		device, err = NewDeviceService(tx).RegisterApprovedDevice(session.UserID, session.ToDeviceRequest(), clientIP,
+			trustLevel, session.InitiatorDeviceID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.notify(session.UserID, session.InitiatorDeviceID, EventPairingResult, map[string]interface{}{
+		"pairing_id": session.PairingID,
+		"status":     models.PairingStatusCompleted,
+		"device_id":  device.DeviceID,
+	})
+
+	return &session, device, nil
+}
+
+// GetSession 获取用户的配对会话
+func (s *PairingService) GetSession(userID uint, pairingID string) (*models.PairingSession, error) {
+	var session models.PairingSession
+	if err := s.db.Where("user_id = ? AND pairing_id = ?", userID, pairingID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrPairingNotFound
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &session, nil
+}
+
+// findClaimable 按配对码或二维码密钥查找可认领的会话
+func (s *PairingService) findClaimable(req *models.ClaimPairingRequest) (*models.PairingSession, error) {
+	var session models.PairingSession
+
+	if req.Code != "" {
+		if err := s.db.Where("code = ? AND status = ? AND expires_at > ?", req.Code, models.PairingStatusPending, time.Now()).
+			First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, models.ErrPairingNotFound
+			}
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+		return &session, nil
+	}
+
+	if err := s.db.Where("pairing_id = ?", req.PairingID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrPairingNotFound
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(session.SecretHash), []byte(hashToken(req.Secret))) != 1 {
+		return nil, models.ErrPairingNotFound
+	}
+
+	switch session.CurrentStatus() {
+	case models.PairingStatusPending:
+		return &session, nil
+	case models.PairingStatusExpired:
+		return nil, models.ErrPairingExpired
+	default:
+		return nil, models.ErrPairingInvalidState
+	}
+}
+
+// generateCode 生成当前未被占用的数字配对码
+func (s *PairingService) generateCode(now time.Time) (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(pairingCodeDigits), nil)
+
+	for i := 0; i < pairingCodeRetries; i++ {
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate pairing code: %w", err)
+		}
+		code := fmt.Sprintf("%0*d", pairingCodeDigits, n)
+
+		var count int64
+		if err := s.db.Model(&models.PairingSession{}).
+			Where("code = ? AND status = ? AND expires_at > ?", code, models.PairingStatusPending, now).
+			Count(&count).Error; err != nil {
+			return "", fmt.Errorf("database error: %w", err)
+		}
+		if count == 0 {
+			return code, nil
+		}
+	}
+
+	return "", fmt.Errorf("failed to allocate a unique pairing code")
+}
+
+// notify 推送实时事件，未设置通知器时忽略
+func (s *PairingService) notify(userID uint, deviceID string, event string, data interface{}) bool {
+	if s.notifier == nil {
+		return false
+	}
+	return s.notifier.NotifyDevice(userID, deviceID, event, data)
+}
+
+// randomToken 生成 URL 安全的随机字符串
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken 计算凭证的 SHA-256 哈希，数据库中只保存哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/logger"
+	"xpaste-sync/internal/models"
+)
+
+const (
+	maxRulesPerUser  = 100                  // 每个用户最多的规则数
+	maxRuleExpiresIn = 365 * 24 * time.Hour // 过期时间动作的上限
+)
+
+// RuleService 剪贴板项规则服务：新建剪贴板项时按优先级匹配用户的规则，执行打标签、设置过期时间、设置标题、丢弃和通知动作
+type RuleService struct {
+	db       *gorm.DB
+	notifier Notifier // 推送通知动作
+}
+
+// NewRuleService 创建规则服务
+func NewRuleService(db *gorm.DB) *RuleService {
+	return &RuleService{db: db}
+}
+
+// compiledRule 校验并预编译的规则
+type compiledRule struct {
+	rule      *models.ClipRule
+	pattern   *regexp.Regexp
+	expiresIn time.Duration
+}
+
+// compileRule 校验规则的条件和动作
+func compileRule(rule *models.ClipRule) (*compiledRule, error) {
+	compiled := &compiledRule{rule: rule}
+	if rule.Actions.IsEmpty() {
+		return nil, fmt.Errorf("%w: at least one action is required", models.ErrRuleInvalid)
+	}
+	if rule.Conditions.ContentRegex != "" {
+		pattern, err := regexp.Compile(rule.Conditions.ContentRegex)
+		if err != nil {
+			return nil, fmt.Errorf("%w: content_regex: %v", models.ErrRuleInvalid, err)
+		}
+		compiled.pattern = pattern
+	}
+	if rule.Actions.ExpiresIn != "" {
+		expiresIn, err := time.ParseDuration(rule.Actions.ExpiresIn)
+		if err != nil || expiresIn <= 0 || expiresIn > maxRuleExpiresIn {
+			return nil, fmt.Errorf("%w: expires_in must be a positive duration such as 2m or 24h, at most 8760h", models.ErrRuleInvalid)
+		}
+		compiled.expiresIn = expiresIn
+	}
+	return compiled, nil
+}
+
+// CreateRule 创建规则
+func (s *RuleService) CreateRule(userID uint, req *models.CreateRuleRequest) (*models.ClipRule, error) {
+	enabled := req.Enabled == nil || *req.Enabled
+	rule := &models.ClipRule{
+		UserID:     userID,
+		Name:       req.Name,
+		Enabled:    enabled,
+		Priority:   req.Priority,
+		Stop:       req.Stop,
+		Conditions: req.Conditions,
+		Actions:    req.Actions,
+	}
+	if _, err := compileRule(rule); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&models.ClipRule{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to count rules: %w", err)
+	}
+	if count >= maxRulesPerUser {
+		return nil, models.ErrRuleLimitReached
+	}
+
+	if err := s.db.Create(rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to create rule: %w", err)
+	}
+	// Enabled 的零值会被 GORM 的默认值覆盖（包括回填到 rule），创建后单独写入
+	if !enabled {
+		if err := s.db.Model(rule).Update("enabled", false).Error; err != nil {
+			return nil, fmt.Errorf("failed to create rule: %w", err)
+		}
+	}
+	return rule, nil
+}
+
+// ListRules 获取用户的规则，按执行顺序排列
+func (s *RuleService) ListRules(userID uint) ([]*models.ClipRule, error) {
+	var rules []*models.ClipRule
+	if err := s.db.Where("user_id = ?", userID).Order("priority ASC, id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get rules: %w", err)
+	}
+	return rules, nil
+}
+
+// GetRule 获取用户的规则
+func (s *RuleService) GetRule(userID uint, ruleID uint) (*models.ClipRule, error) {
+	var rule models.ClipRule
+	if err := s.db.Where("id = ? AND user_id = ?", ruleID, userID).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrRuleNotFound
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &rule, nil
+}
+
+// UpdateRule 修改规则
+func (s *RuleService) UpdateRule(userID uint, ruleID uint, req *models.UpdateRuleRequest) (*models.ClipRule, error) {
+	rule, err := s.GetRule(userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if req.Priority != nil {
+		rule.Priority = *req.Priority
+	}
+	if req.Stop != nil {
+		rule.Stop = *req.Stop
+	}
+	if req.Conditions != nil {
+		rule.Conditions = *req.Conditions
+	}
+	if req.Actions != nil {
+		rule.Actions = *req.Actions
+	}
+	if _, err := compileRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to update rule: %w", err)
+	}
+	return rule, nil
+}
+
+// DeleteRule 删除规则
+func (s *RuleService) DeleteRule(userID uint, ruleID uint) error {
+	rule, err := s.GetRule(userID, ruleID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(rule).Error; err != nil {
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
+	return nil
+}
+
+// enabledRules 获取用户启用的规则并预编译，无效的规则（例如旧版本保存的）跳过
+func (s *RuleService) enabledRules(userID uint) ([]*compiledRule, error) {
+	var rules []*models.ClipRule
+	if err := s.db.Where("user_id = ? AND enabled = ?", userID, true).Order("priority ASC, id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get rules: %w", err)
+	}
+	compiled := make([]*compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		c, err := compileRule(rule)
+		if err != nil {
+			logger.Warnf("Skipping invalid rule %d of user %d: %v", rule.ID, userID, err)
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// Apply 对即将保存的剪贴板项执行用户的规则，直接修改 clipItem，并记录规则的匹配次数
+// 结果中 Dropped 为 true 时调用方不应保存剪贴板项；通知由调用方在保存后通过 Notify 推送
+func (s *RuleService) Apply(userID uint, clipItem *models.ClipItem) (*models.RuleResult, error) {
+	rules, err := s.enabledRules(userID)
+	if err != nil {
+		return nil, err
+	}
+	result := s.evaluate(userID, clipItem, rules)
+
+	if len(result.Matched) > 0 {
+		ids := make([]uint, 0, len(result.Matched))
+		for _, match := range result.Matched {
+			ids = append(ids, match.RuleID)
+		}
+		if err := s.db.Model(&models.ClipRule{}).Where("id IN ?", ids).UpdateColumns(map[string]interface{}{
+			"match_count":     gorm.Expr("match_count + 1"),
+			"last_matched_at": time.Now(),
+		}).Error; err != nil {
+			logger.Warnf("Failed to record rule matches for user %d: %v", userID, err)
+		}
+	}
+	return result, nil
+}
+
+// Notify 推送规则的通知动作，clipItem 为保存后（或被丢弃）的剪贴板项
+func (s *RuleService) Notify(userID uint, result *models.RuleResult, clipItem *models.ClipItem) {
+	if s.notifier == nil {
+		return
+	}
+	for _, notification := range result.Notifications {
+		notification.Clip = MaskClipResponse(clipItem)
+		s.notifier.NotifyUser(userID, EventRuleNotification, notification)
+	}
+}
+
+// DryRun 用示例剪贴板项测试规则，不保存剪贴板项、不记录匹配次数、不推送通知
+// 提供 req.Rule 时只测试该规则，否则测试用户所有启用的规则
+func (s *RuleService) DryRun(userID uint, req *models.RuleDryRunRequest) (*models.RuleResult, error) {
+	var rules []*compiledRule
+	if req.Rule != nil {
+		compiled, err := compileRule(&models.ClipRule{
+			UserID:     userID,
+			Name:       req.Rule.Name,
+			Enabled:    true,
+			Priority:   req.Rule.Priority,
+			Stop:       req.Rule.Stop,
+			Conditions: req.Rule.Conditions,
+			Actions:    req.Rule.Actions,
+		})
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, compiled)
+	} else {
+		var err error
+		if rules, err = s.enabledRules(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	clipItem := &models.ClipItem{
+		UserID:      userID,
+		Scope:       models.ClipScopeUser,
+		DeviceID:    req.Clip.DeviceID,
+		Type:        models.ClipType(req.Clip.Type),
+		Content:     req.Clip.Content,
+		Title:       req.Clip.Title,
+		Description: req.Clip.Description,
+		Tags:        req.Clip.Tags,
+		Metadata:    req.Clip.Metadata,
+		Status:      models.ClipStatusActive,
+		ExpiresAt:   req.Clip.ExpiresAt,
+	}
+	if req.Clip.TeamID != nil {
+		clipItem.Scope = models.ClipScopeTeam
+		clipItem.TeamID = req.Clip.TeamID
+	}
+
+	result := s.evaluate(userID, clipItem, rules)
+	for _, notification := range result.Notifications {
+		notification.Clip = result.Clip
+	}
+	return result, nil
+}
+
+// evaluate 依次匹配规则并执行动作，遇到丢弃动作或 Stop 规则后停止
+func (s *RuleService) evaluate(userID uint, clipItem *models.ClipItem, rules []*compiledRule) *models.RuleResult {
+	result := &models.RuleResult{
+		Matched:       []models.RuleMatch{},
+		Notifications: []*models.RuleNotification{},
+	}
+
+	var platform *string // 按需查询一次来源设备的平台
+	for _, c := range rules {
+		if len(c.rule.Conditions.Platforms) > 0 && platform == nil {
+			p := s.devicePlatform(userID, clipItem.DeviceID)
+			platform = &p
+		}
+
+		submatch, ok := c.match(clipItem, platform)
+		if !ok {
+			continue
+		}
+		match := models.RuleMatch{RuleID: c.rule.ID, Name: c.rule.Name}
+		result.Matched = append(result.Matched, match)
+		c.apply(clipItem, submatch, result)
+
+		if result.Dropped {
+			result.DroppedBy = &match
+			break
+		}
+		if c.rule.Stop {
+			break
+		}
+	}
+
+	result.Clip = clipItem.ToResponse()
+	return result
+}
+
+// devicePlatform 查询用户设备的平台，设备不存在时返回空字符串
+func (s *RuleService) devicePlatform(userID uint, deviceID string) string {
+	if deviceID == "" {
+		return ""
+	}
+	var platforms []string
+	if err := s.db.Model(&models.Device{}).Where("user_id = ? AND device_id = ?", userID, deviceID).Limit(1).Pluck("platform", &platforms).Error; err != nil {
+		logger.Warnf("Failed to look up platform of device %s: %v", deviceID, err)
+		return ""
+	}
+	if len(platforms) == 0 {
+		return ""
+	}
+	return platforms[0]
+}
+
+// match 判断剪贴板项是否满足规则的所有条件，返回正则匹配的位置（用于展开捕获组）
+func (c *compiledRule) match(clipItem *models.ClipItem, platform *string) ([]int, bool) {
+	conditions := &c.rule.Conditions
+	if len(conditions.Types) > 0 && !containsString(conditions.Types, string(clipItem.Type)) {
+		return nil, false
+	}
+	if len(conditions.DeviceIDs) > 0 && !containsString(conditions.DeviceIDs, clipItem.DeviceID) {
+		return nil, false
+	}
+	if len(conditions.Platforms) > 0 && (platform == nil || !containsString(conditions.Platforms, *platform)) {
+		return nil, false
+	}
+	for key, want := range conditions.Metadata {
+		value, exists := clipItem.Metadata[key]
+		if !exists || value == nil {
+			return nil, false
+		}
+		if want != "*" && fmt.Sprint(value) != want {
+			return nil, false
+		}
+	}
+
+	if c.pattern == nil {
+		return nil, true
+	}
+	// 图片和文件的内容是 data URL，不参与正则匹配
+	if clipItem.Type != models.ClipTypeText && clipItem.Type != models.ClipTypeURL {
+		return nil, false
+	}
+	submatch := c.pattern.FindStringSubmatchIndex(clipItem.Content)
+	if submatch == nil {
+		return nil, false
+	}
+	return submatch, true
+}
+
+// apply 执行规则的动作
+func (c *compiledRule) apply(clipItem *models.ClipItem, submatch []int, result *models.RuleResult) {
+	actions := &c.rule.Actions
+
+	for _, tag := range actions.AddTags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !containsString(clipItem.Tags, tag) {
+			clipItem.Tags = append(clipItem.Tags, tag)
+		}
+	}
+	// 多条规则设置过期时间时取最早的
+	if c.expiresIn > 0 {
+		expiresAt := time.Now().Add(c.expiresIn)
+		if clipItem.ExpiresAt == nil || expiresAt.Before(*clipItem.ExpiresAt) {
+			clipItem.ExpiresAt = &expiresAt
+		}
+	}
+	if actions.SetTitle != "" {
+		clipItem.Title = c.expand(actions.SetTitle, clipItem.Content, submatch)
+	}
+	if actions.Notify != "" {
+		result.Notifications = append(result.Notifications, &models.RuleNotification{
+			RuleID:  c.rule.ID,
+			Name:    c.rule.Name,
+			Message: c.expand(actions.Notify, clipItem.Content, submatch),
+		})
+	}
+	if actions.Drop {
+		result.Dropped = true
+	}
+}
+
+// expand 展开模板中的 $1、${name} 捕获组引用，规则没有正则条件时原样返回
+func (c *compiledRule) expand(template string, content string, submatch []int) string {
+	if c.pattern == nil || submatch == nil {
+		return template
+	}
+	return string(c.pattern.ExpandString(nil, template, content, submatch))
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/logger"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/sensitive"
+)
+
+// SensitivePolicy 用户的敏感数据处理设置
+type SensitivePolicy struct {
+	Enabled           bool          // 是否识别敏感数据
+	Detectors         []string      // 启用的识别器，为空表示全部
+	Mask              bool          // 是否在响应中遮盖
+	ExpiresIn         time.Duration // 自动过期时间，0 表示不过期
+	ExcludedPlatforms []string      // 不同步到这些平台
+}
+
+// SensitiveService 敏感数据识别服务：创建剪贴板项时按用户设置识别敏感数据，
+// 标记剪贴板项，并按设置缩短过期时间、排除同步平台和在响应中遮盖
+type SensitiveService struct {
+	db       *gorm.DB
+	pipeline *sensitive.Pipeline
+}
+
+// NewSensitiveService 创建敏感数据识别服务，使用内置的识别器
+func NewSensitiveService(db *gorm.DB) *SensitiveService {
+	return &SensitiveService{db: db, pipeline: sensitive.Default()}
+}
+
+// Pipeline 识别流水线，可以注册自定义的识别器
+func (s *SensitiveService) Pipeline() *sensitive.Pipeline {
+	return s.pipeline
+}
+
+// Policy 读取用户的敏感数据设置，未设置或无法解析的项使用默认值
+func (s *SensitiveService) Policy(userID uint) (*SensitivePolicy, error) {
+	policy := &SensitivePolicy{Enabled: true, Mask: true}
+
+	var settings []*models.Setting
+	if err := s.db.Where("user_id = ? AND key LIKE ?", userID, "user.sensitive_%").Find(&settings).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sensitive data settings: %w", err)
+	}
+	for _, setting := range settings {
+		switch setting.Key {
+		case models.SettingKeyUserSensitiveDetection:
+			if value, err := strconv.ParseBool(setting.Value); err == nil {
+				policy.Enabled = value
+			}
+		case models.SettingKeyUserSensitiveDetectors:
+			policy.Detectors = parseSettingList(setting.Value)
+		case models.SettingKeyUserSensitiveMask:
+			if value, err := strconv.ParseBool(setting.Value); err == nil {
+				policy.Mask = value
+			}
+		case models.SettingKeyUserSensitiveExpiresIn:
+			if value, err := time.ParseDuration(setting.Value); err == nil && value > 0 {
+				policy.ExpiresIn = value
+			}
+		case models.SettingKeyUserSensitiveExcludePlatforms:
+			policy.ExcludedPlatforms = parseSettingList(setting.Value)
+		}
+	}
+	return policy, nil
+}
+
+// Inspect 识别即将保存的剪贴板项中的敏感数据，并按用户设置修改 clipItem，只处理文本和链接
+func (s *SensitiveService) Inspect(userID uint, clipItem *models.ClipItem) error {
+	if clipItem.Type != models.ClipTypeText && clipItem.Type != models.ClipTypeURL {
+		return nil
+	}
+	policy, err := s.Policy(userID)
+	if err != nil {
+		return err
+	}
+	if !policy.Enabled {
+		return nil
+	}
+
+	findings := s.pipeline.Scan(clipItem.Content, policy.Detectors)
+	if len(findings) == 0 {
+		return nil
+	}
+
+	clipItem.Sensitive = true
+	clipItem.SensitiveMatches = make([]models.SensitiveMatch, 0, len(findings))
+	for _, finding := range findings {
+		clipItem.SensitiveMatches = append(clipItem.SensitiveMatches, models.SensitiveMatch(finding))
+	}
+	clipItem.Masked = policy.Mask
+	clipItem.ExcludedPlatforms = policy.ExcludedPlatforms
+	// 已有更早的过期时间时保持不变
+	if policy.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(policy.ExpiresIn)
+		if clipItem.ExpiresAt == nil || expiresAt.Before(*clipItem.ExpiresAt) {
+			clipItem.ExpiresAt = &expiresAt
+		}
+	}
+	logger.Infof("Sensitive data detected in clip for user %d: %s", userID, strings.Join(sensitive.Kinds(findings), ", "))
+	return nil
+}
+
+// MaskClipResponse 转换为响应格式，需要遮盖的剪贴板项只返回遮盖后的内容
+func MaskClipResponse(clipItem *models.ClipItem) *models.ClipItemResponse {
+	response := clipItem.ToResponse()
+	if !clipItem.Masked || len(clipItem.SensitiveMatches) == 0 {
+		return response
+	}
+	findings := make([]sensitive.Finding, 0, len(clipItem.SensitiveMatches))
+	for _, match := range clipItem.SensitiveMatches {
+		findings = append(findings, sensitive.Finding(match))
+	}
+	response.Content = sensitive.Mask(clipItem.Content, findings)
+	response.Sensitive.Masked = true
+	// 识别结果中的链接、验证码等值同样可能包含敏感数据，只保留子类型和语言
+	if clipItem.Classification != nil {
+		response.Classification = &models.ClipClassification{
+			Subtype:  clipItem.Classification.Subtype,
+			Language: clipItem.Classification.Language,
+		}
+	}
+	return response
+}
+
+// parseSettingList 解析 JSON 数组或逗号分隔的设置值
+func parseSettingList(value string) []string {
+	value = strings.TrimSpace(value)
+	var items []string
+	if strings.HasPrefix(value, "[") {
+		if json.Unmarshal([]byte(value), &items) != nil {
+			return nil
+		}
+	} else {
+		items = strings.Split(value, ",")
+	}
+
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" && !containsString(result, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
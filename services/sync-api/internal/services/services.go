@@ -6,21 +6,69 @@ import (
 
 // Services 服务集合
 type Services struct {
-	db      *gorm.DB
-	User    *UserService
-	Device  *DeviceService
-	Clip    *ClipService
-	Setting *SettingService
+	db          *gorm.DB
+	User        *UserService
+	Device      *DeviceService
+	Clip        *ClipService
+	Team        *TeamService
+	Share       *ShareService
+	Webhook     *WebhookService
+	Inbound     *InboundService
+	Rule        *RuleService
+	Sensitive   *SensitiveService
+	Setting     *SettingService
+	Pairing     *PairingService
+	Token       *TokenService
+	AccessToken *AccessTokenService
+	TwoFactor   *TwoFactorService
+	Audit       *AuditService
+	LoginGuard  *LoginGuardService
+	Email       *EmailService
+	OIDC        *OIDCService
+	Admin       *AdminService
 }
 
 // NewServices 创建服务集合
 func NewServices(db *gorm.DB) *Services {
+	device := NewDeviceService(db)
+	audit := NewAuditService(db)
+	loginGuard := NewLoginGuardService(db, audit)
+	user := NewUserService(db)
+	user.guard = loginGuard
+	user.audit = audit
+	token := NewTokenService(db)
+	email := NewEmailService(db, audit, token, loginGuard)
+	webhook := NewWebhookService(db)
+	clip := NewClipService(db)
+	clip.webhooks = webhook
+	rule := NewRuleService(db)
+	clip.rules = rule
+	sensitive := NewSensitiveService(db)
+	clip.sensitive = sensitive
+	twoFactor := NewTwoFactorService(db)
+	twoFactor.guard = loginGuard
+
 	return &Services{
-		db:      db,
-		User:    NewUserService(db),
-		Device:  NewDeviceService(db),
-		Clip:    NewClipService(db),
-		Setting: NewSettingService(db),
+		db:          db,
+		User:        user,
+		Device:      device,
+		Clip:        clip,
+		Team:        NewTeamService(db),
+		Share:       NewShareService(db, clip),
+		Webhook:     webhook,
+		Inbound:     NewInboundService(db, clip),
+		Rule:        rule,
+		Sensitive:   sensitive,
+		Setting:     NewSettingService(db),
+		Pairing:     NewPairingService(db, device),
+		Token:       token,
+		AccessToken: NewAccessTokenService(db),
+		TwoFactor:   twoFactor,
+		Audit:       audit,
+		LoginGuard:  loginGuard,
+		Email:       email,
+		OIDC:        NewOIDCService(db, audit),
+		Admin:       NewAdminService(db, audit, user, token, email, loginGuard),
 	}
 }
 
@@ -29,6 +77,17 @@ func (s *Services) GetDB() *gorm.DB {
 	return s.db
 }
 
+// SetNotifier 设置实时通知器（WebSocket 服务创建后调用）
+func (s *Services) SetNotifier(notifier Notifier) {
+	s.Device.notifier = notifier
+	s.Pairing.notifier = notifier
+	s.Token.notifier = notifier
+	s.LoginGuard.notifier = notifier
+	s.Clip.notifier = notifier
+	s.Inbound.notifier = notifier
+	s.Rule.notifier = notifier
+}
+
 // InitializeServices 初始化服务（创建默认数据等）
 func (s *Services) InitializeServices() error {
 	// 初始化默认系统设置
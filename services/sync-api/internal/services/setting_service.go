@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"fmt"
+	"strconv"
 
 	"gorm.io/gorm"
 
@@ -49,6 +50,19 @@ func (s *SettingService) GetSystemSetting(key string) (*models.Setting, error) {
 	return &setting, nil
 }
 
+// GetSystemBool 读取布尔类型的系统设置，设置不存在或无法解析时返回默认值
+func (s *SettingService) GetSystemBool(key string, defaultValue bool) bool {
+	setting, err := s.GetSystemSetting(key)
+	if err != nil {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(setting.Value)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 // GetUserSettings 获取用户所有设置
 func (s *SettingService) GetUserSettings(userID uint, category string) ([]*models.Setting, error) {
 	var settings []*models.Setting
@@ -56,7 +70,7 @@ func (s *SettingService) GetUserSettings(userID uint, category string) ([]*model
 	if category != "" {
 		query = query.Where("category = ?", category)
 	}
-	query = query.Order("category, sort_order, key")
+	query = query.Order("category, key")
 
 	if err := query.Find(&settings).Error; err != nil {
 		return nil, fmt.Errorf("failed to get user settings: %w", err)
@@ -71,7 +85,7 @@ func (s *SettingService) GetSystemSettings(category string) ([]*models.Setting,
 	if category != "" {
 		query = query.Where("category = ?", category)
 	}
-	query = query.Order("category, sort_order, key")
+	query = query.Order("category, key")
 
 	if err := query.Find(&settings).Error; err != nil {
 		return nil, fmt.Errorf("failed to get system settings: %w", err)
@@ -243,7 +257,7 @@ func (s *SettingService) GetSettingsByCategory(userID *uint, category string) ([
 	} else {
 		query = query.Where("user_id IS NULL")
 	}
-	query = query.Order("sort_order, key")
+	query = query.Order("key")
 
 	if err := query.Find(&settings).Error; err != nil {
 		return nil, fmt.Errorf("failed to get settings by category: %w", err)
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/models"
+)
+
+// ShareService 分享链接服务
+type ShareService struct {
+	db    *gorm.DB
+	clips *ClipService
+
+	baseURL string        // 分享地址前缀，为空时由处理器按请求地址生成
+	maxTTL  time.Duration // 分享链接最长有效期，0 表示不限制
+}
+
+// NewShareService 创建分享链接服务
+func NewShareService(db *gorm.DB, clips *ClipService) *ShareService {
+	return &ShareService{db: db, clips: clips}
+}
+
+// Configure 设置分享地址前缀和最长有效期
+func (s *ShareService) Configure(baseURL string, maxTTL time.Duration) {
+	s.baseURL = baseURL
+	s.maxTTL = maxTTL
+}
+
+// BaseURL 分享地址前缀
+func (s *ShareService) BaseURL() string {
+	return s.baseURL
+}
+
+// WithReadableSince 返回只能分享指定时间之后创建的剪贴板项的服务副本（按设备信任级别限制）
+func (s *ShareService) WithReadableSince(since *time.Time) *ShareService {
+	clone := *s
+	clone.clips = s.clips.WithReadableSince(since)
+	return &clone
+}
+
+// ShareVisitor 访问分享链接的客户端信息，记录到访问日志
+type ShareVisitor struct {
+	IP        string
+	UserAgent string
+	Referer   string
+}
+
+// CreateShareLink 为用户可访问的剪贴板项创建分享链接，团队剪贴板项需要成员及以上角色
+func (s *ShareService) CreateShareLink(userID uint, req *models.CreateShareLinkRequest) (*models.ShareLink, error) {
+	clipItem, role, err := s.clips.findVisible(userID, req.ClipID)
+	if err != nil {
+		return nil, err
+	}
+	if clipItem.Scope == models.ClipScopeTeam && !role.CanPost() {
+		return nil, models.ErrTeamPermissionDenied
+	}
+
+	now := time.Now()
+	expiresAt := req.ExpiresAt
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, models.ErrShareExpiryInvalid
+	}
+	if s.maxTTL > 0 {
+		if limit := now.Add(s.maxTTL); expiresAt == nil || expiresAt.After(limit) {
+			expiresAt = &limit
+		}
+	}
+
+	slug, err := randomToken(12)
+	if err != nil {
+		return nil, err
+	}
+
+	link := &models.ShareLink{
+		Slug:             slug,
+		ClipItemID:       clipItem.ID,
+		UserID:           userID,
+		ExpiresAt:        expiresAt,
+		MaxViews:         req.MaxViews,
+		BurnAfterReading: req.BurnAfterReading,
+	}
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash share password: %w", err)
+		}
+		link.PasswordHash = string(hash)
+	}
+
+	if err := s.db.Create(link).Error; err != nil {
+		return nil, fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	return link, nil
+}
+
+// ListShareLinks 获取用户创建的分享链接，clipID 不为 0 时只返回该剪贴板项的链接
+func (s *ShareService) ListShareLinks(userID uint, clipID uint) ([]*models.ShareLink, error) {
+	query := s.db.Where("user_id = ?", userID)
+	if clipID != 0 {
+		query = query.Where("clip_item_id = ?", clipID)
+	}
+
+	var links []*models.ShareLink
+	if err := query.Order("created_at DESC").Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to get share links: %w", err)
+	}
+	return links, nil
+}
+
+// GetShareLink 获取用户创建的分享链接
+func (s *ShareService) GetShareLink(userID uint, linkID uint) (*models.ShareLink, error) {
+	var link models.ShareLink
+	if err := s.db.Where("id = ? AND user_id = ?", linkID, userID).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrShareLinkNotFound
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &link, nil
+}
+
+// RevokeShareLink 撤销分享链接，已撤销的链接保持原撤销时间
+func (s *ShareService) RevokeShareLink(userID uint, linkID uint) (*models.ShareLink, error) {
+	link, err := s.GetShareLink(userID, linkID)
+	if err != nil {
+		return nil, err
+	}
+	if link.RevokedAt != nil {
+		return link, nil
+	}
+
+	now := time.Now()
+	if err := s.db.Model(link).Update("revoked_at", now).Error; err != nil {
+		return nil, fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	link.RevokedAt = &now
+	return link, nil
+}
+
+// ListShareViews 获取分享链接的访问记录，按时间倒序
+func (s *ShareService) ListShareViews(userID uint, linkID uint, params *models.PaginationParams) ([]*models.ShareView, *models.PaginationResponse, error) {
+	if _, err := s.GetShareLink(userID, linkID); err != nil {
+		return nil, nil, err
+	}
+
+	query := s.db.Model(&models.ShareView{}).Where("share_link_id = ?", linkID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to count share views: %w", err)
+	}
+
+	var views []*models.ShareView
+	if err := query.Order("created_at DESC").Offset(params.GetOffset()).Limit(params.GetLimit()).Find(&views).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get share views: %w", err)
+	}
+
+	pagination := &models.PaginationResponse{
+		Page:     params.Page,
+		PageSize: params.PageSize,
+		Total:    total,
+	}
+	pagination.CalculateTotalPages()
+
+	return views, pagination, nil
+}
+
+// OpenShareLink 打开分享链接：校验状态和密码，计入查看次数并记录访问日志
+// 阅后即焚的链接在第一次查看成功后撤销；查看次数用条件更新计数，并发访问不会超过上限
+func (s *ShareService) OpenShareLink(slug string, password string, visitor *ShareVisitor) (*models.ShareLink, *models.ClipItem, error) {
+	var link models.ShareLink
+	if err := s.db.Where("slug = ?", slug).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.ErrShareLinkNotFound
+		}
+		return nil, nil, fmt.Errorf("database error: %w", err)
+	}
+	if link.RevokedAt != nil {
+		return nil, nil, models.ErrShareLinkNotFound
+	}
+	if link.IsExpired() {
+		return nil, nil, models.ErrShareLinkGone
+	}
+
+	var clipItem models.ClipItem
+	if err := s.db.First(&clipItem, link.ClipItemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.ErrShareLinkNotFound
+		}
+		return nil, nil, fmt.Errorf("database error: %w", err)
+	}
+	if clipItem.ExpiresAt != nil && clipItem.ExpiresAt.Before(time.Now()) {
+		return nil, nil, models.ErrShareLinkGone
+	}
+
+	if link.HasPassword() {
+		if password == "" {
+			return nil, nil, models.ErrSharePasswordRequired
+		}
+		if bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+			s.recordView(link.ID, models.ShareViewWrongPassword, visitor)
+			return nil, nil, models.ErrSharePasswordInvalid
+		}
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"view_count":     gorm.Expr("view_count + 1"),
+		"last_viewed_at": now,
+	}
+	if link.BurnAfterReading {
+		updates["revoked_at"] = now
+	}
+	result := s.db.Model(&models.ShareLink{}).
+		Where("id = ? AND revoked_at IS NULL AND (max_views = 0 OR view_count < max_views)", link.ID).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, nil, fmt.Errorf("failed to count share view: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// 并发访问已用完查看次数或已焚毁
+		return nil, nil, models.ErrShareLinkGone
+	}
+
+	link.ViewCount++
+	link.LastViewedAt = &now
+	if link.BurnAfterReading {
+		link.RevokedAt = &now
+	}
+	s.recordView(link.ID, models.ShareViewViewed, visitor)
+
+	return &link, &clipItem, nil
+}
+
+// recordView 记录访问日志，写入失败不影响访问
+func (s *ShareService) recordView(linkID uint, result models.ShareViewResult, visitor *ShareVisitor) {
+	view := &models.ShareView{ShareLinkID: linkID, Result: result}
+	if visitor != nil {
+		view.IP = visitor.IP
+		view.UserAgent = truncate(visitor.UserAgent, 500)
+		view.Referer = truncate(visitor.Referer, 500)
+	}
+	_ = s.db.Create(view).Error
+}
@@ -0,0 +1,462 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/models"
+)
+
+// TeamService 团队服务
+type TeamService struct {
+	db *gorm.DB
+}
+
+// NewTeamService 创建团队服务
+func NewTeamService(db *gorm.DB) *TeamService {
+	return &TeamService{db: db}
+}
+
+// teamRole 获取用户在团队中的角色，不是成员时返回 ErrTeamNotFound，不暴露团队是否存在
+func teamRole(db *gorm.DB, teamID, userID uint) (models.TeamRole, error) {
+	var member models.TeamMember
+	err := db.Joins("JOIN teams ON teams.id = team_members.team_id AND teams.deleted_at IS NULL").
+		Where("team_members.team_id = ? AND team_members.user_id = ?", teamID, userID).
+		First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", models.ErrTeamNotFound
+		}
+		return "", fmt.Errorf("database error: %w", err)
+	}
+	return member.Role, nil
+}
+
+// memberTeamIDs 用户所在团队ID的子查询
+func memberTeamIDs(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Model(&models.TeamMember{}).Select("team_id").Where("user_id = ?", userID)
+}
+
+// teamMemberIDs 团队所有成员的用户ID
+func teamMemberIDs(db *gorm.DB, teamID uint) ([]uint, error) {
+	var userIDs []uint
+	if err := db.Model(&models.TeamMember{}).Where("team_id = ?", teamID).Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get team members: %w", err)
+	}
+	return userIDs, nil
+}
+
+// CreateTeam 创建团队，创建者成为所有者
+func (s *TeamService) CreateTeam(userID uint, req *models.CreateTeamRequest) (*models.TeamResponse, error) {
+	team := &models.Team{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		OwnerID:     userID,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(team).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.TeamMember{TeamID: team.ID, UserID: userID, Role: models.TeamRoleOwner}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create team: %w", err)
+	}
+
+	return s.toResponse(team, models.TeamRoleOwner)
+}
+
+// ListTeams 获取用户所在的团队
+func (s *TeamService) ListTeams(userID uint) ([]*models.TeamResponse, error) {
+	var members []*models.TeamMember
+	if err := s.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to get team memberships: %w", err)
+	}
+
+	teams := make([]*models.TeamResponse, 0, len(members))
+	for _, member := range members {
+		var team models.Team
+		if err := s.db.First(&team, member.TeamID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get team: %w", err)
+		}
+		response, err := s.toResponse(&team, member.Role)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, response)
+	}
+
+	return teams, nil
+}
+
+// GetTeam 获取团队详情和成员列表，只有成员可以查看
+func (s *TeamService) GetTeam(teamID, userID uint) (*models.TeamDetailResponse, error) {
+	role, err := teamRole(s.db, teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := s.getTeam(teamID)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.toResponse(team, role)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []*models.TeamMember
+	if err := s.db.Preload("User").Where("team_id = ?", teamID).Order("created_at ASC").Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to get team members: %w", err)
+	}
+
+	detail := &models.TeamDetailResponse{
+		TeamResponse: response,
+		Members:      make([]*models.TeamMemberResponse, len(members)),
+	}
+	for i, member := range members {
+		detail.Members[i] = member.ToResponse()
+	}
+
+	return detail, nil
+}
+
+// UpdateTeam 修改团队名称或描述，需要管理员及以上角色
+func (s *TeamService) UpdateTeam(teamID, userID uint, req *models.UpdateTeamRequest) (*models.TeamResponse, error) {
+	role, err := teamRole(s.db, teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !role.AtLeast(models.TeamRoleAdmin) {
+		return nil, models.ErrTeamPermissionDenied
+	}
+
+	team, err := s.getTeam(teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		team.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		team.Description = *req.Description
+	}
+
+	if err := s.db.Save(team).Error; err != nil {
+		return nil, fmt.Errorf("failed to update team: %w", err)
+	}
+
+	return s.toResponse(team, role)
+}
+
+// DeleteTeam 删除团队、成员关系、邀请和团队剪贴板项，只有所有者可以删除
+func (s *TeamService) DeleteTeam(teamID, userID uint) error {
+	role, err := teamRole(s.db, teamID, userID)
+	if err != nil {
+		return err
+	}
+	if role != models.TeamRoleOwner {
+		return models.ErrTeamPermissionDenied
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("scope = ? AND team_id = ?", models.ClipScopeTeam, teamID).Delete(&models.ClipItem{}).Error; err != nil {
+			return fmt.Errorf("failed to delete team clip items: %w", err)
+		}
+		if err := tx.Where("team_id = ?", teamID).Delete(&models.TeamMember{}).Error; err != nil {
+			return fmt.Errorf("failed to delete team members: %w", err)
+		}
+		if err := tx.Where("team_id = ?", teamID).Delete(&models.TeamInvitation{}).Error; err != nil {
+			return fmt.Errorf("failed to delete team invitations: %w", err)
+		}
+		if err := tx.Delete(&models.Team{}, teamID).Error; err != nil {
+			return fmt.Errorf("failed to delete team: %w", err)
+		}
+		return nil
+	})
+}
+
+// InviteMember 按用户名或邮箱邀请成员，默认角色为 member，被邀请人接受后才加入团队
+// 地址未注册、已是成员或重复邀请时同样保存邀请并返回相同的结果，不暴露账户是否存在
+func (s *TeamService) InviteMember(teamID, actorID uint, req *models.AddTeamMemberRequest) (*models.TeamInvitation, error) {
+	newRole := models.TeamRole(req.Role)
+	if newRole == "" {
+		newRole = models.TeamRoleMember
+	}
+
+	role, err := teamRole(s.db, teamID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !role.CanManage(newRole) {
+		return nil, models.ErrTeamPermissionDenied
+	}
+
+	invitee := strings.TrimSpace(req.Username)
+	userID, err := s.inviteeUserID(teamID, invitee)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同一地址的未过期邀请只保留一份，重新邀请时更新角色和有效期
+	invitation := &models.TeamInvitation{}
+	err = s.db.Where("team_id = ? AND invitee = ? AND expires_at > ?", teamID, invitee, time.Now()).First(invitation).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	invitation.TeamID = teamID
+	invitation.InviterID = actorID
+	invitation.Invitee = invitee
+	invitation.UserID = userID
+	invitation.Role = newRole
+	invitation.ExpiresAt = time.Now().Add(models.DefaultTeamInvitationTTL)
+
+	if err := s.db.Omit("Team", "Inviter").Save(invitation).Error; err != nil {
+		return nil, fmt.Errorf("failed to save team invitation: %w", err)
+	}
+	if err := s.db.Preload("Team").Preload("Inviter").First(invitation, invitation.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get team invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// inviteeUserID 查找可以接受邀请的用户，用户不存在或已是成员时返回 nil
+func (s *TeamService) inviteeUserID(teamID uint, invitee string) (*uint, error) {
+	var user models.User
+	if err := s.db.Where("username = ? OR email = ?", invitee, invitee).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	var count int64
+	if err := s.db.Model(&models.TeamMember{}).Where("team_id = ? AND user_id = ?", teamID, user.ID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if count > 0 {
+		return nil, nil
+	}
+	return &user.ID, nil
+}
+
+// ListTeamInvitations 获取团队未过期的邀请，需要管理员及以上角色
+func (s *TeamService) ListTeamInvitations(teamID, actorID uint) ([]*models.TeamInvitation, error) {
+	role, err := teamRole(s.db, teamID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !role.AtLeast(models.TeamRoleAdmin) {
+		return nil, models.ErrTeamPermissionDenied
+	}
+
+	var invitations []*models.TeamInvitation
+	if err := s.db.Preload("Team").Preload("Inviter").
+		Where("team_id = ? AND expires_at > ?", teamID, time.Now()).
+		Order("created_at ASC").Find(&invitations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get team invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation 撤销邀请，操作人必须能管理邀请中的角色
+func (s *TeamService) RevokeInvitation(teamID, actorID, invitationID uint) error {
+	role, err := teamRole(s.db, teamID, actorID)
+	if err != nil {
+		return err
+	}
+
+	var invitation models.TeamInvitation
+	if err := s.db.Where("id = ? AND team_id = ?", invitationID, teamID).First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrTeamInvitationNotFound
+		}
+		return fmt.Errorf("database error: %w", err)
+	}
+	if !role.CanManage(invitation.Role) {
+		return models.ErrTeamPermissionDenied
+	}
+
+	if err := s.db.Delete(&invitation).Error; err != nil {
+		return fmt.Errorf("failed to revoke team invitation: %w", err)
+	}
+	return nil
+}
+
+// ListInvitations 获取用户收到的未过期邀请
+func (s *TeamService) ListInvitations(userID uint) ([]*models.TeamInvitation, error) {
+	var invitations []*models.TeamInvitation
+	if err := s.db.Preload("Team").Preload("Inviter").
+		Joins("JOIN teams ON teams.id = team_invitations.team_id AND teams.deleted_at IS NULL").
+		Where("team_invitations.user_id = ? AND team_invitations.expires_at > ?", userID, time.Now()).
+		Order("team_invitations.created_at ASC").Find(&invitations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get team invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation 接受邀请加入团队，邀请随即失效
+func (s *TeamService) AcceptInvitation(invitationID, userID uint) (*models.TeamResponse, error) {
+	invitation, err := s.getInvitation(invitationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	team, err := s.getTeam(invitation.TeamID)
+	if err != nil {
+		if errors.Is(err, models.ErrTeamNotFound) {
+			return nil, models.ErrTeamInvitationNotFound
+		}
+		return nil, err
+	}
+
+	role := invitation.Role
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var member models.TeamMember
+		err := tx.Where("team_id = ? AND user_id = ?", team.ID, userID).First(&member).Error
+		switch {
+		case err == nil:
+			// 已经是成员时保留现有角色
+			role = member.Role
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(&models.TeamMember{TeamID: team.ID, UserID: userID, Role: role}).Error; err != nil {
+				return fmt.Errorf("failed to add team member: %w", err)
+			}
+		default:
+			return fmt.Errorf("database error: %w", err)
+		}
+
+		if err := tx.Delete(invitation).Error; err != nil {
+			return fmt.Errorf("failed to delete team invitation: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(team, role)
+}
+
+// DeclineInvitation 拒绝邀请
+func (s *TeamService) DeclineInvitation(invitationID, userID uint) error {
+	invitation, err := s.getInvitation(invitationID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(invitation).Error; err != nil {
+		return fmt.Errorf("failed to delete team invitation: %w", err)
+	}
+	return nil
+}
+
+// UpdateMember 修改成员角色，操作人必须能同时管理成员的当前角色和新角色
+func (s *TeamService) UpdateMember(teamID, actorID, userID uint, newRole models.TeamRole) (*models.TeamMember, error) {
+	role, err := teamRole(s.db, teamID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := s.getMember(teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !role.CanManage(member.Role) || !role.CanManage(newRole) {
+		return nil, models.ErrTeamPermissionDenied
+	}
+
+	if err := s.db.Model(member).Update("role", newRole).Error; err != nil {
+		return nil, fmt.Errorf("failed to update team member: %w", err)
+	}
+
+	return member, nil
+}
+
+// RemoveMember 移除成员；成员可以自己退出团队，所有者不能退出
+func (s *TeamService) RemoveMember(teamID, actorID, userID uint) error {
+	role, err := teamRole(s.db, teamID, actorID)
+	if err != nil {
+		return err
+	}
+
+	member, err := s.getMember(teamID, userID)
+	if err != nil {
+		return err
+	}
+	if member.Role == models.TeamRoleOwner {
+		return models.ErrTeamOwnerCannotLeave
+	}
+	if actorID != userID && !role.CanManage(member.Role) {
+		return models.ErrTeamPermissionDenied
+	}
+
+	if err := s.db.Delete(member).Error; err != nil {
+		return fmt.Errorf("failed to remove team member: %w", err)
+	}
+	return nil
+}
+
+// getTeam 根据ID获取团队
+func (s *TeamService) getTeam(teamID uint) (*models.Team, error) {
+	var team models.Team
+	if err := s.db.First(&team, teamID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrTeamNotFound
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &team, nil
+}
+
+// getInvitation 获取发给用户的未过期邀请
+func (s *TeamService) getInvitation(invitationID, userID uint) (*models.TeamInvitation, error) {
+	var invitation models.TeamInvitation
+	err := s.db.Where("id = ? AND user_id = ? AND expires_at > ?", invitationID, userID, time.Now()).First(&invitation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrTeamInvitationNotFound
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &invitation, nil
+}
+
+// getMember 获取团队成员
+func (s *TeamService) getMember(teamID, userID uint) (*models.TeamMember, error) {
+	var member models.TeamMember
+	if err := s.db.Preload("User").Where("team_id = ? AND user_id = ?", teamID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrTeamMemberNotFound
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &member, nil
+}
+
+// toResponse 转换为响应格式并统计成员数量
+func (s *TeamService) toResponse(team *models.Team, role models.TeamRole) (*models.TeamResponse, error) {
+	var count int64
+	if err := s.db.Model(&models.TeamMember{}).Where("team_id = ?", team.ID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to count team members: %w", err)
+	}
+
+	return &models.TeamResponse{
+		ID:          team.ID,
+		Name:        team.Name,
+		Description: team.Description,
+		OwnerID:     team.OwnerID,
+		Role:        role,
+		MemberCount: count,
+		CreatedAt:   team.CreatedAt,
+		UpdatedAt:   team.UpdatedAt,
+	}, nil
+}
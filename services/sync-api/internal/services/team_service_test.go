@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"xpaste-sync/internal/models"
+)
+
+func newTestTeamService(t *testing.T) (*TeamService, *models.TeamResponse, *models.User, *models.User) {
+	t.Helper()
+	db := newTestDB(t, &models.User{}, &models.Team{}, &models.TeamMember{}, &models.TeamInvitation{}, &models.ClipItem{})
+	service := NewTeamService(db)
+
+	owner := &models.User{Username: "alice", Email: "alice@example.com", PasswordHash: "x"}
+	mustCreate(t, db, owner)
+	invitee := &models.User{Username: "bob", Email: "bob@example.com", PasswordHash: "x"}
+	mustCreate(t, db, invitee)
+
+	team, err := service.CreateTeam(owner.ID, &models.CreateTeamRequest{Name: "ops"})
+	if err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	return service, team, owner, invitee
+}
+
+func TestInviteMemberRequiresAcceptance(t *testing.T) {
+	service, team, owner, invitee := newTestTeamService(t)
+
+	invitation, err := service.InviteMember(team.ID, owner.ID, &models.AddTeamMemberRequest{Username: "bob@example.com", Role: "viewer"})
+	if err != nil {
+		t.Fatalf("InviteMember: %v", err)
+	}
+	if _, err := teamRole(service.db, team.ID, invitee.ID); !errors.Is(err, models.ErrTeamNotFound) {
+		t.Fatalf("invitee joined before accepting: %v", err)
+	}
+
+	invitations, err := service.ListInvitations(invitee.ID)
+	if err != nil || len(invitations) != 1 || invitations[0].ID != invitation.ID || invitations[0].Team.Name != "ops" {
+		t.Fatalf("ListInvitations = %+v, %v", invitations, err)
+	}
+
+	// 其他用户不能接受发给 bob 的邀请
+	if _, err := service.AcceptInvitation(invitation.ID, owner.ID); !errors.Is(err, models.ErrTeamInvitationNotFound) {
+		t.Errorf("AcceptInvitation by other user = %v, want ErrTeamInvitationNotFound", err)
+	}
+
+	joined, err := service.AcceptInvitation(invitation.ID, invitee.ID)
+	if err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+	if joined.Role != models.TeamRoleViewer || joined.MemberCount != 2 {
+		t.Errorf("joined team role = %v, members = %d", joined.Role, joined.MemberCount)
+	}
+	if _, err := service.AcceptInvitation(invitation.ID, invitee.ID); !errors.Is(err, models.ErrTeamInvitationNotFound) {
+		t.Errorf("accepting twice = %v, want ErrTeamInvitationNotFound", err)
+	}
+}
+
+func TestInviteMemberUniformForUnknownAddress(t *testing.T) {
+	service, team, owner, invitee := newTestTeamService(t)
+
+	known, err := service.InviteMember(team.ID, owner.ID, &models.AddTeamMemberRequest{Username: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("InviteMember(known) = %v", err)
+	}
+	unknown, err := service.InviteMember(team.ID, owner.ID, &models.AddTeamMemberRequest{Username: "nobody@example.com"})
+	if err != nil {
+		t.Fatalf("InviteMember(unknown) = %v", err)
+	}
+	// 已是成员的地址同样不返回错误
+	member, err := service.InviteMember(team.ID, owner.ID, &models.AddTeamMemberRequest{Username: "alice"})
+	if err != nil {
+		t.Fatalf("InviteMember(member) = %v", err)
+	}
+
+	for _, invitation := range []*models.TeamInvitation{known, unknown, member} {
+		response := invitation.ToResponse()
+		if response.Role != models.TeamRoleMember || response.TeamName != "ops" || response.InviterUsername != "alice" {
+			t.Errorf("invitation response for %s = %+v", invitation.Invitee, response)
+		}
+	}
+
+	invitations, err := service.ListTeamInvitations(team.ID, owner.ID)
+	if err != nil || len(invitations) != 3 {
+		t.Fatalf("ListTeamInvitations = %d, %v, want 3", len(invitations), err)
+	}
+	if got, _ := service.ListInvitations(owner.ID); len(got) != 0 {
+		t.Errorf("existing member received %d invitations, want 0", len(got))
+	}
+	if got, _ := service.ListInvitations(invitee.ID); len(got) != 1 {
+		t.Errorf("invitee received %d invitations, want 1", len(got))
+	}
+}
+
+func TestInvitationPermissions(t *testing.T) {
+	service, team, owner, invitee := newTestTeamService(t)
+
+	invitation, err := service.InviteMember(team.ID, owner.ID, &models.AddTeamMemberRequest{Username: "bob", Role: "admin"})
+	if err != nil {
+		t.Fatalf("InviteMember: %v", err)
+	}
+	if _, err := service.AcceptInvitation(invitation.ID, invitee.ID); err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+
+	// 管理员不能邀请或撤销管理员
+	if _, err := service.InviteMember(team.ID, invitee.ID, &models.AddTeamMemberRequest{Username: "carol", Role: "admin"}); !errors.Is(err, models.ErrTeamPermissionDenied) {
+		t.Errorf("admin inviting admin = %v, want ErrTeamPermissionDenied", err)
+	}
+	adminInvitation, err := service.InviteMember(team.ID, owner.ID, &models.AddTeamMemberRequest{Username: "carol", Role: "admin"})
+	if err != nil {
+		t.Fatalf("InviteMember: %v", err)
+	}
+	if err := service.RevokeInvitation(team.ID, invitee.ID, adminInvitation.ID); !errors.Is(err, models.ErrTeamPermissionDenied) {
+		t.Errorf("admin revoking admin invitation = %v, want ErrTeamPermissionDenied", err)
+	}
+	if err := service.RevokeInvitation(team.ID, owner.ID, adminInvitation.ID); err != nil {
+		t.Errorf("RevokeInvitation: %v", err)
+	}
+
+	// 非成员看不到团队的邀请
+	outsider := &models.User{Username: "dave", Email: "dave@example.com", PasswordHash: "x"}
+	mustCreate(t, service.db, outsider)
+	if _, err := service.ListTeamInvitations(team.ID, outsider.ID); !errors.Is(err, models.ErrTeamNotFound) {
+		t.Errorf("outsider ListTeamInvitations = %v, want ErrTeamNotFound", err)
+	}
+
+	// 拒绝邀请后不能再接受
+	declined, err := service.InviteMember(team.ID, owner.ID, &models.AddTeamMemberRequest{Username: "dave"})
+	if err != nil {
+		t.Fatalf("InviteMember: %v", err)
+	}
+	if err := service.DeclineInvitation(declined.ID, outsider.ID); err != nil {
+		t.Fatalf("DeclineInvitation: %v", err)
+	}
+	if _, err := service.AcceptInvitation(declined.ID, outsider.ID); !errors.Is(err, models.ErrTeamInvitationNotFound) {
+		t.Errorf("accepting declined invitation = %v, want ErrTeamInvitationNotFound", err)
+	}
+}
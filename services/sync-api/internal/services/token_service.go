@@ -0,0 +1,385 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/models"
+)
+
+const (
+	// SessionTTL 默认会话有效期，每次轮换刷新令牌时顺延；可由 JWT_REFRESH_TOKEN_TTL 配置
+	SessionTTL = 7 * 24 * time.Hour
+
+	refreshTokenSize = 32 // 刷新令牌随机字节数
+
+	sessionTouchInterval  = time.Minute         // 最后使用时间的更新间隔，避免每个请求都写库
+	sessionRetentionTime  = 30 * 24 * time.Hour // 过期或撤销的会话保留一段时间便于排查
+	sessionUserAgentLimit = 255
+)
+
+// TokenService 令牌会话服务，按会话和设备记录已签发的令牌，支持立即撤销
+type TokenService struct {
+	db         *gorm.DB
+	notifier   Notifier
+	sessionTTL time.Duration
+}
+
+// NewTokenService 创建令牌会话服务
+func NewTokenService(db *gorm.DB) *TokenService {
+	return &TokenService{db: db, sessionTTL: SessionTTL}
+}
+
+// SetSessionTTL 设置会话（刷新令牌）有效期
+func (s *TokenService) SetSessionTTL(ttl time.Duration) {
+	if ttl > 0 {
+		s.sessionTTL = ttl
+	}
+}
+
+// CreateSession 登录时创建会话并签发该会话的第一个刷新令牌，deviceID 为空表示账户级会话
+// 令牌要绑定的设备已被停用或撤销时拒绝创建
+func (s *TokenService) CreateSession(userID uint, deviceID string, clientIP string, userAgent string) (*models.AuthSession, string, error) {
+	if err := s.checkDevice(userID, deviceID); err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+
+	// 清理早已失效的会话
+	if err := s.cleanup(userID, now); err != nil {
+		return nil, "", err
+	}
+
+	sessionID, err := randomToken(24)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(userAgent) > sessionUserAgentLimit {
+		userAgent = userAgent[:sessionUserAgentLimit]
+	}
+
+	session := &models.AuthSession{
+		SessionID:  sessionID,
+		UserID:     userID,
+		DeviceID:   deviceID,
+		IP:         clientIP,
+		UserAgent:  userAgent,
+		ExpiresAt:  now.Add(s.sessionTTL),
+		LastUsedAt: &now,
+	}
+
+	var refreshToken string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		refreshToken, err = s.issueRefreshToken(tx, session)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return session, refreshToken, nil
+}
+
+// RotateRefreshToken 用刷新令牌换取新的刷新令牌，旧令牌随即作废，会话有效期顺延
+// 已轮换过的令牌再次出现说明令牌可能被窃取：撤销整个令牌家族（会话）并通知用户的设备
+func (s *TokenService) RotateRefreshToken(refreshToken string, clientIP string) (*models.AuthSession, string, error) {
+	if refreshToken == "" {
+		return nil, "", models.ErrInvalidToken
+	}
+
+	var token models.RefreshToken
+	if err := s.db.Where("token_hash = ?", hashToken(refreshToken)).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", models.ErrInvalidToken
+		}
+		return nil, "", fmt.Errorf("database error: %w", err)
+	}
+
+	if token.RotatedAt != nil {
+		return nil, "", s.handleReuse(&token, clientIP)
+	}
+
+	var session models.AuthSession
+	if err := s.db.Where("session_id = ?", token.SessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", models.ErrInvalidToken
+		}
+		return nil, "", fmt.Errorf("database error: %w", err)
+	}
+	if session.IsRevoked() {
+		return nil, "", models.ErrSessionRevoked
+	}
+	if session.IsExpired() || time.Now().After(token.ExpiresAt) {
+		return nil, "", models.ErrSessionExpired
+	}
+	if err := s.checkDevice(session.UserID, session.DeviceID); err != nil {
+		return nil, "", err
+	}
+
+	var newToken string
+	reused := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// 条件更新保证并发使用同一令牌时只有一个请求成功
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL", token.ID).
+			Update("rotated_at", now)
+		if result.Error != nil {
+			return fmt.Errorf("failed to rotate refresh token: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			reused = true
+			return nil
+		}
+
+		session.ExpiresAt = now.Add(s.sessionTTL)
+		session.LastUsedAt = &now
+		session.IP = clientIP
+		if err := tx.Model(&session).Updates(map[string]interface{}{
+			"expires_at":   session.ExpiresAt,
+			"last_used_at": now,
+			"ip":           clientIP,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+
+		var err error
+		newToken, err = s.issueRefreshToken(tx, &session)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if reused {
+		return nil, "", s.handleReuse(&token, clientIP)
+	}
+
+	return &session, newToken, nil
+}
+
+// ListSessions 列出用户未撤销且未过期的会话，附带绑定设备的信息
+func (s *TokenService) ListSessions(userID uint, currentSessionID string) ([]*models.SessionResponse, error) {
+	var sessions []models.AuthSession
+	if err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	var devices []models.Device
+	if err := s.db.Where("user_id = ?", userID).Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get devices: %w", err)
+	}
+	deviceMap := make(map[string]*models.Device, len(devices))
+	for i := range devices {
+		deviceMap[devices[i].DeviceID] = &devices[i]
+	}
+
+	responses := make([]*models.SessionResponse, len(sessions))
+	for i := range sessions {
+		responses[i] = sessions[i].ToResponse(deviceMap[sessions[i].DeviceID], currentSessionID)
+	}
+	return responses, nil
+}
+
+// ValidateSession 校验令牌携带的会话：会话存在且属于该用户和设备、未撤销未过期，绑定的设备未被停用
+// 校验通过时按间隔更新最后使用时间和IP
+func (s *TokenService) ValidateSession(userID uint, sessionID string, deviceID string, clientIP string) (*models.AuthSession, error) {
+	if sessionID == "" {
+		return nil, models.ErrInvalidToken
+	}
+
+	var session models.AuthSession
+	if err := s.db.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	if session.UserID != userID || session.DeviceID != deviceID {
+		return nil, models.ErrInvalidToken
+	}
+	if session.IsRevoked() {
+		return nil, models.ErrSessionRevoked
+	}
+	if session.IsExpired() {
+		return nil, models.ErrSessionExpired
+	}
+	if err := s.checkDevice(userID, deviceID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if session.LastUsedAt == nil || now.Sub(*session.LastUsedAt) > sessionTouchInterval || session.IP != clientIP {
+		if err := s.db.Model(&session).Updates(map[string]interface{}{
+			"last_used_at": now,
+			"ip":           clientIP,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("failed to update session: %w", err)
+		}
+	}
+
+	return &session, nil
+}
+
+// RevokeSession 撤销指定会话及其刷新令牌家族，会话不存在或已失效时返回 ErrSessionNotFound
+// 会话绑定了设备时同时断开该设备的实时连接
+func (s *TokenService) RevokeSession(userID uint, sessionID string, reason string) error {
+	var session models.AuthSession
+	if err := s.db.Where("user_id = ? AND session_id = ? AND revoked_at IS NULL", userID, sessionID).
+		First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrSessionNotFound
+		}
+		return fmt.Errorf("database error: %w", err)
+	}
+
+	result := s.db.Model(&models.AuthSession{}).
+		Where("id = ? AND revoked_at IS NULL", session.ID).
+		Updates(map[string]interface{}{
+			"revoked_at":    time.Now(),
+			"revoke_reason": reason,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke session: %w", result.Error)
+	}
+
+	if session.DeviceID != "" && reason != models.RevokeReasonLogout && s.notifier != nil {
+		s.notifier.DisconnectDevice(userID, session.DeviceID, reason)
+	}
+	return nil
+}
+
+// RevokeDeviceSessions 撤销设备的所有会话，返回撤销的数量
+func (s *TokenService) RevokeDeviceSessions(userID uint, deviceID string, reason string) (int64, error) {
+	result := s.db.Model(&models.AuthSession{}).
+		Where("user_id = ? AND device_id = ? AND revoked_at IS NULL", userID, deviceID).
+		Updates(map[string]interface{}{
+			"revoked_at":    time.Now(),
+			"revoke_reason": reason,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to revoke device sessions: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// RevokeUserSessions 撤销用户的所有会话并断开已绑定设备的实时连接，返回撤销的数量
+func (s *TokenService) RevokeUserSessions(userID uint, reason string) (int64, error) {
+	var sessions []models.AuthSession
+	if err := s.db.Where("user_id = ? AND revoked_at IS NULL", userID).Find(&sessions).Error; err != nil {
+		return 0, fmt.Errorf("database error: %w", err)
+	}
+
+	result := s.db.Model(&models.AuthSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"revoked_at":    time.Now(),
+			"revoke_reason": reason,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to revoke user sessions: %w", result.Error)
+	}
+
+	if s.notifier != nil {
+		for _, session := range sessions {
+			if session.DeviceID != "" {
+				s.notifier.DisconnectDevice(userID, session.DeviceID, reason)
+			}
+		}
+	}
+	return result.RowsAffected, nil
+}
+
+// handleReuse 处理已轮换刷新令牌的再次使用：撤销令牌家族并告警
+func (s *TokenService) handleReuse(token *models.RefreshToken, clientIP string) error {
+	var session models.AuthSession
+	if err := s.db.Where("session_id = ?", token.SessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrInvalidToken
+		}
+		return fmt.Errorf("database error: %w", err)
+	}
+	if session.IsRevoked() {
+		// 家族已撤销（例如已因重用撤销过），不再重复告警
+		return models.ErrRefreshTokenReused
+	}
+
+	if err := s.RevokeSession(session.UserID, session.SessionID, models.RevokeReasonTokenReuse); err != nil &&
+		!errors.Is(err, models.ErrSessionNotFound) {
+		return err
+	}
+
+	if s.notifier != nil {
+		s.notifier.NotifyUser(session.UserID, EventSecurityAlert, &models.SecurityAlert{
+			Type:      models.AlertRefreshTokenReuse,
+			SessionID: session.SessionID,
+			DeviceID:  session.DeviceID,
+			IP:        clientIP,
+			Time:      time.Now(),
+		})
+	}
+	return models.ErrRefreshTokenReused
+}
+
+// issueRefreshToken 为会话签发新的刷新令牌，只保存哈希
+func (s *TokenService) issueRefreshToken(tx *gorm.DB, session *models.AuthSession) (string, error) {
+	token, err := randomToken(refreshTokenSize)
+	if err != nil {
+		return "", err
+	}
+
+	record := &models.RefreshToken{
+		TokenHash: hashToken(token),
+		SessionID: session.SessionID,
+		UserID:    session.UserID,
+		ExpiresAt: session.ExpiresAt,
+	}
+	if err := tx.Create(record).Error; err != nil {
+		return "", fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// cleanup 清理早已失效的会话及其刷新令牌
+func (s *TokenService) cleanup(userID uint, now time.Time) error {
+	cutoff := now.Add(-sessionRetentionTime)
+	if err := s.db.Where("user_id = ? AND expires_at < ?", userID, cutoff).
+		Delete(&models.RefreshToken{}).Error; err != nil {
+		return fmt.Errorf("failed to cleanup refresh tokens: %w", err)
+	}
+	if err := s.db.Where("user_id = ? AND expires_at < ?", userID, cutoff).
+		Delete(&models.AuthSession{}).Error; err != nil {
+		return fmt.Errorf("failed to cleanup sessions: %w", err)
+	}
+	return nil
+}
+
+// checkDevice 检查令牌绑定的设备是否允许访问
+// 未绑定或尚未注册的设备按等待批准处理：允许访问认证和设备注册接口，剪贴板接口由 DeviceTrustMiddleware 拒绝
+// 已删除、停用或撤销的设备不能再使用
+func (s *TokenService) checkDevice(userID uint, deviceID string) error {
+	if deviceID == "" {
+		return nil
+	}
+
+	var device models.Device
+	if err := s.db.Unscoped().Where("user_id = ? AND device_id = ?", userID, deviceID).First(&device).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("database error: %w", err)
+	}
+
+	if device.DeletedAt.Valid || (!device.IsActive() && !device.IsPending()) {
+		return models.ErrDeviceDisabled
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"xpaste-sync/internal/models"
+)
+
+func TestCheckDevice(t *testing.T) {
+	db := newTestDB(t, &models.User{}, &models.Device{})
+	service := NewTokenService(db)
+
+	user := &models.User{Username: "alice", Email: "alice@example.com", PasswordHash: "x"}
+	mustCreate(t, db, user)
+	mustCreate(t, db, &models.Device{UserID: user.ID, DeviceID: "pending", Name: "phone", Status: models.DeviceStatusPending})
+	mustCreate(t, db, &models.Device{UserID: user.ID, DeviceID: "suspended", Name: "tablet", Status: models.DeviceStatusSuspended})
+	mustCreate(t, db, &models.Device{UserID: user.ID, DeviceID: "deleted", Name: "old", Status: models.DeviceStatusActive})
+	if err := db.Where("device_id = ?", "deleted").Delete(&models.Device{}).Error; err != nil {
+		t.Fatalf("delete device: %v", err)
+	}
+
+	tests := []struct {
+		deviceID string
+		want     error
+	}{
+		{"", nil},        // 未绑定设备，按等待批准处理
+		{"made-up", nil}, // 尚未注册，按等待批准处理
+		{"pending", nil},
+		{"suspended", models.ErrDeviceDisabled},
+		{"deleted", models.ErrDeviceDisabled},
+	}
+	for _, tt := range tests {
+		if err := service.checkDevice(user.ID, tt.deviceID); !errors.Is(err, tt.want) {
+			t.Errorf("checkDevice(%q) = %v, want %v", tt.deviceID, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,500 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/models"
+)
+
+const (
+	totpIssuer     = "xPaste"
+	totpPeriod     = 30 // 秒
+	totpDigits     = 6
+	totpSkew       = 1  // 允许前后各一个时间步的时钟偏差
+	totpSecretSize = 20 // 160 位，RFC 4226 推荐长度
+
+	recoveryCodeCount = 10
+
+	// DefaultTwoFactorChallengeTTL 默认挑战令牌有效期
+	DefaultTwoFactorChallengeTTL = 5 * time.Minute
+	// DefaultTwoFactorTrustWindow 默认受信任设备跳过两步验证的时长
+	DefaultTwoFactorTrustWindow = 30 * 24 * time.Hour
+
+	maxChallengeAttempts = 5
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorService 两步验证服务：TOTP、恢复码、登录挑战和受信任设备
+type TwoFactorService struct {
+	db           *gorm.DB
+	guard        *LoginGuardService // 验证码错误计入登录失败退避和锁定，未设置时只按挑战限制次数
+	challengeTTL time.Duration
+	trustWindow  time.Duration
+}
+
+// NewTwoFactorService 创建两步验证服务
+func NewTwoFactorService(db *gorm.DB) *TwoFactorService {
+	return &TwoFactorService{
+		db:           db,
+		challengeTTL: DefaultTwoFactorChallengeTTL,
+		trustWindow:  DefaultTwoFactorTrustWindow,
+	}
+}
+
+// Configure 设置挑战令牌有效期和受信任设备时长，trustWindow 为 0 表示不允许记住设备
+func (s *TwoFactorService) Configure(challengeTTL time.Duration, trustWindow time.Duration) {
+	if challengeTTL > 0 {
+		s.challengeTTL = challengeTTL
+	}
+	if trustWindow >= 0 {
+		s.trustWindow = trustWindow
+	}
+}
+
+// TrustWindow 受信任设备跳过两步验证的时长
+func (s *TwoFactorService) TrustWindow() time.Duration {
+	return s.trustWindow
+}
+
+// GetStatus 获取两步验证状态
+func (s *TwoFactorService) GetStatus(userID uint) (*models.TwoFactorStatusResponse, error) {
+	status := &models.TwoFactorStatusResponse{}
+
+	totp, err := s.getTOTP(userID)
+	if err != nil && !errors.Is(err, models.ErrTwoFactorNotSetup) {
+		return nil, err
+	}
+	if totp == nil || !totp.IsEnabled() {
+		return status, nil
+	}
+
+	status.Enabled = true
+	status.EnabledAt = totp.ConfirmedAt
+	if err := s.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).Count(&status.RecoveryCodesRemaining).Error; err != nil {
+		return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	if err := s.db.Model(&models.TwoFactorTrust{}).
+		Where("user_id = ? AND expires_at > ?", userID, time.Now()).Count(&status.TrustedDevices).Error; err != nil {
+		return nil, fmt.Errorf("failed to count trusted devices: %w", err)
+	}
+	return status, nil
+}
+
+// IsEnabled 用户是否已开启两步验证
+func (s *TwoFactorService) IsEnabled(userID uint) (bool, error) {
+	totp, err := s.getTOTP(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrTwoFactorNotSetup) {
+			return false, nil
+		}
+		return false, err
+	}
+	return totp.IsEnabled(), nil
+}
+
+// Setup 生成新的 TOTP 密钥（未确认），已开启时拒绝
+func (s *TwoFactorService) Setup(user *models.User) (*models.TwoFactorSetupResponse, error) {
+	totp, err := s.getTOTP(user.ID)
+	if err != nil && !errors.Is(err, models.ErrTwoFactorNotSetup) {
+		return nil, err
+	}
+	if totp != nil && totp.IsEnabled() {
+		return nil, models.ErrTwoFactorAlreadyEnabled
+	}
+
+	secretBytes := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	secret := base32NoPadding.EncodeToString(secretBytes)
+
+	if totp == nil {
+		totp = &models.UserTOTP{UserID: user.ID}
+	}
+	totp.Secret = secret
+	totp.LastStep = 0
+	if err := s.db.Save(totp).Error; err != nil {
+		return nil, fmt.Errorf("failed to save secret: %w", err)
+	}
+
+	return &models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: otpAuthURI(user.Username, secret),
+	}, nil
+}
+
+// Enable 用验证码确认密钥并开启两步验证，返回一次性恢复码
+func (s *TwoFactorService) Enable(userID uint, code string) ([]string, error) {
+	totp, err := s.getTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp.IsEnabled() {
+		return nil, models.ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := validateTOTP(totp.Secret, code, totp.LastStep, time.Now())
+	if !ok {
+		return nil, models.ErrInvalidTwoFactorCode
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(totp).Updates(map[string]interface{}{
+			"confirmed_at": now,
+			"last_step":    step,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to enable two-factor: %w", err)
+		}
+		codes, err = s.replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable 关闭两步验证，需要 TOTP 验证码或恢复码；同时删除恢复码和受信任设备
+// 密码由调用方校验
+func (s *TwoFactorService) Disable(userID uint, code string) error {
+	if err := s.VerifyCode(userID, code); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.UserTOTP{}, &models.RecoveryCode{}, &models.TwoFactorTrust{}, &models.LoginChallenge{}} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return fmt.Errorf("failed to disable two-factor: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部失效
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	if err := s.VerifyCode(userID, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = s.replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// VerifyCode 校验 TOTP 验证码或恢复码，恢复码使用后作废
+func (s *TwoFactorService) VerifyCode(userID uint, code string) error {
+	totp, err := s.getTOTP(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrTwoFactorNotSetup) {
+			return models.ErrTwoFactorNotEnabled
+		}
+		return err
+	}
+	if !totp.IsEnabled() {
+		return models.ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totpDigits {
+		step, ok := validateTOTP(totp.Secret, code, totp.LastStep, time.Now())
+		if !ok {
+			return models.ErrInvalidTwoFactorCode
+		}
+		// 条件更新保证同一时间步的验证码只能使用一次
+		result := s.db.Model(&models.UserTOTP{}).
+			Where("id = ? AND last_step < ?", totp.ID, step).
+			Update("last_step", step)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update two-factor: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	// 恢复码
+	result := s.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to use recovery code: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// CreateChallenge 密码验证通过后创建登录挑战，返回挑战令牌
+func (s *TwoFactorService) CreateChallenge(userID uint, deviceID string) (string, time.Duration, error) {
+	now := time.Now()
+	if err := s.db.Where("user_id = ? AND expires_at < ?", userID, now).
+		Delete(&models.LoginChallenge{}).Error; err != nil {
+		return "", 0, fmt.Errorf("failed to cleanup challenges: %w", err)
+	}
+
+	token, err := randomToken(24)
+	if err != nil {
+		return "", 0, err
+	}
+
+	challenge := &models.LoginChallenge{
+		TokenHash: hashToken(token),
+		UserID:    userID,
+		DeviceID:  deviceID,
+		ExpiresAt: now.Add(s.challengeTTL),
+	}
+	if err := s.db.Create(challenge).Error; err != nil {
+		return "", 0, fmt.Errorf("failed to create challenge: %w", err)
+	}
+	return token, s.challengeTTL, nil
+}
+
+// CompleteChallenge 用验证码完成登录挑战，返回挑战记录（用户和设备）
+// 验证码错误次数过多时挑战作废；每次错误都计入账户和 IP 的登录失败次数，通过后清零账户计数
+// 账户或 IP 处于退避或锁定期时返回 *models.LoginThrottledError；成功和失败都写入审计日志
+func (s *TwoFactorService) CompleteChallenge(token, code, clientIP, userAgent string) (*models.LoginChallenge, error) {
+	var challenge models.LoginChallenge
+	if err := s.db.Where("token_hash = ?", hashToken(token)).First(&challenge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrChallengeInvalid
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if challenge.UsedAt != nil || time.Now().After(challenge.ExpiresAt) {
+		return nil, models.ErrChallengeInvalid
+	}
+	if challenge.Attempts >= maxChallengeAttempts {
+		return nil, models.ErrChallengeAttempts
+	}
+
+	var user models.User
+	if err := s.db.First(&user, challenge.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrChallengeInvalid
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	// 账户或 IP 处于退避或锁定期时不校验验证码，跨挑战限制猜测次数
+	if s.guard != nil {
+		if err := s.guard.CheckIP(clientIP); err != nil {
+			s.guard.RecordThrottled(&user.ID, user.Username, clientIP, userAgent, err)
+			return nil, err
+		}
+		if err := s.guard.CheckAccount(user.ID); err != nil {
+			s.guard.RecordThrottled(&user.ID, user.Username, clientIP, userAgent, err)
+			return nil, err
+		}
+	}
+
+	if err := s.VerifyCode(challenge.UserID, code); err != nil {
+		if errors.Is(err, models.ErrInvalidTwoFactorCode) {
+			if updateErr := s.db.Model(&challenge).
+				Update("attempts", gorm.Expr("attempts + 1")).Error; updateErr != nil {
+				return nil, fmt.Errorf("failed to update challenge: %w", updateErr)
+			}
+			if s.guard != nil {
+				if guardErr := s.guard.RecordTwoFactorFailure(&user, clientIP, userAgent, codeMethod(code)); guardErr != nil {
+					return nil, guardErr
+				}
+			}
+		}
+		return nil, err
+	}
+
+	result := s.db.Model(&models.LoginChallenge{}).
+		Where("id = ? AND used_at IS NULL", challenge.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update challenge: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, models.ErrChallengeInvalid
+	}
+
+	if s.guard != nil {
+		if err := s.guard.RecordTwoFactorSuccess(&user, clientIP, userAgent, codeMethod(code)); err != nil {
+			return nil, err
+		}
+	}
+	return &challenge, nil
+}
+
+// TrustDevice 记住设备，返回信任令牌；未绑定设备或未开放记住设备时返回空
+func (s *TwoFactorService) TrustDevice(userID uint, deviceID string) (string, error) {
+	if deviceID == "" || s.trustWindow <= 0 {
+		return "", nil
+	}
+
+	now := time.Now()
+	if err := s.db.Where("user_id = ? AND (device_id = ? OR expires_at < ?)", userID, deviceID, now).
+		Delete(&models.TwoFactorTrust{}).Error; err != nil {
+		return "", fmt.Errorf("failed to cleanup trusted devices: %w", err)
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	trust := &models.TwoFactorTrust{
+		TokenHash: hashToken(token),
+		UserID:    userID,
+		DeviceID:  deviceID,
+		ExpiresAt: now.Add(s.trustWindow),
+	}
+	if err := s.db.Create(trust).Error; err != nil {
+		return "", fmt.Errorf("failed to trust device: %w", err)
+	}
+	return token, nil
+}
+
+// IsTrusted 信任令牌是否有效：属于该用户和设备且未过期
+func (s *TwoFactorService) IsTrusted(userID uint, deviceID string, token string) (bool, error) {
+	if deviceID == "" || token == "" || s.trustWindow <= 0 {
+		return false, nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.TwoFactorTrust{}).
+		Where("token_hash = ? AND user_id = ? AND device_id = ? AND expires_at > ?", hashToken(token), userID, deviceID, time.Now()).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("database error: %w", err)
+	}
+	return count > 0, nil
+}
+
+// RevokeDeviceTrust 撤销设备的受信任状态
+func (s *TwoFactorService) RevokeDeviceTrust(userID uint, deviceID string) error {
+	if err := s.db.Where("user_id = ? AND device_id = ?", userID, deviceID).
+		Delete(&models.TwoFactorTrust{}).Error; err != nil {
+		return fmt.Errorf("failed to revoke trusted device: %w", err)
+	}
+	return nil
+}
+
+func (s *TwoFactorService) getTOTP(userID uint) (*models.UserTOTP, error) {
+	var totp models.UserTOTP
+	if err := s.db.Where("user_id = ?", userID).First(&totp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrTwoFactorNotSetup
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &totp, nil
+}
+
+// replaceRecoveryCodes 删除旧恢复码并生成新的一组
+func (s *TwoFactorService) replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code := strings.ToLower(base32NoPadding.EncodeToString(raw)) // 8 个字符
+		codes[i] = code[:4] + "-" + code[4:]
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: hashToken(code)}
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// codeMethod 审计记录中区分 TOTP 验证码和恢复码
+func codeMethod(code string) string {
+	if len(strings.TrimSpace(code)) == totpDigits {
+		return "totp"
+	}
+	return "recovery_code"
+}
+
+// normalizeRecoveryCode 忽略大小写、空格和连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// otpAuthURI 生成验证器应用使用的 otpauth:// 地址
+func otpAuthURI(account string, secret string) string {
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", totpIssuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprint(totpDigits))
+	values.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(totpIssuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + values.Encode()
+}
+
+// validateTOTP 校验验证码，允许 totpSkew 个时间步的偏差，只接受晚于 lastStep 的时间步
+// 返回通过验证的时间步
+func validateTOTP(secret string, code string, lastStep int64, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := base32NoPadding.DecodeString(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if hmac.Equal([]byte(totpCode(key, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode 计算指定时间步的验证码（RFC 4226 动态截断）
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
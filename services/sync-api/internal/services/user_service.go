@@ -14,7 +14,9 @@ import (
 
 // UserService 用户服务
 type UserService struct {
-	db *gorm.DB
+	db    *gorm.DB
+	guard *LoginGuardService // 登录失败退避和锁定，未设置时不限制
+	audit *AuditService      // 管理操作审计，未设置时不记录
 }
 
 // NewUserService 创建用户服务
@@ -22,17 +24,12 @@ func NewUserService(db *gorm.DB) *UserService {
 	return &UserService{db: db}
 }
 
-// Register 用户注册
-func (s *UserService) Register(req *models.RegisterRequest) (*models.User, error) {
+// Register 用户注册，requireVerification 为 true 时账户在验证邮箱前处于未激活状态
+func (s *UserService) Register(req *models.RegisterRequest, requireVerification bool) (*models.User, error) {
 	// 检查用户名是否已存在
 	var existingUser models.User
 	if err := s.db.Where("username = ? OR email = ?", req.Username, req.Email).First(&existingUser).Error; err == nil {
-		if existingUser.Username == req.Username {
-			return nil, fmt.Errorf("username already exists")
-		}
-		if existingUser.Email == req.Email {
-			return nil, fmt.Errorf("email already exists")
-		}
+		return nil, models.ErrUserExists
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("database error: %w", err)
 	}
@@ -58,28 +55,70 @@ func (s *UserService) Register(req *models.RegisterRequest) (*models.User, error
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	// status 列有默认值，未激活（零值）需在创建后单独更新；标记为等待验证，验证邮箱后自动激活
+	if requireVerification {
+		if err := s.db.Model(user).Updates(map[string]interface{}{
+			"status":               models.UserStatusInactive,
+			"pending_verification": true,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+		user.Status = models.UserStatusInactive
+		user.PendingVerification = true
+	}
+
 	return user, nil
 }
 
 // Login 用户登录
-func (s *UserService) Login(req *models.LoginRequest, clientIP string) (*models.User, error) {
+// 用户不存在和密码错误统一返回 ErrInvalidCredentials，失败计入账户和 IP 的退避计数；
+// 处于退避或锁定期时返回 *models.LoginThrottledError，不再校验密码
+// 密码正确时不清零失败计数，需调用 CompleteLogin 或完成两步验证挑战
+func (s *UserService) Login(req *models.LoginRequest, clientIP string, userAgent string) (*models.User, error) {
+	if s.guard != nil {
+		if err := s.guard.CheckIP(clientIP); err != nil {
+			s.guard.RecordThrottled(nil, req.Username, clientIP, userAgent, err)
+			return nil, err
+		}
+	}
+
 	// 查找用户
 	var user models.User
 	if err := s.db.Where("username = ? OR email = ?", req.Username, req.Username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, models.ErrUserNotFound
+			if s.guard != nil {
+				if err := s.guard.RecordFailure(nil, req.Username, clientIP, userAgent); err != nil {
+					return nil, err
+				}
+			}
+			return nil, models.ErrInvalidCredentials
 		}
 		return nil, fmt.Errorf("database error: %w", err)
 	}
 
-	// 检查用户状态
-	if !user.IsActive() {
-		return nil, fmt.Errorf("user account is inactive")
+	if s.guard != nil {
+		if err := s.guard.CheckAccount(user.ID); err != nil {
+			s.guard.RecordThrottled(&user.ID, req.Username, clientIP, userAgent, err)
+			return nil, err
+		}
 	}
 
 	// 验证密码
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		return nil, models.ErrInvalidPassword
+		if s.guard != nil {
+			if err := s.guard.RecordFailure(&user, req.Username, clientIP, userAgent); err != nil {
+				return nil, err
+			}
+		}
+		return nil, models.ErrInvalidCredentials
+	}
+
+	// 检查用户状态（密码正确后才提示，避免泄露账户状态）
+	if !user.IsActive() {
+		if user.IsPendingVerification() {
+			return nil, models.ErrEmailNotVerified
+		}
+		return nil, models.ErrUserInactive
 	}
 
 	// 更新最后登录信息
@@ -91,6 +130,15 @@ func (s *UserService) Login(req *models.LoginRequest, clientIP string) (*models.
 	return &user, nil
 }
 
+// CompleteLogin 登录完全成功（无需两步验证或受信任设备跳过）后清零账户的失败计数
+// 开启两步验证时由 TwoFactorService.CompleteChallenge 在验证码通过后清零
+func (s *UserService) CompleteLogin(userID uint) error {
+	if s.guard == nil {
+		return nil
+	}
+	return s.guard.RecordSuccess(userID)
+}
+
 // GetUserByID 根据ID获取用户
 func (s *UserService) GetUserByID(userID uint) (*models.User, error) {
 	var user models.User
@@ -188,6 +236,22 @@ func (s *UserService) ChangePassword(userID uint, oldPassword, newPassword strin
 	return nil
 }
 
+// VerifyPassword 校验用户密码，用于敏感操作的二次确认
+func (s *UserService) VerifyPassword(userID uint, password string) error {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrUserNotFound
+		}
+		return fmt.Errorf("database error: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return models.ErrInvalidPassword
+	}
+	return nil
+}
+
 // DeactivateUser 停用用户
 func (s *UserService) DeactivateUser(userID uint) error {
 	var user models.User
@@ -198,7 +262,9 @@ func (s *UserService) DeactivateUser(userID uint) error {
 		return fmt.Errorf("database error: %w", err)
 	}
 
+	// 主动停用的账户不再因验证邮箱而自动激活
 	user.Status = models.UserStatusInactive
+	user.PendingVerification = false
 	if err := s.db.Save(&user).Error; err != nil {
 		return fmt.Errorf("failed to deactivate user: %w", err)
 	}
@@ -252,9 +318,9 @@ func (s *UserService) GetUserStats(userID uint) (*UserStats, error) {
 		return nil, fmt.Errorf("failed to count today's clip items: %w", err)
 	}
 
-	// 获取存储使用量（字节）
+	// 获取存储使用量（字节），剪贴板项没有单独的大小字段，按内容长度计算
 	var totalSize sql.NullInt64
-	if err := s.db.Model(&models.ClipItem{}).Where("user_id = ?", userID).Select("COALESCE(SUM(size), 0)").Scan(&totalSize).Error; err != nil {
+	if err := s.db.Model(&models.ClipItem{}).Where("user_id = ?", userID).Select("COALESCE(SUM(LENGTH(content)), 0)").Scan(&totalSize).Error; err != nil {
 		return nil, fmt.Errorf("failed to calculate storage usage: %w", err)
 	}
 	stats.StorageUsage = totalSize.Int64
@@ -270,6 +336,40 @@ type UserStats struct {
 	StorageUsage       int64 `json:"storage_usage"` // 字节
 }
 
+// SetRole 修改用户角色（管理员功能），角色变化时记录审计日志
+func (s *UserService) SetRole(userID uint, role string, actorID uint, actorIP string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, models.ErrInvalidRole
+	}
+	if userID == actorID {
+		return nil, models.ErrCannotEditSelf
+	}
+
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == role {
+		return user, nil
+	}
+
+	previous := user.Role
+	if err := s.db.Model(user).Update("role", role).Error; err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+
+	if s.audit != nil {
+		_ = s.audit.Record(&models.AuditLog{
+			UserID:  &user.ID,
+			ActorID: &actorID,
+			Event:   models.AuditRoleChanged,
+			IP:      actorIP,
+			Detail:  fmt.Sprintf("%s -> %s", previous, role),
+		})
+	}
+	return user, nil
+}
+
 // ListUsers 获取用户列表（管理员功能）
 func (s *UserService) ListUsers(params *models.PaginationParams) ([]*models.User, *models.PaginationResponse, error) {
 	var users []*models.User
@@ -0,0 +1,707 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"xpaste-sync/internal/models"
+)
+
+// webhook 请求头
+const (
+	WebhookHeaderEvent     = "X-XPaste-Event"
+	WebhookHeaderEventID   = "X-XPaste-Event-ID"
+	WebhookHeaderDelivery  = "X-XPaste-Delivery"
+	WebhookHeaderTimestamp = "X-XPaste-Timestamp"
+	WebhookHeaderSignature = "X-XPaste-Signature-256" // sha256=HMAC-SHA256(secret, "<timestamp>.<body>") 的十六进制
+)
+
+const (
+	webhookSecretPrefix     = "whsec_"
+	webhookResponseBodySize = 2048 // 投递记录保存的响应体长度
+	webhookBatchSize        = 20   // 每次扫描取出的待投递记录数
+	webhookWorkers          = 4    // 并发投递数
+)
+
+// WebhookOptions webhook 投递配置
+type WebhookOptions struct {
+	Timeout              time.Duration // 单次请求超时
+	MaxAttempts          int           // 最多尝试次数（包括第一次）
+	RetryBase            time.Duration // 第一次重试的间隔，之后每次翻倍
+	RetryMax             time.Duration // 重试间隔上限
+	AllowPrivateNetworks bool          // 是否允许投递到回环和内网地址
+	MaxPerUser           int           // 每个用户最多的订阅数，0 表示不限制
+}
+
+// webhookEvent 等待匹配订阅的事件
+type webhookEvent struct {
+	userID   uint
+	event    models.WebhookEvent
+	clip     *models.ClipItem // 剪贴板项事件
+	deviceID string           // 产生事件的设备
+}
+
+// WebhookService 出站 webhook 服务：按订阅筛选事件，签名后投递，失败时按指数退避重试
+// 事件先进入内存队列，由后台协程写入投递记录；投递记录持久化，重启后继续重试
+type WebhookService struct {
+	db     *gorm.DB
+	opts   WebhookOptions
+	client *http.Client
+
+	events chan webhookEvent
+	wake   chan struct{}
+
+	mu       sync.Mutex
+	inflight map[uint]bool // 正在投递的记录
+	slots    chan struct{} // 限制并发投递数
+	pending  sync.WaitGroup
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewWebhookService 创建 webhook 服务
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	s := &WebhookService{
+		db:       db,
+		events:   make(chan webhookEvent, 256),
+		wake:     make(chan struct{}, 1),
+		inflight: make(map[uint]bool),
+		slots:    make(chan struct{}, webhookWorkers),
+	}
+	s.Configure(WebhookOptions{
+		Timeout:     10 * time.Second,
+		MaxAttempts: 8,
+		RetryBase:   30 * time.Second,
+		RetryMax:    time.Hour,
+		MaxPerUser:  20,
+	})
+	return s
+}
+
+// Configure 设置投递参数
+func (s *WebhookService) Configure(opts WebhookOptions) {
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	s.opts = opts
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateNetworks {
+		// 在连接时检查解析出的地址，防止通过 DNS 指向内网
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+				return fmt.Errorf("webhook target %s is not a public address", host)
+			}
+			return nil
+		}
+	}
+	s.client = &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		// 不跟随重定向，3xx 按失败处理
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Start 启动后台投递协程
+func (s *WebhookService) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	go s.run(ctx)
+}
+
+// Stop 停止后台投递协程，等待正在进行的投递结束；未完成的投递在下次启动后继续
+func (s *WebhookService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+// PublishClipEvent 发布剪贴板项事件，匹配发布者的订阅
+func (s *WebhookService) PublishClipEvent(event models.WebhookEvent, clipItem *models.ClipItem) {
+	snapshot := *clipItem
+	s.publish(webhookEvent{userID: clipItem.UserID, event: event, clip: &snapshot, deviceID: clipItem.DeviceID})
+}
+
+// PublishDeviceEvent 发布设备上线、下线事件
+func (s *WebhookService) PublishDeviceEvent(userID uint, deviceID string, online bool) {
+	event := models.WebhookEventDeviceOnline
+	if !online {
+		event = models.WebhookEventDeviceOffline
+	}
+	s.publish(webhookEvent{userID: userID, event: event, deviceID: deviceID})
+}
+
+// publish 事件入队，不阻塞调用方；队列已满时丢弃并记录日志
+func (s *WebhookService) publish(e webhookEvent) {
+	select {
+	case s.events <- e:
+	default:
+		log.Printf("Webhook event queue full, dropping %s for user %d", e.event, e.userID)
+	}
+}
+
+// notify 唤醒投递协程
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *WebhookService) run(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.pending.Wait()
+			return
+		case e := <-s.events:
+			if err := s.enqueue(&e); err != nil {
+				log.Printf("Failed to enqueue webhook event %s: %v", e.event, err)
+			}
+			s.deliverDue(ctx)
+		case <-s.wake:
+			s.deliverDue(ctx)
+		case <-ticker.C:
+			s.deliverDue(ctx)
+		}
+	}
+}
+
+// enqueue 为匹配事件的订阅创建投递记录
+func (s *WebhookService) enqueue(e *webhookEvent) error {
+	var hooks []*models.Webhook
+	if err := s.db.Where("user_id = ? AND active = ?", e.userID, true).Find(&hooks).Error; err != nil {
+		return fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	var matched []*models.Webhook
+	for _, hook := range hooks {
+		if webhookMatches(hook, e) {
+			matched = append(matched, hook)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	data := models.WebhookData{}
+	if e.clip != nil {
+		data.Clip = MaskClipResponse(e.clip) // 发送给第三方，遮盖敏感数据
+	} else {
+		var device models.Device
+		if err := s.db.Where("user_id = ? AND device_id = ?", e.userID, e.deviceID).First(&device).Error; err != nil {
+			return fmt.Errorf("failed to get device %s: %w", e.deviceID, err)
+		}
+		data.Device = device.ToResponse()
+	}
+
+	payload, err := json.Marshal(&models.WebhookPayload{
+		ID:        uuid.New().String(),
+		Event:     e.event,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	for _, hook := range matched {
+		if _, err := s.createDelivery(hook.ID, e.event, payload, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// webhookMatches 事件是否符合订阅的筛选条件
+func webhookMatches(hook *models.Webhook, e *webhookEvent) bool {
+	if !hook.Subscribes(e.event) {
+		return false
+	}
+	if len(hook.DeviceIDs) > 0 && !containsString(hook.DeviceIDs, e.deviceID) {
+		return false
+	}
+	if e.clip == nil {
+		return true
+	}
+	if len(hook.ClipTypes) > 0 && !containsString(hook.ClipTypes, string(e.clip.Type)) {
+		return false
+	}
+	if len(hook.Tags) > 0 {
+		for _, tag := range e.clip.Tags {
+			if containsString(hook.Tags, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// createDelivery 创建待投递记录，payload 中的事件ID写入记录便于查询
+func (s *WebhookService) createDelivery(webhookID uint, event models.WebhookEvent, payload []byte, redeliveryOf *uint) (*models.WebhookDelivery, error) {
+	var envelope struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(payload, &envelope)
+
+	now := time.Now()
+	delivery := &models.WebhookDelivery{
+		WebhookID:     webhookID,
+		EventID:       envelope.ID,
+		Event:         event,
+		Payload:       string(payload),
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  redeliveryOf,
+	}
+	if err := s.db.Create(delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// deliverDue 取出到期的投递记录并发投递，并发数已满时留到下一轮
+func (s *WebhookService) deliverDue(ctx context.Context) {
+	var deliveries []*models.WebhookDelivery
+	if err := s.db.Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, time.Now()).
+		Order("next_attempt_at").Limit(webhookBatchSize).Find(&deliveries).Error; err != nil {
+		log.Printf("Failed to get due webhook deliveries: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		s.mu.Lock()
+		busy := s.inflight[delivery.ID]
+		s.mu.Unlock()
+		if busy {
+			continue
+		}
+
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			return
+		}
+
+		s.mu.Lock()
+		s.inflight[delivery.ID] = true
+		s.mu.Unlock()
+
+		s.pending.Add(1)
+		go func(delivery *models.WebhookDelivery) {
+			defer func() {
+				s.mu.Lock()
+				delete(s.inflight, delivery.ID)
+				s.mu.Unlock()
+				<-s.slots
+				s.pending.Done()
+			}()
+			s.attempt(ctx, delivery)
+		}(delivery)
+	}
+}
+
+// attempt 投递一次并记录结果
+func (s *WebhookService) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	var hook models.Webhook
+	if err := s.db.First(&hook, delivery.WebhookID).Error; err != nil {
+		s.finish(delivery, models.WebhookDeliveryFailed, "webhook no longer exists")
+		return
+	}
+	if !hook.Active && delivery.Event != models.WebhookEventPing {
+		s.finish(delivery, models.WebhookDeliveryFailed, "webhook is disabled")
+		return
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		s.finish(delivery, models.WebhookDeliveryFailed, truncate(err.Error(), 500))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "xPaste-Webhook/1.0")
+	req.Header.Set(WebhookHeaderEvent, string(delivery.Event))
+	req.Header.Set(WebhookHeaderEventID, delivery.EventID)
+	req.Header.Set(WebhookHeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(hook.Secret, timestamp, []byte(delivery.Payload)))
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil && ctx.Err() != nil {
+		// 服务关闭导致的取消不计入尝试次数
+		return
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.DurationMs = now.Sub(start).Milliseconds()
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+	if err != nil {
+		delivery.Error = truncate(err.Error(), 500)
+	} else {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseBodySize))
+		resp.Body.Close()
+		delivery.ResponseStatus = resp.StatusCode
+		delivery.ResponseBody = string(body)
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			delivery.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+		}
+	}
+
+	hookUpdates := map[string]interface{}{"last_delivery_at": now}
+	if delivery.Error == "" {
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+		hookUpdates["failure_count"] = 0
+	} else {
+		if delivery.Attempts >= s.opts.MaxAttempts {
+			delivery.Status = models.WebhookDeliveryFailed
+			delivery.NextAttemptAt = nil
+		} else {
+			next := now.Add(s.backoff(delivery.Attempts))
+			delivery.NextAttemptAt = &next
+		}
+		hookUpdates["failure_count"] = gorm.Expr("failure_count + 1")
+	}
+
+	if err := s.db.Save(delivery).Error; err != nil {
+		log.Printf("Failed to save webhook delivery %d: %v", delivery.ID, err)
+	}
+	if err := s.db.Model(&models.Webhook{}).Where("id = ?", hook.ID).Updates(hookUpdates).Error; err != nil {
+		log.Printf("Failed to update webhook %d: %v", hook.ID, err)
+	}
+}
+
+// finish 不发送请求直接结束投递（订阅已删除或已停用）
+func (s *WebhookService) finish(delivery *models.WebhookDelivery, status models.WebhookDeliveryStatus, reason string) {
+	delivery.Status = status
+	delivery.Error = reason
+	delivery.NextAttemptAt = nil
+	if err := s.db.Save(delivery).Error; err != nil {
+		log.Printf("Failed to save webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// backoff 第 attempts 次失败后的重试间隔：RetryBase 每次翻倍，不超过 RetryMax，加上最多 10% 的随机抖动
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	delay := s.opts.RetryBase
+	for i := 1; i < attempts && delay < s.opts.RetryMax; i++ {
+		delay *= 2
+	}
+	if s.opts.RetryMax > 0 && delay > s.opts.RetryMax {
+		delay = s.opts.RetryMax
+	}
+	if jitter := int64(delay / 10); jitter > 0 {
+		delay += time.Duration(rand.Int63n(jitter))
+	}
+	return delay
+}
+
+// SignWebhookPayload 计算请求签名：sha256=HMAC-SHA256(secret, "<timestamp>.<body>")
+// 接收方应校验签名并拒绝时间戳过旧的请求，防止重放
+func SignWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// CreateWebhook 创建 webhook 订阅，返回的记录包含密钥明文
+func (s *WebhookService) CreateWebhook(userID uint, req *models.CreateWebhookRequest) (*models.Webhook, error) {
+	if err := validateWebhook(req.URL, req.Events); err != nil {
+		return nil, err
+	}
+	if s.opts.MaxPerUser > 0 {
+		var count int64
+		if err := s.db.Model(&models.Webhook{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to count webhooks: %w", err)
+		}
+		if count >= int64(s.opts.MaxPerUser) {
+			return nil, models.ErrWebhookLimitReached
+		}
+	}
+
+	secret, err := randomToken(24)
+	if err != nil {
+		return nil, err
+	}
+
+	active := req.Active == nil || *req.Active
+	hook := &models.Webhook{
+		UserID:    userID,
+		Name:      req.Name,
+		URL:       req.URL,
+		Secret:    webhookSecretPrefix + secret,
+		Active:    active,
+		Events:    req.Events,
+		Tags:      req.Tags,
+		ClipTypes: req.ClipTypes,
+		DeviceIDs: req.DeviceIDs,
+	}
+	if err := s.db.Create(hook).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	// Active 的零值会被 GORM 的默认值覆盖（包括回填到 hook），创建后单独写入
+	if !active {
+		if err := s.db.Model(hook).Update("active", false).Error; err != nil {
+			return nil, fmt.Errorf("failed to create webhook: %w", err)
+		}
+	}
+	return hook, nil
+}
+
+// ListWebhooks 获取用户的 webhook 订阅
+func (s *WebhookService) ListWebhooks(userID uint) ([]*models.Webhook, error) {
+	var hooks []*models.Webhook
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&hooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	return hooks, nil
+}
+
+// GetWebhook 获取用户的 webhook 订阅
+func (s *WebhookService) GetWebhook(userID uint, webhookID uint) (*models.Webhook, error) {
+	var hook models.Webhook
+	if err := s.db.Where("id = ? AND user_id = ?", webhookID, userID).First(&hook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &hook, nil
+}
+
+// UpdateWebhook 修改 webhook 订阅
+func (s *WebhookService) UpdateWebhook(userID uint, webhookID uint, req *models.UpdateWebhookRequest) (*models.Webhook, error) {
+	hook, err := s.GetWebhook(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		hook.Name = *req.Name
+	}
+	if req.URL != nil {
+		hook.URL = *req.URL
+	}
+	if req.Events != nil {
+		hook.Events = req.Events
+	}
+	if req.Tags != nil {
+		hook.Tags = *req.Tags
+	}
+	if req.ClipTypes != nil {
+		hook.ClipTypes = *req.ClipTypes
+	}
+	if req.DeviceIDs != nil {
+		hook.DeviceIDs = *req.DeviceIDs
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+		if hook.Active {
+			hook.FailureCount = 0
+		}
+	}
+	if err := validateWebhook(hook.URL, hook.Events); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(hook).Error; err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return hook, nil
+}
+
+// DeleteWebhook 删除 webhook 订阅和投递记录
+func (s *WebhookService) DeleteWebhook(userID uint, webhookID uint) error {
+	hook, err := s.GetWebhook(userID, webhookID)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", hook.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+		}
+		if err := tx.Delete(hook).Error; err != nil {
+			return fmt.Errorf("failed to delete webhook: %w", err)
+		}
+		return nil
+	})
+}
+
+// RotateSecret 生成新的签名密钥，返回的记录包含密钥明文
+func (s *WebhookService) RotateSecret(userID uint, webhookID uint) (*models.Webhook, error) {
+	hook, err := s.GetWebhook(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	hook.Secret = webhookSecretPrefix + secret
+	if err := s.db.Model(hook).Update("secret", hook.Secret).Error; err != nil {
+		return nil, fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+	return hook, nil
+}
+
+// Ping 向订阅发送一次测试事件，停用的订阅也会发送
+func (s *WebhookService) Ping(userID uint, webhookID uint) (*models.WebhookDelivery, error) {
+	hook, err := s.GetWebhook(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(&models.WebhookPayload{
+		ID:        uuid.New().String(),
+		Event:     models.WebhookEventPing,
+		CreatedAt: time.Now(),
+		Data:      models.WebhookData{Webhook: hook.ToResponse()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	delivery, err := s.createDelivery(hook.ID, models.WebhookEventPing, payload, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.notify()
+	return delivery, nil
+}
+
+// ListDeliveries 获取订阅的投递记录，按时间倒序
+func (s *WebhookService) ListDeliveries(userID uint, webhookID uint, filter *models.WebhookDeliveryFilter, params *models.PaginationParams) ([]*models.WebhookDelivery, *models.PaginationResponse, error) {
+	if _, err := s.GetWebhook(userID, webhookID); err != nil {
+		return nil, nil, err
+	}
+
+	query := s.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	var deliveries []*models.WebhookDelivery
+	if err := query.Order("created_at DESC, id DESC").Offset(params.GetOffset()).Limit(params.GetLimit()).Find(&deliveries).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	pagination := &models.PaginationResponse{
+		Page:     params.Page,
+		PageSize: params.PageSize,
+		Total:    total,
+	}
+	pagination.CalculateTotalPages()
+
+	return deliveries, pagination, nil
+}
+
+// GetDelivery 获取投递记录
+func (s *WebhookService) GetDelivery(userID uint, webhookID uint, deliveryID uint) (*models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(userID, webhookID); err != nil {
+		return nil, err
+	}
+	var delivery models.WebhookDelivery
+	if err := s.db.Where("id = ? AND webhook_id = ?", deliveryID, webhookID).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &delivery, nil
+}
+
+// Redeliver 用原请求内容重新投递，创建新的投递记录，事件ID不变
+func (s *WebhookService) Redeliver(userID uint, webhookID uint, deliveryID uint) (*models.WebhookDelivery, error) {
+	original, err := s.GetDelivery(userID, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := s.createDelivery(original.WebhookID, original.Event, []byte(original.Payload), &original.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.notify()
+	return delivery, nil
+}
+
+// validateWebhook 校验投递地址和订阅的事件
+func validateWebhook(rawURL string, events []models.WebhookEvent) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return models.ErrWebhookURLInvalid
+	}
+	if len(events) == 0 {
+		return models.ErrWebhookEventInvalid
+	}
+	for _, event := range events {
+		if !event.IsValid() {
+			return models.ErrWebhookEventInvalid
+		}
+	}
+	return nil
+}
+
+// isPrivateIP 是否是回环、内网、链路本地或未指定地址
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
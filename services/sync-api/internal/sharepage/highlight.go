@@ -0,0 +1,177 @@
+package sharepage
+
+import (
+	"html"
+	"html/template"
+	"strings"
+)
+
+// 语法高亮的 CSS 类名
+const (
+	classKeyword = "kw"
+	classString  = "str"
+	classComment = "com"
+	classNumber  = "num"
+	classTag     = "tag"
+)
+
+// maxHighlightSize 超过此大小的文本不做语法高亮，只转义输出
+const maxHighlightSize = 512 * 1024
+
+// Highlight 按语言高亮代码，返回逐行的 HTML，所有文本都经过转义
+// 跨行的注释和字符串在每行单独闭合，方便按行显示行号
+func Highlight(content string, languageID string) []template.HTML {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	lang := LookupLanguage(languageID)
+
+	out := &lineWriter{}
+	if lang == nil || lang.ID == LanguageMarkdown || len(content) > maxHighlightSize {
+		out.write("", content)
+		return out.finish()
+	}
+
+	src := content
+	inTag := false
+	for i := 0; i < len(src); {
+		rest := src[i:]
+
+		if lang.BlockComment[0] != "" && strings.HasPrefix(rest, lang.BlockComment[0]) {
+			end := strings.Index(rest[len(lang.BlockComment[0]):], lang.BlockComment[1])
+			n := len(rest)
+			if end >= 0 {
+				n = len(lang.BlockComment[0]) + end + len(lang.BlockComment[1])
+			}
+			out.write(classComment, rest[:n])
+			i += n
+			continue
+		}
+		if !inTag && hasAnyPrefix(rest, lang.LineComments) && (rest[0] != '#' || i == 0 || !isIdentByte(src[i-1])) {
+			n := strings.IndexByte(rest, '\n')
+			if n < 0 {
+				n = len(rest)
+			}
+			out.write(classComment, rest[:n])
+			i += n
+			continue
+		}
+
+		c := rest[0]
+		switch {
+		case lang.Markup && c == '<' && len(rest) > 1 && (isIdentStart(rest[1]) || rest[1] == '/' || rest[1] == '!' || rest[1] == '?'):
+			n := 1
+			for n < len(rest) && (rest[n] == '/' || rest[n] == '!' || rest[n] == '?') {
+				n++
+			}
+			for n < len(rest) && (isIdentByte(rest[n]) || rest[n] == '-' || rest[n] == ':') {
+				n++
+			}
+			out.write(classTag, rest[:n])
+			inTag = true
+			i += n
+		case lang.Markup && inTag && c == '>':
+			out.write(classTag, ">")
+			inTag = false
+			i++
+		case strings.IndexByte(lang.Quotes, c) >= 0 && (!lang.Markup || inTag):
+			n := scanString(rest, c)
+			out.write(classString, rest[:n])
+			i += n
+		case !lang.Markup && isDigit(c) && (i == 0 || !isIdentByte(src[i-1])):
+			n := 1
+			for n < len(rest) && (isIdentByte(rest[n]) || rest[n] == '.') {
+				n++
+			}
+			out.write(classNumber, rest[:n])
+			i += n
+		case isIdentStart(c):
+			n := 1
+			for n < len(rest) && isIdentByte(rest[n]) {
+				n++
+			}
+			word := rest[:n]
+			class := ""
+			if lang.keywords[word] || (lang.IgnoreCase && lang.keywords[strings.ToLower(word)]) {
+				class = classKeyword
+			}
+			out.write(class, word)
+			i += n
+		default:
+			out.write("", rest[:1])
+			i++
+		}
+	}
+	return out.finish()
+}
+
+// scanString 返回从定界符开始的字符串长度，反引号字符串可以跨行，其它字符串在行尾结束
+func scanString(s string, quote byte) int {
+	for n := 1; n < len(s); n++ {
+		switch s[n] {
+		case '\\':
+			n++
+		case quote:
+			return n + 1
+		case '\n':
+			if quote != '`' {
+				return n
+			}
+		}
+	}
+	return len(s)
+}
+
+// lineWriter 把带类名的文本片段拼成逐行的 HTML
+type lineWriter struct {
+	lines   []template.HTML
+	current strings.Builder
+}
+
+func (w *lineWriter) write(class string, text string) {
+	for {
+		part, rest, more := strings.Cut(text, "\n")
+		if part != "" {
+			if class != "" {
+				w.current.WriteString(`<span class="` + class + `">`)
+				w.current.WriteString(html.EscapeString(part))
+				w.current.WriteString(`</span>`)
+			} else {
+				w.current.WriteString(html.EscapeString(part))
+			}
+		}
+		if !more {
+			return
+		}
+		w.lines = append(w.lines, template.HTML(w.current.String()))
+		w.current.Reset()
+		text = rest
+	}
+}
+
+func (w *lineWriter) finish() []template.HTML {
+	if w.current.Len() > 0 || len(w.lines) == 0 {
+		w.lines = append(w.lines, template.HTML(w.current.String()))
+		w.current.Reset()
+	}
+	return w.lines
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentByte(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
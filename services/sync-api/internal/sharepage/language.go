@@ -0,0 +1,243 @@
+package sharepage
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Language 语法高亮使用的语言定义
+type Language struct {
+	ID           string
+	Name         string
+	Extensions   []string
+	Keywords     []string
+	LineComments []string
+	BlockComment [2]string
+	Quotes       string // 字符串定界符
+	Markup       bool   // 标记语言：只在标签内高亮字符串，标签名单独着色
+	IgnoreCase   bool   // 关键字不区分大小写
+
+	keywords map[string]bool
+	features []*regexp.Regexp // 自动识别时使用的特征，每匹配一个计一分
+}
+
+// 纯文本和 Markdown 不做语法高亮，Markdown 渲染为 HTML
+const (
+	LanguagePlainText = "text"
+	LanguageMarkdown  = "markdown"
+)
+
+var languages = []*Language{
+	{
+		ID: "go", Name: "Go", Extensions: []string{".go"},
+		Keywords:     words("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var nil true false iota"),
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: "\"'`",
+		features: patterns(`(?m)^package \w+`, `(?m)^func (\(\w+ \*?\w+\) )?\w+\(`, `:= `, `(?m)^import \(`, `\bfmt\.\w+\(`, `\berr != nil\b`),
+	},
+	{
+		ID: "python", Name: "Python", Extensions: []string{".py"},
+		Keywords:     words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield None True False self"),
+		LineComments: []string{"#"}, Quotes: "\"'",
+		features: patterns(`(?m)^\s*def \w+\(.*\):\s*$`, `(?m)^\s*(from \w+(\.\w+)* )?import \w+`, `(?m)^\s*class \w+(\(.*\))?:\s*$`, `(?m)^\s*(if|elif|for|while) .+:\s*$`, `\bprint\(`, `\bself\.`, `(?m)^if __name__ == `),
+	},
+	{
+		ID: "javascript", Name: "JavaScript", Extensions: []string{".js", ".mjs", ".cjs", ".jsx"},
+		Keywords:     words("async await break case catch class const continue default delete do else export extends finally for function if import in instanceof let new of return super switch this throw try typeof var void while yield null undefined true false"),
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: "\"'`",
+		features: patterns(`\b(const|let) \w+ = `, `=> ?\{`, `\bconsole\.log\(`, `\bfunction \w*\(`, `\brequire\(['"]`, `\bdocument\.\w+`, `(?m)^export (default )?(function|const|class)`),
+	},
+	{
+		ID: "typescript", Name: "TypeScript", Extensions: []string{".ts", ".tsx"},
+		Keywords:     words("abstract async await break case catch class const continue default delete do else enum export extends finally for function if implements import in instanceof interface let new of private protected public readonly return super switch this throw try type typeof var void while yield null undefined true false string number boolean any unknown never"),
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: "\"'`",
+		features: patterns(`(?m)^(export )?interface \w+`, `(?m)^(export )?type \w+ = `, `: (string|number|boolean|any|unknown)\b`, `\bas const\b`, `\bReadonly<`, `(?m)^import .* from ['"]`),
+	},
+	{
+		ID: "java", Name: "Java", Extensions: []string{".java"},
+		Keywords:     words("abstract boolean break byte case catch char class continue default do double else enum extends final finally float for if implements import instanceof int interface long new package private protected public return short static super switch this throw throws try void volatile while null true false"),
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: "\"'",
+		features: patterns(`\bpublic (static )?(final )?(class|void|interface) `, `\bSystem\.out\.println\(`, `(?m)^import java\.`, `@Override\b`, `\bprivate (final )?\w+(<.*>)? \w+;`),
+	},
+	{
+		ID: "c", Name: "C", Extensions: []string{".c", ".h"},
+		Keywords:     words("auto break case char const continue default do double else enum extern float for goto if int long register return short signed sizeof static struct switch typedef union unsigned void volatile while NULL"),
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: "\"'",
+		features: patterns(`(?m)^#include <\w+\.h>`, `\bprintf\(`, `\bmalloc\(`, `(?m)^int main\(`, `(?m)^#define \w+`),
+	},
+	{
+		ID: "cpp", Name: "C++", Extensions: []string{".cpp", ".cc", ".hpp"},
+		Keywords:     words("auto bool break case catch char class const constexpr continue default delete do double else enum explicit float for friend if inline int long namespace new nullptr operator private protected public return short sizeof static struct switch template this throw try typedef typename using virtual void while true false"),
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: "\"'",
+		features: patterns(`(?m)^#include <\w+>`, `\bstd::`, `\bcout <<`, `(?m)^using namespace `, `\btemplate ?<`),
+	},
+	{
+		ID: "rust", Name: "Rust", Extensions: []string{".rs"},
+		Keywords:     words("as async await break const continue crate else enum extern fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while true false"),
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: "\"",
+		features: patterns(`(?m)^\s*(pub )?fn \w+`, `\blet mut \w+`, `\bprintln!\(`, `(?m)^use \w+(::\w+)+`, `\bimpl\b.*\{`, `-> \w+`, `&mut \w+`),
+	},
+	{
+		ID: "ruby", Name: "Ruby", Extensions: []string{".rb"},
+		Keywords:     words("alias and begin break case class def defined do else elsif end ensure false for if in module next nil not or redo rescue retry return self super then true undef unless until when while yield require"),
+		LineComments: []string{"#"}, Quotes: "\"'",
+		features: patterns(`(?m)^\s*def \w+[?!]?(\(.*\))?\s*$`, `(?m)^\s*end\s*$`, `\bputs `, `(?m)^require ['"]`, `\.each do \|`, `attr_(reader|accessor) :`),
+	},
+	{
+		ID: "php", Name: "PHP", Extensions: []string{".php"},
+		Keywords:     words("abstract array as break case catch class const continue declare default do echo else elseif extends final for foreach function if implements interface namespace new private protected public require return static switch throw trait try use var while null true false"),
+		LineComments: []string{"//", "#"}, BlockComment: [2]string{"/*", "*/"}, Quotes: "\"'",
+		features: patterns(`<\?php`, `\$\w+ = `, `\becho `, `->\w+\(`, `(?m)^namespace [\w\\]+;`),
+	},
+	{
+		ID: "shell", Name: "Shell", Extensions: []string{".sh", ".bash", ".zsh"},
+		Keywords:     words("if then else elif fi case esac for while until do done in function return export local readonly echo exit set unset source"),
+		LineComments: []string{"#"}, Quotes: "\"'",
+		features: patterns(`(?m)^#!/(usr/)?bin/(env )?(ba|z)?sh`, `(?m)^\s*(sudo )?(apt(-get)?|brew|yum|npm|pip|go|docker|kubectl|git|curl|cd|ls|mkdir|export) `, `(?m)^\s*fi\s*$`, `\$\{?\w+\}?`, `(?m)^\s*echo `, `\| (grep|awk|sed|xargs) `),
+	},
+	{
+		ID: "sql", Name: "SQL", Extensions: []string{".sql"},
+		Keywords:     words("select from where and or not insert into values update set delete create table alter drop index join left right inner outer on group by order having limit offset as distinct null is in like primary key references union all begin commit"),
+		LineComments: []string{"--"}, BlockComment: [2]string{"/*", "*/"}, Quotes: "'\"", IgnoreCase: true,
+		features: patterns(`(?i)\bselect\b.+\bfrom\b`, `(?i)\binsert into\b`, `(?i)\bcreate table\b`, `(?i)\bupdate \w+ set\b`, `(?i)\bwhere \w+ ?=`, `(?i)\b(inner|left|right) join\b`),
+	},
+	{
+		ID: "html", Name: "HTML", Extensions: []string{".html", ".htm", ".xml", ".svg", ".vue"},
+		BlockComment: [2]string{"<!--", "-->"}, Quotes: "\"'", Markup: true,
+		features: patterns(`(?i)<!doctype html`, `(?i)<(html|head|body|div|span|p|a|script)[\s>]`, `(?i)</(div|p|a|span|li|body|html)>`, `<\?xml `),
+	},
+	{
+		ID: "css", Name: "CSS", Extensions: []string{".css", ".scss", ".less"},
+		BlockComment: [2]string{"/*", "*/"}, Quotes: "\"'",
+		features: patterns(`(?m)^[.#]?[\w-]+(\s*[,>]\s*[.#]?[\w-]+)*\s*\{\s*$`, `(?m)^\s*[\w-]+: [^;]+;\s*$`, `@media `, `#[0-9a-fA-F]{3,6};`),
+	},
+	{
+		ID: "json", Name: "JSON", Extensions: []string{".json"},
+		Keywords: words("true false null"), Quotes: "\"",
+	},
+	{
+		ID: "yaml", Name: "YAML", Extensions: []string{".yaml", ".yml"},
+		Keywords:     words("true false null yes no"),
+		LineComments: []string{"#"}, Quotes: "\"'",
+		features: patterns(`(?m)^\w[\w-]*:\s*$`, `(?m)^\s+- \w+`, `(?m)^\s+\w[\w-]*: \S`, `(?m)^---\s*$`),
+	},
+	{
+		ID: LanguageMarkdown, Name: "Markdown", Extensions: []string{".md", ".markdown"},
+		features: patterns(`(?m)^#{1,6} \S`, `(?m)^\s*[-*] \S`, `\[[^\]]+\]\([^)]+\)`, "(?m)^```", `\*\*[^*]+\*\*`, `(?m)^> `),
+	},
+}
+
+var languagesByID = func() map[string]*Language {
+	byID := make(map[string]*Language, len(languages))
+	for _, lang := range languages {
+		lang.keywords = make(map[string]bool, len(lang.Keywords))
+		for _, keyword := range lang.Keywords {
+			lang.keywords[keyword] = true
+		}
+		byID[lang.ID] = lang
+	}
+	return byID
+}()
+
+// 常见的语言别名
+var languageAliases = map[string]string{
+	"golang": "go", "py": "python", "js": "javascript", "node": "javascript", "ts": "typescript",
+	"c++": "cpp", "rs": "rust", "rb": "ruby", "sh": "shell", "bash": "shell", "zsh": "shell",
+	"xml": "html", "yml": "yaml", "md": LanguageMarkdown, "plain": LanguagePlainText, "txt": LanguagePlainText,
+}
+
+// LookupLanguage 按 ID 或别名查找语言，纯文本和未知语言返回 nil
+func LookupLanguage(id string) *Language {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if alias, ok := languageAliases[id]; ok {
+		id = alias
+	}
+	return languagesByID[id]
+}
+
+// LanguageName 语言的显示名称
+func LanguageName(id string) string {
+	if lang := LookupLanguage(id); lang != nil {
+		return lang.Name
+	}
+	return "Text"
+}
+
+// FileExtension 下载时使用的扩展名
+func FileExtension(id string) string {
+	if lang := LookupLanguage(id); lang != nil && len(lang.Extensions) > 0 {
+		return lang.Extensions[0]
+	}
+	return ".txt"
+}
+
+// DetectLanguage 识别文本的语言：依次使用客户端指定的语言、文件扩展名、shebang 和内容特征，无法识别时返回纯文本
+func DetectLanguage(content string, hint string, fileName string) string {
+	if hint != "" {
+		if lang := LookupLanguage(hint); lang != nil {
+			return lang.ID
+		}
+	}
+	if ext := strings.ToLower(path.Ext(fileName)); ext != "" {
+		for _, lang := range languages {
+			for _, e := range lang.Extensions {
+				if e == ext {
+					return lang.ID
+				}
+			}
+		}
+	}
+
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return LanguagePlainText
+	}
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+		return "json"
+	}
+	if strings.HasPrefix(trimmed, "#!") {
+		shebang, _, _ := strings.Cut(trimmed, "\n")
+		switch {
+		case strings.Contains(shebang, "python"):
+			return "python"
+		case strings.Contains(shebang, "node"):
+			return "javascript"
+		case strings.Contains(shebang, "ruby"):
+			return "ruby"
+		case strings.Contains(shebang, "sh"):
+			return "shell"
+		}
+	}
+
+	// 只取开头部分计算特征，避免超长文本拖慢请求
+	sample := trimmed
+	if len(sample) > 16*1024 {
+		sample = sample[:16*1024]
+	}
+	best, bestScore := LanguagePlainText, 1
+	for _, lang := range languages {
+		score := 0
+		for _, feature := range lang.features {
+			if feature.MatchString(sample) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = lang.ID, score
+		}
+	}
+	return best
+}
+
+func words(s string) []string {
+	return strings.Fields(s)
+}
+
+func patterns(exprs ...string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, len(exprs))
+	for i, expr := range exprs {
+		compiled[i] = regexp.MustCompile(expr)
+	}
+	return compiled
+}
@@ -0,0 +1,414 @@
+package sharepage
+
+import (
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Markdown 渲染支持标题、段落、列表、引用、分隔线、代码块、表格和常用行内格式。
+// 原始 HTML 一律转义输出，链接只允许 http、https 和 mailto，图片渲染为链接（分享页不加载外部资源）
+
+var (
+	mdHeading   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdRule      = regexp.MustCompile(`^\s{0,3}([-*_])(\s*([-*_]))\s*([-*_]\s*)+$`)
+	mdBullet    = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+	mdOrdered   = regexp.MustCompile(`^\s{0,3}(\d{1,9})[.)]\s+(.*)$`)
+	mdFence     = regexp.MustCompile("^\\s{0,3}(```+|~~~+)\\s*([\\w+#.-]*)")
+	mdTableSep  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdTaskItem  = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	mdBareURL   = regexp.MustCompile(`^https?://[^\s<>"]+`)
+	mdTrailPunc = ".,:;!?)'\""
+)
+
+// RenderMarkdown 把 Markdown 渲染为安全的 HTML
+func RenderMarkdown(src string) template.HTML {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"))
+	return template.HTML(b.String())
+}
+
+func renderBlocks(b *strings.Builder, lines []string) {
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>")
+			b.WriteString(renderInline(strings.Join(paragraph, "\n")))
+			b.WriteString("</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case mdFence.MatchString(line):
+			flush()
+			m := mdFence.FindStringSubmatch(line)
+			fence, lang := m[1], m[2]
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			content := strings.Join(code, "\n")
+			if lang == "" {
+				lang = DetectLanguage(content, "", "")
+			}
+			b.WriteString(`<pre class="code"><code>`)
+			for _, codeLine := range Highlight(content, lang) {
+				b.WriteString(`<span class="line">` + string(codeLine) + "</span>\n")
+			}
+			b.WriteString("</code></pre>\n")
+
+		case mdHeading.MatchString(line):
+			flush()
+			m := mdHeading.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + renderInline(m[2]) + "</h" + level + ">\n")
+
+		case mdRule.MatchString(line):
+			flush()
+			b.WriteString("<hr>\n")
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quoted []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if !strings.HasPrefix(t, ">") {
+					i--
+					break
+				}
+				t = strings.TrimPrefix(t, ">")
+				quoted = append(quoted, strings.TrimPrefix(t, " "))
+			}
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted)
+			b.WriteString("</blockquote>\n")
+
+		case mdBullet.MatchString(line) || mdOrdered.MatchString(line):
+			flush()
+			i = renderList(b, lines, i)
+
+		case strings.Contains(line, "|") && i+1 < len(lines) && mdTableSep.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			flush()
+			i = renderTable(b, lines, i)
+
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flush()
+}
+
+// renderList 渲染从 start 开始的列表，返回列表最后一行的下标
+func renderList(b *strings.Builder, lines []string, start int) int {
+	ordered := mdOrdered.MatchString(lines[start])
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+		if n := mdOrdered.FindStringSubmatch(lines[start])[1]; n != "1" {
+			b.WriteString(`<ol start="` + strconv.Itoa(atoi(n)) + `">` + "\n")
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+
+	var item []string
+	writeItem := func() {
+		if item == nil {
+			return
+		}
+		text := strings.Join(item, "\n")
+		b.WriteString("<li>")
+		if m := mdTaskItem.FindStringSubmatch(text); m != nil {
+			box := "☐"
+			if m[1] != " " {
+				box = "☑"
+			}
+			b.WriteString(`<span class="task">` + box + "</span> ")
+			text = m[2]
+		}
+		b.WriteString(renderInline(text))
+		b.WriteString("</li>\n")
+		item = nil
+	}
+
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		var m []string
+		if ordered {
+			if om := mdOrdered.FindStringSubmatch(line); om != nil {
+				m = []string{om[0], om[2]}
+			}
+		} else {
+			m = mdBullet.FindStringSubmatch(line)
+		}
+		switch {
+		case m != nil:
+			writeItem()
+			item = []string{m[1]}
+		case strings.TrimSpace(line) != "" && (strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t")):
+			// 缩进的续行属于上一个列表项
+			item = append(item, strings.TrimSpace(line))
+		default:
+			writeItem()
+			b.WriteString("</" + tag + ">\n")
+			return i - 1
+		}
+	}
+	writeItem()
+	b.WriteString("</" + tag + ">\n")
+	return i - 1
+}
+
+// renderTable 渲染从 start 开始的表格，返回表格最后一行的下标
+func renderTable(b *strings.Builder, lines []string, start int) int {
+	b.WriteString("<table>\n<thead><tr>")
+	for _, cell := range splitTableRow(lines[start]) {
+		b.WriteString("<th>" + renderInline(cell) + "</th>")
+	}
+	b.WriteString("</tr></thead>\n<tbody>\n")
+
+	i := start + 2
+	for ; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+		b.WriteString("<tr>")
+		for _, cell := range splitTableRow(lines[i]) {
+			b.WriteString("<td>" + renderInline(cell) + "</td>")
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	return i - 1
+}
+
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// renderInline 渲染行内格式：代码、强调、删除线、链接和自动链接，其余文本转义
+func renderInline(s string) string {
+	var b strings.Builder
+	text := 0 // 尚未输出的普通文本起点
+	emit := func(end int) {
+		b.WriteString(html.EscapeString(s[text:end]))
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_{}[]()#+-.!~|<>", s[i+1]) >= 0:
+			emit(i)
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			text = i
+			continue
+
+		case c == '\n':
+			emit(i)
+			b.WriteString("<br>\n")
+			i++
+			text = i
+			continue
+
+		case c == '`':
+			run := countRun(s[i:], '`')
+			if end := strings.Index(s[i+run:], strings.Repeat("`", run)); end >= 0 {
+				emit(i)
+				code := strings.TrimSpace(s[i+run : i+run+end])
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += run + end + run
+				text = i
+				continue
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			run := countRun(s[i:], c)
+			if c == '~' && run != 2 {
+				break
+			}
+			if run > 2 {
+				run = 2
+			}
+			// 下划线只在单词边界处生效，避免 snake_case 被当作强调
+			if c == '_' && i > 0 && isIdentByte(s[i-1]) {
+				break
+			}
+			delim := strings.Repeat(string(c), run)
+			end := strings.Index(s[i+run:], delim)
+			if end <= 0 || s[i+run] == ' ' {
+				break
+			}
+			if c == '_' && i+run+end+run < len(s) && isIdentByte(s[i+run+end+run]) {
+				break
+			}
+			emit(i)
+			tag := "em"
+			switch {
+			case c == '~':
+				tag = "del"
+			case run == 2:
+				tag = "strong"
+			}
+			b.WriteString("<" + tag + ">" + renderInline(s[i+run:i+run+end]) + "</" + tag + ">")
+			i += run + end + run
+			text = i
+			continue
+
+		case c == '[' || (c == '!' && i+1 < len(s) && s[i+1] == '['):
+			image := c == '!'
+			open := i
+			if image {
+				open++
+			}
+			label, href, n, ok := parseLink(s[open:])
+			if !ok {
+				break
+			}
+			emit(i)
+			if image && label == "" {
+				label = href
+			}
+			b.WriteString(renderLink(href, renderInline(label), image))
+			i = open + n
+			text = i
+			continue
+
+		case c == '<':
+			if end := strings.IndexByte(s[i:], '>'); end > 0 {
+				if target := s[i+1 : i+end]; mdBareURL.MatchString(target) || strings.HasPrefix(target, "mailto:") {
+					emit(i)
+					b.WriteString(renderLink(target, html.EscapeString(target), false))
+					i += end + 1
+					text = i
+					continue
+				}
+			}
+
+		case c == 'h' && (i == 0 || !isIdentByte(s[i-1])):
+			if m := mdBareURL.FindString(s[i:]); m != "" {
+				m = strings.TrimRight(m, mdTrailPunc)
+				emit(i)
+				b.WriteString(renderLink(m, html.EscapeString(m), false))
+				i += len(m)
+				text = i
+				continue
+			}
+		}
+		i++
+	}
+	emit(len(s))
+	return b.String()
+}
+
+// parseLink 解析 [label](href)，返回消耗的字节数
+func parseLink(s string) (label string, href string, n int, ok bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				if i+1 >= len(s) || s[i+1] != '(' {
+					return "", "", 0, false
+				}
+				end := closingParen(s[i+2:])
+				if end < 0 {
+					return "", "", 0, false
+				}
+				target := strings.TrimSpace(s[i+2 : i+2+end])
+				// 去掉可选的标题 [x](url "title")
+				if sp := strings.IndexAny(target, " \t"); sp >= 0 {
+					target = target[:sp]
+				}
+				return s[1:i], strings.Trim(target, "<>"), i + 2 + end + 1, true
+			}
+		}
+	}
+	return "", "", 0, false
+}
+
+// renderLink 输出链接，不安全的地址只输出文本
+func renderLink(href string, label string, image bool) string {
+	if !SafeURL(href) {
+		return label
+	}
+	class := ""
+	if image {
+		class = ` class="image-link"`
+	}
+	return `<a href="` + html.EscapeString(href) + `"` + class + ` rel="nofollow noopener noreferrer" target="_blank">` + label + "</a>"
+}
+
+// SafeURL 是否是可以输出为链接的地址（http、https 或 mailto）
+func SafeURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return u.Opaque != ""
+	}
+	return false
+}
+
+// closingParen 返回与已打开的括号匹配的右括号位置，地址中可以包含成对的括号
+func closingParen(s string) int {
+	depth := 1
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '\n':
+			return -1
+		}
+	}
+	return -1
+}
+
+func countRun(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,225 @@
+// Package sharepage 把分享的文本剪贴板项渲染为 HTML 页面：代码语法高亮、Markdown 渲染和链接预览卡片。
+// 模板和样式表嵌入在二进制中，自托管部署不需要单独的前端
+package sharepage
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/url"
+	"strings"
+	"time"
+
+	"xpaste-sync/internal/models"
+)
+
+//go:embed templates/*.html static/*
+var files embed.FS
+
+// ContentSecurityPolicy 分享页的内容安全策略：不执行脚本，只加载本站样式表，图片只允许 data URL
+const ContentSecurityPolicy = "default-src 'none'; style-src 'self'; img-src data:; form-action 'self'; base-uri 'none'; frame-ancestors 'none'"
+
+// StylesheetPath 分享页样式表地址
+const StylesheetPath = "/s-assets/share.css"
+
+// 页面内容的展示方式
+const (
+	KindCode     = "code"
+	KindMarkdown = "markdown"
+	KindText     = "text"
+	KindURL      = "url"
+)
+
+// Templates 解析嵌入的页面模板
+func Templates() *template.Template {
+	return template.Must(template.New("").Funcs(template.FuncMap{
+		"formatTime": func(t time.Time) string {
+			return t.UTC().Format("2006-01-02 15:04 UTC")
+		},
+	}).ParseFS(files, "templates/*.html"))
+}
+
+// Static 嵌入的静态文件
+func Static() fs.FS {
+	static, err := fs.Sub(files, "static")
+	if err != nil {
+		panic(err)
+	}
+	return static
+}
+
+// Page 分享页模板数据
+type Page struct {
+	Title      string
+	Stylesheet string
+	Slug       string
+
+	Kind     string
+	Language string // 显示名称
+	Lines    []template.HTML
+	Markdown template.HTML
+	Card     *URLCard
+	Size     string
+
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+	ViewsLeft int // -1 表示不限制
+
+	// 原始内容和下载地址，链接已失效（阅后即焚或查看次数用完）时不显示
+	RawURL      string
+	DownloadURL string
+	Password    string // 有密码的链接通过表单提交原始内容和下载请求
+	Burned      bool
+}
+
+// URLCard 链接预览卡片，使用客户端保存的标题和描述，服务器不访问目标地址
+type URLCard struct {
+	URL         string
+	Safe        bool // 是否可以输出为可点击的链接
+	Host        string
+	Path        string
+	Title       string
+	Description string
+	SiteName    string
+}
+
+// NewPage 生成分享页数据，password 是访问者提交的密码
+func NewPage(link *models.ShareLink, clipItem *models.ClipItem, password string) *Page {
+	base := "/s/" + link.Slug
+	page := &Page{
+		Title:       clipItem.Title,
+		Stylesheet:  StylesheetPath,
+		Slug:        link.Slug,
+		Size:        formatSize(len(clipItem.Content)),
+		CreatedAt:   clipItem.CreatedAt,
+		ExpiresAt:   link.ExpiresAt,
+		ViewsLeft:   -1,
+		RawURL:      base + "/raw",
+		DownloadURL: base + "/download",
+		Password:    password,
+		Burned:      link.RevokedAt != nil || link.IsExpired(),
+	}
+	if link.MaxViews > 0 {
+		page.ViewsLeft = link.MaxViews - link.ViewCount
+	}
+
+	content := clipItem.Content
+	if card := NewURLCard(clipItem); card != nil {
+		page.Kind = KindURL
+		page.Card = card
+		if page.Title == "" {
+			page.Title = card.Title
+		}
+		return page
+	}
+
+	lang := DetectClipLanguage(clipItem)
+	page.Language = LanguageName(lang)
+	switch lang {
+	case LanguageMarkdown:
+		page.Kind = KindMarkdown
+		page.Markdown = RenderMarkdown(content)
+	case LanguagePlainText:
+		page.Kind = KindText
+		page.Lines = Highlight(content, lang)
+	default:
+		page.Kind = KindCode
+		page.Lines = Highlight(content, lang)
+	}
+	if page.Title == "" {
+		page.Title = firstLine(content, 80)
+	}
+	return page
+}
+
+// DetectClipLanguage 识别剪贴板项的语言，客户端可以通过 metadata.language 指定
+func DetectClipLanguage(clipItem *models.ClipItem) string {
+	hint, _ := clipItem.Metadata["language"].(string)
+	fileName, _ := clipItem.Metadata["file_name"].(string)
+	return DetectLanguage(clipItem.Content, hint, fileName)
+}
+
+// NewURLCard 为链接类型或内容只有一个 URL 的剪贴板项生成预览卡片，其它内容返回 nil
+func NewURLCard(clipItem *models.ClipItem) *URLCard {
+	raw := strings.TrimSpace(clipItem.Content)
+	if clipItem.Type != models.ClipTypeURL && (strings.ContainsAny(raw, " \t\n") || !SafeURL(raw) || strings.HasPrefix(raw, "mailto:")) {
+		return nil
+	}
+
+	card := &URLCard{URL: raw, Safe: SafeURL(raw), Title: clipItem.Title, Description: clipItem.Description}
+	if u, err := url.Parse(raw); err == nil {
+		card.Host = u.Hostname()
+		card.Path = u.EscapedPath()
+		if u.RawQuery != "" {
+			card.Path += "?" + u.RawQuery
+		}
+	}
+	if title, _ := clipItem.Metadata["title"].(string); title != "" && card.Title == "" {
+		card.Title = title
+	}
+	if description, _ := clipItem.Metadata["description"].(string); description != "" && card.Description == "" {
+		card.Description = description
+	}
+	card.SiteName, _ = clipItem.Metadata["site_name"].(string)
+	if card.Title == "" {
+		card.Title = card.Host
+	}
+	if card.Title == "" {
+		card.Title = raw
+	}
+	return card
+}
+
+// DownloadFileName 下载文本时使用的文件名
+func DownloadFileName(slug string, clipItem *models.ClipItem) string {
+	if name, _ := clipItem.Metadata["file_name"].(string); name != "" {
+		return name
+	}
+	return slug + FileExtension(DetectClipLanguage(clipItem))
+}
+
+// ErrorPage 错误页模板数据
+type ErrorPage struct {
+	Title      string
+	Stylesheet string
+	Message    string
+}
+
+// NewErrorPage 生成错误页数据
+func NewErrorPage(title string, message string) *ErrorPage {
+	return &ErrorPage{Title: title, Stylesheet: StylesheetPath, Message: message}
+}
+
+// PasswordPage 密码输入页模板数据
+type PasswordPage struct {
+	Title      string
+	Stylesheet string
+	Action     string
+	Invalid    bool
+}
+
+// NewPasswordPage 生成密码输入页数据，action 为提交地址
+func NewPasswordPage(action string, invalid bool) *PasswordPage {
+	return &PasswordPage{Title: "Password required", Stylesheet: StylesheetPath, Action: action, Invalid: invalid}
+}
+
+func firstLine(s string, max int) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	line = strings.TrimSpace(line)
+	if runes := []rune(line); len(runes) > max {
+		return string(runes[:max]) + "…"
+	}
+	return line
+}
+
+func formatSize(n int) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/1024/1024)
+	}
+}
@@ -0,0 +1,98 @@
+package websocket
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gorilla/websocket"
+)
+
+// Codec 消息编解码器
+type Codec interface {
+	// Name 对应的 WebSocket 子协议名称
+	Name() string
+	// FrameType 写出时使用的帧类型（文本或二进制）
+	FrameType() int
+	// Encode 编码消息
+	Encode(message Message) ([]byte, error)
+	// Decode 解码消息
+	Decode(data []byte, message *Message) error
+}
+
+// jsonCodec JSON 编码（默认及回退方式）
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string   { return SubprotocolJSON }
+func (jsonCodec) FrameType() int { return websocket.TextMessage }
+
+func (jsonCodec) Encode(message Message) ([]byte, error) {
+	return json.Marshal(message)
+}
+
+func (jsonCodec) Decode(data []byte, message *Message) error {
+	return json.Unmarshal(data, message)
+}
+
+// cborCodec CBOR 二进制编码，字段使用整数键以减小体积
+type cborCodec struct {
+	enc cbor.EncMode
+	dec cbor.DecMode
+}
+
+func (cborCodec) Name() string   { return SubprotocolCBOR }
+func (cborCodec) FrameType() int { return websocket.BinaryMessage }
+
+func (c cborCodec) Encode(message Message) ([]byte, error) {
+	return c.enc.Marshal(message)
+}
+
+func (c cborCodec) Decode(data []byte, message *Message) error {
+	return c.dec.Unmarshal(data, message)
+}
+
+// newCBORCodec 创建 CBOR 编解码器
+func newCBORCodec() cborCodec {
+	enc, err := cbor.EncOptions{
+		Time: cbor.TimeRFC3339Nano,
+	}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+
+	// Data 字段为 interface{}，解码为 map[string]interface{} 以便与 JSON 行为一致
+	dec, err := cbor.DecOptions{
+		DefaultMapType: reflect.TypeOf(map[string]interface{}(nil)),
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+
+	return cborCodec{enc: enc, dec: dec}
+}
+
+var (
+	defaultJSONCodec Codec = jsonCodec{}
+	defaultCBORCodec Codec = newCBORCodec()
+)
+
+// supportedSubprotocols 服务端支持的子协议，按优先级排列
+var supportedSubprotocols = []string{SubprotocolCBOR, SubprotocolJSON}
+
+// codecForSubprotocol 根据协商得到的子协议选择编码，未协商时回退为 JSON
+func codecForSubprotocol(subprotocol string) Codec {
+	switch subprotocol {
+	case SubprotocolCBOR:
+		return defaultCBORCodec
+	default:
+		return defaultJSONCodec
+	}
+}
+
+// codecForFrame 根据收到的帧类型选择解码方式
+func codecForFrame(frameType int) Codec {
+	if frameType == websocket.BinaryMessage {
+		return defaultCBORCodec
+	}
+	return defaultJSONCodec
+}
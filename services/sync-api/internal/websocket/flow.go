@@ -0,0 +1,232 @@
+package websocket
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"xpaste-sync/internal/config"
+)
+
+// OverflowPolicy 发送队列已满时的处理策略
+type OverflowPolicy string
+
+const (
+	OverflowDropOldest OverflowPolicy = "drop_oldest" // 丢弃队列中最旧的消息，为新消息腾出位置
+	OverflowCoalesce   OverflowPolicy = "coalesce"    // 不入队，稍后合并为一条重新同步通知
+	OverflowDisconnect OverflowPolicy = "disconnect"  // 断开连接，由客户端重连后全量同步
+)
+
+// valid 是否为已知策略
+func (p OverflowPolicy) valid() bool {
+	switch p {
+	case OverflowDropOldest, OverflowCoalesce, OverflowDisconnect:
+		return true
+	}
+	return false
+}
+
+// flowControl 按消息类型选择溢出策略
+type flowControl struct {
+	defaultPolicy   OverflowPolicy
+	policies        map[MessageType]OverflowPolicy
+	resyncWatermark int // 队列长度降到该值及以下时才发送合并后的重新同步通知
+}
+
+// newFlowControl 根据配置创建流控策略，未知策略名回退为默认策略
+func newFlowControl(cfg *config.SyncConfig) *flowControl {
+	fc := &flowControl{
+		defaultPolicy:   OverflowPolicy(cfg.WebSocketOverflowPolicy),
+		policies:        make(map[MessageType]OverflowPolicy),
+		resyncWatermark: cfg.WebSocketResyncWatermark,
+	}
+	if !fc.defaultPolicy.valid() {
+		log.Printf("Unknown WebSocket overflow policy %q, using %s", cfg.WebSocketOverflowPolicy, OverflowDropOldest)
+		fc.defaultPolicy = OverflowDropOldest
+	}
+
+	for messageType, name := range cfg.WebSocketOverflowPolicies {
+		policy := OverflowPolicy(name)
+		if !policy.valid() {
+			log.Printf("Unknown WebSocket overflow policy %q for %s, using %s", name, messageType, fc.defaultPolicy)
+			continue
+		}
+		fc.policies[MessageType(messageType)] = policy
+	}
+
+	return fc
+}
+
+// policyFor 获取消息类型对应的溢出策略
+func (fc *flowControl) policyFor(messageType MessageType) OverflowPolicy {
+	if policy, exists := fc.policies[messageType]; exists {
+		return policy
+	}
+	return fc.defaultPolicy
+}
+
+// FlowStats 流控计数器
+type FlowStats struct {
+	Enqueued    atomic.Int64 // 成功入队的消息
+	Dropped     atomic.Int64 // 因队列已满被丢弃的消息
+	Coalesced   atomic.Int64 // 被合并进重新同步通知的消息
+	Resyncs     atomic.Int64 // 已发送的重新同步通知
+	Disconnects atomic.Int64 // 因队列溢出断开的连接
+}
+
+// FlowStatsSnapshot 流控计数器快照
+type FlowStatsSnapshot struct {
+	Enqueued    int64 `json:"enqueued"`
+	Dropped     int64 `json:"dropped"`
+	Coalesced   int64 `json:"coalesced"`
+	Resyncs     int64 `json:"resyncs"`
+	Disconnects int64 `json:"disconnects"`
+}
+
+// Snapshot 获取计数器快照
+func (s *FlowStats) Snapshot() FlowStatsSnapshot {
+	return FlowStatsSnapshot{
+		Enqueued:    s.Enqueued.Load(),
+		Dropped:     s.Dropped.Load(),
+		Coalesced:   s.Coalesced.Load(),
+		Resyncs:     s.Resyncs.Load(),
+		Disconnects: s.Disconnects.Load(),
+	}
+}
+
+// ConnectionFlowStats 单个连接的流控状态
+type ConnectionFlowStats struct {
+	ClientID      string `json:"client_id"`
+	UserID        uint   `json:"user_id"`
+	DeviceID      string `json:"device_id"`
+	Queued        int    `json:"queued"`
+	PendingResync int64  `json:"pending_resync"`
+	FlowStatsSnapshot
+}
+
+// enqueue 按流控策略投递消息，返回 false 表示应断开该连接
+func (c *Client) enqueue(message Message) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// 连接正在关闭，消息直接丢弃
+	if c.Send == nil {
+		return true
+	}
+
+	// 串行化同一连接的生产者，保证丢弃最旧消息后一定有空位
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
+	select {
+	case c.Send <- message:
+		c.countFlow(func(s *FlowStats) { s.Enqueued.Add(1) })
+		return true
+	default:
+	}
+
+	flow := c.Manager.flow
+	switch flow.policyFor(message.Type) {
+	case OverflowCoalesce:
+		c.coalesce()
+		return true
+
+	case OverflowDisconnect:
+		c.countFlow(func(s *FlowStats) { s.Disconnects.Add(1) })
+		return false
+
+	default:
+		select {
+		case oldest := <-c.Send:
+			// 被挤出的消息如果属于可合并类型，仍然计入重新同步通知
+			if flow.policyFor(oldest.Type) == OverflowCoalesce {
+				c.coalesce()
+			} else {
+				c.countFlow(func(s *FlowStats) { s.Dropped.Add(1) })
+			}
+		default:
+		}
+
+		select {
+		case c.Send <- message:
+			c.countFlow(func(s *FlowStats) { s.Enqueued.Add(1) })
+		default:
+			c.countFlow(func(s *FlowStats) { s.Dropped.Add(1) })
+		}
+		return true
+	}
+}
+
+// coalesce 记录一条被合并的消息
+func (c *Client) coalesce() {
+	c.pendingResync.Add(1)
+	c.countFlow(func(s *FlowStats) { s.Coalesced.Add(1) })
+}
+
+// takeResync 队列回落到水位线以下时，取出合并后的重新同步通知
+func (c *Client) takeResync(queued int) (Message, bool) {
+	if queued > c.Manager.flow.resyncWatermark {
+		return Message{}, false
+	}
+
+	changed := c.pendingResync.Swap(0)
+	if changed == 0 {
+		return Message{}, false
+	}
+
+	c.countFlow(func(s *FlowStats) { s.Resyncs.Add(1) })
+	return Message{
+		Type: MessageTypeClipSync,
+		Data: gin.H{
+			"resync":  true,
+			"reason":  "backpressure",
+			"changed": changed,
+		},
+		Timestamp: time.Now().Unix(),
+	}, true
+}
+
+// countFlow 同时更新连接和管理器的流控计数
+func (c *Client) countFlow(update func(s *FlowStats)) {
+	update(&c.flow)
+	update(&c.Manager.flowTotals)
+}
+
+// flowStats 获取连接的流控状态
+func (c *Client) flowStats() ConnectionFlowStats {
+	c.mu.RLock()
+	queued := len(c.Send)
+	c.mu.RUnlock()
+
+	return ConnectionFlowStats{
+		ClientID:          c.ID,
+		UserID:            c.UserID,
+		DeviceID:          c.DeviceID,
+		Queued:            queued,
+		PendingResync:     c.pendingResync.Load(),
+		FlowStatsSnapshot: c.flow.Snapshot(),
+	}
+}
+
+// GetFlowStats 获取流控统计，仅列出出现过积压的连接
+func (m *Manager) GetFlowStats() map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	congested := make([]ConnectionFlowStats, 0)
+	for _, client := range m.clients {
+		stats := client.flowStats()
+		if stats.Queued == 0 && stats.Dropped == 0 && stats.Coalesced == 0 {
+			continue
+		}
+		congested = append(congested, stats)
+	}
+
+	return map[string]interface{}{
+		"queue_capacity":        m.config.WebSocketSendQueueSize,
+		"totals":                m.flowTotals.Snapshot(),
+		"congested_connections": congested,
+	}
+}
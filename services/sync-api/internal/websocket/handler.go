@@ -1,13 +1,21 @@
 package websocket
 
 import (
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 
+	"xpaste-sync/internal/config"
+	"xpaste-sync/internal/middleware"
 	"xpaste-sync/internal/models"
 	"xpaste-sync/internal/services"
 )
@@ -17,15 +25,62 @@ type Handler struct {
 	manager       *Manager
 	userService   *services.UserService
 	deviceService *services.DeviceService
+	config        *config.SyncConfig
+	upgrader      websocket.Upgrader
+	tickets       *TicketStore
+
+	// allowedOrigins 允许发起握手的跨域 Origin，取自 CORS 允许列表中明确配置的前端地址
+	allowedOrigins []string
+	// allowAnyOrigin 允许任意 Origin 握手，需要显式开启
+	allowAnyOrigin bool
 }
 
 // NewHandler 创建 WebSocket 处理器
-func NewHandler(manager *Manager, userService *services.UserService, deviceService *services.DeviceService) *Handler {
-	return &Handler{
-		manager:       manager,
-		userService:   userService,
-		deviceService: deviceService,
+func NewHandler(manager *Manager, userService *services.UserService, deviceService *services.DeviceService, cfg *config.SyncConfig, cors *config.CORSConfig) *Handler {
+	h := &Handler{
+		manager:        manager,
+		userService:    userService,
+		deviceService:  deviceService,
+		config:         cfg,
+		tickets:        NewTicketStore(cfg.WebSocketTicketTTL),
+		allowedOrigins: explicitOrigins(cors.AllowOrigins),
+		allowAnyOrigin: cfg.WebSocketAllowAnyOrigin,
 	}
+	h.upgrader = newUpgrader(cfg, h.checkOrigin)
+	return h
+}
+
+// newUpgrader 根据配置创建 WebSocket 升级器
+func newUpgrader(cfg *config.SyncConfig, checkOrigin func(r *http.Request) bool) websocket.Upgrader {
+	return websocket.Upgrader{
+		ReadBufferSize:    cfg.WebSocketBufferSize,
+		WriteBufferSize:   cfg.WebSocketBufferSize,
+		EnableCompression: cfg.WebSocketCompression,
+		Subprotocols:      supportedSubprotocols,
+		CheckOrigin:       checkOrigin,
+	}
+}
+
+// rejectUpgrader 仅用于拒绝握手：先完成升级再发送关闭帧，使浏览器客户端能读到关闭码
+// 连接不会读取任何消息，因此不需要校验 Origin；仍需协商子协议，否则请求了子协议的浏览器会直接断开而读不到关闭码
+var rejectUpgrader = websocket.Upgrader{
+	Subprotocols: supportedSubprotocols,
+	CheckOrigin:  func(r *http.Request) bool { return true },
+}
+
+// handshakeError 握手失败原因
+type handshakeError struct {
+	code   int
+	reason string
+}
+
+// closeCodeStatus 关闭码对应的 HTTP 状态码（非 WebSocket 请求时使用）
+var closeCodeStatus = map[int]int{
+	CloseBadRequest:       http.StatusBadRequest,
+	CloseUnauthorized:     http.StatusUnauthorized,
+	CloseForbidden:        http.StatusForbidden,
+	CloseOriginNotAllowed: http.StatusForbidden,
+	CloseServiceRestart:   http.StatusServiceUnavailable,
 }
 
 // HandleWebSocket 处理 WebSocket 连接
@@ -35,83 +90,287 @@ func NewHandler(manager *Manager, userService *services.UserService, deviceServi
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param device_id query string true "设备ID"
+// @Param ticket query string false "一次性连接票据（通过 POST /ws/ticket 获取），未提供时使用 Authorization 头"
+// @Param device_id query string false "设备ID（使用 Authorization 头认证时可选，须与令牌绑定的设备一致）"
+// @Param Sec-WebSocket-Protocol header string false "消息编码子协议（xpaste.v1.cbor 或 xpaste.v1.json，默认 JSON）"
 // @Success 101 "切换协议成功"
-// @Failure 400 {object} models.Response "请求参数错误"
-// @Failure 401 {object} models.Response "未授权"
+// @Failure 400 {object} models.Response "请求参数错误（关闭码 4400）"
+// @Failure 401 {object} models.Response "未授权或票据无效（关闭码 4401）"
+// @Failure 403 {object} models.Response "设备不可用或 Origin 不允许（关闭码 4403 / 4406）"
 // @Failure 500 {object} models.Response "服务器内部错误"
+// @Failure 503 {object} models.Response "服务正在重启（关闭码 1012）"
 // @Router /ws [get]
 func (h *Handler) HandleWebSocket(c *gin.Context) {
-	// 获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+	// 校验 Origin
+	if !h.checkOrigin(c.Request) {
+		h.rejectHandshake(c, &handshakeError{CloseOriginNotAllowed, "Origin not allowed"})
 		return
 	}
 
-	// 获取设备ID
-	deviceID := c.Query("device_id")
-	if deviceID == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse("Device ID is required"))
+	// 服务正在关闭时拒绝新连接
+	if h.manager.IsDraining() {
+		h.rejectDraining(c)
+		return
+	}
+
+	// 认证握手请求
+	userID, deviceID, herr := h.authenticateHandshake(c)
+	if herr != nil {
+		h.rejectHandshake(c, herr)
 		return
 	}
 
 	// 验证设备是否属于当前用户
-	device, err := h.deviceService.GetDeviceByDeviceID(userID.(uint), deviceID)
+	device, err := h.deviceService.GetDeviceByDeviceID(userID, deviceID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid device ID: "+err.Error()))
+		if errors.Is(err, models.ErrDeviceNotFound) {
+			h.rejectHandshake(c, &handshakeError{CloseForbidden, "Device not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get device: "+err.Error()))
+		}
 		return
 	}
 
-	if device.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, models.ErrorResponse("Device does not belong to user"))
+	if device.UserID != userID {
+		h.rejectHandshake(c, &handshakeError{CloseForbidden, "Device does not belong to user"})
+		return
+	}
+
+	if !device.IsActive() {
+		h.rejectHandshake(c, &handshakeError{CloseForbidden, "Device is " + device.Status.String()})
 		return
 	}
 
 	// 升级 HTTP 连接为 WebSocket
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("Failed to upgrade connection: %v", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to upgrade connection: "+err.Error()))
 		return
 	}
 
+	// 压缩只有在客户端同意 permessage-deflate 时才会生效
+	compressThreshold := -1
+	if h.config.WebSocketCompression {
+		compressThreshold = h.config.WebSocketCompressionThreshold
+		if err := conn.SetCompressionLevel(h.config.WebSocketCompressionLevel); err != nil {
+			log.Printf("Invalid compression level %d: %v", h.config.WebSocketCompressionLevel, err)
+		}
+	}
+
 	// 创建客户端
 	client := &Client{
 		ID:       uuid.New().String(),
-		UserID:   userID.(uint),
+		UserID:   userID,
 		DeviceID: deviceID,
 		Conn:     conn,
-		Send:     make(chan Message, 256),
+		Send:     make(chan Message, h.config.WebSocketSendQueueSize),
 		Manager:  h.manager,
 		LastSeen: time.Now(),
+
+		codec:             codecForSubprotocol(conn.Subprotocol()),
+		compressThreshold: compressThreshold,
+
+		ip:         c.ClientIP(),
+		platform:   device.Platform,
+		presence:   models.PresenceActive,
+		presenceAt: time.Now(),
+	}
+
+	// 注册客户端并启动读写协程，在线状态由管理器负责持久化
+	if !h.manager.Register(client) {
+		client.Close()
+		return
+	}
+
+	log.Printf("WebSocket connection established for user %d, device %s (codec: %s)", userID, deviceID, client.codec.Name())
+}
+
+// authenticateHandshake 认证握手请求
+// 浏览器无法在握手时设置请求头，因此优先使用一次性票据；原生客户端可以直接在 Authorization 头中携带 JWT
+func (h *Handler) authenticateHandshake(c *gin.Context) (uint, string, *handshakeError) {
+	if value := c.Query("ticket"); value != "" {
+		ticket, err := h.tickets.Redeem(value)
+		if err != nil {
+			return 0, "", &handshakeError{CloseUnauthorized, err.Error()}
+		}
+
+		if deviceID := c.Query("device_id"); deviceID != "" && deviceID != ticket.DeviceID {
+			return 0, "", &handshakeError{CloseForbidden, "Ticket was issued for another device"}
+		}
+
+		// 票据签发后用户可能已被停用
+		user, err := h.userService.GetUserByID(ticket.UserID)
+		if err != nil || !user.IsActive() {
+			return 0, "", &handshakeError{CloseUnauthorized, "User account is inactive"}
+		}
+
+		return ticket.UserID, ticket.DeviceID, nil
+	}
+
+	// Authorization 头由 OptionalAuthMiddleware 解析
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		return 0, "", &handshakeError{CloseUnauthorized, "Missing connection ticket or authorization header"}
+	}
+
+	// 令牌必须绑定设备，且只能用于该设备的连接
+	tokenDeviceID, bound := middleware.GetDeviceIDFromContext(c)
+	if !bound {
+		return 0, "", &handshakeError{CloseForbidden, "Token is not bound to a device"}
+	}
+	deviceID := c.Query("device_id")
+	if deviceID != "" && deviceID != tokenDeviceID {
+		return 0, "", &handshakeError{CloseForbidden, "Token was issued for another device"}
+	}
+	deviceID = tokenDeviceID
+
+	return userID, deviceID, nil
+}
+
+// explicitOrigins 过滤 CORS 允许列表中的通配符
+// CORS 默认允许任意来源，而浏览器不对 WebSocket 握手执行同源策略，因此只接受明确配置的地址
+func explicitOrigins(origins []string) []string {
+	explicit := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		if origin == "*" {
+			continue
+		}
+		explicit = append(explicit, origin)
+	}
+	return explicit
+}
+
+// checkOrigin 校验握手请求的 Origin
+// 未携带 Origin 的请求（原生客户端）和同源请求总是允许，其余按 CORS 允许列表中明确配置的地址匹配
+// 开启 SYNC_WEBSOCKET_ALLOW_ANY_ORIGIN 后不再校验
+func (h *Handler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || h.allowAnyOrigin {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	for _, allowed := range h.allowedOrigins {
+		if strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectHandshake 拒绝握手
+// WebSocket 请求会先升级再以关闭码关闭，普通 HTTP 请求直接返回对应的状态码
+func (h *Handler) rejectHandshake(c *gin.Context, herr *handshakeError) {
+	if !websocket.IsWebSocketUpgrade(c.Request) {
+		c.JSON(closeCodeStatus[herr.code], models.ErrorResponse(herr.reason))
+		return
+	}
+
+	conn, err := rejectUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade rejected connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	closeMessage := websocket.FormatCloseMessage(herr.code, herr.reason)
+	if err := conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second)); err != nil {
+		log.Printf("Failed to write close message: %v", err)
+	}
+}
+
+// rejectDraining 服务关闭期间拒绝新连接，并提示客户端稍后重试
+func (h *Handler) rejectDraining(c *gin.Context) {
+	retryAfter := h.config.ShutdownReconnectDelay + h.config.ShutdownReconnectJitter
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	h.rejectHandshake(c, &handshakeError{CloseServiceRestart, "Server is restarting, please reconnect later"})
+}
+
+// IssueTicket 签发 WebSocket 连接票据
+// @Summary 获取连接票据
+// @Description 为指定设备签发短期有效的一次性连接票据，握手时通过 ticket 参数携带，避免在 URL 中暴露 JWT
+// @Tags WebSocket
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body IssueTicketRequest false "签发票据请求（未指定设备时使用令牌中的设备ID）"
+// @Success 200 {object} models.Response{data=TicketResponse} "签发成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "设备不可用"
+// @Router /ws/ticket [post]
+func (h *Handler) IssueTicket(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
 	}
 
-	// 注册客户端
-	h.manager.register <- client
+	var req IssueTicketRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse("Invalid request parameters: "+err.Error()))
+			return
+		}
+	}
 
-	// 更新设备在线状态
-	go func() {
-		if err := h.deviceService.UpdateDeviceOnlineStatus(device.UserID, device.DeviceID, true, ""); err != nil {
-			log.Printf("Failed to update device status: %v", err)
+	// 令牌必须绑定设备，且只能为该设备签发票据
+	tokenDeviceID, bound := middleware.GetDeviceIDFromContext(c)
+	if !bound {
+		c.JSON(http.StatusForbidden, models.ErrorResponse("Token is not bound to a device"))
+		return
+	}
+	if req.DeviceID != "" && req.DeviceID != tokenDeviceID {
+		c.JSON(http.StatusForbidden, models.ErrorResponse("Token was issued for another device"))
+		return
+	}
+	req.DeviceID = tokenDeviceID
+
+	device, err := h.deviceService.GetDeviceByDeviceID(user.ID, req.DeviceID)
+	if err != nil {
+		if errors.Is(err, models.ErrDeviceNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Device not found"))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to get device: "+err.Error()))
 		}
-	}()
+		return
+	}
 
-	// 启动读写协程
-	go client.writePump()
-	go client.readPump()
+	if !device.IsActive() {
+		c.JSON(http.StatusForbidden, models.ErrorResponse("Device is "+device.Status.String()))
+		return
+	}
 
-	log.Printf("WebSocket connection established for user %d, device %s", userID.(uint), deviceID)
+	value, ticket, err := h.tickets.Issue(user.ID, user.Username, device.DeviceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse("Failed to issue ticket: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Ticket issued successfully", TicketResponse{
+		Ticket:    value,
+		DeviceID:  ticket.DeviceID,
+		ExpiresAt: ticket.ExpiresAt,
+		ExpiresIn: int64(h.config.WebSocketTicketTTL.Seconds()),
+	}))
 }
 
 // GetOnlineDevices 获取在线设备列表
 // @Summary 获取在线设备
-// @Description 获取当前用户的在线设备列表
+// @Description 获取当前用户的在线设备及其实时状态
 // @Tags WebSocket
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} models.Response{data=[]string} "获取成功"
+// @Success 200 {object} models.Response{data=[]models.DevicePresence} "获取成功"
 // @Failure 401 {object} models.Response "未授权"
 // @Router /ws/devices/online [get]
 func (h *Handler) GetOnlineDevices(c *gin.Context) {
@@ -121,29 +380,126 @@ func (h *Handler) GetOnlineDevices(c *gin.Context) {
 		return
 	}
 
-	devices := h.manager.GetOnlineDevices(userID.(uint))
+	devices := h.manager.GetPresence(userID.(uint))
 	c.JSON(http.StatusOK, models.SuccessResponse("Online devices retrieved successfully", devices))
 }
 
+// GetPresence 获取设备在线状态
+// @Summary 获取设备在线状态
+// @Description 获取当前用户所有设备的在线状态（active、idle、locked、dnd、offline）及前台应用类别
+// @Tags WebSocket
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Response{data=[]models.DevicePresence} "获取成功"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 500 {object} models.Response "服务器内部错误"
+// @Router /ws/presence [get]
+func (h *Handler) GetPresence(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	devices, err := h.deviceService.GetUserPresence(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponseWithMessage("Failed to get device presence", err.Error()))
+		return
+	}
+
+	// 数据库中的状态异步写入，已连接设备以连接上的实时状态为准
+	live := make(map[string]*models.DevicePresence)
+	for _, presence := range h.manager.GetPresence(userID) {
+		live[presence.DeviceID] = presence
+	}
+
+	presences := make([]*models.DevicePresence, 0, len(devices))
+	for _, device := range devices {
+		presence := device.ToPresence()
+		if current, ok := live[device.DeviceID]; ok {
+			presence.State = current.State
+			presence.AppCategory = current.AppCategory
+			presence.IsOnline = true
+			presence.LastSeen = current.LastSeen
+			presence.UpdatedAt = current.UpdatedAt
+		}
+		presences = append(presences, presence)
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse("Device presence retrieved successfully", presences))
+}
+
+// UpdatePresence 更新设备在线状态
+// @Summary 更新设备在线状态
+// @Description 上报设备在线状态和前台应用类别，并通知订阅的其他设备；设备没有 WebSocket 连接时只保存状态，不标记为在线。已建立连接的设备也可以直接发送 presence_update 消息
+// @Tags WebSocket
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.UpdatePresenceRequest true "在线状态"
+// @Success 200 {object} models.Response{data=models.DevicePresence} "更新成功"
+// @Failure 400 {object} models.Response "请求参数错误"
+// @Failure 401 {object} models.Response "未授权"
+// @Failure 404 {object} models.Response "设备不存在"
+// @Router /ws/presence [put]
+func (h *Handler) UpdatePresence(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	var req models.UpdatePresenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponseWithMessage("Invalid request parameters", err.Error()))
+		return
+	}
+
+	// 绑定设备的令牌只能更新该设备的状态
+	if tokenDeviceID, bound := middleware.GetDeviceIDFromContext(c); bound {
+		if req.DeviceID != "" && req.DeviceID != tokenDeviceID {
+			c.JSON(http.StatusForbidden, models.ErrorResponse("Token was issued for another device"))
+			return
+		}
+		req.DeviceID = tokenDeviceID
+	}
+	if req.DeviceID == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse("Device ID is required"))
+		return
+	}
+
+	if _, err := h.deviceService.GetDeviceByDeviceID(userID, req.DeviceID); err != nil {
+		if errors.Is(err, models.ErrDeviceNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse("Device not found"))
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponseWithMessage("Failed to get device", err.Error()))
+		}
+		return
+	}
+
+	presence := h.manager.SetPresence(userID, req.DeviceID, req.State, req.AppCategory)
+	c.JSON(http.StatusOK, models.SuccessResponse("Device presence updated successfully", presence))
+}
+
 // GetConnectionStats 获取连接统计
 // @Summary 获取连接统计
-// @Description 获取 WebSocket 连接统计信息
+// @Description 获取 WebSocket 连接统计信息，包括发送队列流控计数和积压的连接（管理员权限）
 // @Tags WebSocket
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} models.Response{data=gin.H} "获取成功"
 // @Failure 401 {object} models.Response "未授权"
+// @Failure 403 {object} models.Response "权限不足"
 // @Router /ws/stats [get]
 func (h *Handler) GetConnectionStats(c *gin.Context) {
-	// 这里应该检查管理员权限，暂时跳过
-	// TODO: 实现管理员权限检查
-
 	total, byUser := h.manager.GetClientCount()
 
 	stats := gin.H{
 		"total_connections": total,
 		"connections_by_user": byUser,
+		"flow_control": h.manager.GetFlowStats(),
 		"timestamp": time.Now().Unix(),
 	}
 
@@ -264,6 +620,19 @@ type SendMessageRequest struct {
 	Data     interface{} `json:"data"`
 }
 
+// IssueTicketRequest 签发连接票据请求
+type IssueTicketRequest struct {
+	DeviceID string `json:"device_id,omitempty"`
+}
+
+// TicketResponse 连接票据响应
+type TicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	DeviceID  string    `json:"device_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	ExpiresIn int64     `json:"expires_in"` // 秒
+}
+
 // BroadcastMessageRequest 广播消息请求
 type BroadcastMessageRequest struct {
 	Type            string      `json:"type" binding:"required"`
@@ -272,13 +641,21 @@ type BroadcastMessageRequest struct {
 }
 
 // RegisterRoutes 注册 WebSocket 相关路由
-func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+// 握手路由自行完成认证（票据或 Authorization 头），其余接口统一使用 auth 中间件
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, auth gin.HandlerFunc) {
 	// WebSocket 连接路由（根路径，因为已经在 /ws 路由组下）
 	router.GET("", h.HandleWebSocket)
 
 	// WebSocket 管理路由
-	router.GET("/devices/online", h.GetOnlineDevices)
-	router.GET("/stats", h.GetConnectionStats)
-	router.POST("/send", h.SendMessage)
-	router.POST("/broadcast", h.BroadcastMessage)
+	authenticated := router.Group("")
+	authenticated.Use(auth)
+	{
+		authenticated.POST("/ticket", h.IssueTicket)
+		authenticated.GET("/devices/online", h.GetOnlineDevices)
+		authenticated.GET("/presence", h.GetPresence)
+		authenticated.PUT("/presence", h.UpdatePresence)
+		authenticated.GET("/stats", middleware.RequirePermission(models.PermissionSystemStats), h.GetConnectionStats)
+		authenticated.POST("/send", h.SendMessage)
+		authenticated.POST("/broadcast", h.BroadcastMessage)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,74 @@
+package websocket
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"xpaste-sync/internal/config"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		name        string
+		corsOrigins []string
+		allowAny    bool
+		origin      string
+		want        bool
+	}{
+		{"native client without origin", []string{"*"}, false, "", true},
+		{"same origin", []string{"*"}, false, "http://sync.example.com", true},
+		{"cors wildcard ignored", []string{"*"}, false, "https://evil.example.org", false},
+		{"configured frontend", []string{"*", "https://app.example.com"}, false, "https://APP.example.com", true},
+		{"other origin", []string{"https://app.example.com"}, false, "https://evil.example.org", false},
+		{"explicit opt-in", nil, true, "https://evil.example.org", true},
+		{"malformed origin", nil, false, "://bad", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(nil, nil, nil,
+				&config.SyncConfig{WebSocketAllowAnyOrigin: tt.allowAny},
+				&config.CORSConfig{AllowOrigins: tt.corsOrigins})
+
+			r := httptest.NewRequest("GET", "http://sync.example.com/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := h.checkOrigin(r); got != tt.want {
+				t.Errorf("checkOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRejectHandshakeNegotiatesSubprotocol(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewHandler(nil, nil, nil, &config.SyncConfig{}, &config.CORSConfig{AllowOrigins: []string{"https://app.example.com"}})
+	router := gin.New()
+	router.GET("/ws", h.HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	dialer := websocket.Dialer{Subprotocols: []string{SubprotocolCBOR}}
+	header := http.Header{"Origin": []string{"https://evil.example.org"}}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", header)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	if got := resp.Header.Get("Sec-WebSocket-Protocol"); got != SubprotocolCBOR {
+		t.Errorf("negotiated subprotocol = %q, want %q", got, SubprotocolCBOR)
+	}
+
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != CloseOriginNotAllowed {
+		t.Errorf("ReadMessage = %v, want close code %d", err, CloseOriginNotAllowed)
+	}
+}
@@ -1,52 +1,26 @@
 package websocket
 
 import (
+	"context"
+	"fmt"
 	"log"
-	"net/http"
+	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 
+	"xpaste-sync/internal/config"
 	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
 )
 
-// WebSocket 升级器配置
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// 在生产环境中应该检查 Origin
-		return true
-	},
-}
-
 // MessageType 消息类型
+// 具体取值与 Message 结构定义在 protocol_gen.go 中，由 packages/protocol 生成
 type MessageType string
 
-const (
-	MessageTypeClipSync     MessageType = "clip_sync"     // 剪贴板同步
-	MessageTypeClipNew      MessageType = "clip_new"      // 新剪贴板项
-	MessageTypeClipUpdate   MessageType = "clip_update"   // 剪贴板项更新
-	MessageTypeClipDelete   MessageType = "clip_delete"   // 剪贴板项删除
-	MessageTypeDeviceOnline MessageType = "device_online" // 设备上线
-	MessageTypeDeviceOffline MessageType = "device_offline" // 设备下线
-	MessageTypeDeviceUpdate MessageType = "device_update" // 设备更新
-	MessageTypeHeartbeat    MessageType = "heartbeat"     // 心跳
-	MessageTypePing         MessageType = "ping"          // Ping
-	MessageTypePong         MessageType = "pong"          // Pong
-	MessageTypeError        MessageType = "error"         // 错误
-)
-
-// Message WebSocket 消息结构
-type Message struct {
-	Type      MessageType `json:"type"`
-	Data      interface{} `json:"data,omitempty"`
-	Timestamp int64       `json:"timestamp"`
-	MessageID string      `json:"message_id,omitempty"`
-}
-
 // Client WebSocket 客户端
 type Client struct {
 	ID       string          // 客户端唯一标识
@@ -57,6 +31,21 @@ type Client struct {
 	Manager  *Manager        // 管理器引用
 	LastSeen time.Time       // 最后活跃时间
 	mu       sync.RWMutex    // 读写锁
+
+	codec             Codec  // 协商得到的消息编码
+	compressThreshold int    // 超过该大小（字节）的消息才启用压缩，小于 0 表示不压缩
+	closeMessage      []byte // 发送队列清空后写出的关闭帧
+
+	queueMu       sync.Mutex   // 串行化发送队列的生产者
+	pendingResync atomic.Int64 // 已合并、尚未通知客户端的消息数
+	flow          FlowStats    // 流控计数
+
+	ip                 string                // 客户端 IP
+	platform           models.DevicePlatform // 设备平台，用于排除不同步到该平台的敏感剪贴板项
+	presence           models.PresenceState  // 在线状态
+	appCategory        string                // 前台应用类别
+	presenceAt         time.Time             // 在线状态更新时间
+	presenceSubscribed bool                  // 是否订阅其他设备的在线状态变化
 }
 
 // Manager WebSocket 连接管理器
@@ -68,10 +57,24 @@ type Manager struct {
 	unregister chan *Client         // 注销客户端通道
 	broadcast  chan Message         // 广播消息通道
 	mu         sync.RWMutex         // 读写锁
+
+	config     *config.SyncConfig
+	flow       *flowControl // 发送队列溢出策略
+
+	deviceService   *services.DeviceService
+	presenceUpdates chan presenceUpdate // 待持久化的在线状态
+
+	pairingService *services.PairingService // 处理发起设备对配对请求的批准
+	webhookService *services.WebhookService // 发布设备上线、下线事件
+
+	flowTotals FlowStats    // 所有连接累计的流控计数
+	draining   atomic.Bool  // 正在关闭，不再接受新连接
+	pumps    sync.WaitGroup // 正在运行的写协程
+	done     chan struct{}  // Run 退出后关闭
 }
 
 // NewManager 创建新的 WebSocket 管理器
-func NewManager() *Manager {
+func NewManager(cfg *config.SyncConfig, deviceService *services.DeviceService, pairingService *services.PairingService, webhookService *services.WebhookService) *Manager {
 	return &Manager{
 		clients:       make(map[string]*Client),
 		userClients:   make(map[uint][]*Client),
@@ -79,11 +82,29 @@ func NewManager() *Manager {
 		register:      make(chan *Client),
 		unregister:    make(chan *Client),
 		broadcast:     make(chan Message),
+		config:        cfg,
+		flow:          newFlowControl(cfg),
+		done:          make(chan struct{}),
+
+		deviceService:   deviceService,
+		presenceUpdates: make(chan presenceUpdate, 256),
+
+		pairingService: pairingService,
+		webhookService: webhookService,
 	}
 }
 
-// Run 启动 WebSocket 管理器
-func (m *Manager) Run() {
+// Run 启动 WebSocket 管理器，直到 ctx 取消
+func (m *Manager) Run(ctx context.Context) {
+	defer close(m.done)
+
+	stopPersist := make(chan struct{})
+	persisted := make(chan struct{})
+	go func() {
+		m.persistPresence(stopPersist)
+		close(persisted)
+	}()
+
 	for {
 		select {
 		case client := <-m.register:
@@ -94,14 +115,106 @@ func (m *Manager) Run() {
 
 		case message := <-m.broadcast:
 			m.broadcastMessage(message)
+
+		case <-ctx.Done():
+			m.mu.Lock()
+			for _, client := range m.clients {
+				m.queuePresence(presenceUpdate{userID: client.UserID, deviceID: client.DeviceID, state: models.PresenceOffline})
+			}
+			m.closeAll()
+			m.mu.Unlock()
+
+			// 等待在线状态写完
+			close(stopPersist)
+			<-persisted
+			log.Println("WebSocket manager stopped")
+			return
+		}
+	}
+}
+
+// Register 注册客户端并启动读写协程，管理器正在关闭时返回 false
+func (m *Manager) Register(client *Client) bool {
+	if m.IsDraining() {
+		return false
+	}
+
+	// 读写协程在 registerClient 中启动，保证客户端的第一条消息到达时已完成注册
+	m.pumps.Add(1)
+	select {
+	case m.register <- client:
+	case <-m.done:
+		m.pumps.Done()
+		return false
+	}
+
+	return true
+}
+
+// requestUnregister 请求注销客户端，管理器已停止时直接返回
+func (m *Manager) requestUnregister(client *Client) {
+	select {
+	case m.unregister <- client:
+	case <-m.done:
+	}
+}
+
+// IsDraining 管理器是否正在关闭
+func (m *Manager) IsDraining() bool {
+	return m.draining.Load()
+}
+
+// Shutdown 优雅关闭：停止接受新连接，通知客户端稍后重连，
+// 等待发送队列中的消息写完后再关闭连接；ctx 到期时强制断开
+func (m *Manager) Shutdown(ctx context.Context) error {
+	if !m.draining.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	m.mu.Lock()
+	count := len(m.clients)
+	for _, client := range m.clients {
+		// 加入随机抖动，避免所有客户端同时重连
+		delay := m.config.ShutdownReconnectDelay
+		if jitter := m.config.ShutdownReconnectJitter; jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(jitter)))
 		}
+		reason := fmt.Sprintf("server restarting, reconnect after %d ms", delay.Milliseconds())
+		client.drain(websocket.FormatCloseMessage(CloseServiceRestart, reason))
+	}
+	m.mu.Unlock()
+
+	log.Printf("Draining %d WebSocket connections...", count)
+
+	drained := make(chan struct{})
+	go func() {
+		m.pumps.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		log.Println("All WebSocket connections drained")
+		return nil
+	case <-ctx.Done():
+		log.Println("WebSocket drain deadline exceeded, closing remaining connections")
+		m.mu.Lock()
+		m.closeAll()
+		m.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// closeAll 关闭所有客户端连接（调用方负责加锁）
+func (m *Manager) closeAll() {
+	for _, client := range m.clients {
+		client.Close()
 	}
 }
 
 // registerClient 注册客户端
 func (m *Manager) registerClient(client *Client) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 
 	// 如果设备已经连接，先断开旧连接
 	if existingClient, exists := m.deviceClients[client.DeviceID]; exists {
@@ -114,25 +227,54 @@ func (m *Manager) registerClient(client *Client) {
 	m.clients[client.ID] = client
 	m.deviceClients[client.DeviceID] = client
 	m.userClients[client.UserID] = append(m.userClients[client.UserID], client)
+	m.mu.Unlock()
+
+	go client.writePump()
+	go client.readPump()
 
 	log.Printf("Client registered: %s (User: %d, Device: %s)", client.ID, client.UserID, client.DeviceID)
 
-	// 通知其他设备该设备上线
+	presence := client.Presence()
+	m.queuePresence(presenceUpdate{
+		userID:      client.UserID,
+		deviceID:    client.DeviceID,
+		state:       presence.State,
+		appCategory: presence.AppCategory,
+		clientIP:    client.ip,
+	})
+
+	// 通知其他设备该设备上线（通知时需要读锁，必须在释放写锁之后）
 	m.notifyDeviceStatus(client.UserID, client.DeviceID, true)
+	m.webhookService.PublishDeviceEvent(client.UserID, client.DeviceID, true)
 }
 
 // unregisterClient 注销客户端
 func (m *Manager) unregisterClient(client *Client) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+
+	// 同一设备重连时旧连接已被替换，不能再影响新连接的状态
+	if m.clients[client.ID] != client {
+		m.mu.Unlock()
+		client.Close()
+		return
+	}
 
 	m.removeClientFromMaps(client)
 	client.Close()
+	m.mu.Unlock()
 
 	log.Printf("Client unregistered: %s (User: %d, Device: %s)", client.ID, client.UserID, client.DeviceID)
 
+	m.queuePresence(presenceUpdate{userID: client.UserID, deviceID: client.DeviceID, state: models.PresenceOffline})
+
+	// 关闭期间其他设备也在断开，不再广播下线通知
+	if m.IsDraining() {
+		return
+	}
+
 	// 通知其他设备该设备下线
 	m.notifyDeviceStatus(client.UserID, client.DeviceID, false)
+	m.webhookService.PublishDeviceEvent(client.UserID, client.DeviceID, false)
 }
 
 // removeClientFromMaps 从映射中移除客户端
@@ -141,7 +283,9 @@ func (m *Manager) removeClientFromMaps(client *Client) {
 	delete(m.clients, client.ID)
 
 	// 从 deviceClients 中移除
-	delete(m.deviceClients, client.DeviceID)
+	if m.deviceClients[client.DeviceID] == client {
+		delete(m.deviceClients, client.DeviceID)
+	}
 
 	// 从 userClients 中移除
 	if userClients, exists := m.userClients[client.UserID]; exists {
@@ -164,14 +308,7 @@ func (m *Manager) broadcastMessage(message Message) {
 	defer m.mu.RUnlock()
 
 	for _, client := range m.clients {
-		select {
-		case client.Send <- message:
-		default:
-			// 发送失败，关闭客户端
-			go func(c *Client) {
-				m.unregister <- c
-			}(client)
-		}
+		m.deliver(client, message)
 	}
 }
 
@@ -182,14 +319,7 @@ func (m *Manager) SendToUser(userID uint, message Message) {
 
 	if clients, exists := m.userClients[userID]; exists {
 		for _, client := range clients {
-			select {
-			case client.Send <- message:
-			default:
-				// 发送失败，关闭客户端
-				go func(c *Client) {
-					m.unregister <- c
-				}(client)
-			}
+			m.deliver(client, message)
 		}
 	}
 }
@@ -200,17 +330,26 @@ func (m *Manager) SendToDevice(deviceID string, message Message) {
 	defer m.mu.RUnlock()
 
 	if client, exists := m.deviceClients[deviceID]; exists {
-		select {
-		case client.Send <- message:
-		default:
-			// 发送失败，关闭客户端
-			go func(c *Client) {
-				m.unregister <- c
-			}(client)
-		}
+		m.deliver(client, message)
 	}
 }
 
+// DisconnectDevice 以 4403 关闭码断开设备的连接（设备被撤销时调用），设备未连接时返回 false
+// 读协程随后退出并按正常流程注销客户端，其他设备会收到下线通知
+func (m *Manager) DisconnectDevice(userID uint, deviceID string, reason string) bool {
+	m.mu.RLock()
+	client, exists := m.deviceClients[deviceID]
+	m.mu.RUnlock()
+
+	if !exists || client.UserID != userID {
+		return false
+	}
+
+	log.Printf("Disconnecting revoked device %s (User: %d): %s", deviceID, userID, reason)
+	client.closeWithCode(CloseForbidden, reason)
+	return true
+}
+
 // SendToUserExceptDevice 向用户的其他设备发送消息（排除指定设备）
 func (m *Manager) SendToUserExceptDevice(userID uint, excludeDeviceID string, message Message) {
 	m.mu.RLock()
@@ -219,14 +358,35 @@ func (m *Manager) SendToUserExceptDevice(userID uint, excludeDeviceID string, me
 	if clients, exists := m.userClients[userID]; exists {
 		for _, client := range clients {
 			if client.DeviceID != excludeDeviceID {
-				select {
-				case client.Send <- message:
-				default:
-					// 发送失败，关闭客户端
-					go func(c *Client) {
-						m.unregister <- c
-					}(client)
-				}
+				m.deliver(client, message)
+			}
+		}
+	}
+}
+
+// SendToUsersExceptDevice 向多个用户的所有设备发送消息（排除指定设备），用于团队剪贴板扇出
+func (m *Manager) SendToUsersExceptDevice(userIDs []uint, excludeDeviceID string, message Message) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, userID := range userIDs {
+		for _, client := range m.userClients[userID] {
+			if client.DeviceID != excludeDeviceID {
+				m.deliver(client, message)
+			}
+		}
+	}
+}
+
+// SendToUsersWhere 向多个用户中满足条件的在线设备发送消息
+func (m *Manager) SendToUsersWhere(userIDs []uint, accept func(client *Client) bool, message Message) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, userID := range userIDs {
+		for _, client := range m.userClients[userID] {
+			if accept(client) {
+				m.deliver(client, message)
 			}
 		}
 	}
@@ -234,9 +394,9 @@ func (m *Manager) SendToUserExceptDevice(userID uint, excludeDeviceID string, me
 
 // notifyDeviceStatus 通知设备状态变化
 func (m *Manager) notifyDeviceStatus(userID uint, deviceID string, online bool) {
-	messageType := MessageTypeDeviceOnline
+	messageType, state := MessageTypeDeviceOnline, models.PresenceActive
 	if !online {
-		messageType = MessageTypeDeviceOffline
+		messageType, state = MessageTypeDeviceOffline, models.PresenceOffline
 	}
 
 	message := Message{
@@ -244,6 +404,7 @@ func (m *Manager) notifyDeviceStatus(userID uint, deviceID string, online bool)
 		Data: gin.H{
 			"device_id": deviceID,
 			"online":    online,
+			"state":     state,
 		},
 		Timestamp: time.Now().Unix(),
 	}
@@ -292,16 +453,18 @@ func (m *Manager) GetClientCount() (total int, byUser map[uint]int) {
 
 // GetStats 获取连接统计信息
 func (m *Manager) GetStats() map[string]interface{} {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
 	total, byUser := m.GetClientCount()
 
+	m.mu.RLock()
+	devicesOnline := len(m.deviceClients)
+	m.mu.RUnlock()
+
 	return map[string]interface{}{
-		"total_connections": total,
-		"users_online":      len(byUser),
+		"total_connections":   total,
+		"users_online":        len(byUser),
 		"connections_by_user": byUser,
-		"devices_online":    len(m.deviceClients),
+		"devices_online":      devicesOnline,
+		"flow_control":        m.GetFlowStats(),
 	}
 }
 
@@ -321,31 +484,81 @@ func (c *Client) Close() {
 	}
 }
 
+// closeWithCode 写出关闭帧后立即关闭连接，发送队列中未写出的消息被丢弃
+func (c *Client) closeWithCode(code int, reason string) {
+	c.mu.Lock()
+	conn := c.Conn
+	c.mu.Unlock()
+
+	if conn != nil {
+		// WriteControl 可以与写协程并发调用
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	}
+	c.Close()
+}
+
+// drain 关闭发送通道，写协程发送完剩余消息后写出指定的关闭帧
+// 调用方需持有 Manager 的写锁，确保没有并发发送
+func (c *Client) drain(closeMessage []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Send == nil {
+		return
+	}
+	c.closeMessage = closeMessage
+	close(c.Send)
+	c.Send = nil
+}
+
 // writePump 处理向客户端写入消息
 func (c *Client) writePump() {
 	ticker := time.NewTicker(54 * time.Second)
 	defer func() {
 		ticker.Stop()
-		c.Manager.unregister <- c
+		c.Manager.pumps.Done()
+		c.Manager.requestUnregister(c)
 	}()
 
+	// Close/drain 会把字段置空，这里持有引用
+	c.mu.RLock()
+	conn, send := c.Conn, c.Send
+	c.mu.RUnlock()
+	if conn == nil || send == nil {
+		return
+	}
+
 	for {
 		select {
-		case message, ok := <-c.Send:
-			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		case message, ok := <-send:
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if !ok {
-				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.mu.RLock()
+				closeMessage := c.closeMessage
+				c.mu.RUnlock()
+				if closeMessage == nil {
+					closeMessage = []byte{}
+				}
+				conn.WriteMessage(websocket.CloseMessage, closeMessage)
 				return
 			}
 
-			if err := c.Conn.WriteJSON(message); err != nil {
+			if err := c.write(conn, message); err != nil {
 				log.Printf("Error writing message to client %s: %v", c.ID, err)
 				return
 			}
 
+			// 积压缓解后补发合并的重新同步通知
+			if resync, ok := c.takeResync(len(send)); ok {
+				if err := c.write(conn, resync); err != nil {
+					log.Printf("Error writing resync to client %s: %v", c.ID, err)
+					return
+				}
+			}
+
 		case <-ticker.C:
-			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				log.Printf("Error sending ping to client %s: %v", c.ID, err)
 				return
 			}
@@ -353,23 +566,42 @@ func (c *Client) writePump() {
 	}
 }
 
+// write 编码并写出一条消息，无法编码的消息会被跳过
+func (c *Client) write(conn *websocket.Conn, message Message) error {
+	data, err := c.codec.Encode(message)
+	if err != nil {
+		log.Printf("Error encoding message for client %s: %v", c.ID, err)
+		return nil
+	}
+
+	// 小消息压缩收益不大，只对超过阈值的消息启用压缩
+	conn.EnableWriteCompression(c.compressThreshold >= 0 && len(data) >= c.compressThreshold)
+	return conn.WriteMessage(c.codec.FrameType(), data)
+}
+
 // readPump 处理从客户端读取消息
 func (c *Client) readPump() {
 	defer func() {
-		c.Manager.unregister <- c
+		c.Manager.requestUnregister(c)
 	}()
 
-	c.Conn.SetReadLimit(512)
-	c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-	c.Conn.SetPongHandler(func(string) error {
-		c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-		c.LastSeen = time.Now()
+	c.mu.RLock()
+	conn := c.Conn
+	c.mu.RUnlock()
+	if conn == nil {
+		return
+	}
+
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		c.touch()
 		return nil
 	})
 
 	for {
-		var message Message
-		err := c.Conn.ReadJSON(&message)
+		frameType, data, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket error from client %s: %v", c.ID, err)
@@ -377,7 +609,14 @@ func (c *Client) readPump() {
 			break
 		}
 
-		c.LastSeen = time.Now()
+		// 文本帧始终按 JSON 解析，二进制帧按 CBOR 解析
+		var message Message
+		if err := codecForFrame(frameType).Decode(data, &message); err != nil {
+			log.Printf("Failed to decode message from client %s: %v", c.ID, err)
+			continue
+		}
+
+		c.touch()
 		c.handleMessage(message)
 	}
 }
@@ -392,15 +631,29 @@ func (c *Client) handleMessage(message Message) {
 			Timestamp: time.Now().Unix(),
 			MessageID: message.MessageID,
 		}
-		select {
-		case c.Send <- pongMessage:
-		default:
+		if !c.enqueue(pongMessage) {
 			log.Printf("Failed to send pong to client %s", c.ID)
+			go c.Manager.requestUnregister(c)
 		}
 
 	case MessageTypeHeartbeat:
 		// 更新最后活跃时间
-		c.LastSeen = time.Now()
+		c.touch()
+
+	case MessageTypePresenceUpdate:
+		c.handlePresenceUpdate(message)
+
+	case MessageTypePresenceSubscribe:
+		c.handlePresenceSubscribe(message)
+
+	case MessageTypePresenceUnsubscribe:
+		c.setPresenceSubscribed(false)
+
+	case MessageTypePairingApprove:
+		c.handlePairingDecision(message, true)
+
+	case MessageTypePairingDeny:
+		c.handlePairingDecision(message, false)
 
 	case MessageTypeClipSync:
 		// 处理剪贴板同步请求
@@ -422,7 +675,7 @@ func (c *Client) handleClipSync(message Message) {
 func (m *Manager) NotifyClipNew(userID uint, excludeDeviceID string, clipItem *models.ClipItem) {
 	message := Message{
 		Type:      MessageTypeClipNew,
-		Data:      clipItem.ToResponse(),
+		Data:      services.MaskClipResponse(clipItem),
 		Timestamp: time.Now().Unix(),
 	}
 
@@ -433,7 +686,7 @@ func (m *Manager) NotifyClipNew(userID uint, excludeDeviceID string, clipItem *m
 func (m *Manager) NotifyClipUpdate(userID uint, excludeDeviceID string, clipItem *models.ClipItem) {
 	message := Message{
 		Type:      MessageTypeClipUpdate,
-		Data:      clipItem.ToResponse(),
+		Data:      services.MaskClipResponse(clipItem),
 		Timestamp: time.Now().Unix(),
 	}
 
@@ -0,0 +1,71 @@
+package websocket
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"xpaste-sync/internal/models"
+)
+
+// handlePairingDecision 处理发起设备对配对请求的批准或拒绝
+func (c *Client) handlePairingDecision(message Message, approve bool) {
+	var decision models.PairingDecision
+	if err := decodeMessageData(message.Data, &decision); err != nil || decision.PairingID == "" {
+		c.sendError(message.MessageID, "Invalid pairing decision")
+		return
+	}
+	switch decision.TrustLevel {
+	case "", models.TrustLevelFull, models.TrustLevelLimited:
+	default:
+		c.sendError(message.MessageID, "Invalid trust level")
+		return
+	}
+
+	if c.Manager.pairingService == nil {
+		c.sendError(message.MessageID, "Pairing is not available")
+		return
+	}
+
+	session, err := c.Manager.pairingService.Decide(c.UserID, c.DeviceID, decision.PairingID, approve, decision.TrustLevel)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPairingNotFound):
+			c.sendError(message.MessageID, "Pairing session not found")
+		case errors.Is(err, models.ErrPairingExpired):
+			c.sendError(message.MessageID, "Pairing session expired")
+		case errors.Is(err, models.ErrPairingInvalidState):
+			c.sendError(message.MessageID, "Pairing session is not waiting for approval")
+		case errors.Is(err, models.ErrDeviceNotTrusted):
+			c.sendError(message.MessageID, "Device is not allowed to approve pairing")
+		default:
+			log.Printf("Failed to decide pairing %s for client %s: %v", decision.PairingID, c.ID, err)
+			c.sendError(message.MessageID, "Failed to update pairing session")
+		}
+		return
+	}
+
+	result := Message{
+		Type:      MessageTypePairingResult,
+		Data:      session.ToStatusResponse(),
+		Timestamp: time.Now().Unix(),
+		MessageID: message.MessageID,
+	}
+	if !c.enqueue(result) {
+		go c.Manager.requestUnregister(c)
+	}
+}
+
+// SendToUserDevice 向用户的指定设备发送消息，设备未连接时返回 false
+func (m *Manager) SendToUserDevice(userID uint, deviceID string, message Message) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	client, exists := m.deviceClients[deviceID]
+	if !exists || client.UserID != userID {
+		return false
+	}
+
+	m.deliver(client, message)
+	return true
+}
@@ -0,0 +1,246 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"xpaste-sync/internal/models"
+)
+
+// presenceUpdate 待持久化的在线状态
+type presenceUpdate struct {
+	userID      uint
+	deviceID    string
+	state       models.PresenceState
+	appCategory string
+	clientIP    string
+	// detached 设备没有 WebSocket 连接（通过 REST 上报），只更新状态，不改变在线标记
+	detached bool
+}
+
+// silentMessageTypes 目标设备勿扰时需要静默投递的消息类型
+// 剪贴板接力（clip_new）和设备上下线通知照常同步，但客户端不应弹窗或自动写入系统剪贴板
+var silentMessageTypes = map[MessageType]bool{
+	MessageTypeClipNew:       true,
+	MessageTypeDeviceOnline:  true,
+	MessageTypeDeviceOffline: true,
+}
+
+// touch 更新最后活跃时间
+func (c *Client) touch() {
+	c.mu.Lock()
+	c.LastSeen = time.Now()
+	c.mu.Unlock()
+}
+
+// Presence 获取客户端当前的在线状态
+func (c *Client) Presence() *models.DevicePresence {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	lastSeen, updatedAt := c.LastSeen, c.presenceAt
+	return &models.DevicePresence{
+		DeviceID:    c.DeviceID,
+		State:       c.presence,
+		AppCategory: c.appCategory,
+		IsOnline:    true,
+		LastSeen:    &lastSeen,
+		UpdatedAt:   &updatedAt,
+	}
+}
+
+// setPresence 更新客户端的在线状态
+func (c *Client) setPresence(state models.PresenceState, appCategory string) {
+	c.mu.Lock()
+	c.presence = state
+	c.appCategory = appCategory
+	c.presenceAt = time.Now()
+	c.mu.Unlock()
+}
+
+// isDoNotDisturb 客户端是否处于勿扰状态
+func (c *Client) isDoNotDisturb() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.presence == models.PresenceDND
+}
+
+// setPresenceSubscribed 订阅或取消订阅其他设备的在线状态变化
+func (c *Client) setPresenceSubscribed(subscribed bool) {
+	c.mu.Lock()
+	c.presenceSubscribed = subscribed
+	c.mu.Unlock()
+}
+
+// isPresenceSubscribed 是否订阅了在线状态变化
+func (c *Client) isPresenceSubscribed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.presenceSubscribed
+}
+
+// handlePresenceUpdate 处理客户端上报的在线状态
+func (c *Client) handlePresenceUpdate(message Message) {
+	var req models.UpdatePresenceRequest
+	if err := decodeMessageData(message.Data, &req); err != nil || !req.State.IsValid() || len(req.AppCategory) > 50 {
+		c.sendError(message.MessageID, "Invalid presence update")
+		return
+	}
+
+	c.Manager.SetPresence(c.UserID, c.DeviceID, req.State, req.AppCategory)
+}
+
+// handlePresenceSubscribe 订阅在线状态变化，并回复当前快照
+func (c *Client) handlePresenceSubscribe(message Message) {
+	c.setPresenceSubscribed(true)
+
+	snapshot := Message{
+		Type:      MessageTypePresenceSnapshot,
+		Data:      c.Manager.GetPresence(c.UserID),
+		Timestamp: time.Now().Unix(),
+		MessageID: message.MessageID,
+	}
+	if !c.enqueue(snapshot) {
+		go c.Manager.requestUnregister(c)
+	}
+}
+
+// sendError 向客户端回复错误消息
+func (c *Client) sendError(messageID string, reason string) {
+	message := Message{
+		Type:      MessageTypeError,
+		Data:      map[string]string{"error": reason},
+		Timestamp: time.Now().Unix(),
+		MessageID: messageID,
+	}
+	if !c.enqueue(message) {
+		go c.Manager.requestUnregister(c)
+	}
+}
+
+// decodeMessageData 将消息中的 Data 解码为结构体
+// JSON 与 CBOR 解码后 Data 均为通用 map，这里统一经 JSON 转换
+func decodeMessageData(data interface{}, v interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// deliver 向单个客户端投递消息，目标设备勿扰时静默投递
+func (m *Manager) deliver(client *Client, message Message) {
+	if silentMessageTypes[message.Type] && client.isDoNotDisturb() {
+		message.Silent = true
+	}
+
+	if !client.enqueue(message) {
+		// 队列溢出且策略要求断开
+		go m.requestUnregister(client)
+	}
+}
+
+// SetPresence 更新设备在线状态：更新连接状态、持久化并通知订阅的其他设备
+// 在线标记只由连接决定：设备没有 WebSocket 连接时（例如通过 REST 上报）只保存状态和勿扰设置，仍视为离线
+func (m *Manager) SetPresence(userID uint, deviceID string, state models.PresenceState, appCategory string) *models.DevicePresence {
+	m.mu.RLock()
+	client, connected := m.deviceClients[deviceID]
+	m.mu.RUnlock()
+	connected = connected && client.UserID == userID
+
+	var presence *models.DevicePresence
+	if connected {
+		client.setPresence(state, appCategory)
+		presence = client.Presence()
+	} else {
+		now := time.Now()
+		presence = &models.DevicePresence{
+			DeviceID:    deviceID,
+			State:       state,
+			AppCategory: appCategory,
+			IsOnline:    false,
+			UpdatedAt:   &now,
+		}
+	}
+
+	m.queuePresence(presenceUpdate{userID: userID, deviceID: deviceID, state: state, appCategory: appCategory, detached: !connected})
+	m.notifyPresence(userID, presence)
+	return presence
+}
+
+// GetPresence 获取用户在线设备的实时状态
+func (m *Manager) GetPresence(userID uint) []*models.DevicePresence {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	presences := make([]*models.DevicePresence, 0)
+	for _, client := range m.userClients[userID] {
+		presences = append(presences, client.Presence())
+	}
+	return presences
+}
+
+// notifyPresence 通知订阅了在线状态的其他设备
+func (m *Manager) notifyPresence(userID uint, presence *models.DevicePresence) {
+	message := Message{
+		Type:      MessageTypePresenceUpdate,
+		Data:      presence,
+		Timestamp: time.Now().Unix(),
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, client := range m.userClients[userID] {
+		if client.DeviceID != presence.DeviceID && client.isPresenceSubscribed() {
+			m.deliver(client, message)
+		}
+	}
+}
+
+// queuePresence 提交在线状态持久化请求，队列已满时丢弃并记录日志
+func (m *Manager) queuePresence(update presenceUpdate) {
+	if m.deviceService == nil {
+		return
+	}
+
+	select {
+	case m.presenceUpdates <- update:
+	default:
+		log.Printf("Presence queue full, dropping update for device %s", update.deviceID)
+	}
+}
+
+// persistPresence 按顺序持久化在线状态，保证同一设备的上线和下线不会乱序
+// stop 关闭后写完队列中剩余的状态再退出
+func (m *Manager) persistPresence(stop <-chan struct{}) {
+	for {
+		select {
+		case update := <-m.presenceUpdates:
+			m.savePresence(update)
+		case <-stop:
+			for {
+				select {
+				case update := <-m.presenceUpdates:
+					m.savePresence(update)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// savePresence 写入单个设备的在线状态
+func (m *Manager) savePresence(update presenceUpdate) {
+	if update.detached {
+		if err := m.deviceService.UpdateDetachedPresence(update.userID, update.deviceID, update.state, update.appCategory); err != nil {
+			log.Printf("Failed to persist presence for device %s: %v", update.deviceID, err)
+		}
+		return
+	}
+	if _, err := m.deviceService.UpdateDevicePresence(update.userID, update.deviceID, update.state, update.appCategory, update.clientIP); err != nil {
+		log.Printf("Failed to persist presence for device %s: %v", update.deviceID, err)
+	}
+}
@@ -0,0 +1,61 @@
+package websocket
+
+import (
+	"database/sql"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	_ "modernc.org/sqlite"
+
+	"xpaste-sync/internal/config"
+	"xpaste-sync/internal/models"
+	"xpaste-sync/internal/services"
+)
+
+func TestSetPresenceWithoutConnectionKeepsDeviceOffline(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite", ":memory:?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	defer sqlDB.Close()
+	db, err := gorm.Open(sqlite.Dialector{Conn: sqlDB}, &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	if err != nil {
+		t.Fatalf("open gorm: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.Device{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	user := &models.User{Username: "alice", Email: "alice@example.com", PasswordHash: "x"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	device := &models.Device{UserID: user.ID, DeviceID: "laptop", Name: "laptop", Status: models.DeviceStatusActive}
+	if err := db.Create(device).Error; err != nil {
+		t.Fatalf("create device: %v", err)
+	}
+
+	manager := NewManager(&config.SyncConfig{}, services.NewDeviceService(db), nil, nil)
+	presence := manager.SetPresence(user.ID, "laptop", models.PresenceDND, "ide")
+	if presence.IsOnline {
+		t.Error("presence of a device without connection reported online")
+	}
+	manager.savePresence(<-manager.presenceUpdates)
+
+	var saved models.Device
+	if err := db.First(&saved, device.ID).Error; err != nil {
+		t.Fatalf("load device: %v", err)
+	}
+	if saved.IsOnline || saved.LastSeen != nil {
+		t.Errorf("IsOnline = %v, LastSeen = %v, want offline and unchanged", saved.IsOnline, saved.LastSeen)
+	}
+	if saved.Presence != models.PresenceDND || saved.AppCategory != "ide" {
+		t.Errorf("Presence = %q, AppCategory = %q, want dnd and ide", saved.Presence, saved.AppCategory)
+	}
+	if saved.ToPresence().State != models.PresenceOffline {
+		t.Errorf("reported state = %q, want offline", saved.ToPresence().State)
+	}
+}
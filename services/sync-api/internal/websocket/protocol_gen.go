@@ -0,0 +1,56 @@
+// Code generated by gen-protocol from packages/protocol/src/index.ts. DO NOT EDIT.
+
+package websocket
+
+// SchemaVersion 消息编码 schema 版本
+const SchemaVersion = 1
+
+// WebSocket 子协议
+const (
+	SubprotocolJSON = "xpaste.v1.json"
+	SubprotocolCBOR = "xpaste.v1.cbor"
+)
+
+// 消息类型
+const (
+	MessageTypeClipSync              MessageType = "clip_sync"
+	MessageTypeClipNew               MessageType = "clip_new"
+	MessageTypeClipUpdate            MessageType = "clip_update"
+	MessageTypeClipDelete            MessageType = "clip_delete"
+	MessageTypeDeviceOnline          MessageType = "device_online"
+	MessageTypeDeviceOffline         MessageType = "device_offline"
+	MessageTypeDeviceUpdate          MessageType = "device_update"
+	MessageTypeDeviceApprovalRequest MessageType = "device_approval_request"
+	MessageTypeSecurityAlert         MessageType = "security_alert"
+	MessageTypeRuleNotification      MessageType = "rule_notification"
+	MessageTypeHeartbeat             MessageType = "heartbeat"
+	MessageTypePing                  MessageType = "ping"
+	MessageTypePong                  MessageType = "pong"
+	MessageTypeError                 MessageType = "error"
+	MessageTypePresenceUpdate        MessageType = "presence_update"
+	MessageTypePresenceSubscribe     MessageType = "presence_subscribe"
+	MessageTypePresenceUnsubscribe   MessageType = "presence_unsubscribe"
+	MessageTypePresenceSnapshot      MessageType = "presence_snapshot"
+	MessageTypePairingRequest        MessageType = "pairing_request"
+	MessageTypePairingApprove        MessageType = "pairing_approve"
+	MessageTypePairingDeny           MessageType = "pairing_deny"
+	MessageTypePairingResult         MessageType = "pairing_result"
+)
+
+// 关闭码
+const (
+	CloseServiceRestart   = 1012
+	CloseBadRequest       = 4400
+	CloseUnauthorized     = 4401
+	CloseForbidden        = 4403
+	CloseOriginNotAllowed = 4406
+)
+
+// Message WebSocket 消息结构
+type Message struct {
+	Type      MessageType `json:"type" cbor:"1,keyasint"`
+	Data      interface{} `json:"data,omitempty" cbor:"2,keyasint,omitempty"`
+	Timestamp int64       `json:"timestamp" cbor:"3,keyasint"`
+	MessageID string      `json:"message_id,omitempty" cbor:"4,keyasint,omitempty"`
+	Silent    bool        `json:"silent,omitempty" cbor:"5,keyasint,omitempty"`
+}
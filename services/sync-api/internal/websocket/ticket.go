@@ -0,0 +1,91 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+)
+
+// 连接票据相关错误
+var (
+	ErrTicketInvalid = errors.New("invalid connection ticket")
+	ErrTicketExpired = errors.New("connection ticket expired")
+)
+
+// Ticket 一次性 WebSocket 连接票据
+// 客户端先通过已认证的 REST 接口换取票据，再携带票据发起握手，避免把长期有效的 JWT 放进 URL
+type Ticket struct {
+	UserID    uint
+	Username  string
+	DeviceID  string
+	ExpiresAt time.Time
+}
+
+// TicketStore 连接票据存储（仅保存在内存中，服务重启后全部失效）
+type TicketStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	tickets map[string]*Ticket
+}
+
+// NewTicketStore 创建连接票据存储
+func NewTicketStore(ttl time.Duration) *TicketStore {
+	return &TicketStore{
+		ttl:     ttl,
+		tickets: make(map[string]*Ticket),
+	}
+}
+
+// Issue 签发票据
+func (s *TicketStore) Issue(userID uint, username, deviceID string) (string, *Ticket, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	value := base64.RawURLEncoding.EncodeToString(buf)
+
+	ticket := &Ticket{
+		UserID:    userID,
+		Username:  username,
+		DeviceID:  deviceID,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purgeExpired()
+	s.tickets[value] = ticket
+
+	return value, ticket, nil
+}
+
+// Redeem 兑换票据，票据无论是否有效都只能使用一次
+func (s *TicketStore) Redeem(value string) (*Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ticket, exists := s.tickets[value]
+	if !exists {
+		return nil, ErrTicketInvalid
+	}
+	delete(s.tickets, value)
+
+	if time.Now().After(ticket.ExpiresAt) {
+		return nil, ErrTicketExpired
+	}
+
+	return ticket, nil
+}
+
+// purgeExpired 清理过期票据，调用方需持有锁
+func (s *TicketStore) purgeExpired() {
+	now := time.Now()
+	for value, ticket := range s.tickets {
+		if now.After(ticket.ExpiresAt) {
+			delete(s.tickets, value)
+		}
+	}
+}
@@ -1,44 +1,71 @@
 package websocket
 
 import (
+	"context"
 	"log"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"xpaste-sync/internal/config"
 	"xpaste-sync/internal/middleware"
+	"xpaste-sync/internal/models"
 	"xpaste-sync/internal/services"
 )
 
+//go:generate go run ../../cmd/gen-protocol -in ../../../../packages/protocol/src/index.ts -out protocol_gen.go
+
 // WebSocketService WebSocket 服务
 type WebSocketService struct {
 	Manager  *Manager
 	Handler  *Handler
 	services *services.Services
+	cancel   context.CancelFunc
 }
 
 // NewWebSocketService 创建 WebSocket 服务
-func NewWebSocketService(services *services.Services) *WebSocketService {
-	manager := NewManager()
-	handler := NewHandler(manager, services.User, services.Device)
+func NewWebSocketService(services *services.Services, cfg *config.Config) *WebSocketService {
+	manager := NewManager(&cfg.Sync, services.Device, services.Pairing, services.Webhook)
+	handler := NewHandler(manager, services.User, services.Device, &cfg.Sync, &cfg.CORS)
 
-	return &WebSocketService{
+	ws := &WebSocketService{
 		Manager:  manager,
 		Handler:  handler,
 		services: services,
 	}
+
+	// 服务层通过 WebSocket 向设备推送实时事件
+	services.SetNotifier(ws)
+
+	return ws
 }
 
 // Start 启动 WebSocket 服务
-func (ws *WebSocketService) Start() {
+func (ws *WebSocketService) Start(ctx context.Context) {
 	log.Println("Starting WebSocket manager...")
-	go ws.Manager.Run()
+
+	// 上次退出时遗留的在线状态已失效，等待设备重新连接
+	if err := ws.services.Device.ResetPresence(); err != nil {
+		log.Printf("Failed to reset device presence: %v", err)
+	}
+
+	ctx, ws.cancel = context.WithCancel(ctx)
+	go ws.Manager.Run(ctx)
 }
 
 // Stop 停止 WebSocket 服务
-func (ws *WebSocketService) Stop() {
+// 先排空现有连接（通知客户端稍后重连），再停止管理器
+func (ws *WebSocketService) Stop(ctx context.Context) error {
 	log.Println("Stopping WebSocket service...")
-	// 这里可以添加清理逻辑
+
+	err := ws.Manager.Shutdown(ctx)
+
+	if ws.cancel != nil {
+		ws.cancel()
+		<-ws.Manager.done
+	}
+
+	return err
 }
 
 // RegisterRoutes 注册 WebSocket 路由
@@ -47,7 +74,7 @@ func (ws *WebSocketService) RegisterRoutes(router *gin.RouterGroup) {
 	middleware.SetupWebSocketMiddlewares(router, ws.services.GetDB())
 
 	// 注册路由
-	ws.Handler.RegisterRoutes(router)
+	ws.Handler.RegisterRoutes(router, middleware.AuthMiddleware(ws.services.GetDB()))
 }
 
 // GetManager 获取 WebSocket 管理器
@@ -109,9 +136,131 @@ func (ws *WebSocketService) NotifyDeviceEvent(userID uint, event string, data in
 		Timestamp: time.Now().Unix(),
 	}
 
+	if excluded := excludedPlatforms(data); len(excluded) > 0 {
+		ws.Manager.SendToUsersWhere([]uint{userID}, func(client *Client) bool {
+			return !containsPlatform(excluded, client.platform)
+		}, message)
+		return
+	}
+	ws.Manager.SendToUser(userID, message)
+}
+
+// NotifyDevice 向用户的指定设备推送事件，实现 services.Notifier
+func (ws *WebSocketService) NotifyDevice(userID uint, deviceID string, event string, data interface{}) bool {
+	var messageType MessageType
+
+	switch event {
+	case services.EventPairingRequest:
+		messageType = MessageTypePairingRequest
+	case services.EventPairingResult:
+		messageType = MessageTypePairingResult
+	default:
+		return false
+	}
+
+	message := Message{
+		Type:      messageType,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	}
+
+	return ws.Manager.SendToUserDevice(userID, deviceID, message)
+}
+
+// NotifyUser 向用户的所有在线设备推送事件，实现 services.Notifier
+func (ws *WebSocketService) NotifyUser(userID uint, event string, data interface{}) {
+	var messageType MessageType
+
+	switch event {
+	case services.EventDeviceApprovalRequest:
+		messageType = MessageTypeDeviceApprovalRequest
+	case services.EventDeviceUpdate:
+		messageType = MessageTypeDeviceUpdate
+	case services.EventSecurityAlert:
+		messageType = MessageTypeSecurityAlert
+	case services.EventClipNew:
+		messageType = MessageTypeClipNew
+	case services.EventRuleNotification:
+		messageType = MessageTypeRuleNotification
+	default:
+		return
+	}
+
+	message := Message{
+		Type:      messageType,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	}
+
+	if excluded := excludedPlatforms(data); len(excluded) > 0 {
+		ws.Manager.SendToUsersWhere([]uint{userID}, func(client *Client) bool {
+			return !containsPlatform(excluded, client.platform)
+		}, message)
+		return
+	}
 	ws.Manager.SendToUser(userID, message)
 }
 
+// NotifyUsers 向多个用户的所有在线设备推送事件，实现 services.Notifier
+func (ws *WebSocketService) NotifyUsers(userIDs []uint, excludeDeviceID string, event string, data interface{}) {
+	var messageType MessageType
+
+	switch event {
+	case services.EventClipNew:
+		messageType = MessageTypeClipNew
+	case services.EventClipUpdate:
+		messageType = MessageTypeClipUpdate
+	case services.EventClipDelete:
+		messageType = MessageTypeClipDelete
+	default:
+		return
+	}
+
+	message := Message{
+		Type:      messageType,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	}
+
+	if excluded := excludedPlatforms(data); len(excluded) > 0 {
+		ws.Manager.SendToUsersWhere(userIDs, func(client *Client) bool {
+			return client.DeviceID != excludeDeviceID && !containsPlatform(excluded, client.platform)
+		}, message)
+		return
+	}
+	ws.Manager.SendToUsersExceptDevice(userIDs, excludeDeviceID, message)
+}
+
+// excludedPlatforms 推送内容中的剪贴板项不同步到的平台
+func excludedPlatforms(data interface{}) []string {
+	switch d := data.(type) {
+	case *models.ClipItemResponse:
+		if d != nil && d.Sensitive != nil {
+			return d.Sensitive.ExcludedPlatforms
+		}
+	case *models.RuleNotification:
+		if d != nil {
+			return excludedPlatforms(d.Clip)
+		}
+	}
+	return nil
+}
+
+// containsPlatform 平台是否在列表中
+func containsPlatform(platforms []string, platform models.DevicePlatform) bool {
+	for _, p := range platforms {
+		if p == string(platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// DisconnectDevice 断开被撤销设备的连接，实现 services.Notifier
+func (ws *WebSocketService) DisconnectDevice(userID uint, deviceID string, reason string) bool {
+	return ws.Manager.DisconnectDevice(userID, deviceID, reason)
+}
+
 // GetConnectionStats 获取连接统计信息
 func (ws *WebSocketService) GetConnectionStats() map[string]interface{} {
 	return ws.Manager.GetStats()